@@ -0,0 +1,103 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package admission calls the admission webhooks of the registry over HTTP.
+package admission
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"github.com/dataphos/lib-httputil/pkg/httputil"
+	"github.com/dataphos/schema-registry/registry"
+)
+
+// DefaultTimeout is the time a webhook has to review a schema version if no timeout is given.
+const DefaultTimeout = 5 * time.Second
+
+// maxResponseSize is the largest webhook response which is read, in bytes.
+const maxResponseSize = 1 << 20
+
+// client propagates the trace context of the request to the webhooks and records a span of the call.
+var client = &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}
+
+// Webhook is an admission webhook reached over HTTP. The registry.AdmissionReview is posted to its url as JSON, and
+// it must answer with the status 200 and a registry.AdmissionResponse in JSON. Any other answer, or no answer before
+// the timeout, is a failure, handled according to the failure policy of the webhook.
+type Webhook struct {
+	name     string
+	url      string
+	timeout  time.Duration
+	failOpen bool
+}
+
+// New returns a new Webhook. DefaultTimeout is used if the timeout isn't positive.
+func New(name, url string, timeout time.Duration, failOpen bool) *Webhook {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Webhook{
+		name:     name,
+		url:      url,
+		timeout:  timeout,
+		failOpen: failOpen,
+	}
+}
+
+func (w *Webhook) Name() string {
+	return w.name
+}
+
+func (w *Webhook) FailOpen() bool {
+	return w.failOpen
+}
+
+// Review posts the review to the webhook and returns its decision.
+func (w *Webhook) Review(ctx context.Context, review registry.AdmissionReview) (registry.AdmissionResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+
+	// this can't generate an error, so it's safe to ignore
+	data, _ := json.Marshal(review)
+
+	request, err := httputil.Post(ctx, w.url, "application/json", bytes.NewBuffer(data))
+	if err != nil {
+		return registry.AdmissionResponse{}, errors.Wrap(err, "couldn't create the admission request")
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return registry.AdmissionResponse{}, errors.Wrap(err, "admission request failed")
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return registry.AdmissionResponse{}, errors.Errorf("admission request failed with status code [%v]", response.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxResponseSize))
+	if err != nil {
+		return registry.AdmissionResponse{}, errors.Wrap(err, "couldn't read the admission response")
+	}
+	var decision registry.AdmissionResponse
+	if err = json.Unmarshal(body, &decision); err != nil {
+		return registry.AdmissionResponse{}, errors.Wrap(err, "couldn't unmarshal the admission response")
+	}
+	return decision, nil
+}
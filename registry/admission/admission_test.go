@@ -0,0 +1,90 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dataphos/schema-registry/registry"
+)
+
+func TestWebhookReview(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var review registry.AdmissionReview
+		if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		response := registry.AdmissionResponse{Allowed: review.PublisherID == "payments"}
+		if !response.Allowed {
+			response.Reason = "publisher isn't allowed in the domain"
+		}
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	webhook := New("domains", server.URL, time.Second, false)
+
+	response, err := webhook.Review(context.Background(), registry.AdmissionReview{Operation: registry.AdmissionOperationCreate, PublisherID: "payments"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !response.Allowed {
+		t.Fatalf("expected the schema to be allowed, got %+v", response)
+	}
+
+	response, err = webhook.Review(context.Background(), registry.AdmissionReview{Operation: registry.AdmissionOperationCreate, PublisherID: "marketing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Allowed || response.Reason != "publisher isn't allowed in the domain" {
+		t.Fatalf("expected the schema to be denied, got %+v", response)
+	}
+}
+
+func TestWebhookFailures(t *testing.T) {
+	tt := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"status", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}},
+		{"body", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("allowed"))
+		}},
+		{"timeout", func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(200 * time.Millisecond)
+			_, _ = w.Write([]byte(`{"allowed": true}`))
+		}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(tc.handler)
+			defer server.Close()
+
+			webhook := New(tc.name, server.URL, 50*time.Millisecond, true)
+			if _, err := webhook.Review(context.Background(), registry.AdmissionReview{}); err == nil {
+				t.Fatal("expected the review to fail")
+			}
+		})
+	}
+}
@@ -15,7 +15,13 @@
 package main
 
 import (
+	"fmt"
+	"os"
 	"runtime/debug"
+	"strconv"
+	"text/tabwriter"
+
+	"gorm.io/gorm"
 
 	"github.com/dataphos/lib-logger/logger"
 	"github.com/dataphos/lib-logger/standardlogger"
@@ -24,6 +30,13 @@ import (
 	"github.com/dataphos/schema-registry/registry/repository/postgres"
 )
 
+const usage = "usage: initdb [migrate up|down [steps]|status]"
+
+// main applies the missing database migrations, or runs one of the migrate subcommands:
+//
+//	migrate up            applies every missing migration, the same as running without arguments
+//	migrate down [steps]  reverts the given number of the latest migrations, 1 by default
+//	migrate status        lists the migrations and whether they are applied
 func main() {
 	labels := logger.Labels{
 		"product":   "Schema Registry",
@@ -51,20 +64,110 @@ func main() {
 		log.Warn(w)
 	}
 
+	command := "up"
+	args := os.Args[1:]
+	if len(args) > 0 {
+		if args[0] != "migrate" || len(args) < 2 {
+			log.Fatal(usage, errcodes.Miscellaneous)
+			return
+		}
+		command, args = args[1], args[2:]
+	}
+
 	db, err := postgres.InitializeGormFromEnv()
 	if err != nil {
 		log.Fatal(err.Error(), errcodes.DatabaseConnectionInitialization)
 		return
 	}
 
-	if postgres.HealthCheck(db) {
-		log.Warn("database already initialized")
-		return
+	switch command {
+	case "up":
+		migrateUp(log, db)
+	case "down":
+		steps := 1
+		if len(args) > 0 {
+			if steps, err = strconv.Atoi(args[0]); err != nil || steps < 1 {
+				log.Fatal("steps must be a positive integer", errcodes.Miscellaneous)
+				return
+			}
+		}
+		migrateDown(log, db, steps)
+	case "status":
+		migrationStatus(log, db)
+	default:
+		log.Fatal(usage, errcodes.Miscellaneous)
 	}
+}
 
-	if err = postgres.Initdb(db); err != nil {
+func migrateUp(log logger.Log, db *gorm.DB) {
+	applied, err := postgres.MigrateUp(db)
+	for _, migration := range applied {
+		log.Infow("migration applied", logger.F{"version": migration.Version, "description": migration.Description})
+	}
+	if err != nil {
 		log.Fatal(err.Error(), errcodes.DatabaseInitialization)
 		return
 	}
+	fingerprinted, err := postgres.BackfillAvroFingerprints(db)
+	if err != nil {
+		log.Fatal(err.Error(), errcodes.DatabaseInitialization)
+		return
+	}
+	if fingerprinted > 0 {
+		log.Infow("avro fingerprints backfilled", logger.F{"versions": fingerprinted})
+	}
+	rehashed, err := postgres.BackfillSchemaHashes(db)
+	if err != nil {
+		log.Fatal(err.Error(), errcodes.DatabaseInitialization)
+		return
+	}
+	if rehashed > 0 {
+		log.Infow("schema hashes backfilled", logger.F{"versions": rehashed})
+	}
+	compressed, err := postgres.CompressSpecifications(db)
+	if err != nil {
+		log.Fatal(err.Error(), errcodes.DatabaseInitialization)
+		return
+	}
+	if compressed > 0 {
+		log.Infow("schema specifications compressed", logger.F{"versions": compressed})
+	}
+	if len(applied) == 0 {
+		log.Info("database already up to date")
+		return
+	}
 	log.Info("database initialized successfully")
 }
+
+func migrateDown(log logger.Log, db *gorm.DB, steps int) {
+	reverted, err := postgres.MigrateDown(db, steps)
+	for _, migration := range reverted {
+		log.Infow("migration reverted", logger.F{"version": migration.Version, "description": migration.Description})
+	}
+	if err != nil {
+		log.Fatal(err.Error(), errcodes.DatabaseInitialization)
+		return
+	}
+	if len(reverted) == 0 {
+		log.Info("no migrations to revert")
+	}
+}
+
+func migrationStatus(log logger.Log, db *gorm.DB) {
+	statuses, err := postgres.GetMigrationStatus(db)
+	if err != nil {
+		log.Fatal(err.Error(), errcodes.InvalidDatabaseState)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "VERSION\tAPPLIED AT\tDESCRIPTION")
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.Applied {
+			appliedAt = status.AppliedAt.UTC().Format("2006-01-02 15:04:05")
+		}
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, appliedAt, status.Description)
+	}
+	_ = w.Flush()
+}
@@ -16,7 +16,11 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -25,13 +29,16 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/go-chi/cors"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
+	"github.com/dataphos/schema-registry/admission"
 	"github.com/dataphos/schema-registry/compatibility"
 	"github.com/dataphos/schema-registry/internal/config"
 	"github.com/dataphos/schema-registry/internal/errcodes"
-	"github.com/dataphos/schema-registry/internal/errtemplates"
+	"github.com/dataphos/schema-registry/internal/metrics"
+	"github.com/dataphos/schema-registry/internal/tracing"
 	"github.com/dataphos/schema-registry/registry"
 	"github.com/dataphos/schema-registry/registry/repository/postgres"
 	"github.com/dataphos/schema-registry/server"
@@ -40,17 +47,12 @@ import (
 	"github.com/dataphos/lib-logger/standardlogger"
 )
 
-const (
-	serverPortEnvKey = "SERVER_PORT"
-)
-
-const (
-	defaultServerPort = 8080
-)
-
 // @title		Schema Registry API
 // @version		1.0
 func main() {
+	configFile := flag.String("f", "", "toml file containing the configuration of the registry server")
+	flag.Parse()
+
 	labels := logger.Labels{
 		"product":   "Schema Registry",
 		"component": "registry",
@@ -76,6 +78,29 @@ func main() {
 		log.Warn(w)
 	}
 
+	var cfg config.Registry
+	if err := cfg.Read(*configFile); err != nil {
+		log.Error(errors.Wrap(err, "reading the configuration failed").Error(), errcodes.ServerInitialization)
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Error(errors.Wrap(err, "invalid configuration").Error(), errcodes.ServerInitialization)
+		return
+	}
+
+	shutdownTracing, err := tracing.Init(context.Background(), "schema-registry")
+	if err != nil {
+		log.Error(err.Error(), errcodes.ServerInitialization)
+		return
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.Error(errors.Wrap(err, "flushing spans failed").Error(), errcodes.ServerShutdown)
+		}
+	}()
+
 	db, err := postgres.InitializeGormFromEnv()
 	if err != nil {
 		log.Error(err.Error(), errcodes.DatabaseConnectionInitialization)
@@ -85,39 +110,95 @@ func main() {
 		log.Error("database state invalid", errcodes.InvalidDatabaseState)
 		return
 	}
+	if err = postgres.CheckMigrations(db); err != nil {
+		log.Error(errors.Wrap(err, "run initdb to migrate the database").Error(), errcodes.InvalidDatabaseState)
+		return
+	}
 
-	var port int
-	portStr := os.Getenv(serverPortEnvKey)
-	if portStr == "" {
-		port = defaultServerPort
-	} else {
-		port, err = strconv.Atoi(portStr)
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Error(err.Error(), errcodes.DatabaseConnectionInitialization)
+		return
+	}
+	if err = metrics.RegisterDBStats(sqlDB); err != nil {
+		log.Error(errors.Wrap(err, "registering database metrics failed").Error(), errcodes.ServerInitialization)
+		return
+	}
+
+	var repositoryOptions []postgres.Option
+	if cfg.Audit.LogFile != "" {
+		file, err := os.OpenFile(cfg.Audit.LogFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
 		if err != nil {
-			log.Error(errtemplates.ExpectedInt(serverPortEnvKey, portStr).Error(), errcodes.ServerInitialization)
+			log.Error(errors.Wrap(err, "opening the audit log file failed").Error(), errcodes.ServerInitialization)
 			return
 		}
+		defer file.Close()
+		repositoryOptions = append(repositoryOptions, postgres.WithAuditSink(registry.NewJSONLinesSink(file)))
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	compChecker, globalCompMode, err := compatibility.InitCompatibilityChecker(ctx)
+	compChecker, globalCompMode, err := compatibility.InitCompatibilityChecker(ctx, cfg.CompatibilityChecker.URL, cfg.CompatibilityChecker.TimeoutBase, cfg.GlobalCompatibilityMode)
 	if err != nil {
 		log.Error(err.Error(), errcodes.ExternalCheckerInitialization)
 		return
 	}
 	log.Info("Successfully connected compatibility checker.")
 
-	valChecker, globalValMode, err := validity.InitExternalValidityChecker(ctx)
+	valChecker, globalValMode, err := validity.InitExternalValidityChecker(ctx, cfg.ValidityChecker.URL, cfg.ValidityChecker.TimeoutBase, cfg.GlobalValidityMode)
 	if err != nil {
 		log.Error(err.Error(), errcodes.ExternalCheckerInitialization)
 		return
 	}
 	log.Info("Successfully connected validity checker.")
 
+	// stops the background work of the service, such as listening for cache invalidations, on shutdown
+	serviceCtx, stopService := context.WithCancel(context.Background())
+	defer stopService()
+
+	service := registry.New(postgres.New(db, repositoryOptions...), compChecker, valChecker, globalCompMode, globalValMode,
+		registry.WithContext(serviceCtx),
+		registry.WithRepositoryCache(cfg.Cache.Size, cfg.Cache.TTL),
+		registry.WithUsageTTL(cfg.Usage.TTL),
+		registry.WithSemanticVersioning(cfg.SemanticVersioning),
+	)
+	for _, webhook := range cfg.Admission.Webhooks {
+		service.AdmissionWebhooks = append(service.AdmissionWebhooks, admission.New(webhook.Name, webhook.URL, webhook.Timeout, webhook.FailurePolicy == config.FailurePolicyIgnore))
+		log.Infow("calling admission webhook", logger.F{"name": webhook.Name, "url": webhook.URL})
+	}
+
+	serverConfig := cfg.Server
+	tlsConfig, err := newTLSConfig(serverConfig.TLS)
+	if err != nil {
+		log.Error(err.Error(), errcodes.ServerInitialization)
+		return
+	}
+	serverOptions := []server.Option{
+		server.WithRequestTimeout(serverConfig.Timeouts.Request),
+		server.WithMaxBodySize(serverConfig.Limits.MaxBodySize),
+		server.WithMaxSchemaSize(serverConfig.Limits.MaxSchemaSize),
+		server.WithRateLimit(serverConfig.RateLimit.Requests, serverConfig.RateLimit.Window),
+		server.WithBaseURL(serverConfig.BaseURL),
+	}
+	if len(serverConfig.CORS.AllowedOrigins) > 0 {
+		serverOptions = append(serverOptions, server.WithCORS(cors.Options{
+			AllowedOrigins:   serverConfig.CORS.AllowedOrigins,
+			AllowedMethods:   serverConfig.CORS.AllowedMethods,
+			AllowedHeaders:   serverConfig.CORS.AllowedHeaders,
+			AllowCredentials: serverConfig.CORS.AllowCredentials,
+			MaxAge:           int(serverConfig.CORS.MaxAge.Seconds()),
+		}))
+	}
+
 	srv := http.Server{
-		Addr:    fmt.Sprintf(":%d", port),
-		Handler: server.New(server.NewHandler(registry.New(postgres.New(db), compChecker, valChecker, globalCompMode, globalValMode), log)),
+		Addr:              net.JoinHostPort(serverConfig.Address, strconv.Itoa(serverConfig.Port)),
+		Handler:           server.New(server.NewHandler(service, log), serverOptions...),
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: serverConfig.Timeouts.ReadHeader,
+		ReadTimeout:       serverConfig.Timeouts.Read,
+		WriteTimeout:      serverConfig.Timeouts.Write,
+		IdleTimeout:       serverConfig.Timeouts.Idle,
 	}
 
 	idleConnsClosed := make(chan struct{})
@@ -128,25 +209,31 @@ func main() {
 		<-c
 
 		log.Info("initiating graceful shutdown")
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), serverConfig.ShutdownTimeout)
 		defer cancel()
 		if err = srv.Shutdown(ctx); err != nil {
 			log.Error(errors.Wrap(err, "graceful shutdown failed").Error(), errcodes.ServerShutdown)
 		}
+		stopService()
 		close(idleConnsClosed)
 	}()
 	go func() {
-		http.Handle("/metrics", promhttp.Handler())
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.Handler())
 
-		log.Infow("starting Prometheus server", logger.F{"port": 2112})
-		err1 := http.ListenAndServe(":2112", nil)
-		if err1 != nil {
+		log.Infow("starting Prometheus server", logger.F{"port": cfg.Metrics.Port})
+		if err := http.ListenAndServe(fmt.Sprintf(":%d", cfg.Metrics.Port), mux); err != nil {
 			log.Error(errors.Wrap(err, "an error occurred starting Prometheus server").Error(), errcodes.ServerShutdown)
 		}
 	}()
 
-	log.Infow("starting server", logger.F{"port": srv.Addr})
-	if err = srv.ListenAndServe(); err != nil {
+	log.Infow("starting server", logger.F{"port": srv.Addr, "tls": serverConfig.TLS.Enabled})
+	if serverConfig.TLS.Enabled {
+		err = srv.ListenAndServeTLS(serverConfig.TLS.CertFile, serverConfig.TLS.KeyFile)
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if err != nil {
 		if err != http.ErrServerClosed {
 			log.Error(errors.Wrap(err, "an error occurred starting or closing server").Error(), errcodes.ServerShutdown)
 		}
@@ -156,3 +243,26 @@ func main() {
 
 	log.Info("shutting down")
 }
+
+// newTLSConfig returns the TLS configuration of the server, which requires and verifies client certificates if a
+// client CA file is given. It returns nil if TLS isn't enabled.
+func newTLSConfig(cfg config.TLS) (*tls.Config, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.ClientCaFile != "" {
+		caCert, err := os.ReadFile(cfg.ClientCaFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading the client CA file failed")
+		}
+		clientCAs := x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(caCert) {
+			return nil, errors.New("the client CA file doesn't contain any PEM encoded certificates")
+		}
+		tlsConfig.ClientCAs = clientCAs
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,295 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/dataphos/schema-registry/registry"
+	"github.com/dataphos/schema-registry/validity"
+)
+
+// The supported output formats.
+const (
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+	outputTable = "table"
+)
+
+// hashLength is the number of characters of a schema hash shown in tables.
+const hashLength = 12
+
+// cli holds the state of a single run of a command.
+type cli struct {
+	name   string
+	stdout io.Writer
+	stderr io.Writer
+	output string
+	client *client
+}
+
+// insertInfo is the response of the registry to the registration of a schema or a schema version.
+type insertInfo struct {
+	ID         string               `json:"identification"`
+	Version    string               `json:"version"`
+	Semver     string               `json:"semver,omitempty"`
+	Message    string               `json:"message"`
+	Violations []validity.Violation `json:"violations,omitempty"`
+}
+
+// report is a message of the registry.
+type report struct {
+	Message    string               `json:"message"`
+	Violations []validity.Violation `json:"violations,omitempty"`
+}
+
+// checkResult is the result of a compatibility or validity check.
+type checkResult struct {
+	Passed     bool                 `json:"passed"`
+	Message    string               `json:"message"`
+	Violations []validity.Violation `json:"violations,omitempty"`
+}
+
+// newFlagSet returns an empty flag set of the command.
+func (c *cli) newFlagSet() *flag.FlagSet {
+	flags := flag.NewFlagSet("sr-cli "+c.name, flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	return flags
+}
+
+// parse parses the flags of the command, including the common ones, and sets up the client of the selected registry.
+func (c *cli) parse(flags *flag.FlagSet, args []string) error {
+	var common commonFlags
+	common.register(flags)
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		// the flag set already reported the error, together with the usage of the command
+		return errUsage
+	}
+	if flags.NArg() > 0 {
+		return errors.Wrapf(errUsage, "unexpected arguments %v", flags.Args())
+	}
+
+	switch common.output {
+	case outputJSON, outputYAML, outputTable:
+		c.output = common.output
+	default:
+		return errors.Wrapf(errUsage, "unknown output format %q", common.output)
+	}
+
+	selected, err := common.resolveProfile()
+	if err != nil {
+		return err
+	}
+	c.client = &client{
+		baseURL:    selected.URL,
+		actor:      selected.Actor,
+		httpClient: &http.Client{},
+	}
+	return nil
+}
+
+// require returns an error if the flag with the given name wasn't set.
+func require(name, value string) error {
+	if value == "" {
+		return errors.Wrapf(errUsage, "-%s must be provided", name)
+	}
+	return nil
+}
+
+// print writes the value in the selected output format, using table to write it as a table.
+func (c *cli) print(value interface{}, table func(w io.Writer)) error {
+	switch c.output {
+	case outputJSON:
+		encoder := json.NewEncoder(c.stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	case outputYAML:
+		return writeYAML(c.stdout, value)
+	default:
+		w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+		table(w)
+		return w.Flush()
+	}
+}
+
+// writeYAML writes the value as YAML, with the same keys as its JSON representation, in the same order.
+func writeYAML(w io.Writer, value interface{}) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	var node yaml.Node
+	if err = yaml.Unmarshal(encoded, &node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err = encoder.Encode(&node); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// blockStyle resets the flow style of the JSON the node was decoded from, so it's written in the block style.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+func versionsTable(versions []registry.VersionDetails) func(w io.Writer) {
+	return func(w io.Writer) {
+		_, _ = fmt.Fprintln(w, "SCHEMA ID\tVERSION\tSEMVER\tHASH\tCREATED AT\tACTIVE\tDESCRIPTION")
+		for _, details := range versions {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%t\t%s\n",
+				details.SchemaID,
+				details.Version,
+				details.Semver,
+				shortHash(details.SchemaHash),
+				details.CreatedAt.UTC().Format(time.RFC3339),
+				!details.VersionDeactivated,
+				details.Description,
+			)
+		}
+	}
+}
+
+func schemasTable(schemas []registry.Schema) func(w io.Writer) {
+	return func(w io.Writer) {
+		_, _ = fmt.Fprintln(w, "ID\tNAME\tTYPE\tLAST VERSION\tVERSIONS\tPUBLISHER\tCOMPATIBILITY\tVALIDITY")
+		for _, schema := range schemas {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
+				schema.SchemaID,
+				schema.Name,
+				schema.SchemaType,
+				schema.LastCreated,
+				len(schema.VersionDetails),
+				schema.PublisherID,
+				schema.CompatibilityMode,
+				schema.ValidityMode,
+			)
+		}
+	}
+}
+
+func insertInfoTable(info insertInfo) func(w io.Writer) {
+	return func(w io.Writer) {
+		_, _ = fmt.Fprintln(w, "ID\tVERSION\tSEMVER\tMESSAGE")
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", info.ID, info.Version, info.Semver, info.Message)
+		violationsTable(w, info.Violations)
+	}
+}
+
+func reportTable(message report) func(w io.Writer) {
+	return func(w io.Writer) {
+		_, _ = fmt.Fprintln(w, message.Message)
+		violationsTable(w, message.Violations)
+	}
+}
+
+func usageTable(usage []registry.Usage) func(w io.Writer) {
+	return func(w io.Writer) {
+		_, _ = fmt.Fprintln(w, "SCHEMA ID\tVERSION\tTOPIC\tROLE\tCLIENT ID\tLAST SEEN")
+		for _, current := range usage {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				current.SchemaID,
+				current.Version,
+				current.Topic,
+				current.Role,
+				current.ClientID,
+				current.LastSeen.UTC().Format(time.RFC3339),
+			)
+		}
+	}
+}
+
+func aliasesTable(aliases []registry.Alias) func(w io.Writer) {
+	return func(w io.Writer) {
+		_, _ = fmt.Fprintln(w, "ALIAS\tVERSION\tUPDATED AT")
+		for _, alias := range aliases {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", alias.Alias, alias.Version, alias.UpdatedAt.UTC().Format(time.RFC3339))
+		}
+	}
+}
+
+// matrixTable writes the matrix as a grid with the older versions as rows and the newer ones as columns.
+// A cell is "ok" if the pair is compatible, "FAIL" if it isn't and the mode requires it, and "fail" if the mode doesn't.
+func matrixTable(matrix registry.CompatibilityMatrix) func(w io.Writer) {
+	return func(w io.Writer) {
+		cells := make(map[[2]string]string, len(matrix.Pairs))
+		for _, pair := range matrix.Pairs {
+			cell := "ok"
+			if !pair.Compatible {
+				cell = "fail"
+				if pair.Required {
+					cell = "FAIL"
+				}
+			}
+			cells[[2]string{pair.Older, pair.Newer}] = cell
+		}
+
+		_, _ = fmt.Fprint(w, matrix.Mode)
+		for _, newer := range matrix.Versions {
+			_, _ = fmt.Fprintf(w, "\t%s", newer)
+		}
+		_, _ = fmt.Fprintln(w)
+		for _, older := range matrix.Versions {
+			_, _ = fmt.Fprint(w, older)
+			for _, newer := range matrix.Versions {
+				cell, ok := cells[[2]string{older, newer}]
+				if !ok {
+					cell = "-"
+				}
+				_, _ = fmt.Fprintf(w, "\t%s", cell)
+			}
+			_, _ = fmt.Fprintln(w)
+		}
+		_, _ = fmt.Fprintf(w, "\ncompatible: %t\n", matrix.Compatible)
+	}
+}
+
+// violationsTable writes the lint rule violations after the message they were reported with.
+func violationsTable(w io.Writer, violations []validity.Violation) {
+	if len(violations) == 0 {
+		return
+	}
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, "SEVERITY\tRULE\tPATH\tMESSAGE")
+	for _, violation := range violations {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", violation.Severity, violation.Rule, violation.Path, violation.Message)
+	}
+}
+
+func shortHash(hash string) string {
+	if len(hash) > hashLength {
+		return hash[:hashLength]
+	}
+	return hash
+}
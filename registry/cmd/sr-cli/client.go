@@ -0,0 +1,171 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/dataphos/schema-registry/internal/errcodes"
+	"github.com/dataphos/schema-registry/registry"
+	"github.com/dataphos/schema-registry/validity"
+)
+
+// actorHeader identifies the user of sr-cli in the audit log of the registry.
+const actorHeader = "X-Actor"
+
+// client is a client of the schema registry REST API.
+type client struct {
+	baseURL    string
+	actor      string
+	httpClient *http.Client
+}
+
+// response is a response of the registry.
+type response struct {
+	status int
+	body   []byte
+}
+
+// problem is the problem details body of an error response of the registry. Older registries respond with just a
+// message instead.
+type problem struct {
+	Title      string               `json:"title"`
+	Detail     string               `json:"detail"`
+	Code       int                  `json:"code"`
+	Message    string               `json:"message"`
+	Violations []validity.Violation `json:"violations,omitempty"`
+	Usage      []registry.Usage     `json:"usage,omitempty"`
+}
+
+// apiError is an error response of the registry.
+type apiError struct {
+	status  int
+	code    int
+	message string
+}
+
+func (e *apiError) Error() string {
+	if e.message == "" {
+		return fmt.Sprintf("registry responded with status %d", e.status)
+	}
+	return fmt.Sprintf("registry responded with status %d: %s", e.status, e.message)
+}
+
+// Unwrap maps the error code of the response, or its status if there is no code, to the error which determines the
+// exit code.
+func (e *apiError) Unwrap() error {
+	switch e.code {
+	case errcodes.NotFound:
+		return errNotFound
+	case errcodes.SchemaNotValid, errcodes.SchemaNotCompatible, errcodes.BreakingChange, errcodes.AdmissionDenied:
+		return errRejected
+	case 0:
+		if e.status == http.StatusNotFound {
+			return errNotFound
+		}
+	}
+	return nil
+}
+
+// do sends a request to the registry, encoding the body as JSON if there is one.
+// Only a failure to get a response is returned as an error, the status of the response is left to the caller.
+func (c *client) do(ctx context.Context, method, path string, query url.Values, body interface{}) (response, error) {
+	endpoint := strings.TrimSuffix(c.baseURL, "/") + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return response{}, err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return response{}, err
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	request.Header.Set(actorHeader, c.actor)
+
+	resp, err := c.httpClient.Do(request)
+	if err != nil {
+		return response{}, err
+	}
+	defer resp.Body.Close()
+
+	encoded, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return response{}, err
+	}
+	return response{status: resp.StatusCode, body: encoded}, nil
+}
+
+// get sends a GET request and decodes the response into result, returning an apiError if it isn't successful.
+func (c *client) get(ctx context.Context, path string, query url.Values, result interface{}) error {
+	resp, err := c.do(ctx, http.MethodGet, path, query, nil)
+	if err != nil {
+		return err
+	}
+	if err = resp.err(); err != nil {
+		return err
+	}
+	return resp.decode(result)
+}
+
+// err returns an apiError if the response isn't successful.
+func (r response) err() error {
+	if r.status < http.StatusBadRequest {
+		return nil
+	}
+	var body problem
+	_ = json.Unmarshal(r.body, &body)
+	message := body.Detail
+	if message == "" {
+		message = body.Title
+	}
+	if message == "" {
+		message = body.Message
+	}
+	for _, violation := range body.Violations {
+		message += fmt.Sprintf("\n  %s %s at %s: %s", violation.Severity, violation.Rule, violation.Path, violation.Message)
+	}
+	for _, usage := range body.Usage {
+		message += fmt.Sprintf("\n  version %s is used by %s %s of %s", usage.Version, usage.ClientID, usage.Role, usage.Topic)
+	}
+	return &apiError{status: r.status, code: body.Code, message: message}
+}
+
+// decode decodes the JSON body of the response into v.
+func (r response) decode(v interface{}) error {
+	if err := json.Unmarshal(r.body, v); err != nil {
+		return errors.Wrap(err, "couldn't decode the response of the registry")
+	}
+	return nil
+}
@@ -0,0 +1,495 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/dataphos/schema-registry/registry"
+)
+
+// latestVersion is the version argument which selects the latest version of a schema.
+const latestVersion = "latest"
+
+func registerSchema(ctx context.Context, c *cli, args []string) error {
+	flags := c.newFlagSet()
+	filename := flags.String("f", "", "the file containing the schema specification")
+	schemaType := flags.String("t", "", "schema type")
+	name := flags.String("n", "schema-janitor", "schema name")
+	description := flags.String("d", "description of the schema", "schema description")
+	publisherID := flags.String("p", "publisherId", "publisher id")
+	compMode := flags.String("c", "", "compatibility mode")
+	valMode := flags.String("v", "", "validity mode")
+	attributes := flags.String("attributes", "", "schema attributes")
+	rulesFilename := flags.String("rules", "", "the YAML or JSON file containing the data contract rules")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	for _, flag := range []struct{ name, value string }{{"f", *filename}, {"t", *schemaType}, {"c", *compMode}, {"v", *valMode}} {
+		if err := require(flag.name, flag.value); err != nil {
+			return err
+		}
+	}
+
+	specification, err := os.ReadFile(*filename)
+	if err != nil {
+		return err
+	}
+	rules, err := readRules(*rulesFilename)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.do(ctx, http.MethodPost, "/schemas", nil, registry.SchemaRegistrationRequest{
+		Description:       *description,
+		Specification:     string(specification),
+		Name:              *name,
+		SchemaType:        *schemaType,
+		PublisherID:       *publisherID,
+		CompatibilityMode: *compMode,
+		ValidityMode:      *valMode,
+		Attributes:        *attributes,
+		Rules:             rules,
+	})
+	return c.printInsertInfo(resp, err)
+}
+
+func updateSchema(ctx context.Context, c *cli, args []string) error {
+	flags := c.newFlagSet()
+	filename := flags.String("f", "", "the file containing the updated schema specification")
+	description := flags.String("d", "", "updated schema description")
+	id := flags.String("id", "", "id of the schema")
+	attributes := flags.String("attributes", "", "schema attributes")
+	rulesFilename := flags.String("rules", "", "the YAML or JSON file containing the data contract rules")
+	allowBreaking := flags.Bool("allow-breaking", false, "allow a breaking change to bump the major semantic version")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	if err := require("f", *filename); err != nil {
+		return err
+	}
+	if err := require("id", *id); err != nil {
+		return err
+	}
+
+	specification, err := os.ReadFile(*filename)
+	if err != nil {
+		return err
+	}
+	rules, err := readRules(*rulesFilename)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.do(ctx, http.MethodPut, "/schemas/"+url.PathEscape(*id), nil, registry.SchemaUpdateRequest{
+		Description:         *description,
+		Specification:       string(specification),
+		Attributes:          *attributes,
+		Rules:               rules,
+		AllowBreakingChange: *allowBreaking,
+	})
+	return c.printInsertInfo(resp, err)
+}
+
+// readRules reads the list of data contract rules from the YAML or JSON file, if its name isn't empty.
+func readRules(filename string) ([]registry.Rule, error) {
+	if filename == "" {
+		return nil, nil
+	}
+	encoded, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var rules []registry.Rule
+	if err = yaml.Unmarshal(encoded, &rules); err != nil {
+		return nil, errors.Wrapf(err, "couldn't read the rules in %s", filename)
+	}
+	return rules, nil
+}
+
+// printInsertInfo prints the response to a registration, which isn't an error if the specification already exists.
+func (c *cli) printInsertInfo(resp response, err error) error {
+	if err != nil {
+		return err
+	}
+	if resp.status != http.StatusConflict {
+		if err = resp.err(); err != nil {
+			return err
+		}
+	}
+
+	var info insertInfo
+	if err = resp.decode(&info); err != nil {
+		return err
+	}
+	return c.print(info, insertInfoTable(info))
+}
+
+func getSchemaVersion(ctx context.Context, c *cli, args []string) error {
+	flags := c.newFlagSet()
+	id := flags.String("id", "", "id of the schema")
+	version := flags.String("version", "", "version of the schema, or latest")
+	spec := flags.Bool("spec", false, "print only the specification")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	if err := require("id", *id); err != nil {
+		return err
+	}
+	if err := require("version", *version); err != nil {
+		return err
+	}
+	return c.printSchemaVersion(ctx, *id, *version, *spec)
+}
+
+func getLatestSchemaVersion(ctx context.Context, c *cli, args []string) error {
+	flags := c.newFlagSet()
+	id := flags.String("id", "", "id of the schema")
+	spec := flags.Bool("spec", false, "print only the specification")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	if err := require("id", *id); err != nil {
+		return err
+	}
+	return c.printSchemaVersion(ctx, *id, latestVersion, *spec)
+}
+
+func (c *cli) printSchemaVersion(ctx context.Context, id, version string, spec bool) error {
+	details, err := c.fetchSchemaVersion(ctx, id, version)
+	if err != nil {
+		return err
+	}
+	if spec {
+		_, err = c.stdout.Write(details.Specification)
+		return err
+	}
+	return c.print(details, versionsTable([]registry.VersionDetails{details}))
+}
+
+// fetchSchemaVersion gets the given version of the schema, which can also be latestVersion.
+func (c *cli) fetchSchemaVersion(ctx context.Context, id, version string) (registry.VersionDetails, error) {
+	var details registry.VersionDetails
+	err := c.client.get(ctx, "/schemas/"+url.PathEscape(id)+"/versions/"+url.PathEscape(version), nil, &details)
+	return details, err
+}
+
+func listSchemas(ctx context.Context, c *cli, args []string) error {
+	flags := c.newFlagSet()
+	id := flags.String("id", "", "list the versions of the schema with this id instead")
+	all := flags.Bool("all", false, "include the deleted schemas or versions")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+
+	if *id != "" {
+		path := "/schemas/" + url.PathEscape(*id) + "/versions"
+		if *all {
+			path += "/all"
+		}
+		var schema registry.Schema
+		if err := c.client.get(ctx, path, nil, &schema); err != nil {
+			return err
+		}
+		return c.print(schema, versionsTable(schema.VersionDetails))
+	}
+
+	path := "/schemas"
+	if *all {
+		path += "/all"
+	}
+	var schemas []registry.Schema
+	if err := c.client.get(ctx, path, nil, &schemas); err != nil {
+		return err
+	}
+	return c.print(schemas, schemasTable(schemas))
+}
+
+func searchSchemas(ctx context.Context, c *cli, args []string) error {
+	flags := c.newFlagSet()
+	parameters := []struct{ name, usage string }{
+		{"id", "schema id"},
+		{"version", "schema version"},
+		{"type", "schema type"},
+		{"name", "schema name"},
+		{"orderBy", "order by name, type, id or version"},
+		{"sort", "sort schemas either asc or desc"},
+		{"limit", "maximum number of schemas"},
+		{"attributes", "comma separated schema attributes"},
+	}
+	values := make([]*string, len(parameters))
+	for i, parameter := range parameters {
+		values[i] = flags.String(parameter.name, "", parameter.usage)
+	}
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	for i, parameter := range parameters {
+		if *values[i] != "" {
+			query.Set(parameter.name, *values[i])
+		}
+	}
+	if len(query) == 0 {
+		return errors.Wrap(errUsage, "at least one search parameter must be provided")
+	}
+
+	var schemas []registry.Schema
+	if err := c.client.get(ctx, "/schemas/search", query, &schemas); err != nil {
+		return err
+	}
+	return c.print(schemas, schemasTable(schemas))
+}
+
+func deleteSchema(ctx context.Context, c *cli, args []string) error {
+	flags := c.newFlagSet()
+	id := flags.String("id", "", "id of the schema")
+	version := flags.String("version", "", "version of the schema, the whole schema is deleted if it isn't given")
+	force := flags.Bool("force", false, "delete even if the schema or the version is in use")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	if err := require("id", *id); err != nil {
+		return err
+	}
+
+	path := "/schemas/" + url.PathEscape(*id)
+	if *version != "" {
+		path += "/versions/" + url.PathEscape(*version)
+	}
+	var query url.Values
+	if *force {
+		query = url.Values{"force": {"true"}}
+	}
+	resp, err := c.client.do(ctx, http.MethodDelete, path, query, nil)
+	if err != nil {
+		return err
+	}
+	if err = resp.err(); err != nil {
+		return err
+	}
+
+	var message report
+	if err = resp.decode(&message); err != nil {
+		return err
+	}
+	return c.print(message, reportTable(message))
+}
+
+func listUsage(ctx context.Context, c *cli, args []string) error {
+	flags := c.newFlagSet()
+	id := flags.String("id", "", "id of the schema")
+	version := flags.String("version", "", "version of the schema, the usage of every version is listed if it isn't given")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	if err := require("id", *id); err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	if *version != "" {
+		query.Set("version", *version)
+	}
+	var usage []registry.Usage
+	if err := c.client.get(ctx, "/schemas/"+url.PathEscape(*id)+"/usage", query, &usage); err != nil {
+		return err
+	}
+	return c.print(usage, usageTable(usage))
+}
+
+// manageAliases lists the aliases of a schema, or points one of them at a version, or deletes it.
+func manageAliases(ctx context.Context, c *cli, args []string) error {
+	flags := c.newFlagSet()
+	id := flags.String("id", "", "id of the schema")
+	name := flags.String("name", "", "name of the alias, the aliases of the schema are listed if it isn't given")
+	version := flags.String("version", "", "version number, or another alias, the alias is pointed at")
+	remove := flags.Bool("delete", false, "delete the alias instead of pointing it at a version")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	if err := require("id", *id); err != nil {
+		return err
+	}
+
+	path := "/schemas/" + url.PathEscape(*id) + "/aliases"
+	if *name == "" {
+		if *version != "" || *remove {
+			return errors.Wrap(errUsage, "-name must be provided together with -version or -delete")
+		}
+		var aliases []registry.Alias
+		if err := c.client.get(ctx, path, nil, &aliases); err != nil {
+			return err
+		}
+		return c.print(aliases, aliasesTable(aliases))
+	}
+	if (*version == "") == !*remove {
+		return errors.Wrap(errUsage, "exactly one of -version and -delete must be provided together with -name")
+	}
+
+	path += "/" + url.PathEscape(*name)
+	if *remove {
+		resp, err := c.client.do(ctx, http.MethodDelete, path, nil, nil)
+		if err != nil {
+			return err
+		}
+		if err = resp.err(); err != nil {
+			return err
+		}
+		var message report
+		if err = resp.decode(&message); err != nil {
+			return err
+		}
+		return c.print(message, reportTable(message))
+	}
+
+	resp, err := c.client.do(ctx, http.MethodPut, path, nil, registry.AliasRequest{Version: *version})
+	if err != nil {
+		return err
+	}
+	if err = resp.err(); err != nil {
+		return err
+	}
+	var alias registry.Alias
+	if err = resp.decode(&alias); err != nil {
+		return err
+	}
+	return c.print(alias, aliasesTable([]registry.Alias{alias}))
+}
+
+func checkCompatibility(ctx context.Context, c *cli, args []string) error {
+	flags := c.newFlagSet()
+	id := flags.String("id", "", "id of the schema the specification is checked against")
+	filename := flags.String("f", "", "the file containing the new specification")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	if err := require("id", *id); err != nil {
+		return err
+	}
+	if err := require("f", *filename); err != nil {
+		return err
+	}
+
+	specification, err := os.ReadFile(*filename)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.do(ctx, http.MethodPost, "/check/compatibility", nil, registry.SchemaCompatibilityRequest{
+		SchemaID:  *id,
+		NewSchema: string(specification),
+	})
+	return c.printCheck(resp, err, "Schemas are compatible")
+}
+
+func checkValidity(ctx context.Context, c *cli, args []string) error {
+	flags := c.newFlagSet()
+	filename := flags.String("f", "", "the file containing the specification")
+	format := flags.String("t", "", "schema type")
+	mode := flags.String("mode", "", "validity mode (none, syntax-only, full or lint)")
+	id := flags.String("id", "", "schema id whose lint rules and previous versions are used in the lint mode")
+	publisher := flags.String("publisher", "", "publisher id whose lint rules are used in the lint mode")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	if err := require("f", *filename); err != nil {
+		return err
+	}
+	if err := require("t", *format); err != nil {
+		return err
+	}
+
+	specification, err := os.ReadFile(*filename)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.do(ctx, http.MethodPost, "/check/validity", nil, registry.SchemaValidityRequest{
+		NewSchema:   string(specification),
+		Format:      *format,
+		Mode:        *mode,
+		SchemaID:    *id,
+		PublisherID: *publisher,
+	})
+	return c.printCheck(resp, err, "Schema is valid")
+}
+
+func compatibilityMatrix(ctx context.Context, c *cli, args []string) error {
+	flags := c.newFlagSet()
+	id := flags.String("id", "", "id of the schema")
+	mode := flags.String("mode", "", "compatibility mode the versions are checked under (default: the mode of the schema)")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	if err := require("id", *id); err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	if *mode != "" {
+		query.Set("mode", *mode)
+	}
+	var matrix registry.CompatibilityMatrix
+	if err := c.client.get(ctx, "/schemas/"+url.PathEscape(*id)+"/compatibility-matrix", query, &matrix); err != nil {
+		return err
+	}
+	if err := c.print(matrix, matrixTable(matrix)); err != nil {
+		return err
+	}
+	if !matrix.Compatible {
+		return errors.Wrapf(errRejected, "the history of the schema doesn't satisfy %s", matrix.Mode)
+	}
+	return nil
+}
+
+// printCheck prints the result of a check, returning errRejected if the check failed.
+func (c *cli) printCheck(resp response, err error, passedMessage string) error {
+	if err != nil {
+		return err
+	}
+	if resp.status != http.StatusConflict {
+		if err = resp.err(); err != nil {
+			return err
+		}
+	}
+
+	result := checkResult{
+		Passed:  resp.status != http.StatusConflict,
+		Message: passedMessage,
+	}
+	// a check which failed or passed with lint warnings responds with a message instead of a boolean
+	if !result.Passed || bytes.HasPrefix(bytes.TrimSpace(resp.body), []byte("{")) {
+		var message report
+		if err = resp.decode(&message); err != nil {
+			return err
+		}
+		result.Message = message.Message
+		result.Violations = message.Violations
+	}
+	if err = c.print(result, reportTable(report{Message: result.Message, Violations: result.Violations})); err != nil {
+		return err
+	}
+	if !result.Passed {
+		return errors.Wrap(errRejected, result.Message)
+	}
+	return nil
+}
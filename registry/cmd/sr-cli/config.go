@@ -0,0 +1,150 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	configEnvKey  = "SR_CLI_CONFIG"
+	profileEnvKey = "SR_CLI_PROFILE"
+	urlEnvKey     = "SR_URL"
+)
+
+const (
+	defaultProfile = "default"
+	defaultURL     = "http://localhost:8080"
+	defaultActor   = "sr-cli"
+)
+
+// config is the config file of sr-cli, which holds a profile for every registry the user works with, for example:
+//
+//	current: dev
+//	profiles:
+//	  dev:
+//	    url: http://localhost:8080
+//	  prod:
+//	    url: https://schema-registry.example.com
+//	    actor: release-pipeline
+type config struct {
+	Current  string             `yaml:"current"`
+	Profiles map[string]profile `yaml:"profiles"`
+}
+
+// profile holds the settings of a single registry.
+type profile struct {
+	URL   string `yaml:"url"`
+	Actor string `yaml:"actor"`
+}
+
+// commonFlags are the flags every command accepts.
+type commonFlags struct {
+	profile string
+	config  string
+	url     string
+	output  string
+}
+
+// register registers the common flags in the given flag set.
+func (f *commonFlags) register(flags *flag.FlagSet) {
+	flags.StringVar(&f.profile, "profile", "", "profile of the config file")
+	flags.StringVar(&f.config, "config", "", "path of the config file")
+	flags.StringVar(&f.url, "url", "", "url of the registry, overrides the profile")
+	flags.StringVar(&f.output, "output", outputTable, "output format, one of json, yaml and table")
+	flags.StringVar(&f.output, "o", outputTable, "shorthand for -output")
+}
+
+// configPath returns the path of the config file.
+func (f *commonFlags) configPath() string {
+	if f.config != "" {
+		return f.config
+	}
+	if path := os.Getenv(configEnvKey); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "sr-cli", "config.yaml")
+}
+
+// resolveProfile returns the profile selected by the flags, the environment and the config file, in that order.
+func (f *commonFlags) resolveProfile() (profile, error) {
+	cfg, err := loadConfig(f.configPath())
+	if err != nil {
+		return profile{}, err
+	}
+
+	name, explicit := f.profile, true
+	if name == "" {
+		name = os.Getenv(profileEnvKey)
+	}
+	if name == "" {
+		name, explicit = cfg.Current, false
+	}
+	if name == "" {
+		name = defaultProfile
+	}
+
+	selected, ok := cfg.Profiles[name]
+	if !ok && explicit {
+		return profile{}, errors.Wrapf(errUsage, "profile %q isn't defined in the config file", name)
+	}
+
+	if f.url != "" {
+		selected.URL = f.url
+	} else if url := os.Getenv(urlEnvKey); url != "" {
+		selected.URL = url
+	}
+	if selected.URL == "" {
+		selected.URL = defaultURL
+	}
+	if selected.Actor == "" {
+		selected.Actor = os.Getenv("USER")
+	}
+	if selected.Actor == "" {
+		selected.Actor = defaultActor
+	}
+	return selected, nil
+}
+
+// loadConfig reads the config file under the given path, a missing file is the same as an empty one.
+func loadConfig(path string) (config, error) {
+	if path == "" {
+		return config{}, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return config{}, nil
+		}
+		return config{}, err
+	}
+	defer file.Close()
+
+	var cfg config
+	if err = yaml.NewDecoder(file).Decode(&cfg); err != nil && err != io.EOF {
+		return config{}, errors.Wrapf(err, "couldn't read config file %s", path)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,226 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around every change.
+const diffContext = 3
+
+// diffResult is the result of comparing two specifications.
+type diffResult struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Identical bool   `json:"identical"`
+	Diff      string `json:"diff"`
+}
+
+// diffOp is a line of a diff, which is either kept, removed or added.
+type diffOp struct {
+	kind byte
+	line string
+}
+
+func diffSchemaVersions(ctx context.Context, c *cli, args []string) error {
+	flags := c.newFlagSet()
+	id := flags.String("id", "", "id of the schema")
+	from := flags.String("from", "", "version compared from, latest by default if -f is given")
+	to := flags.String("to", latestVersion, "version compared to")
+	filename := flags.String("f", "", "compare the version given by -from to the specification in this file instead")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	if err := require("id", *id); err != nil {
+		return err
+	}
+	if *filename == "" {
+		if err := require("from", *from); err != nil {
+			return err
+		}
+	} else if *from == "" {
+		*from = latestVersion
+	}
+
+	fromSpecification, err := c.fetchSpecification(ctx, *id, *from)
+	if err != nil {
+		return err
+	}
+	result := diffResult{From: fmt.Sprintf("schema %s version %s", *id, *from)}
+
+	var toSpecification []byte
+	if *filename != "" {
+		if toSpecification, err = os.ReadFile(*filename); err != nil {
+			return err
+		}
+		result.To = *filename
+	} else {
+		if toSpecification, err = c.fetchSpecification(ctx, *id, *to); err != nil {
+			return err
+		}
+		result.To = fmt.Sprintf("schema %s version %s", *id, *to)
+	}
+
+	hunks := unifiedDiff(diffLines(splitLines(normalize(fromSpecification)), splitLines(normalize(toSpecification))), diffContext)
+	result.Identical = len(hunks) == 0
+	if !result.Identical {
+		result.Diff = strings.Join(append([]string{"--- " + result.From, "+++ " + result.To}, hunks...), "\n") + "\n"
+	}
+
+	err = c.print(result, func(w io.Writer) {
+		if result.Identical {
+			_, _ = fmt.Fprintln(w, "specifications are identical")
+			return
+		}
+		_, _ = io.WriteString(w, result.Diff)
+	})
+	if err != nil {
+		return err
+	}
+	if !result.Identical {
+		return errDifferent
+	}
+	return nil
+}
+
+// fetchSpecification gets the specification of the given schema version.
+func (c *cli) fetchSpecification(ctx context.Context, id, version string) ([]byte, error) {
+	details, err := c.fetchSchemaVersion(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+	return details.Specification, nil
+}
+
+// normalize indents JSON specifications the same way, so only the actual changes show up in the diff.
+func normalize(specification []byte) string {
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, bytes.TrimSpace(specification), "", "  "); err != nil {
+		return string(specification)
+	}
+	return indented.String()
+}
+
+// splitLines splits the text into lines, ignoring the trailing newline.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines returns the operations which turn the lines of a into the lines of b, based on their longest common
+// subsequence.
+func diffLines(a, b []string) []diffOp {
+	// common[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else if common[i+1][j] >= common[i][j+1] {
+				common[i][j] = common[i+1][j]
+			} else {
+				common[i][j] = common[i][j+1]
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{kind: ' ', line: a[i]})
+			i++
+			j++
+		case common[i+1][j] >= common[i][j+1]:
+			ops = append(ops, diffOp{kind: '-', line: a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{kind: '+', line: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{kind: '-', line: a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{kind: '+', line: b[j]})
+	}
+	return ops
+}
+
+// unifiedDiff returns the lines of the hunks of the unified diff of the operations, with the given number of
+// unchanged lines around every change. Returns no lines if nothing changed.
+func unifiedDiff(ops []diffOp, context int) []string {
+	// positions[k] holds the number of lines of a and b before the k-th operation
+	positions := make([][2]int, len(ops)+1)
+	for k, op := range ops {
+		positions[k+1] = positions[k]
+		if op.kind != '+' {
+			positions[k+1][0]++
+		}
+		if op.kind != '-' {
+			positions[k+1][1]++
+		}
+	}
+
+	var hunks []string
+	for k := 0; k < len(ops); {
+		for k < len(ops) && ops[k].kind == ' ' {
+			k++
+		}
+		if k == len(ops) {
+			break
+		}
+
+		start := k - context
+		if start < 0 {
+			start = 0
+		}
+		// changes separated by fewer unchanged lines than the context on both sides belong to the same hunk
+		end := k
+		for next := k; next < len(ops) && next-end <= 2*context; next++ {
+			if ops[next].kind != ' ' {
+				end = next
+			}
+		}
+		stop := end + context + 1
+		if stop > len(ops) {
+			stop = len(ops)
+		}
+
+		hunks = append(hunks, fmt.Sprintf("@@ -%d,%d +%d,%d @@",
+			positions[start][0]+1, positions[stop][0]-positions[start][0],
+			positions[start][1]+1, positions[stop][1]-positions[start][1],
+		))
+		for _, op := range ops[start:stop] {
+			hunks = append(hunks, string(op.kind)+op.line)
+		}
+		k = stop
+	}
+	return hunks
+}
@@ -12,162 +12,138 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// Command sr-cli is a command line client of the schema registry REST API.
 package main
 
 import (
 	"context"
 	"flag"
-	"log"
+	"fmt"
+	"io"
 	"os"
+	"strings"
 	"time"
 
-	"github.com/dataphos/schema-registry/compatibility"
-	"github.com/dataphos/schema-registry/internal/errcodes"
-	"github.com/dataphos/schema-registry/registry"
-	"github.com/dataphos/schema-registry/registry/repository/postgres"
-	"github.com/dataphos/schema-registry/validity"
+	"github.com/pkg/errors"
 )
 
-func main() {
-	registerCommand := flag.NewFlagSet("register", flag.ExitOnError)
-	updateCommand := flag.NewFlagSet("update", flag.ExitOnError)
-
-	if len(os.Args) < 2 {
-		log.Fatal("register or update command must be provided")
-	}
-
-	switch os.Args[1] {
-	case "register":
-		registerSchema(registerCommand)
-	case "update":
-		updateSchema(updateCommand)
-	default:
-		log.Fatal("command not supported")
-	}
-}
-
-func registerSchema(registerCommand *flag.FlagSet) {
-	filename := registerCommand.String("f", "", "the json file containing schema specification")
-	schemaType := registerCommand.String("t", "", "schema type")
-	name := registerCommand.String("n", "schema-janitor", "schema name")
-	description := registerCommand.String("d", "description of the schema", "schema description")
-	publisherId := registerCommand.String("p", "publisherId", "publisher id")
-	compMode := registerCommand.String("c", "", "compatibility mode")
-	valMode := registerCommand.String("v", "", "validity mode")
-
-	err := registerCommand.Parse(os.Args[2:])
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	if *filename == "" {
-		log.Fatal("filename must be provided")
-	}
-
-	if *schemaType == "" {
-		log.Fatal("type must be provided")
-	}
-
-	if *valMode == "" {
-		log.Fatal("validity mode must be provided")
-	}
-
-	if *compMode == "" {
-		log.Fatal("compatibility mode must be provided")
-	}
-
-	file, err := os.ReadFile(*filename)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	schemaRegistrationRequest := registry.SchemaRegistrationRequest{
-		Description:       *description,
-		Specification:     string(file),
-		Name:              *name,
-		SchemaType:        *schemaType,
-		PublisherID:       *publisherId,
-		CompatibilityMode: *compMode,
-		ValidityMode:      *valMode,
-	}
-
-	service := createService()
-	details, added, err := service.CreateSchema(schemaRegistrationRequest)
-
-	if err != nil {
-		log.Fatal(err)
-	}
-	if !added {
-		log.Print("schema already exists")
-	} else {
-		log.Printf("created schema under the id %s and version %s", details.SchemaID, details.Version)
-	}
-}
-
-func updateSchema(updateCommand *flag.FlagSet) {
-	filename := updateCommand.String("f", "", "the json file containing updated schema specification")
-	description := updateCommand.String("d", "", "updated schema description")
-	id := updateCommand.String("id", "", "id of the schema")
-
-	err := updateCommand.Parse(os.Args[2:])
-	if err != nil {
-		log.Fatal(err)
-	}
+// The exit codes of sr-cli, so CI pipelines can tell why a command failed.
+const (
+	exitOK = iota
+	exitError
+	exitUsage
+	exitNotFound
+	exitRejected
+	exitDifferent
+)
 
-	if *filename == "" {
-		log.Fatal("filename must be provided")
-	}
+// requestTimeout is the timeout of every command.
+const requestTimeout = 30 * time.Second
 
-	if *id == "" {
-		log.Fatal("id must be provided")
-	}
+var (
+	errUsage     = errors.New("invalid usage")
+	errNotFound  = errors.New("not found")
+	errRejected  = errors.New("rejected")
+	errDifferent = errors.New("specifications differ")
+)
 
-	file, err := os.ReadFile(*filename)
-	if err != nil {
-		log.Fatal(err)
-	}
+const usage = `usage: sr-cli <command> [flags]
+
+commands:
+  register             register a new schema
+  update               add a new version to a schema
+  get                  get a schema version
+  latest               get the latest version of a schema
+  list                 list the schemas, or the versions of a schema
+  search               search the schemas
+  delete               delete a schema or a schema version
+  usage                list the producers and consumers using a schema
+  alias                list, set or delete the version aliases of a schema
+  check compatibility  check if a specification is compatible with a schema
+  check validity       check if a specification is valid
+  diff                 compare the specifications of two schema versions
+  matrix               check the compatibility of every pair of versions of a schema
+
+common flags:
+  -profile string  profile of the config file (default: SR_CLI_PROFILE, then the current profile)
+  -config string   path of the config file (default: SR_CLI_CONFIG, then <user config dir>/sr-cli/config.yaml)
+  -url string      url of the registry, overrides the profile (default: SR_URL)
+  -o, -output      output format, one of json, yaml and table (default table)
+
+exit codes:
+  0 success, 1 error, 2 invalid usage, 3 not found, 4 rejected by a check, 5 specifications differ
+
+Run 'sr-cli <command> -h' for the flags of a command.
+`
+
+// commandFunc runs a command with the flags which follow its name.
+type commandFunc func(ctx context.Context, c *cli, args []string) error
 
-	schemaUpdateRequest := registry.SchemaUpdateRequest{
-		Specification: string(file),
-	}
-	if *description != "" {
-		schemaUpdateRequest.Description = *description
-	}
-
-	service := createService()
-	details, updated, err := service.UpdateSchema(*id, schemaUpdateRequest)
-	if err != nil {
-		log.Fatal(err)
-	}
-	if !updated {
-		log.Print("schema already exists")
-	} else {
-		log.Print("schema successfully updated, added version ", details.Version)
-	}
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
 }
 
-func createService() *registry.Service {
-	db, err := postgres.InitializeGormFromEnv()
-	if err != nil {
-		log.Fatal(err, errcodes.DatabaseConnectionInitialization)
-	}
-	if !postgres.HealthCheck(db) {
-		log.Fatal("database state invalid", errcodes.InvalidDatabaseState)
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// run runs the command given by the arguments and returns the exit code.
+func run(args []string, stdout, stderr io.Writer) int {
+	commands := map[string]commandFunc{
+		"register":            registerSchema,
+		"update":              updateSchema,
+		"get":                 getSchemaVersion,
+		"latest":              getLatestSchemaVersion,
+		"list":                listSchemas,
+		"search":              searchSchemas,
+		"delete":              deleteSchema,
+		"usage":               listUsage,
+		"alias":               manageAliases,
+		"check compatibility": checkCompatibility,
+		"check validity":      checkValidity,
+		"diff":                diffSchemaVersions,
+		"matrix":              compatibilityMatrix,
+	}
+
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		_, _ = fmt.Fprint(stderr, usage)
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return exitOK
+	}
+
+	name := args[0]
+	if name == "check" && len(args) > 1 {
+		name += " " + args[1]
+	}
+	command, ok := commands[name]
+	if !ok {
+		_, _ = fmt.Fprintf(stderr, "unknown command %q\n\n%s", name, usage)
+		return exitUsage
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
 	defer cancel()
 
-	compChecker, globalCompMode, err := compatibility.InitCompatibilityChecker(ctx)
-	if err != nil {
-		log.Fatal(err, errcodes.ExternalCheckerInitialization)
+	c := &cli{name: name, stdout: stdout, stderr: stderr}
+	err := command(ctx, c, args[len(strings.Fields(name)):])
+	if err != nil && !errors.Is(err, errDifferent) {
+		_, _ = fmt.Fprintln(stderr, "Error:", err)
 	}
+	return exitCode(err)
+}
 
-	valChecker, globalValMode, err := validity.InitExternalValidityChecker(ctx)
-	if err != nil {
-		log.Fatal(err, errcodes.ExternalCheckerInitialization)
+// exitCode returns the exit code of the error returned by a command.
+func exitCode(err error) int {
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.Is(err, errUsage):
+		return exitUsage
+	case errors.Is(err, errNotFound):
+		return exitNotFound
+	case errors.Is(err, errRejected):
+		return exitRejected
+	case errors.Is(err, errDifferent):
+		return exitDifferent
+	default:
+		return exitError
 	}
-
-	service := registry.New(postgres.New(db), compChecker, valChecker, globalCompMode, globalValMode)
-	return service
 }
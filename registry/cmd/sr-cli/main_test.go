@@ -0,0 +1,280 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/dataphos/schema-registry/internal/errcodes"
+	"github.com/dataphos/schema-registry/registry"
+)
+
+// newFakeRegistry returns a server which answers like a registry with a single schema of two versions.
+func newFakeRegistry(t *testing.T, actors *[]string) *httptest.Server {
+	version := func(version, specification string) registry.VersionDetails {
+		return registry.VersionDetails{
+			Version:       version,
+			SchemaID:      "1",
+			Specification: []byte(specification),
+			SchemaHash:    "9f8f1a88fdc11bf262095a82a607a61086641ad8da16ab4b6e104dd32920d20f",
+			CreatedAt:     time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		}
+	}
+	versions := map[string]registry.VersionDetails{
+		"1":      version("1", `{"type":"object"}`),
+		"2":      version("2", `{"type":"object","properties":{"name":{"type":"string"}}}`),
+		"latest": version("2", `{"type":"object","properties":{"name":{"type":"string"}}}`),
+	}
+
+	write := func(w http.ResponseWriter, status int, body interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(body)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/schemas", func(w http.ResponseWriter, r *http.Request) {
+		*actors = append(*actors, r.Header.Get(actorHeader))
+		write(w, http.StatusCreated, insertInfo{ID: "1", Version: "1", Message: "schema successfully created"})
+	})
+	usage := []registry.Usage{{
+		SchemaID: "1",
+		Version:  "2",
+		Topic:    "orders",
+		Role:     registry.UsageRoleProducer,
+		ClientID: "orders-service",
+		LastSeen: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+	}}
+	mux.HandleFunc("/schemas/1/usage", func(w http.ResponseWriter, r *http.Request) {
+		write(w, http.StatusOK, usage)
+	})
+	aliases := []registry.Alias{{SchemaID: "1", Alias: "prod", Version: "1", UpdatedAt: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)}}
+	mux.HandleFunc("/schemas/1/aliases", func(w http.ResponseWriter, r *http.Request) {
+		write(w, http.StatusOK, aliases)
+	})
+	mux.HandleFunc("/schemas/1/aliases/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			write(w, http.StatusOK, report{Message: "Alias prod of schema with id=1 successfully deleted"})
+			return
+		}
+		var request registry.AliasRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		alias := aliases[0]
+		alias.Version = request.Version
+		write(w, http.StatusOK, alias)
+	})
+	mux.HandleFunc("/schemas/1/versions/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			if r.URL.Query().Get("force") != "true" {
+				write(w, http.StatusConflict, problem{Title: "Schema is in use", Detail: "Schema with id=1 and version=2 is in use", Code: errcodes.SchemaInUse, Usage: usage})
+				return
+			}
+			write(w, http.StatusOK, report{Message: "Schema with id=1 and version=2 successfully deleted"})
+			return
+		}
+		details, ok := versions[strings.TrimPrefix(r.URL.Path, "/schemas/1/versions/")]
+		if !ok {
+			write(w, http.StatusNotFound, problem{Title: "Not found", Detail: "Schema with id=1 and version=3 is not registered", Code: errcodes.NotFound})
+			return
+		}
+		write(w, http.StatusOK, details)
+	})
+	mux.HandleFunc("/check/compatibility", func(w http.ResponseWriter, r *http.Request) {
+		var request registry.SchemaCompatibilityRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		if strings.Contains(request.NewSchema, "incompatible") {
+			write(w, http.StatusConflict, report{Message: "Schemas are not compatible"})
+			return
+		}
+		write(w, http.StatusOK, true)
+	})
+	mux.HandleFunc("/schemas/1/compatibility-matrix", func(w http.ResponseWriter, r *http.Request) {
+		mode := r.URL.Query().Get("mode")
+		if mode == "" {
+			mode = "BACKWARD"
+		}
+		// the first version is incompatible with the second one only in the forward direction
+		compatible := !strings.HasPrefix(mode, "FORWARD")
+		write(w, http.StatusOK, registry.CompatibilityMatrix{
+			SchemaID:   "1",
+			Mode:       mode,
+			Versions:   []string{"1", "2"},
+			Compatible: compatible,
+			Pairs:      []registry.CompatibilityPair{{Older: "1", Newer: "2", Compatible: compatible, Required: true}},
+		})
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRun(t *testing.T) {
+	var actors []string
+	srv := newFakeRegistry(t, &actors)
+	t.Setenv(configEnvKey, filepath.Join(t.TempDir(), "missing.yaml"))
+	t.Setenv(urlEnvKey, srv.URL)
+	t.Setenv(profileEnvKey, "")
+
+	spec := writeFile(t, "spec.json", `{"type":"object"}`)
+	incompatible := writeFile(t, "incompatible.json", `"incompatible"`)
+
+	tt := []struct {
+		name     string
+		args     []string
+		exitCode int
+		output   string
+	}{
+		{"no command", nil, exitUsage, ""},
+		{"unknown command", []string{"publish"}, exitUsage, ""},
+		{"missing flag", []string{"register", "-f", spec}, exitUsage, ""},
+		{"unknown output", []string{"latest", "-id", "1", "-o", "xml"}, exitUsage, ""},
+		{"register", []string{"register", "-f", spec, "-t", "json", "-c", "none", "-v", "none"}, exitOK, "schema successfully created"},
+		{"get", []string{"get", "-id", "1", "-version", "1"}, exitOK, "9f8f1a88fdc1"},
+		{"get json", []string{"get", "-id", "1", "-version", "1", "-o", "json"}, exitOK, `"schema_hash": "9f8f1a88fdc11bf262095a82a607a61086641ad8da16ab4b6e104dd32920d20f"`},
+		{"get yaml", []string{"get", "-id", "1", "-version", "1", "-output", "yaml"}, exitOK, "version: \"1\"\nschema_id: \"1\"\n"},
+		{"get spec", []string{"get", "-id", "1", "-version", "1", "-spec"}, exitOK, `{"type":"object"}`},
+		{"get missing", []string{"get", "-id", "1", "-version", "3"}, exitNotFound, ""},
+		{"latest", []string{"latest", "-id", "1", "-spec"}, exitOK, `"properties"`},
+		{"compatible", []string{"check", "compatibility", "-id", "1", "-f", spec}, exitOK, "Schemas are compatible"},
+		{"incompatible", []string{"check", "compatibility", "-id", "1", "-f", incompatible}, exitRejected, "Schemas are not compatible"},
+		{"identical", []string{"diff", "-id", "1", "-from", "1", "-f", spec}, exitOK, "identical"},
+		{"different", []string{"diff", "-id", "1", "-from", "1", "-to", "2"}, exitDifferent, "+  \"properties\": {"},
+		{"usage", []string{"usage", "-id", "1"}, exitOK, "orders-service"},
+		{"aliases", []string{"alias", "-id", "1"}, exitOK, "prod   1"},
+		{"set alias", []string{"alias", "-id", "1", "-name", "prod", "-version", "2"}, exitOK, "prod   2"},
+		{"delete alias", []string{"alias", "-id", "1", "-name", "prod", "-delete"}, exitOK, "successfully deleted"},
+		{"alias without name", []string{"alias", "-id", "1", "-delete"}, exitUsage, ""},
+		{"delete in use", []string{"delete", "-id", "1", "-version", "2"}, exitError, ""},
+		{"delete forced", []string{"delete", "-id", "1", "-version", "2", "-force"}, exitOK, "successfully deleted"},
+		{"matrix", []string{"matrix", "-id", "1"}, exitOK, "1         -  ok"},
+		{"matrix rejected", []string{"matrix", "-id", "1", "-mode", "FORWARD"}, exitRejected, "1        -  FAIL"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tc.args, &stdout, &stderr); code != tc.exitCode {
+				t.Fatalf("expected exit code %d, got %d, stderr: %s", tc.exitCode, code, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tc.output) {
+				t.Errorf("expected output to contain %q, got %q", tc.output, stdout.String())
+			}
+		})
+	}
+
+	if len(actors) != 1 || actors[0] == "" {
+		t.Errorf("expected the actor to be sent with the registration, got %v", actors)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	var actors []string
+	srv := newFakeRegistry(t, &actors)
+	config := writeFile(t, "config.yaml", `current: local
+profiles:
+  local:
+    url: `+srv.URL+`
+    actor: pipeline
+  unreachable:
+    url: http://127.0.0.1:1
+`)
+	t.Setenv(configEnvKey, config)
+	t.Setenv(urlEnvKey, "")
+	t.Setenv(profileEnvKey, "")
+	spec := writeFile(t, "spec.json", `{}`)
+
+	register := []string{"register", "-f", spec, "-t", "json", "-c", "none", "-v", "none"}
+	if code := run(register, io.Discard, io.Discard); code != exitOK {
+		t.Fatalf("expected the current profile to be used, got exit code %d", code)
+	}
+	if len(actors) != 1 || actors[0] != "pipeline" {
+		t.Errorf("expected the actor of the profile, got %v", actors)
+	}
+	if code := run(append(register, "-profile", "unreachable"), io.Discard, io.Discard); code != exitError {
+		t.Errorf("expected the selected profile to be used, got exit code %d", code)
+	}
+	if code := run(append(register, "-profile", "missing"), io.Discard, io.Discard); code != exitUsage {
+		t.Errorf("expected an unknown profile to be rejected, got exit code %d", code)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	a := []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11", "12"}
+	b := []string{"1", "2", "3", "four", "5", "6", "7", "8", "9", "10", "11", "12", "13"}
+
+	expected := []string{
+		"@@ -1,7 +1,7 @@", " 1", " 2", " 3", "-4", "+four", " 5", " 6", " 7",
+		"@@ -10,3 +10,4 @@", " 10", " 11", " 12", "+13",
+	}
+	got := unifiedDiff(diffLines(a, b), diffContext)
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+	if hunks := unifiedDiff(diffLines(a, a), diffContext); len(hunks) != 0 {
+		t.Errorf("expected no hunks for identical lines, got %v", hunks)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	tt := []struct {
+		name     string
+		status   int
+		body     string
+		message  string
+		exitCode int
+	}{
+		{"problem", http.StatusBadRequest, `{"title":"Schema is not valid","status":400,"detail":"Schema is not valid","code":40005}`, "Schema is not valid", exitRejected},
+		{"problem without detail", http.StatusForbidden, `{"title":"Denied by admission webhook","status":403,"code":40301}`, "Denied by admission webhook", exitRejected},
+		{"problem code over status", http.StatusUnprocessableEntity, `{"title":"Invalid value","status":422,"detail":"Id=1 and/or version=first are not of supported data types","code":40001}`, "Id=1 and/or version=first are not of supported data types", exitError},
+		{"not found code", http.StatusNotFound, `{"title":"Not found","status":404,"code":404}`, "Not found", exitNotFound},
+		{"message", http.StatusNotFound, `{"message":"Schema with id=1 is not registered"}`, "Schema with id=1 is not registered", exitNotFound},
+		{"no body", http.StatusBadGateway, "", "", exitError},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := response{status: tc.status, body: []byte(tc.body)}.err()
+			var apiErr *apiError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected an apiError, got %v", err)
+			}
+			if apiErr.message != tc.message {
+				t.Errorf("expected message %q, got %q", tc.message, apiErr.message)
+			}
+			if code := exitCode(err); code != tc.exitCode {
+				t.Errorf("expected exit code %d, got %d", tc.exitCode, code)
+			}
+		})
+	}
+}
@@ -14,12 +14,15 @@
 
 package compatibility
 
+import "context"
+
+// Checker checks whether a schema is compatible with the raw specifications of the previous versions in the given mode.
 type Checker interface {
-	Check(schema string, history []string, mode string) (bool, error)
+	Check(ctx context.Context, schema string, history []string, mode string) (bool, error)
 }
 
-type CheckerFunc func(schema string, history []string, mode string) (bool, error)
+type CheckerFunc func(ctx context.Context, schema string, history []string, mode string) (bool, error)
 
-func (f CheckerFunc) Check(schema string, history []string, mode string) (bool, error) {
-	return f(schema, history, mode)
+func (f CheckerFunc) Check(ctx context.Context, schema string, history []string, mode string) (bool, error) {
+	return f(ctx, schema, history, mode)
 }
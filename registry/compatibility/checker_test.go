@@ -16,7 +16,6 @@ package compatibility
 
 import (
 	"context"
-	"encoding/base64"
 	"encoding/json"
 	"os"
 	"path/filepath"
@@ -48,7 +47,7 @@ func TestCompatibilityChecker_Check(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	checker, err := NewFromEnv(ctx)
+	checker, err := New(ctx, "http://localhost:8088", DefaultTimeoutBase)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -98,9 +97,9 @@ func TestCompatibilityChecker_Check(t *testing.T) {
 				t.Fatalf("couldn't unmarshall schema history")
 			}
 
-			schemaHistory = append(schemaHistory, base64.StdEncoding.EncodeToString([]byte(previousSchemaJson.Schema)))
+			schemaHistory = append(schemaHistory, previousSchemaJson.Schema)
 
-			compatible, err := checker.Check(string(newSchema), schemaHistory, tc.mode)
+			compatible, err := checker.Check(context.Background(), string(newSchema), schemaHistory, tc.mode)
 			if err != nil {
 				t.Errorf("validator error: %s", err)
 			}
@@ -16,8 +16,6 @@ package compatibility
 
 import (
 	"context"
-	"encoding/base64"
-	"os"
 	"strings"
 	"time"
 
@@ -29,14 +27,6 @@ import (
 	"github.com/dataphos/lib-retry/pkg/retry"
 	"github.com/dataphos/schema-registry/compatibility/http"
 	"github.com/dataphos/schema-registry/internal/config"
-	"github.com/dataphos/schema-registry/internal/errcodes"
-	"github.com/dataphos/schema-registry/internal/errtemplates"
-)
-
-const (
-	urlEnvKey               = "COMPATIBILITY_CHECKER_URL"
-	timeoutEnvKey           = "COMPATIBILITY_CHECKER_TIMEOUT_BASE"
-	globalCompatibilityMode = "GLOBAL_COMPATIBILITY_MODE"
 )
 
 const (
@@ -50,25 +40,6 @@ type ExternalChecker struct {
 	Log         logger.Log
 }
 
-// NewFromEnv loads the needed environment variables and calls New.
-func NewFromEnv(ctx context.Context) (*ExternalChecker, error) {
-	url := os.Getenv(urlEnvKey)
-	if url == "" {
-		return nil, errtemplates.EnvVariableNotDefined(urlEnvKey)
-	}
-
-	timeout := DefaultTimeoutBase
-	if timeoutStr := os.Getenv(timeoutEnvKey); timeoutStr != "" {
-		var err error
-		timeout, err = time.ParseDuration(timeoutStr)
-		if err != nil {
-			return nil, errors.Wrap(err, errtemplates.ParsingEnvVariableFailed(timeoutEnvKey))
-		}
-	}
-
-	return New(ctx, url, timeout)
-}
-
 // New returns a new instance of Repository.
 func New(ctx context.Context, url string, timeoutBase time.Duration) (*ExternalChecker, error) {
 	if err := retry.Do(ctx, retry.WithJitter(retry.Constant(2*time.Second)), func(ctx context.Context) error {
@@ -94,37 +65,20 @@ func New(ctx context.Context, url string, timeoutBase time.Duration) (*ExternalC
 	}, nil
 }
 
-func (c *ExternalChecker) Check(schemaInfo string, history []string, mode string) (bool, error) {
+func (c *ExternalChecker) Check(ctx context.Context, schemaInfo string, history []string, mode string) (bool, error) {
 	//check if compatibility mode is none, if it is, don't send HTTP request to java code
 	if strings.ToLower(mode) == "none" {
 		return true, nil
 	}
 	size := calculateSizeInBytes(schemaInfo, history, mode)
-	ctx, cancel := context.WithTimeout(context.Background(), http.EstimateHTTPTimeout(size, c.TimeoutBase))
+	ctx, cancel := context.WithTimeout(ctx, http.EstimateHTTPTimeout(size, c.TimeoutBase))
 	defer cancel()
 
-	decodedHistory, err := c.DecodeHistory(history)
-	if err != nil {
-		c.Log.Error("could not decode", errcodes.SchemaUndecodable)
-		return false, err
-	}
-	compatible, info, err := http.CheckOverHTTP(ctx, schemaInfo, decodedHistory, mode, c.Url+"/")
+	compatible, info, err := http.CheckOverHTTP(ctx, schemaInfo, history, mode, c.Url+"/")
 	c.Log.Info(info)
 	return compatible, err
 }
 
-func (c *ExternalChecker) DecodeHistory(history []string) ([]string, error) {
-	var decodedHistory []string
-	for i := 0; i < len(history); i++ {
-		decoded, err := base64.StdEncoding.DecodeString(history[i])
-		if err != nil {
-			return nil, err
-		}
-		decodedHistory = append(decodedHistory, string(decoded))
-	}
-	return decodedHistory, nil
-}
-
 func calculateSizeInBytes(schema string, history []string, mode string) int {
 	bytes := []byte(schema + mode)
 	for i := 0; i < len(history); i++ {
@@ -133,21 +87,22 @@ func calculateSizeInBytes(schema string, history []string, mode string) int {
 	return len(bytes)
 }
 
-func InitCompatibilityChecker(ctx context.Context) (*ExternalChecker, string, error) {
-	compChecker, err := NewFromEnv(ctx)
-	if err != nil {
-		return nil, "", err
-	}
-	globalCompMode := os.Getenv(globalCompatibilityMode)
+// InitCompatibilityChecker connects to the compatibility checker at the given url and checks that the global
+// compatibility mode is supported, BACKWARD if it's empty.
+func InitCompatibilityChecker(ctx context.Context, url string, timeoutBase time.Duration, globalCompMode string) (*ExternalChecker, string, error) {
 	if globalCompMode == "" {
 		globalCompMode = defaultGlobalCompatibilityMode
 	}
-	if globalCompMode == "BACKWARD" || globalCompMode == "BACKWARD_TRANSITIVE" ||
-		globalCompMode == "FORWARD" || globalCompMode == "FORWARD_TRANSITIVE" ||
-		globalCompMode == "FULL" || globalCompMode == "FULL_TRANSITIVE" || globalCompMode == "NONE" {
-		return compChecker, globalCompMode, nil
+	if globalCompMode != "BACKWARD" && globalCompMode != "BACKWARD_TRANSITIVE" &&
+		globalCompMode != "FORWARD" && globalCompMode != "FORWARD_TRANSITIVE" &&
+		globalCompMode != "FULL" && globalCompMode != "FULL_TRANSITIVE" && globalCompMode != "NONE" {
+		return nil, "", errors.Errorf("unsupported compatibility mode")
+	}
+	compChecker, err := New(ctx, url, timeoutBase)
+	if err != nil {
+		return nil, "", err
 	}
-	return nil, "", errors.Errorf("unsupported compatibility mode")
+	return compChecker, globalCompMode, nil
 }
 
 func CheckIfValidMode(mode *string) bool {
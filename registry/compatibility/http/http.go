@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 // checkRequest contains a new schema, list of old schemas and a compatibility mode which should be enforced. The structure represents an HTTP
@@ -41,6 +42,9 @@ type checkResponse struct {
 	Info   string `json:"info"`
 }
 
+// client propagates the trace context of the request to the checker and records a span of the call.
+var client = &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}
+
 // HTTPTimeoutBytesUnit the base amount of bytes used by EstimateHTTPTimeout.
 const HTTPTimeoutBytesUnit = 1024 * 100
 
@@ -102,5 +106,5 @@ func sendCheckRequest(ctx context.Context, payload string, history []string, mod
 		return nil, err
 	}
 
-	return http.DefaultClient.Do(request)
+	return client.Do(request)
 }
@@ -1,5 +1,4 @@
 // Code generated by swaggo/swag. DO NOT EDIT.
-
 package docs
 
 import "github.com/swaggo/swag"
@@ -16,6 +15,82 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/audit": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get audit records",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "schema id",
+                        "name": "schema_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 timestamp of the oldest record",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "id of the record the page starts after",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "maximum number of records, 100 by default and at most 1000",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/fingerprints/{fingerprint}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get schema versions by fingerprint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SHA-256 schema hash or CRC-64-AVRO fingerprint, in hex",
+                        "name": "fingerprint",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
         "/schemas": {
             "get": {
                 "produces": [
@@ -59,11 +134,20 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request"
                     },
+                    "403": {
+                        "description": "Forbidden"
+                    },
                     "409": {
                         "description": "Conflict"
                     },
+                    "413": {
+                        "description": "Request Entity Too Large"
+                    },
                     "500": {
                         "description": "Internal Server Error"
+                    },
+                    "503": {
+                        "description": "Service Unavailable"
                     }
                 }
             }
@@ -87,6 +171,45 @@ const docTemplate = `{
                 }
             }
         },
+        "/schemas/lookup": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Look up a schema by its specification",
+                "parameters": [
+                    {
+                        "description": "schema lookup request",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/registry.LookupRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
         "/schemas/search": {
             "get": {
                 "produces": [
@@ -193,14 +316,23 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request"
                     },
+                    "403": {
+                        "description": "Forbidden"
+                    },
                     "404": {
                         "description": "Not Found"
                     },
                     "409": {
                         "description": "Conflict"
                     },
+                    "413": {
+                        "description": "Request Entity Too Large"
+                    },
                     "500": {
                         "description": "Internal Server Error"
+                    },
+                    "503": {
+                        "description": "Service Unavailable"
                     }
                 }
             },
@@ -216,6 +348,12 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "delete the schema even if it is in use",
+                        "name": "force",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -227,16 +365,20 @@ const docTemplate = `{
                     },
                     "404": {
                         "description": "Not Found"
+                    },
+                    "409": {
+                        "description": "Conflict"
                     }
                 }
-            }
-        },
-        "/schemas/{id}/versions": {
-            "get": {
+            },
+            "patch": {
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Get all active schema versions by schema id",
+                "summary": "Change the metadata of a schema",
                 "parameters": [
                     {
                         "type": "string",
@@ -244,27 +386,45 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "schema metadata request",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/registry.SchemaMetadataRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK"
                     },
+                    "400": {
+                        "description": "Bad Request"
+                    },
                     "404": {
                         "description": "Not Found"
                     },
+                    "413": {
+                        "description": "Request Entity Too Large"
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity"
+                    },
                     "500": {
                         "description": "Internal Server Error"
                     }
                 }
             }
         },
-        "/schemas/{id}/versions/all": {
+        "/schemas/{id}/aliases": {
             "get": {
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Get schema by schema id",
+                "summary": "Get the aliases of a schema",
                 "parameters": [
                     {
                         "type": "string",
@@ -278,6 +438,9 @@ const docTemplate = `{
                     "200": {
                         "description": "OK"
                     },
+                    "400": {
+                        "description": "Bad Request"
+                    },
                     "404": {
                         "description": "Not Found"
                     },
@@ -287,12 +450,15 @@ const docTemplate = `{
                 }
             }
         },
-        "/schemas/{id}/versions/latest": {
-            "get": {
+        "/schemas/{id}/aliases/{alias}": {
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Get the latest schema version by schema id",
+                "summary": "Point an alias at a schema version",
                 "parameters": [
                     {
                         "type": "string",
@@ -300,12 +466,31 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "alias",
+                        "name": "alias",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "alias request",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/registry.AliasRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK"
                     },
+                    "400": {
+                        "description": "Bad Request"
+                    },
                     "404": {
                         "description": "Not Found"
                     },
@@ -313,14 +498,12 @@ const docTemplate = `{
                         "description": "Internal Server Error"
                     }
                 }
-            }
-        },
-        "/schemas/{id}/versions/{version}": {
-            "get": {
+            },
+            "delete": {
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Get schema version by schema id and version",
+                "summary": "Delete an alias of a schema",
                 "parameters": [
                     {
                         "type": "string",
@@ -331,8 +514,8 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "version",
-                        "name": "version",
+                        "description": "alias",
+                        "name": "alias",
                         "in": "path",
                         "required": true
                     }
@@ -341,6 +524,9 @@ const docTemplate = `{
                     "200": {
                         "description": "OK"
                     },
+                    "400": {
+                        "description": "Bad Request"
+                    },
                     "404": {
                         "description": "Not Found"
                     },
@@ -348,15 +534,14 @@ const docTemplate = `{
                         "description": "Internal Server Error"
                     }
                 }
-            },
-            "delete": {
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/schemas/{id}/compatibility-matrix": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Delete schema version by schema id and version",
+                "summary": "Get the compatibility matrix of a schema",
                 "parameters": [
                     {
                         "type": "string",
@@ -367,8 +552,38 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "version",
-                        "name": "version",
+                        "description": "compatibility mode, the mode of the schema is used if empty",
+                        "name": "mode",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/schemas/{id}/history": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get the version history of a schema",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "schema id",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
@@ -382,16 +597,19 @@ const docTemplate = `{
                     },
                     "404": {
                         "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
                     }
                 }
             }
         },
-        "/schemas/{id}/versions/{version}/spec": {
+        "/schemas/{id}/usage": {
             "get": {
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Get schema specification by schema id and version",
+                "summary": "Get the usage of a schema",
                 "parameters": [
                     {
                         "type": "string",
@@ -404,6 +622,33 @@ const docTemplate = `{
                         "type": "string",
                         "description": "version",
                         "name": "version",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/schemas/{id}/versions": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get all active schema versions by schema id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "schema id",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
@@ -420,22 +665,407 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "registry.SchemaRegistrationRequest": {
-            "type": "object",
-            "properties": {
-                "attributes": {
-                    "type": "string"
-                },
-                "compatibility_mode": {
-                    "type": "string"
-                },
-                "description": {
-                    "type": "string"
-                },
-                "last_created": {
+        },
+        "/schemas/{id}/versions/all": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get schema by schema id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "schema id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/schemas/{id}/versions/latest": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get the latest schema version by schema id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "schema id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 timestamp the version was the latest one at",
+                        "name": "as_of",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/schemas/{id}/versions/{version}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get schema version by schema id and version",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "schema id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "version, alias or semantic version",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "delete": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Delete schema version by schema id and version",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "schema id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "version, alias or semantic version",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "delete the version even if it is in use",
+                        "name": "force",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    }
+                }
+            }
+        },
+        "/schemas/{id}/versions/{version}/examples": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Generate example payloads of a schema version",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "schema id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "version, alias or semantic version",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of payloads to generate, between 1 and 100",
+                        "name": "count",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "seed of the random generator",
+                        "name": "seed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "generate payloads which violate the schema",
+                        "name": "invalid",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/schemas/{id}/versions/{version}/restore": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Restore a deactivated schema version",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "schema id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "version or alias",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large"
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/schemas/{id}/versions/{version}/spec": {
+            "get": {
+                "produces": [
+                    "application/json",
+                    "application/schema+json",
+                    "application/vnd.apache.avro+json",
+                    "text/x-protobuf",
+                    "application/xml",
+                    "text/csv"
+                ],
+                "summary": "Get schema specification by schema id and version",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "schema id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "version, alias or semantic version",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "json or raw, overrides the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "return the canonical form of the specification",
+                        "name": "canonical",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "406": {
+                        "description": "Not Acceptable"
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/schemas/{id}/versions/{version}/usage": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Register the usage of a schema version",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "schema id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "version, alias or semantic version",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "usage registration request",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/registry.UsageRegistrationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "registry.AliasRequest": {
+            "type": "object",
+            "properties": {
+                "version": {
+                    "description": "Version is the version number, or another alias, the alias is pointed at.",
+                    "type": "string"
+                }
+            }
+        },
+        "registry.LookupRequest": {
+            "type": "object",
+            "properties": {
+                "schema_type": {
+                    "type": "string"
+                },
+                "specification": {
+                    "type": "string"
+                }
+            }
+        },
+        "registry.Rule": {
+            "type": "object",
+            "properties": {
+                "expression": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "severity": {
+                    "description": "Severity is either RuleSeverityError, which dead-letters the messages failing the rule, or RuleSeverityWarning,\nwhich only marks them. RuleSeverityError is used if it is empty.",
+                    "type": "string"
+                }
+            }
+        },
+        "registry.SchemaMetadataRequest": {
+            "type": "object",
+            "properties": {
+                "description": {
                     "type": "string"
                 },
                 "name": {
@@ -443,6 +1073,35 @@ const docTemplate = `{
                 },
                 "publisher_id": {
                     "type": "string"
+                }
+            }
+        },
+        "registry.SchemaRegistrationRequest": {
+            "type": "object",
+            "properties": {
+                "attributes": {
+                    "type": "string"
+                },
+                "compatibility_mode": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "last_created": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "publisher_id": {
+                    "type": "string"
+                },
+                "rules": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/registry.Rule"
+                    }
                 },
                 "schema_type": {
                     "type": "string"
@@ -458,16 +1117,41 @@ const docTemplate = `{
         "registry.SchemaUpdateRequest": {
             "type": "object",
             "properties": {
+                "allow_breaking_change": {
+                    "description": "AllowBreakingChange allows a major change under semantic versioning, which is otherwise only allowed if the\ncompatibility mode of the schema is none, even if the version isn't compatible under that mode.",
+                    "type": "boolean"
+                },
                 "attributes": {
                     "type": "string"
                 },
                 "description": {
                     "type": "string"
                 },
+                "rules": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/registry.Rule"
+                    }
+                },
                 "specification": {
                     "type": "string"
                 }
             }
+        },
+        "registry.UsageRegistrationRequest": {
+            "type": "object",
+            "properties": {
+                "client_id": {
+                    "description": "ClientID identifies the service instance, the actor of the request is used if it is empty.",
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "topic": {
+                    "type": "string"
+                }
+            }
         }
     }
 }`
@@ -0,0 +1,40 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docs
+
+import (
+	_ "embed"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// openAPI is the OpenAPI 3.1 document of the registry REST API.
+//
+//go:embed openapi.json
+var openAPI []byte
+
+// OpenAPI returns the OpenAPI 3.1 document of the registry REST API, with the given base URL as its only server.
+// The base URL can be relative (for example "/" or "/registry"), which keeps the document usable behind a proxy.
+func OpenAPI(baseURL string) ([]byte, error) {
+	var document map[string]interface{}
+	if err := json.Unmarshal(openAPI, &document); err != nil {
+		return nil, errors.Wrap(err, "couldn't unmarshal the OpenAPI document")
+	}
+
+	document["servers"] = []map[string]string{{"url": baseURL}}
+
+	return json.MarshalIndent(document, "", "    ")
+}
@@ -0,0 +1,98 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// loadDefaultsAndEnv sets every field of the struct to the environment variable named after its key path, like
+// fig.UseEnv, or else to the value of its default tag. The key path of a field is made of the toml tags, prefixed by
+// the given path.
+func loadDefaultsAndEnv(v reflect.Value, path string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := field.Tag.Get("toml")
+		if key == "" {
+			continue
+		}
+		if path != "" {
+			key = path + "." + key
+		}
+
+		if field.Type.Kind() == reflect.Struct {
+			if err := loadDefaultsAndEnv(v.Field(i), key); err != nil {
+				return err
+			}
+			continue
+		}
+		value, ok := os.LookupEnv(strings.ToUpper(strings.ReplaceAll(key, ".", "_")))
+		if !ok {
+			value, ok = field.Tag.Lookup("default")
+		}
+		if !ok {
+			continue
+		}
+		if err := setValue(v.Field(i), value); err != nil {
+			return errors.Wrapf(err, "%s", key)
+		}
+	}
+	return nil
+}
+
+// setValue parses the value into the field, where lists are written like fig writes them, as [a,b,c].
+func setValue(field reflect.Value, value string) error {
+	switch {
+	case field.Type() == durationType:
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(duration))
+	case field.Kind() == reflect.String:
+		field.SetString(value)
+	case field.Kind() == reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(parsed)
+	case field.Kind() == reflect.Int || field.Kind() == reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(parsed)
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String:
+		value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+		var values []string
+		if value != "" {
+			values = strings.Split(value, ",")
+		}
+		field.Set(reflect.ValueOf(values))
+	default:
+		return errors.Errorf("can't be set from the environment, it's a %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/kkyr/fig"
+	"github.com/pkg/errors"
+)
+
+// Registry is the configuration of the registry server. It's read from a TOML file and every setting can be overridden
+// by the environment variable named after its key path, in upper case and joined by underscores, so server.port is
+// overridden by SERVER_PORT.
+type Registry struct {
+	Server               Server    `toml:"server"`
+	Metrics              Metrics   `toml:"metrics"`
+	Admission            Admission `toml:"admission"`
+	CompatibilityChecker Checker   `toml:"compatibility_checker"`
+	ValidityChecker      Checker   `toml:"validity_checker"`
+	// GlobalCompatibilityMode and GlobalValidityMode are the modes of the schemas registered without one.
+	GlobalCompatibilityMode string `toml:"global_compatibility_mode" default:"BACKWARD"`
+	GlobalValidityMode      string `toml:"global_validity_mode" default:"FULL"`
+	Cache                   Cache  `toml:"cache"`
+	Usage                   Usage  `toml:"usage"`
+	// SemanticVersioning enables deriving a semantic version of every new schema version from the change it makes.
+	SemanticVersioning bool  `toml:"semantic_versioning"`
+	Audit              Audit `toml:"audit"`
+}
+
+// Server configures the HTTP server of the registry API.
+type Server struct {
+	// Address is the host the server listens on, every interface if it's empty.
+	Address string `toml:"address"`
+	// BaseURL is the URL the registry is reachable at, as seen by the clients, advertised by the OpenAPI document.
+	BaseURL         string        `toml:"base_url" default:"/"`
+	Port            int           `toml:"port" default:"8080"`
+	ShutdownTimeout time.Duration `toml:"shutdown_timeout" default:"10s"`
+	Timeouts        Timeouts      `toml:"timeouts"`
+	Limits          Limits        `toml:"limits"`
+	TLS             TLS           `toml:"tls"`
+	CORS            CORS          `toml:"cors"`
+	RateLimit       RateLimit     `toml:"rate_limit"`
+}
+
+// Timeouts configures the timeouts of the HTTP server. Request is the time after which the context of a request is
+// canceled, while the others are the timeouts of http.Server.
+type Timeouts struct {
+	ReadHeader time.Duration `toml:"read_header" default:"10s"`
+	Read       time.Duration `toml:"read" default:"30s"`
+	Write      time.Duration `toml:"write" default:"60s"`
+	Idle       time.Duration `toml:"idle" default:"120s"`
+	Request    time.Duration `toml:"request" default:"30s"`
+}
+
+// Limits configures the maximum sizes of the requests, in bytes.
+type Limits struct {
+	MaxBodySize   int64 `toml:"max_body_size" default:"10485760"`
+	MaxSchemaSize int   `toml:"max_schema_size" default:"4194304"`
+}
+
+// TLS configures serving the API over TLS. Client certificates are required and verified against ClientCaFile if
+// it's set.
+type TLS struct {
+	Enabled      bool   `toml:"enabled"`
+	CertFile     string `toml:"cert_file"`
+	KeyFile      string `toml:"key_file"`
+	ClientCaFile string `toml:"client_ca_file"`
+}
+
+// CORS configures the cross-origin requests, which are only answered if AllowedOrigins isn't empty.
+type CORS struct {
+	AllowedOrigins   []string      `toml:"allowed_origins"`
+	AllowedMethods   []string      `toml:"allowed_methods" default:"[GET,POST,PUT,PATCH,DELETE]"`
+	AllowedHeaders   []string      `toml:"allowed_headers" default:"[Accept,Content-Type,Authorization]"`
+	AllowCredentials bool          `toml:"allow_credentials"`
+	MaxAge           time.Duration `toml:"max_age" default:"5m"`
+}
+
+// RateLimit configures the number of requests every client, identified by its IP address, can make in the window.
+// The requests aren't limited if Requests isn't positive.
+type RateLimit struct {
+	Requests int           `toml:"requests"`
+	Window   time.Duration `toml:"window" default:"1m"`
+}
+
+// Metrics configures the server exposing the Prometheus metrics.
+type Metrics struct {
+	Port int `toml:"port" default:"2112"`
+}
+
+// Admission configures the webhooks called, in order, before a new schema or schema version is registered.
+type Admission struct {
+	Webhooks []Webhook `toml:"webhooks"`
+}
+
+// The failure policies of the admission webhooks, deciding whether a schema is refused or admitted when the webhook
+// can't review it.
+const (
+	FailurePolicyFail   = "fail"
+	FailurePolicyIgnore = "ignore"
+)
+
+// Webhook configures an admission webhook.
+type Webhook struct {
+	Name          string        `toml:"name"`
+	URL           string        `toml:"url"`
+	Timeout       time.Duration `toml:"timeout" default:"5s"`
+	FailurePolicy string        `toml:"failure_policy" default:"fail"`
+}
+
+// Checker configures the connection to an external compatibility or validity checker. The timeout of a check grows
+// from TimeoutBase with the size of the checked schemas.
+type Checker struct {
+	URL         string        `toml:"url"`
+	TimeoutBase time.Duration `toml:"timeout_base" default:"2s"`
+}
+
+// Cache configures the in-memory cache of the repository, which is disabled if Size isn't positive.
+type Cache struct {
+	Size int           `toml:"size"`
+	TTL  time.Duration `toml:"ttl" default:"5m"`
+}
+
+// Usage configures how long a registered usage keeps a schema version in use without a heartbeat.
+type Usage struct {
+	TTL time.Duration `toml:"ttl" default:"5m"`
+}
+
+// Audit configures the file every committed audit record is appended to, as a line of JSON, if LogFile is set.
+type Audit struct {
+	LogFile string `toml:"log_file"`
+}
+
+// Read loads the configuration from the given file, overridden by the environment. If the filename is empty, the
+// configuration is loaded from the defaults and the environment only.
+func (cfg *Registry) Read(filename string) error {
+	if filename == "" {
+		// fig always reads a file, so the defaults and the environment are loaded the way it loads them
+		return loadDefaultsAndEnv(reflect.ValueOf(cfg).Elem(), "")
+	}
+
+	return fig.Load(cfg, fig.File(filename), fig.Dirs(""), fig.Tag("toml"), fig.UseEnv(""))
+}
+
+// Validate checks that the configuration is usable.
+func (cfg *Registry) Validate() error {
+	server := cfg.Server
+	if server.Port <= 0 || server.Port > 65535 {
+		return errors.Errorf("server.port must be between 1 and 65535, got %d", server.Port)
+	}
+	if cfg.Metrics.Port <= 0 || cfg.Metrics.Port > 65535 {
+		return errors.Errorf("metrics.port must be between 1 and 65535, got %d", cfg.Metrics.Port)
+	}
+	if server.Limits.MaxBodySize <= 0 {
+		return errors.New("server.limits.max_body_size must be positive")
+	}
+	if server.Limits.MaxSchemaSize <= 0 {
+		return errors.New("server.limits.max_schema_size must be positive")
+	}
+	if server.RateLimit.Requests > 0 && server.RateLimit.Window <= 0 {
+		return errors.New("server.rate_limit.window must be positive")
+	}
+	checkers := []struct {
+		key     string
+		checker Checker
+	}{
+		{"compatibility_checker", cfg.CompatibilityChecker},
+		{"validity_checker", cfg.ValidityChecker},
+	}
+	for _, c := range checkers {
+		key, checker := c.key, c.checker
+		if checker.URL == "" {
+			return errors.Errorf("%s.url is required", key)
+		}
+		if checker.TimeoutBase <= 0 {
+			return errors.Errorf("%s.timeout_base must be positive", key)
+		}
+	}
+	if cfg.Cache.Size > 0 && cfg.Cache.TTL <= 0 {
+		return errors.New("cache.ttl must be positive")
+	}
+	if cfg.Usage.TTL <= 0 {
+		return errors.New("usage.ttl must be positive")
+	}
+	for i, webhook := range cfg.Admission.Webhooks {
+		if webhook.Name == "" || webhook.URL == "" {
+			return errors.Errorf("admission.webhooks[%d] must have a name and a url", i)
+		}
+		if webhook.Timeout <= 0 {
+			return errors.Errorf("admission.webhooks[%d].timeout must be positive", i)
+		}
+		if webhook.FailurePolicy != FailurePolicyFail && webhook.FailurePolicy != FailurePolicyIgnore {
+			return errors.Errorf("admission.webhooks[%d].failure_policy must be either %s or %s, got %q", i, FailurePolicyFail, FailurePolicyIgnore, webhook.FailurePolicy)
+		}
+	}
+	if server.TLS.Enabled {
+		if server.TLS.CertFile == "" || server.TLS.KeyFile == "" {
+			return errors.New("server.tls.cert_file and server.tls.key_file are required when TLS is enabled")
+		}
+		for _, file := range []string{server.TLS.CertFile, server.TLS.KeyFile, server.TLS.ClientCaFile} {
+			if file == "" {
+				continue
+			}
+			if _, err := os.Stat(file); err != nil {
+				return errors.Wrap(err, "TLS file can't be read")
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRegistryRead(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		setCheckerURLs(t)
+
+		var cfg Registry
+		if err := cfg.Read(""); err != nil {
+			t.Fatal(err)
+		}
+		if err := cfg.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Server.Port != 8080 || cfg.Metrics.Port != 2112 {
+			t.Fatalf("unexpected default ports %d and %d", cfg.Server.Port, cfg.Metrics.Port)
+		}
+		if cfg.Server.Timeouts.Request != 30*time.Second {
+			t.Fatalf("unexpected default request timeout %s", cfg.Server.Timeouts.Request)
+		}
+		if len(cfg.Server.CORS.AllowedMethods) != 5 {
+			t.Fatalf("unexpected default allowed methods %v", cfg.Server.CORS.AllowedMethods)
+		}
+		if cfg.Server.BaseURL != "/" || cfg.Cache.Size != 0 || cfg.Cache.TTL != 5*time.Minute || cfg.Usage.TTL != 5*time.Minute {
+			t.Fatalf("unexpected defaults %+v", cfg)
+		}
+		if cfg.GlobalCompatibilityMode != "BACKWARD" || cfg.GlobalValidityMode != "FULL" || cfg.CompatibilityChecker.TimeoutBase != 2*time.Second {
+			t.Fatalf("unexpected checker defaults %+v", cfg)
+		}
+	})
+
+	t.Run("environment", func(t *testing.T) {
+		setCheckerURLs(t)
+		t.Setenv("SERVER_BASE_URL", "https://example.com/registry")
+		t.Setenv("SERVER_CORS_ALLOWED_ORIGINS", "[https://example.com,https://example.org]")
+		t.Setenv("CACHE_SIZE", "1000")
+		t.Setenv("CACHE_TTL", "30s")
+		t.Setenv("SEMANTIC_VERSIONING", "true")
+		t.Setenv("AUDIT_LOG_FILE", "/var/log/registry/audit.jsonl")
+
+		var cfg Registry
+		if err := cfg.Read(""); err != nil {
+			t.Fatal(err)
+		}
+		if err := cfg.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Server.BaseURL != "https://example.com/registry" || len(cfg.Server.CORS.AllowedOrigins) != 2 {
+			t.Fatalf("environment wasn't loaded: %+v", cfg.Server)
+		}
+		if cfg.Cache.Size != 1000 || cfg.Cache.TTL != 30*time.Second || !cfg.SemanticVersioning || cfg.Audit.LogFile != "/var/log/registry/audit.jsonl" {
+			t.Fatalf("environment wasn't loaded: %+v", cfg)
+		}
+		if cfg.CompatibilityChecker.URL != "http://localhost:8088" || cfg.ValidityChecker.URL != "http://localhost:8089" {
+			t.Fatalf("unexpected checker urls %+v and %+v", cfg.CompatibilityChecker, cfg.ValidityChecker)
+		}
+
+		t.Setenv("CACHE_TTL", "soon")
+		if err := new(Registry).Read(""); err == nil {
+			t.Fatal("expected an invalid duration to fail")
+		}
+	})
+
+	t.Run("file and environment", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "registry.toml")
+		file := `
+[server]
+port = 9090
+shutdown_timeout = "20s"
+
+[server.limits]
+max_schema_size = 1024
+
+[server.cors]
+allowed_origins = ["https://example.com"]
+
+[[admission.webhooks]]
+name = "pii-review"
+url = "http://localhost:9000/admit"
+`
+		if err := os.WriteFile(filename, []byte(file), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		setCheckerURLs(t)
+		t.Setenv("SERVER_PORT", "9091")
+		t.Setenv("SERVER_RATE_LIMIT_REQUESTS", "100")
+
+		var cfg Registry
+		if err := cfg.Read(filename); err != nil {
+			t.Fatal(err)
+		}
+		if err := cfg.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Server.Port != 9091 {
+			t.Fatalf("expected the environment to override the port, got %d", cfg.Server.Port)
+		}
+		if cfg.Server.ShutdownTimeout != 20*time.Second || cfg.Server.Limits.MaxSchemaSize != 1024 {
+			t.Fatalf("file wasn't loaded: %+v", cfg.Server)
+		}
+		if len(cfg.Server.CORS.AllowedOrigins) != 1 || cfg.Server.CORS.AllowedOrigins[0] != "https://example.com" {
+			t.Fatalf("unexpected allowed origins %v", cfg.Server.CORS.AllowedOrigins)
+		}
+		if cfg.Server.RateLimit.Requests != 100 || cfg.Server.RateLimit.Window != time.Minute {
+			t.Fatalf("unexpected rate limit %+v", cfg.Server.RateLimit)
+		}
+		if len(cfg.Admission.Webhooks) != 1 {
+			t.Fatalf("unexpected admission webhooks %+v", cfg.Admission.Webhooks)
+		}
+		if webhook := cfg.Admission.Webhooks[0]; webhook.Timeout != 5*time.Second || webhook.FailurePolicy != FailurePolicyFail {
+			t.Fatalf("webhook defaults weren't set: %+v", webhook)
+		}
+	})
+}
+
+func TestRegistryValidate(t *testing.T) {
+	setCheckerURLs(t)
+	var cfg Registry
+	if err := cfg.Read(""); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.ValidityChecker.URL = ""
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected a missing checker url to be invalid")
+	}
+
+	cfg.ValidityChecker.URL = "http://localhost:8089"
+	cfg.Server.TLS = TLS{Enabled: true, CertFile: "cert.pem"}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected TLS without a key file to be invalid")
+	}
+
+	cfg.Server.TLS = TLS{}
+	cfg.Admission.Webhooks = []Webhook{{Name: "pii-review", URL: "http://localhost:9000/admit", Timeout: time.Second, FailurePolicy: "retry"}}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected an unknown failure policy to be invalid")
+	}
+}
+
+// setCheckerURLs sets the urls of the checkers, which have no defaults, for the duration of the test.
+func setCheckerURLs(t *testing.T) {
+	t.Setenv("COMPATIBILITY_CHECKER_URL", "http://localhost:8088")
+	t.Setenv("VALIDITY_CHECKER_URL", "http://localhost:8089")
+}
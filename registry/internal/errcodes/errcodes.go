@@ -37,3 +37,33 @@ func FromHttpStatusCode(code int) uint64 {
 		return Miscellaneous
 	}
 }
+
+// The codes of the problems written back by the REST API, in the code field of the problem details. The generic ones
+// share the number of their HTTP status, like BadRequest and InternalServerError, while the specific ones are the
+// status they're usually written back with, followed by two digits. The codes are stable, unlike the messages. The
+// validator's janitorsr client mirrors them, so a new code is added there as well.
+const (
+	NotFound         = 404
+	MethodNotAllowed = 405
+	NotAcceptable    = 406
+	PayloadTooLarge  = 413
+	TooManyRequests  = 429
+
+	InvalidValue             = 40001
+	UnknownCompatibilityMode = 40002
+	UnknownValidityMode      = 40003
+	UnknownSchemaFormat      = 40004
+	SchemaNotValid           = 40005
+	SchemaNotCompatible      = 40006
+	BreakingChange           = 40007
+	InvalidRules             = 40008
+	InvalidUsage             = 40009
+	InvalidAlias             = 40010
+	InvalidFingerprint       = 40011
+	InvalidMetadata          = 40012
+	AdmissionDenied          = 40301
+	SchemaInUse              = 40901
+	NotCanonicalizable       = 42201
+	ExamplesUnsupported      = 42202
+	AdmissionUnavailable     = 50301
+)
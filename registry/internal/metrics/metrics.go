@@ -12,52 +12,146 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// Package metrics contains the Prometheus metrics exposed by the registry.
 package metrics
 
 import (
+	"database/sql"
+	"strconv"
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
+const namespace = "schema_registry"
+
 var (
-	schemaDeletedProm = promauto.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: "schema_registry",
-		Name:      "schema_deleted",
-		Help:      "Indicates whether the schema has been deleted (1 = schema deleted)",
-	},
-		[]string{"id"})
-	schemaRegisteredProm = promauto.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: "schema_registry",
-		Name:      "schema_registered",
-		Help:      "Indicates whether new schema has been registered (1 = schema registered)",
-	},
-		[]string{"id", "version"})
-	schemaUpdatedProm = promauto.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: "schema_registry",
-		Name:      "schema_updated",
-		Help:      "Indicates whether the schema has been updated (1 = schema updated)",
-	},
-		[]string{"id", "version"})
-	schemaVersionDeletedProm = promauto.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: "schema_registry",
-		Name:      "schema_version_deleted",
-		Help:      "Indicates whether schema version has been deleted (1 = schema version deleted)",
-	},
-		[]string{"id", "version"})
+	schemaOperationsProm = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "schema_operations_total",
+		Help:      "Number of successful schema registrations, updates and deletions",
+	},
+		[]string{"operation"})
+	httpRequestsProm = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of handled HTTP requests",
+	},
+		[]string{"method", "route", "code"})
+	httpRequestErrorsProm = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_request_errors_total",
+		Help:      "Number of HTTP requests which weren't completed successfully (status code 400 or above)",
+	},
+		[]string{"method", "route"})
+	httpRequestDurationProm = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of the handled HTTP requests",
+		Buckets:   prometheus.DefBuckets,
+	},
+		[]string{"method", "route"})
+	checkerDurationProm = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "checker_duration_seconds",
+		Help:      "Latency of the compatibility and validity checks",
+		Buckets:   prometheus.DefBuckets,
+	},
+		[]string{"checker"})
+	checkerErrorsProm = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "checker_errors_total",
+		Help:      "Number of compatibility and validity checks which failed with an error",
+	},
+		[]string{"checker"})
+	repositoryQueryDurationProm = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "repository_query_duration_seconds",
+		Help:      "Latency of the repository queries",
+		Buckets:   prometheus.DefBuckets,
+	},
+		[]string{"operation"})
+	repositoryErrorsProm = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "repository_errors_total",
+		Help:      "Number of repository queries which failed with an error",
+	},
+		[]string{"operation"})
+	cacheHitsProm = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_hits_total",
+		Help:      "Number of repository reads served from the in-memory cache",
+	},
+		[]string{"kind"})
+	cacheMissesProm = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_misses_total",
+		Help:      "Number of repository reads which weren't found in the in-memory cache",
+	},
+		[]string{"kind"})
 )
 
-func UpdateSchemaMetricUpdate(id string, ver string) {
-	schemaUpdatedProm.WithLabelValues(id, ver).Set(1)
+func UpdateSchemaMetricUpdate() {
+	schemaOperationsProm.WithLabelValues("update").Inc()
+}
+
+func AddedSchemaMetricUpdate() {
+	schemaOperationsProm.WithLabelValues("register").Inc()
+}
+
+func DeletedSchemaMetricUpdate() {
+	schemaOperationsProm.WithLabelValues("delete").Inc()
+}
+
+func DeleteSchemaVersionMetricUpdate() {
+	schemaOperationsProm.WithLabelValues("delete_version").Inc()
+}
+
+func UpdateSchemaMetadataMetricUpdate() {
+	schemaOperationsProm.WithLabelValues("update_metadata").Inc()
+}
+
+func RestoreSchemaVersionMetricUpdate() {
+	schemaOperationsProm.WithLabelValues("restore_version").Inc()
+}
+
+// HTTPRequestMetricUpdate records a handled request. The route is the matched route pattern rather than the path,
+// which keeps the number of label values bounded.
+func HTTPRequestMetricUpdate(method, route string, status int, duration time.Duration) {
+	httpRequestsProm.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	if status >= 400 {
+		httpRequestErrorsProm.WithLabelValues(method, route).Inc()
+	}
+	httpRequestDurationProm.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// CheckerMetricUpdate records a compatibility or validity check which started at the given time.
+func CheckerMetricUpdate(checker string, start time.Time, err error) {
+	checkerDurationProm.WithLabelValues(checker).Observe(time.Since(start).Seconds())
+	if err != nil {
+		checkerErrorsProm.WithLabelValues(checker).Inc()
+	}
+}
+
+// RepositoryMetricUpdate records a repository query which started at the given time.
+func RepositoryMetricUpdate(operation string, start time.Time, err error) {
+	repositoryQueryDurationProm.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		repositoryErrorsProm.WithLabelValues(operation).Inc()
+	}
 }
 
-func AddedSchemaMetricUpdate(id string, ver string) {
-	schemaRegisteredProm.WithLabelValues(id, ver).Set(1)
+func CacheHitMetricUpdate(kind string) {
+	cacheHitsProm.WithLabelValues(kind).Inc()
 }
 
-func DeletedSchemaMetricUpdate(id string) {
-	schemaDeletedProm.WithLabelValues(id).Set(1)
+func CacheMissMetricUpdate(kind string) {
+	cacheMissesProm.WithLabelValues(kind).Inc()
 }
 
-func DeleteSchemaVersionMetricUpdate(id string, ver string) {
-	schemaVersionDeletedProm.WithLabelValues(id, ver).Set(1)
+// RegisterDBStats exposes the connection pool statistics of the given database.
+func RegisterDBStats(db *sql.DB) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db, namespace))
 }
@@ -0,0 +1,232 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The operations reviewed by the admission webhooks.
+const (
+	AdmissionOperationCreate = "create"
+	AdmissionOperationUpdate = "update"
+)
+
+// AdmissionWebhook reviews a schema version before it's registered, usually by calling a service which owns a
+// governance check, like whether the publisher may register schemas in its domain.
+type AdmissionWebhook interface {
+	// Name identifies the webhook in the denials and the logs.
+	Name() string
+	// FailOpen returns true if the schema version is admitted when the webhook can't review it, instead of refused.
+	FailOpen() bool
+	Review(ctx context.Context, review AdmissionReview) (AdmissionResponse, error)
+}
+
+// AdmissionReview is the schema version sent to the admission webhooks, after it passed the validity and
+// compatibility checks and was canonicalized.
+type AdmissionReview struct {
+	// Operation is either AdmissionOperationCreate or AdmissionOperationUpdate.
+	Operation string `json:"operation"`
+	// SchemaID is empty when a new schema is created.
+	SchemaID          string   `json:"schema_id,omitempty"`
+	Name              string   `json:"name"`
+	Description       string   `json:"description"`
+	SchemaType        string   `json:"schema_type"`
+	Specification     string   `json:"specification"`
+	PublisherID       string   `json:"publisher_id"`
+	CompatibilityMode string   `json:"compatibility_mode"`
+	ValidityMode      string   `json:"validity_mode"`
+	Attributes        []string `json:"attributes"`
+	Semver            string   `json:"semver,omitempty"`
+	// Diff is the change made to the latest active version of the schema, it's only set on updates.
+	Diff *AdmissionDiff `json:"diff,omitempty"`
+}
+
+// AdmissionDiff is the change a new schema version makes to the latest active version.
+type AdmissionDiff struct {
+	PreviousVersion   string   `json:"previous_version"`
+	AddedAttributes   []string `json:"added_attributes"`
+	RemovedAttributes []string `json:"removed_attributes"`
+}
+
+// AdmissionResponse is the decision of an admission webhook. An admitted schema version can have its metadata
+// changed by the webhook, but only the description can be changed on updates, since the name and the publisher id
+// belong to the schema rather than the version.
+type AdmissionResponse struct {
+	Allowed  bool                   `json:"allowed"`
+	Reason   string                 `json:"reason,omitempty"`
+	Metadata *SchemaMetadataRequest `json:"metadata,omitempty"`
+}
+
+// AdmissionDeniedError is returned when an admission webhook refuses a schema version.
+type AdmissionDeniedError struct {
+	Webhook string
+	Reason  string
+}
+
+func (e *AdmissionDeniedError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("admission webhook %s denied the schema", e.Webhook)
+	}
+	return fmt.Sprintf("admission webhook %s denied the schema: %s", e.Webhook, e.Reason)
+}
+
+// Unwrap returns ErrAdmissionDenied, so callers not interested in the webhook can handle it like any other refusal.
+func (e *AdmissionDeniedError) Unwrap() error {
+	return ErrAdmissionDenied
+}
+
+// admit calls the admission webhooks in order, every one reviewing the metadata changed by the previous ones, and
+// returns the admitted review. Webhooks failing open are skipped if they can't review the schema version.
+func (service *Service) admit(ctx context.Context, review AdmissionReview) (AdmissionReview, error) {
+	for _, webhook := range service.AdmissionWebhooks {
+		response, err := webhook.Review(ctx, review)
+		if err == nil {
+			err = checkAdmissionResponse(review.Operation, response)
+		}
+		if err != nil {
+			if webhook.FailOpen() {
+				log.Printf("Admission webhook %s failed, admitting the schema: %v", webhook.Name(), err)
+				continue
+			}
+			return AdmissionReview{}, errors.Wrapf(ErrAdmissionUnavailable, "admission webhook %s failed: %v", webhook.Name(), err)
+		}
+		if !response.Allowed {
+			return AdmissionReview{}, &AdmissionDeniedError{Webhook: webhook.Name(), Reason: response.Reason}
+		}
+		if metadata := response.Metadata; metadata != nil {
+			if metadata.Name != nil {
+				review.Name = *metadata.Name
+			}
+			if metadata.Description != nil {
+				review.Description = *metadata.Description
+			}
+			if metadata.PublisherID != nil {
+				review.PublisherID = *metadata.PublisherID
+			}
+		}
+	}
+	return review, nil
+}
+
+// checkAdmissionResponse returns an error if the webhook changed metadata it isn't allowed to.
+func checkAdmissionResponse(operation string, response AdmissionResponse) error {
+	metadata := response.Metadata
+	if !response.Allowed || metadata == nil {
+		return nil
+	}
+	if metadata.Name != nil && strings.TrimSpace(*metadata.Name) == "" {
+		return errors.New("the name can't be changed to an empty one")
+	}
+	if operation == AdmissionOperationUpdate && (metadata.Name != nil || metadata.PublisherID != nil) {
+		return errors.New("only the description can be changed on updates")
+	}
+	return nil
+}
+
+// admitCreate reviews a new schema and applies the metadata changed by the webhooks to the request.
+func (service *Service) admitCreate(ctx context.Context, request *SchemaRegistrationRequest) error {
+	if len(service.AdmissionWebhooks) == 0 {
+		return nil
+	}
+	review, err := service.admit(ctx, AdmissionReview{
+		Operation:         AdmissionOperationCreate,
+		Name:              request.Name,
+		Description:       request.Description,
+		SchemaType:        request.SchemaType,
+		Specification:     request.Specification,
+		PublisherID:       request.PublisherID,
+		CompatibilityMode: request.CompatibilityMode,
+		ValidityMode:      request.ValidityMode,
+		Attributes:        splitAttributes(request.Attributes),
+		Semver:            request.Semver,
+	})
+	if err != nil {
+		return err
+	}
+	request.Name = review.Name
+	request.Description = review.Description
+	request.PublisherID = review.PublisherID
+	return nil
+}
+
+// admitUpdate reviews a new version of the schema and applies the description changed by the webhooks to the request.
+func (service *Service) admitUpdate(ctx context.Context, id string, schema Schema, request *SchemaUpdateRequest) error {
+	if len(service.AdmissionWebhooks) == 0 {
+		return nil
+	}
+	attributes := splitAttributes(request.Attributes)
+	review := AdmissionReview{
+		Operation:         AdmissionOperationUpdate,
+		SchemaID:          id,
+		Name:              schema.Name,
+		Description:       request.Description,
+		SchemaType:        schema.SchemaType,
+		Specification:     request.Specification,
+		PublisherID:       schema.PublisherID,
+		CompatibilityMode: schema.CompatibilityMode,
+		ValidityMode:      schema.ValidityMode,
+		Attributes:        attributes,
+		Semver:            request.Semver,
+	}
+	if len(schema.VersionDetails) > 0 {
+		previous := latestVersion(schema)
+		previousAttributes := splitAttributes(previous.Attributes)
+		review.Diff = &AdmissionDiff{
+			PreviousVersion:   previous.Version,
+			AddedAttributes:   subtractAttributes(attributes, previousAttributes),
+			RemovedAttributes: subtractAttributes(previousAttributes, attributes),
+		}
+	}
+	review, err := service.admit(ctx, review)
+	if err != nil {
+		return err
+	}
+	request.Description = review.Description
+	return nil
+}
+
+// splitAttributes returns the sorted attribute paths of the comma separated attributes of a schema version.
+func splitAttributes(attributes string) []string {
+	split := []string{}
+	for _, attribute := range strings.Split(attributes, ",") {
+		if attribute != "" {
+			split = append(split, attribute)
+		}
+	}
+	sort.Strings(split)
+	return split
+}
+
+// subtractAttributes returns the attributes which aren't in others.
+func subtractAttributes(attributes, others []string) []string {
+	present := make(map[string]bool, len(others))
+	for _, attribute := range others {
+		present[attribute] = true
+	}
+	difference := []string{}
+	for _, attribute := range attributes {
+		if !present[attribute] {
+			difference = append(difference, attribute)
+		}
+	}
+	return difference
+}
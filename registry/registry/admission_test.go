@@ -0,0 +1,159 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// admissionRepository is a mockRepository which keeps the last registered request.
+type admissionRepository struct {
+	*mockRepository
+	created SchemaRegistrationRequest
+	updated SchemaUpdateRequest
+}
+
+func (r *admissionRepository) CreateSchema(ctx context.Context, request SchemaRegistrationRequest) (VersionDetails, bool, error) {
+	r.created = request
+	return r.mockRepository.CreateSchema(ctx, request)
+}
+
+func (r *admissionRepository) UpdateSchemaById(ctx context.Context, id string, request SchemaUpdateRequest) (VersionDetails, bool, error) {
+	r.updated = request
+	return r.mockRepository.UpdateSchemaById(ctx, id, request)
+}
+
+// fakeWebhook answers every review with the same response or error, and keeps the reviews.
+type fakeWebhook struct {
+	name     string
+	failOpen bool
+	response AdmissionResponse
+	err      error
+	reviews  []AdmissionReview
+}
+
+func (w *fakeWebhook) Name() string {
+	return w.name
+}
+
+func (w *fakeWebhook) FailOpen() bool {
+	return w.failOpen
+}
+
+func (w *fakeWebhook) Review(_ context.Context, review AdmissionReview) (AdmissionResponse, error) {
+	w.reviews = append(w.reviews, review)
+	return w.response, w.err
+}
+
+func Test_CreateSchemaAdmission(t *testing.T) {
+	request := SchemaRegistrationRequest{
+		Specification:     `{"type":"object","properties":{"id":{"type":"string"}}}`,
+		Name:              "orders",
+		SchemaType:        "json",
+		PublisherID:       "payments",
+		ValidityMode:      "none",
+		CompatibilityMode: "none",
+	}
+	description := "reviewed"
+	unavailable := errors.New("connection refused")
+
+	tt := []struct {
+		name        string
+		webhooks    []*fakeWebhook
+		err         error
+		description string
+	}{
+		{"allowed", []*fakeWebhook{{name: "domains", response: AdmissionResponse{Allowed: true}}}, nil, ""},
+		{"denied", []*fakeWebhook{{name: "domains", response: AdmissionResponse{Reason: "not in the domain"}}}, ErrAdmissionDenied, ""},
+		{"mutated", []*fakeWebhook{
+			{name: "pii", response: AdmissionResponse{Allowed: true, Metadata: &SchemaMetadataRequest{Description: &description}}},
+			{name: "domains", response: AdmissionResponse{Allowed: true}},
+		}, nil, description},
+		{"fail closed", []*fakeWebhook{{name: "pii", err: unavailable}}, ErrAdmissionUnavailable, ""},
+		{"fail open", []*fakeWebhook{{name: "pii", failOpen: true, err: unavailable}}, nil, ""},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &admissionRepository{mockRepository: NewMockRepository()}
+			service := New(repo, &mockCompChecker{}, &mockValChecker{}, "none", "none")
+			for _, webhook := range tc.webhooks {
+				service.AdmissionWebhooks = append(service.AdmissionWebhooks, webhook)
+			}
+
+			_, _, err := service.CreateSchema(context.Background(), request)
+			if !errors.Is(err, tc.err) || (tc.err == nil) != (err == nil) {
+				t.Fatalf("expected error %v, got %v", tc.err, err)
+			}
+			if err != nil {
+				return
+			}
+			if repo.created.Description != tc.description {
+				t.Errorf("expected description %q, got %q", tc.description, repo.created.Description)
+			}
+			review := tc.webhooks[0].reviews[0]
+			if review.Operation != AdmissionOperationCreate || review.PublisherID != "payments" || review.Diff != nil {
+				t.Errorf("unexpected review %+v", review)
+			}
+			if !reflect.DeepEqual(review.Attributes, []string{"properties/id/type"}) {
+				t.Errorf("unexpected attributes %v", review.Attributes)
+			}
+			if last := tc.webhooks[len(tc.webhooks)-1]; len(tc.webhooks) > 1 && last.reviews[0].Description != tc.description {
+				t.Errorf("expected the last webhook to review the mutated description, got %q", last.reviews[0].Description)
+			}
+		})
+	}
+}
+
+func Test_UpdateSchemaAdmission(t *testing.T) {
+	repo := &admissionRepository{mockRepository: NewMockRepository()}
+	schema := MockSchema("1")
+	schema.SchemaType = "json"
+	schema.VersionDetails = []VersionDetails{
+		{Version: "1", Attributes: "properties/id/type,properties/total/type"},
+		{Version: "2", Attributes: "properties/id/type,properties/email/type"},
+	}
+	repo.SetGetSchemaVersionsByIdResponse("1", schema, nil)
+	service := New(repo, &mockCompChecker{}, &mockValChecker{}, "none", "none")
+
+	name := "renamed"
+	webhook := &fakeWebhook{name: "pii", response: AdmissionResponse{Allowed: true, Metadata: &SchemaMetadataRequest{Name: &name}}}
+	service.AdmissionWebhooks = []AdmissionWebhook{webhook}
+	request := SchemaUpdateRequest{Specification: `{"type":"object","properties":{"id":{"type":"string"},"region":{"type":"string"}}}`}
+
+	if _, _, err := service.UpdateSchema(context.Background(), "1", request); !errors.Is(err, ErrAdmissionUnavailable) {
+		t.Fatalf("expected renaming the schema on update to fail, got %v", err)
+	}
+	diff := webhook.reviews[0].Diff
+	if diff == nil || diff.PreviousVersion != "2" {
+		t.Fatalf("unexpected diff %+v", diff)
+	}
+	if !reflect.DeepEqual(diff.AddedAttributes, []string{"properties/region/type"}) || !reflect.DeepEqual(diff.RemovedAttributes, []string{"properties/email/type"}) {
+		t.Errorf("unexpected diff %+v", diff)
+	}
+
+	description := "reviewed"
+	webhook.response = AdmissionResponse{Allowed: true, Metadata: &SchemaMetadataRequest{Description: &description}}
+	if _, _, err := service.UpdateSchema(context.Background(), "1", request); err != nil {
+		t.Fatal(err)
+	}
+	if repo.updated.Description != description {
+		t.Errorf("expected description %q, got %q", description, repo.updated.Description)
+	}
+}
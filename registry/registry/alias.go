@@ -0,0 +1,98 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// aliasPattern restricts alias names, so they can't be mistaken for version numbers and are safe to use in paths.
+var aliasPattern = regexp.MustCompile(`^[a-z][a-z0-9_.-]{0,63}$`)
+
+// reservedAliases can't be used as alias names, since the API already gives them a meaning.
+var reservedAliases = map[string]bool{
+	"latest": true,
+	"all":    true,
+}
+
+// IsAlias returns true if the version is an alias name rather than a version number.
+func IsAlias(version string) bool {
+	if _, err := strconv.Atoi(version); err == nil {
+		return false
+	}
+	return aliasPattern.MatchString(version)
+}
+
+// SetAlias points the alias of the schema at the given version, creating the alias if it doesn't exist.
+// The version can itself be an alias, in which case the alias is pointed at the version the other alias currently
+// points at.
+func (service *Service) SetAlias(ctx context.Context, id, alias string, request AliasRequest) (Alias, error) {
+	if !aliasPattern.MatchString(alias) || reservedAliases[alias] {
+		return Alias{}, errors.Wrap(ErrInvalidAlias, "alias must start with a lowercase letter, contain only lowercase letters, digits, '_', '.' and '-', and be at most 64 characters long")
+	}
+	if request.Version == "" {
+		return Alias{}, errors.Wrap(ErrInvalidAlias, "version must be provided")
+	}
+
+	version, err := service.resolveVersion(ctx, id, request.Version)
+	if err != nil {
+		return Alias{}, err
+	}
+	return service.Repository.SetAlias(ctx, id, alias, version)
+}
+
+// GetAliases returns the aliases of the schema.
+func (service *Service) GetAliases(ctx context.Context, id string) ([]Alias, error) {
+	if _, err := service.Repository.GetSchemaMetadata(ctx, id); err != nil {
+		return nil, err
+	}
+	return service.Repository.GetAliases(ctx, id)
+}
+
+// DeleteAlias deletes the alias of the schema. The version it pointed at is left untouched.
+func (service *Service) DeleteAlias(ctx context.Context, id, alias string) (bool, error) {
+	return service.Repository.DeleteAlias(ctx, id, alias)
+}
+
+// resolveVersion returns the version the alias of the schema points at, or the version with the given semantic version.
+// ErrNotFound is returned if no active version of the schema has the semantic version.
+//
+// Version numbers and names which aren't aliases of the schema are returned as they are, leaving it to the Repository
+// to reject them.
+func (service *Service) resolveVersion(ctx context.Context, id, version string) (string, error) {
+	if IsSemver(version) {
+		return service.resolveSemver(ctx, id, version)
+	}
+	if !IsAlias(version) {
+		return version, nil
+	}
+	aliases, err := service.Repository.GetAliases(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrInvalidValueHeader) {
+			return version, nil
+		}
+		return "", err
+	}
+	for _, alias := range aliases {
+		if alias.Alias == version {
+			return alias.Version, nil
+		}
+	}
+	return version, nil
+}
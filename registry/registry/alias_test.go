@@ -0,0 +1,104 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// aliasRepository is a mockRepository which stores aliases.
+type aliasRepository struct {
+	*mockRepository
+	aliases map[string]string
+}
+
+func (r *aliasRepository) SetAlias(_ context.Context, id, alias, version string) (Alias, error) {
+	r.aliases[alias] = version
+	return Alias{SchemaID: id, Alias: alias, Version: version}, nil
+}
+
+func (r *aliasRepository) GetAliases(_ context.Context, id string) ([]Alias, error) {
+	var aliases []Alias
+	for alias, version := range r.aliases {
+		aliases = append(aliases, Alias{SchemaID: id, Alias: alias, Version: version})
+	}
+	return aliases, nil
+}
+
+func TestIsAlias(t *testing.T) {
+	tt := []struct {
+		version string
+		alias   bool
+	}{
+		{"1", false},
+		{"42", false},
+		{"prod", true},
+		{"release-1.2", true},
+		{"Prod", false},
+		{"1st", false},
+		{"", false},
+	}
+
+	for _, tc := range tt {
+		if got := IsAlias(tc.version); got != tc.alias {
+			t.Errorf("IsAlias(%q): expected %t, got %t", tc.version, tc.alias, got)
+		}
+	}
+}
+
+func Test_SetAlias(t *testing.T) {
+	repo := &aliasRepository{mockRepository: NewMockRepository(), aliases: map[string]string{}}
+	service := New(repo, &mockCompChecker{}, &mockValChecker{}, "none", "none")
+	ctx := context.Background()
+
+	for _, alias := range []string{"Prod", "latest", "all", "2"} {
+		if _, err := service.SetAlias(ctx, "mocking", alias, AliasRequest{Version: "1"}); !errors.Is(err, ErrInvalidAlias) {
+			t.Errorf("expected ErrInvalidAlias for alias %q, got %v", alias, err)
+		}
+	}
+	if _, err := service.SetAlias(ctx, "mocking", "prod", AliasRequest{}); !errors.Is(err, ErrInvalidAlias) {
+		t.Errorf("expected ErrInvalidAlias without a version, got %v", err)
+	}
+
+	if _, err := service.SetAlias(ctx, "mocking", "prod", AliasRequest{Version: "3"}); err != nil {
+		t.Fatal(err)
+	}
+	// an alias pointed at another alias points at its version, so it doesn't follow it when it moves
+	stable, err := service.SetAlias(ctx, "mocking", "stable", AliasRequest{Version: "prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stable.Version != "3" {
+		t.Errorf("expected stable to point at version 3, got %s", stable.Version)
+	}
+
+	details, err := service.GetSchemaVersion(ctx, "mocking", "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if details.Version != "3" {
+		t.Errorf("expected prod to resolve to version 3, got %s", details.Version)
+	}
+	details, err = service.GetSchemaVersion(ctx, "mocking", "beta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if details.Version != "beta" {
+		t.Errorf("expected an unknown alias to be passed to the repository as it is, got %s", details.Version)
+	}
+}
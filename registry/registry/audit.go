@@ -0,0 +1,114 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// The actions recorded in the audit log.
+const (
+	AuditCreateSchema         = "create_schema"
+	AuditUpdateSchema         = "update_schema"
+	AuditDeleteSchema         = "delete_schema"
+	AuditDeleteSchemaVersion  = "delete_schema_version"
+	AuditSetAlias             = "set_alias"
+	AuditDeleteAlias          = "delete_alias"
+	AuditUpdateSchemaMetadata = "update_schema_metadata"
+	AuditRestoreSchemaVersion = "restore_schema_version"
+)
+
+// AnonymousActor is the actor of the mutations whose context doesn't identify anyone.
+const AnonymousActor = "anonymous"
+
+// AuditInfo identifies who made a mutation and within which request.
+//
+// Only Actor is trusted, it comes from a verified client certificate. ClaimedActor is whatever the client says it is.
+type AuditInfo struct {
+	Actor        string
+	ClaimedActor string
+	RequestID    string
+}
+
+type auditInfoKey struct{}
+
+// ContextWithAuditInfo returns a copy of the context which carries the given AuditInfo to the Repository.
+func ContextWithAuditInfo(ctx context.Context, info AuditInfo) context.Context {
+	return context.WithValue(ctx, auditInfoKey{}, info)
+}
+
+// AuditInfoFromContext returns the AuditInfo carried by the context, with AnonymousActor if there is no actor.
+func AuditInfoFromContext(ctx context.Context) AuditInfo {
+	info, _ := ctx.Value(auditInfoKey{}).(AuditInfo)
+	if info.Actor == "" {
+		info.Actor = AnonymousActor
+	}
+	return info
+}
+
+// NewAuditRecord returns the AuditRecord of the given mutation, made by the actor of the context.
+func NewAuditRecord(ctx context.Context, action, schemaID, version, beforeHash, afterHash string) AuditRecord {
+	info := AuditInfoFromContext(ctx)
+	return AuditRecord{
+		SchemaID:     schemaID,
+		Version:      version,
+		Action:       action,
+		Actor:        info.Actor,
+		ClaimedActor: info.ClaimedActor,
+		RequestID:    info.RequestID,
+		BeforeHash:   beforeHash,
+		AfterHash:    afterHash,
+		Timestamp:    time.Now().UTC(),
+	}
+}
+
+// The number of audit records returned at once, when the query doesn't say and at most.
+const (
+	DefaultAuditLimit = 100
+	MaxAuditLimit     = 1000
+)
+
+// AuditSink receives the audit records once they are committed to the Repository.
+type AuditSink interface {
+	Write(record AuditRecord) error
+}
+
+// JSONLinesSink writes every AuditRecord as a line of JSON.
+type JSONLinesSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONLinesSink returns a new instance of JSONLinesSink, writing to w.
+func NewJSONLinesSink(w io.Writer) *JSONLinesSink {
+	return &JSONLinesSink{w: w}
+}
+
+func (s *JSONLinesSink) Write(record AuditRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(line)
+	return err
+}
@@ -0,0 +1,55 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestJSONLinesSink(t *testing.T) {
+	var buffer bytes.Buffer
+	sink := NewJSONLinesSink(&buffer)
+
+	ctx := ContextWithAuditInfo(context.Background(), AuditInfo{Actor: "alice", RequestID: "request-1"})
+	if err := sink.Write(NewAuditRecord(ctx, AuditCreateSchema, "1", "1", "", "hash")); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Write(NewAuditRecord(context.Background(), AuditDeleteSchema, "1", "", "hash", "")); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", buffer.String())
+	}
+	var records []AuditRecord
+	for _, line := range lines {
+		var record AuditRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	if records[0].Actor != "alice" || records[0].RequestID != "request-1" || records[0].AfterHash != "hash" {
+		t.Errorf("first record not as expected: %+v", records[0])
+	}
+	if records[1].Actor != AnonymousActor || records[1].Action != AuditDeleteSchema {
+		t.Errorf("second record not as expected: %+v", records[1])
+	}
+}
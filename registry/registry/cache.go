@@ -15,92 +15,362 @@
 package registry
 
 import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
 	lru "github.com/hashicorp/golang-lru"
-	"github.com/spf13/cast"
 	"golang.org/x/sync/singleflight"
+
+	"github.com/dataphos/schema-registry/internal/metrics"
+)
+
+// cacheKind identifies which Repository method a cache entry belongs to.
+type cacheKind string
+
+const (
+	versionKind     cacheKind = "version"
+	latestKind      cacheKind = "latest"
+	versionsKind    cacheKind = "versions"
+	allVersionsKind cacheKind = "all_versions"
+	metadataKind    cacheKind = "metadata"
+	schemasKind     cacheKind = "schemas"
+	allSchemasKind  cacheKind = "all_schemas"
+	aliasesKind     cacheKind = "aliases"
+	historyKind     cacheKind = "history"
 )
 
-// cached decorates Service with a lru cache.
+// reconnectInterval is the time waited before listening for invalidations again, after the connection was lost.
+const reconnectInterval = 5 * time.Second
+
+type cacheKey struct {
+	kind    cacheKind
+	id      string
+	version string
+}
+
+func (k cacheKey) String() string {
+	return string(k.kind) + "_" + k.id + "_" + k.version
+}
+
+type cacheEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// cached decorates Repository with a lru cache.
+//
+// Every mutation invalidates the affected entries and, if a Notifier is given, broadcasts the invalidation to the other
+// replicas, so no replica serves entries which were changed on another one for longer than it takes the notification
+// to arrive.
 type cached struct {
 	Repository
-	cache *lru.TwoQueueCache
-	group singleflight.Group
+	cache    *lru.TwoQueueCache
+	group    singleflight.Group
+	ttl      time.Duration
+	notifier Notifier
+	// generation is incremented on every invalidation, so results loaded before it aren't cached after it
+	generation uint64
+	mu         sync.Mutex
 }
 
-// newCache returns a new cached.
-func newCache(repository Repository, size int) (*cached, error) {
-	cache, err := lru.New2Q(size)
+// newCache returns a new cached, which listens for invalidations until the context is cancelled.
+func newCache(ctx context.Context, repository Repository, settings CacheSettings) (*cached, error) {
+	cache, err := lru.New2Q(settings.Size)
 	if err != nil {
 		return nil, err
 	}
 
-	return &cached{
+	c := &cached{
 		Repository: repository,
 		cache:      cache,
 		group:      singleflight.Group{},
-	}, nil
+		ttl:        settings.TTL,
+		notifier:   settings.Notifier,
+	}
+	if c.notifier != nil {
+		go c.listen(ctx)
+	}
+
+	return c, nil
 }
 
-// GetSchemaVersionByIdAndVersion overrides the Repository.GetSchemaVersionByIdAndVersion method, caching each call to the underlying Repository, while also
-// making sure there's only one inflight request for the same key (if multiple goroutines request the same schema,
+// get returns the entry under the given key, loading it with load in case of a cache miss, while also
+// making sure there's only one inflight request for the same key (if multiple goroutines request the same entry,
 // only one request is actually sent down, the rest wait for the first one to share its result).
-func (c *cached) GetSchemaVersionByIdAndVersion(id, version string) (VersionDetails, error) {
-	// this should be faster than string concatenation
-	arrKey := [2]string{id, version}
-	var err error
-	v, ok := c.cache.Get(arrKey)
-	if !ok {
-		// cache miss, we need a string version of the key to satisfy the singleflight.Group method signature
-		key := id + "_" + version
-		v, err, _ = c.group.Do(key, func() (interface{}, error) {
-			if v, err = c.Repository.GetSchemaVersionByIdAndVersion(id, version); err != nil {
-				return VersionDetails{}, err
-			}
-			c.cache.Add(arrKey, v)
-			return v, err
-		})
-	}
-	return v.(VersionDetails), err
-}
-
-// DeleteSchemaVersion overrides the Repository.DeleteSchemaVersion method, caching each call to the underlying Repository, while also
-// making sure there's only one inflight request for the same key (if multiple goroutines request the deletion of the same
-// Schema version, only one request is actually sent down, the rest wait for the first one to share its result).
-func (c *cached) DeleteSchemaVersion(id, version string) (bool, error) {
-	key := id + "_" + version
-	bool, err, _ := c.group.Do(key, func() (interface{}, error) {
-		bool, err := c.Repository.DeleteSchemaVersion(id, version)
+func (c *cached) get(key cacheKey, load func() (interface{}, error)) (interface{}, error) {
+	if v, ok := c.cache.Get(key); ok {
+		entry := v.(cacheEntry)
+		if c.ttl <= 0 || time.Now().Before(entry.expires) {
+			metrics.CacheHitMetricUpdate(string(key.kind))
+			return entry.value, nil
+		}
+		c.cache.Remove(key)
+	}
+	metrics.CacheMissMetricUpdate(string(key.kind))
+
+	v, err, _ := c.group.Do(key.String(), func() (interface{}, error) {
+		c.mu.Lock()
+		generation := c.generation
+		c.mu.Unlock()
+
+		v, err := load()
 		if err != nil {
-			return false, err
+			return nil, err
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if generation == c.generation {
+			c.cache.Add(key, cacheEntry{value: v, expires: time.Now().Add(c.ttl)})
 		}
+		return v, nil
+	})
+	return v, err
+}
+
+// GetSchemaVersionByIdAndVersion overrides the Repository.GetSchemaVersionByIdAndVersion method, caching each call to the underlying Repository.
+func (c *cached) GetSchemaVersionByIdAndVersion(ctx context.Context, id, version string) (VersionDetails, error) {
+	v, err := c.get(cacheKey{kind: versionKind, id: id, version: version}, func() (interface{}, error) {
+		return c.Repository.GetSchemaVersionByIdAndVersion(ctx, id, version)
+	})
+	if err != nil {
+		return VersionDetails{}, err
+	}
+	return v.(VersionDetails), nil
+}
+
+// GetLatestSchemaVersion overrides the Repository.GetLatestSchemaVersion method, caching each call to the underlying Repository.
+func (c *cached) GetLatestSchemaVersion(ctx context.Context, id string) (VersionDetails, error) {
+	v, err := c.get(cacheKey{kind: latestKind, id: id}, func() (interface{}, error) {
+		return c.Repository.GetLatestSchemaVersion(ctx, id)
+	})
+	if err != nil {
+		return VersionDetails{}, err
+	}
+	return v.(VersionDetails), nil
+}
+
+// GetSchemaVersionsById overrides the Repository.GetSchemaVersionsById method, caching each call to the underlying Repository.
+func (c *cached) GetSchemaVersionsById(ctx context.Context, id string) (Schema, error) {
+	v, err := c.get(cacheKey{kind: versionsKind, id: id}, func() (interface{}, error) {
+		return c.Repository.GetSchemaVersionsById(ctx, id)
+	})
+	if err != nil {
+		return Schema{}, err
+	}
+	return copySchema(v.(Schema)), nil
+}
+
+// GetAllSchemaVersions overrides the Repository.GetAllSchemaVersions method, caching each call to the underlying Repository.
+func (c *cached) GetAllSchemaVersions(ctx context.Context, id string) (Schema, error) {
+	v, err := c.get(cacheKey{kind: allVersionsKind, id: id}, func() (interface{}, error) {
+		return c.Repository.GetAllSchemaVersions(ctx, id)
+	})
+	if err != nil {
+		return Schema{}, err
+	}
+	return copySchema(v.(Schema)), nil
+}
+
+// GetSchemaMetadata overrides the Repository.GetSchemaMetadata method, caching each call to the underlying Repository.
+func (c *cached) GetSchemaMetadata(ctx context.Context, id string) (Schema, error) {
+	v, err := c.get(cacheKey{kind: metadataKind, id: id}, func() (interface{}, error) {
+		return c.Repository.GetSchemaMetadata(ctx, id)
+	})
+	if err != nil {
+		return Schema{}, err
+	}
+	return copySchema(v.(Schema)), nil
+}
 
-		arrKey := [2]string{id, version}
-		c.cache.Remove(arrKey)
-		return bool, err
+// GetSchemas overrides the Repository.GetSchemas method, caching each call to the underlying Repository.
+// Since searching filters the active schemas, search results are served from this entry as well.
+func (c *cached) GetSchemas(ctx context.Context) ([]Schema, error) {
+	v, err := c.get(cacheKey{kind: schemasKind}, func() (interface{}, error) {
+		return c.Repository.GetSchemas(ctx)
 	})
-	return cast.ToBool(bool), err
+	if err != nil {
+		return nil, err
+	}
+	return copySchemas(v.([]Schema)), nil
 }
 
-// DeleteSchema overrides the Repository.DeleteSchema method, caching each call to the underlying Repository, while also
-// making sure there's only one inflight request for the same key (if multiple goroutines request the deletion of the same
-// Schema, only one request is actually sent down, the rest wait for the first one to share its result).
-func (c *cached) DeleteSchema(id string) (bool, error) {
-	v, err := c.Repository.GetSchemaVersionsById(id)
+// GetAllSchemas overrides the Repository.GetAllSchemas method, caching each call to the underlying Repository.
+func (c *cached) GetAllSchemas(ctx context.Context) ([]Schema, error) {
+	v, err := c.get(cacheKey{kind: allSchemasKind}, func() (interface{}, error) {
+		return c.Repository.GetAllSchemas(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copySchemas(v.([]Schema)), nil
+}
+
+// CreateSchema overrides the Repository.CreateSchema method, invalidating the cached lists of schemas.
+func (c *cached) CreateSchema(ctx context.Context, schemaRegisterRequest SchemaRegistrationRequest) (VersionDetails, bool, error) {
+	details, added, err := c.Repository.CreateSchema(ctx, schemaRegisterRequest)
+	if err == nil && added {
+		c.invalidate(details.SchemaID)
+	}
+	return details, added, err
+}
+
+// UpdateSchemaById overrides the Repository.UpdateSchemaById method, invalidating the cached entries of the schema.
+func (c *cached) UpdateSchemaById(ctx context.Context, id string, schemaUpdateRequest SchemaUpdateRequest) (VersionDetails, bool, error) {
+	details, updated, err := c.Repository.UpdateSchemaById(ctx, id, schemaUpdateRequest)
+	if err == nil && updated {
+		c.invalidate(id)
+	}
+	return details, updated, err
+}
+
+// UpdateSchemaMetadata overrides the Repository.UpdateSchemaMetadata method, invalidating the cached entries of the schema.
+func (c *cached) UpdateSchemaMetadata(ctx context.Context, id string, request SchemaMetadataRequest) (Schema, error) {
+	schema, err := c.Repository.UpdateSchemaMetadata(ctx, id, request)
+	if err == nil {
+		c.invalidate(id)
+	}
+	return schema, err
+}
+
+// DeleteSchemaVersion overrides the Repository.DeleteSchemaVersion method, invalidating the cached entries of the schema.
+func (c *cached) DeleteSchemaVersion(ctx context.Context, id, version string, usedSince time.Time) (bool, error) {
+	deleted, err := c.Repository.DeleteSchemaVersion(ctx, id, version, usedSince)
+	if err == nil && deleted {
+		c.invalidate(id)
+	}
+	return deleted, err
+}
+
+// RestoreSchemaVersion overrides the Repository.RestoreSchemaVersion method, invalidating the cached entries of the schema.
+func (c *cached) RestoreSchemaVersion(ctx context.Context, id, version string) (VersionDetails, bool, error) {
+	details, restored, err := c.Repository.RestoreSchemaVersion(ctx, id, version)
+	if err == nil && restored {
+		c.invalidate(id)
+	}
+	return details, restored, err
+}
+
+// DeleteSchema overrides the Repository.DeleteSchema method, invalidating the cached entries of the schema.
+func (c *cached) DeleteSchema(ctx context.Context, id string, usedSince time.Time) (bool, error) {
+	deleted, err := c.Repository.DeleteSchema(ctx, id, usedSince)
+	if err == nil && deleted {
+		c.invalidate(id)
+	}
+	return deleted, err
+}
+
+// GetAliases overrides the Repository.GetAliases method, caching each call to the underlying Repository.
+func (c *cached) GetAliases(ctx context.Context, id string) ([]Alias, error) {
+	v, err := c.get(cacheKey{kind: aliasesKind, id: id}, func() (interface{}, error) {
+		return c.Repository.GetAliases(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]Alias(nil), v.([]Alias)...), nil
+}
+
+// GetSchemaVersionEvents overrides the Repository.GetSchemaVersionEvents method, caching each call to the underlying
+// Repository.
+func (c *cached) GetSchemaVersionEvents(ctx context.Context, id string) ([]VersionEvent, error) {
+	v, err := c.get(cacheKey{kind: historyKind, id: id}, func() (interface{}, error) {
+		return c.Repository.GetSchemaVersionEvents(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]VersionEvent(nil), v.([]VersionEvent)...), nil
+}
+
+// SetAlias overrides the Repository.SetAlias method, invalidating the cached entries of the schema.
+func (c *cached) SetAlias(ctx context.Context, id, alias, version string) (Alias, error) {
+	schemaAlias, err := c.Repository.SetAlias(ctx, id, alias, version)
 	if err == nil {
-		//Schema with the given ID exists, and it's not already deactivated
-		bool, err, _ := c.group.Do(id, func() (interface{}, error) {
-			bool, err := c.Repository.DeleteSchema(id)
-			if err != nil {
-				return false, err
-			}
-			// remove schemas that are present in cache
-			for _, v := range v.VersionDetails {
-				arrKey := [2]string{v.SchemaID, v.Version}
-				c.cache.Remove(arrKey)
-			}
-			return bool, nil
-		})
-		return cast.ToBool(bool), err
-	}
-	return false, nil
+		c.invalidate(id)
+	}
+	return schemaAlias, err
+}
+
+// DeleteAlias overrides the Repository.DeleteAlias method, invalidating the cached entries of the schema.
+func (c *cached) DeleteAlias(ctx context.Context, id, alias string) (bool, error) {
+	deleted, err := c.Repository.DeleteAlias(ctx, id, alias)
+	if err == nil && deleted {
+		c.invalidate(id)
+	}
+	return deleted, err
+}
+
+// invalidate removes the cached entries of the given schema on this replica and notifies the other replicas to do the same.
+func (c *cached) invalidate(id string) {
+	c.invalidateLocally(id)
+
+	if c.notifier == nil {
+		return
+	}
+	if err := c.notifier.Notify(context.Background(), id); err != nil {
+		log.Println("Couldn't notify other replicas to invalidate their cache:", err)
+	}
+}
+
+// invalidateLocally removes the cached entries of the given schema, together with the cached lists of schemas.
+func (c *cached) invalidateLocally(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	for _, k := range c.cache.Keys() {
+		key := k.(cacheKey)
+		if key.id == id || key.kind == schemasKind || key.kind == allSchemasKind {
+			c.cache.Remove(key)
+		}
+	}
+}
+
+// purge removes every cached entry.
+func (c *cached) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.cache.Purge()
+}
+
+// listen applies the invalidations broadcast by other replicas, until the context is cancelled.
+func (c *cached) listen(ctx context.Context) {
+	for {
+		err := c.notifier.Listen(ctx, c.invalidateLocally)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Println("Stopped listening for cache invalidations:", err)
+
+		// invalidations might have been missed while not listening
+		c.purge()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectInterval):
+		}
+	}
+}
+
+// copySchema returns a copy of the given Schema, so callers can't modify the cached one.
+func copySchema(schema Schema) Schema {
+	if schema.VersionDetails != nil {
+		schema.VersionDetails = append([]VersionDetails(nil), schema.VersionDetails...)
+	}
+	return schema
+}
+
+// copySchemas returns a copy of the given schemas, so callers can't modify the cached ones.
+func copySchemas(schemas []Schema) []Schema {
+	copied := make([]Schema, len(schemas))
+	for i, schema := range schemas {
+		copied[i] = copySchema(schema)
+	}
+	return copied
 }
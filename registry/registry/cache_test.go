@@ -15,26 +15,29 @@
 package registry
 
 import (
+	"context"
 	"strconv"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 )
 
 func TestCacheGetSchemaVersionByIdAndVersion(t *testing.T) {
 	repo := NewMockRepository()
-	c, err := newCache(repo, 10)
+	c, err := newCache(context.Background(), repo, CacheSettings{Size: 10, TTL: time.Minute})
 	if err != nil {
 		t.Error(err)
 	}
 	id, version := "1", "1"
 	var storedSchema, cachedSchema VersionDetails
 	// after the first call the schema should be stored in cache
-	if storedSchema, err = c.GetSchemaVersionByIdAndVersion(id, version); err != nil {
+	if storedSchema, err = c.GetSchemaVersionByIdAndVersion(context.Background(), id, version); err != nil {
 		t.Error(err)
 	}
 	// second call returns schema from cache
-	if cachedSchema, err = c.GetSchemaVersionByIdAndVersion(id, version); err != nil {
+	if cachedSchema, err = c.GetSchemaVersionByIdAndVersion(context.Background(), id, version); err != nil {
 		t.Error(err)
 	}
 
@@ -48,17 +51,17 @@ func TestCacheGetSchemaVersionByIdAndVersion(t *testing.T) {
 
 func TestCacheDeleteSchemaVersion(t *testing.T) {
 	repo := NewMockRepository()
-	c, err := newCache(repo, 10)
+	c, err := newCache(context.Background(), repo, CacheSettings{Size: 10, TTL: time.Minute})
 	if err != nil {
 		t.Error(err)
 	}
 
 	id, version := "1", "1"
-	arrKey := [2]string{id, version}
+	arrKey := cacheKey{kind: versionKind, id: id, version: version}
 	VersionDetails := MockVersionDetails(id, version)
-	c.cache.Add(arrKey, VersionDetails)
+	c.cache.Add(arrKey, cacheEntry{value: VersionDetails, expires: time.Now().Add(time.Minute)})
 
-	if _, err = c.DeleteSchemaVersion(id, version); err != nil {
+	if _, err = c.DeleteSchemaVersion(context.Background(), id, version, time.Time{}); err != nil {
 		t.Error(err)
 	}
 	if _, bool := c.cache.Get(arrKey); bool {
@@ -68,7 +71,7 @@ func TestCacheDeleteSchemaVersion(t *testing.T) {
 
 func TestDeleteSchema(t *testing.T) {
 	repo := NewMockRepository()
-	c, err := newCache(repo, 10)
+	c, err := newCache(context.Background(), repo, CacheSettings{Size: 10, TTL: time.Minute})
 	if err != nil {
 		t.Error(err)
 	}
@@ -78,12 +81,12 @@ func TestDeleteSchema(t *testing.T) {
 	for i := 1; i <= 10; i++ {
 		k := strconv.Itoa(i)
 		VersionDetails := MockVersionDetails(k, k)
-		arrKey := [2]string{id, k}
-		c.cache.Add(arrKey, VersionDetails)
+		arrKey := cacheKey{kind: versionKind, id: id, version: k}
+		c.cache.Add(arrKey, cacheEntry{value: VersionDetails, expires: time.Now().Add(time.Minute)})
 		schema.VersionDetails = append(schema.VersionDetails, VersionDetails)
 	}
 	repo.SetGetSchemaVersionsByIdResponse(id, schema, nil)
-	if bool, err := c.DeleteSchema(id); err != nil {
+	if bool, err := c.DeleteSchema(context.Background(), id, time.Time{}); err != nil {
 		t.Error(err)
 	} else {
 		if c.cache.Len() != 0 {
@@ -94,3 +97,193 @@ func TestDeleteSchema(t *testing.T) {
 	}
 
 }
+
+// countingRepository counts the calls which reach the underlying Repository.
+type countingRepository struct {
+	Repository
+	mu    sync.Mutex
+	calls int
+}
+
+func (r *countingRepository) GetLatestSchemaVersion(ctx context.Context, id string) (VersionDetails, error) {
+	r.mu.Lock()
+	r.calls++
+	r.mu.Unlock()
+	return r.Repository.GetLatestSchemaVersion(ctx, id)
+}
+
+func (r *countingRepository) GetSchemas(ctx context.Context) ([]Schema, error) {
+	r.mu.Lock()
+	r.calls++
+	r.mu.Unlock()
+	return r.Repository.GetSchemas(ctx)
+}
+
+func (r *countingRepository) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls
+}
+
+// channelNotifier broadcasts invalidations to every replica subscribed to it.
+type channelNotifier struct {
+	mu          sync.Mutex
+	subscribers []chan string
+	listening   int
+}
+
+func (n *channelNotifier) Notify(_ context.Context, schemaID string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, subscriber := range n.subscribers {
+		subscriber <- schemaID
+	}
+	return nil
+}
+
+func (n *channelNotifier) Listen(ctx context.Context, invalidate func(schemaID string)) error {
+	subscriber := make(chan string, 10)
+	n.mu.Lock()
+	n.subscribers = append(n.subscribers, subscriber)
+	n.listening++
+	n.mu.Unlock()
+	defer func() {
+		n.mu.Lock()
+		n.listening--
+		n.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case schemaID := <-subscriber:
+			invalidate(schemaID)
+		}
+	}
+}
+
+func (n *channelNotifier) subscribed() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.subscribers)
+}
+
+func (n *channelNotifier) active() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.listening
+}
+
+func TestCacheGetLatestSchemaVersion(t *testing.T) {
+	repo := &countingRepository{Repository: NewMockRepository()}
+	c, err := newCache(context.Background(), repo, CacheSettings{Size: 10, TTL: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err = c.GetLatestSchemaVersion(context.Background(), "1"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = c.GetSchemas(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if repo.count() != 2 {
+		t.Errorf("expected 2 calls to the repository, got %d", repo.count())
+	}
+
+	if _, _, err = c.UpdateSchemaById(context.Background(), "1", SchemaUpdateRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.GetLatestSchemaVersion(context.Background(), "1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.GetSchemas(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if repo.count() != 4 {
+		t.Errorf("expected the update to invalidate the cached entries, got %d calls to the repository", repo.count())
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	repo := &countingRepository{Repository: NewMockRepository()}
+	c, err := newCache(context.Background(), repo, CacheSettings{Size: 10, TTL: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = c.GetLatestSchemaVersion(context.Background(), "1"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, err = c.GetLatestSchemaVersion(context.Background(), "1"); err != nil {
+		t.Fatal(err)
+	}
+	if repo.count() != 2 {
+		t.Errorf("expected the entry to expire, got %d calls to the repository", repo.count())
+	}
+}
+
+func TestCacheInvalidationAcrossReplicas(t *testing.T) {
+	notifier := &channelNotifier{}
+	repo := &countingRepository{Repository: NewMockRepository()}
+
+	first, err := newCache(context.Background(), repo, CacheSettings{Size: 10, TTL: time.Minute, Notifier: notifier})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := newCache(context.Background(), repo, CacheSettings{Size: 10, TTL: time.Minute, Notifier: notifier})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for notifier.subscribed() != 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err = second.GetLatestSchemaVersion(context.Background(), "1"); err != nil {
+		t.Fatal(err)
+	}
+	if second.cache.Len() != 1 {
+		t.Fatal("latest schema version not stored in cache")
+	}
+
+	if _, err = first.DeleteSchemaVersion(context.Background(), "1", "1", time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for second.cache.Len() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the deletion on one replica didn't invalidate the cache of the other")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCacheStopsListeningOnCancel(t *testing.T) {
+	notifier := &channelNotifier{}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if _, err := newCache(ctx, &countingRepository{Repository: NewMockRepository()}, CacheSettings{Size: 10, TTL: time.Minute, Notifier: notifier}); err != nil {
+		t.Fatal(err)
+	}
+	for notifier.active() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for notifier.active() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the cache kept listening for invalidations after the context was cancelled")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if notifier.subscribed() != 1 {
+		t.Errorf("expected the cache not to listen again after the context was cancelled, got %d subscriptions", notifier.subscribed())
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/desc/protoprint"
+	"github.com/pkg/errors"
+
+	"github.com/dataphos/schema-registry/registry/internal/hashutils"
+)
+
+// protoFilename is the name under which the specification is handed to the Protobuf parser.
+const protoFilename = "schema.proto"
+
+// CanonicalSchemaHash returns the hash of the canonical form of the specification, which is the schema hash of the
+// versions registered under any validity mode other than none.
+func CanonicalSchemaHash(specification []byte, schemaType string) (string, error) {
+	canonical, err := canonicalizeSchema(specification, strings.ToLower(schemaType))
+	if err != nil {
+		return "", err
+	}
+	return hashutils.SHA256([]byte(canonical)), nil
+}
+
+// canonicalizeProtobuf prints the descriptor of the .proto file with its elements and options in a canonical order and
+// without comments, so files which only differ in formatting, comments or the order of declarations are the same.
+//
+// The well-known types of google/protobuf are the only imports the parser can resolve, since the registry holds every
+// .proto file on its own. A file importing any other is canonicalized as it is, only without the surrounding whitespace.
+func canonicalizeProtobuf(specification []byte) (string, error) {
+	parser := protoparse.Parser{
+		Accessor: protoparse.FileContentsFromMap(map[string]string{protoFilename: string(specification)}),
+	}
+	files, err := parser.ParseFiles(protoFilename)
+	if errors.Is(err, os.ErrNotExist) {
+		return strings.TrimSpace(string(specification)), nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "couldn't parse protobuf schema")
+	}
+
+	printer := protoprint.Printer{
+		SortElements: true,
+		OmitComments: protoprint.CommentsAll,
+		Compact:      true,
+		Indent:       "  ",
+	}
+	canonical, err := printer.PrintProtoToString(files[0])
+	if err != nil {
+		return "", errors.Wrap(err, "couldn't print protobuf schema")
+	}
+	return strings.TrimSpace(canonical), nil
+}
+
+// canonicalizeXML serializes the XML document following Canonical XML without comments: the XML declaration is dropped,
+// empty elements are written with end tags, redundant namespace declarations are removed, and the namespace declarations
+// and attributes are sorted. Unlike Canonical XML, whitespace between elements is dropped too, since it's insignificant
+// in XML schemas.
+func canonicalizeXML(specification []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(specification))
+
+	var buf strings.Builder
+	// scopes holds the namespaces declared by every open element, on top of the ones of its ancestors
+	scopes := []map[string]string{{"xml": "http://www.w3.org/XML/1998/namespace"}}
+	var open []xml.Name
+	afterRoot := false
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", errors.Wrap(err, "couldn't parse xml schema")
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if afterRoot && len(open) == 0 {
+				return "", errors.New("couldn't parse xml schema: more than one root element")
+			}
+			open = append(open, t.Name)
+			parent := scopes[len(scopes)-1]
+			scope := make(map[string]string, len(parent))
+			for prefix, uri := range parent {
+				scope[prefix] = uri
+			}
+
+			var declarations, attributes []xml.Attr
+			for _, attr := range t.Attr {
+				prefix, isDeclaration := namespaceDeclaration(attr)
+				if !isDeclaration {
+					attributes = append(attributes, attr)
+					continue
+				}
+				if uri, declared := parent[prefix]; declared && uri == attr.Value {
+					continue
+				}
+				scope[prefix] = attr.Value
+				declarations = append(declarations, attr)
+			}
+			scopes = append(scopes, scope)
+
+			sort.Slice(declarations, func(i, j int) bool {
+				pi, _ := namespaceDeclaration(declarations[i])
+				pj, _ := namespaceDeclaration(declarations[j])
+				return pi < pj
+			})
+			// unprefixed attributes have no namespace and come first, the others are sorted by their namespace uri
+			sort.Slice(attributes, func(i, j int) bool {
+				ni, nj := scope[attributes[i].Name.Space], scope[attributes[j].Name.Space]
+				if attributes[i].Name.Space == "" {
+					ni = ""
+				}
+				if attributes[j].Name.Space == "" {
+					nj = ""
+				}
+				if ni != nj {
+					return ni < nj
+				}
+				return attributes[i].Name.Local < attributes[j].Name.Local
+			})
+
+			buf.WriteString("<" + xmlName(t.Name))
+			for _, attr := range append(declarations, attributes...) {
+				buf.WriteString(" " + xmlName(attr.Name) + `="` + escapeXMLAttribute(attr.Value) + `"`)
+			}
+			buf.WriteString(">")
+		case xml.EndElement:
+			if len(open) == 0 || open[len(open)-1] != t.Name {
+				return "", errors.Errorf("couldn't parse xml schema: unexpected end element %s", xmlName(t.Name))
+			}
+			open = open[:len(open)-1]
+			scopes = scopes[:len(scopes)-1]
+			afterRoot = len(open) == 0
+			buf.WriteString("</" + xmlName(t.Name) + ">")
+		case xml.CharData:
+			if len(open) == 0 || len(bytes.TrimSpace(t)) == 0 {
+				continue
+			}
+			buf.WriteString(escapeXMLText(string(t)))
+		case xml.ProcInst:
+			if t.Target == "xml" {
+				continue
+			}
+			// processing instructions outside the root element are separated from it by line breaks
+			if afterRoot && len(open) == 0 {
+				buf.WriteString("\n")
+			}
+			buf.WriteString("<?" + t.Target)
+			if len(t.Inst) > 0 {
+				buf.WriteString(" " + string(t.Inst))
+			}
+			buf.WriteString("?>")
+			if !afterRoot && len(open) == 0 {
+				buf.WriteString("\n")
+			}
+		}
+	}
+	if len(open) != 0 || !afterRoot {
+		return "", errors.New("couldn't parse xml schema: unexpected end of document")
+	}
+
+	return buf.String(), nil
+}
+
+// namespaceDeclaration returns the prefix declared by the attribute, which is empty for the default namespace.
+func namespaceDeclaration(attr xml.Attr) (string, bool) {
+	if attr.Name.Space == "" && attr.Name.Local == "xmlns" {
+		return "", true
+	}
+	if attr.Name.Space == "xmlns" {
+		return attr.Name.Local, true
+	}
+	return "", false
+}
+
+func xmlName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+var xmlTextEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+
+var xmlAttributeEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+
+func escapeXMLText(s string) string {
+	return xmlTextEscaper.Replace(s)
+}
+
+func escapeXMLAttribute(s string) string {
+	return xmlAttributeEscaper.Replace(s)
+}
@@ -0,0 +1,165 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"testing"
+)
+
+func TestCanonicalizeSchema(t *testing.T) {
+	tt := []struct {
+		name       string
+		schemaType string
+		a, b       string
+		same       bool
+	}{
+		{
+			name:       "protobuf formatting, comments and order",
+			schemaType: "protobuf",
+			a: `syntax = "proto3";
+package shop;
+
+// An order.
+message Order {
+  string id = 1 [json_name = "orderId", deprecated = true];
+  Item item = 2;
+}
+
+message Item { string sku = 1; }
+`,
+			b: `syntax="proto3"; package shop;
+message Item {
+    string sku = 1; // the stock keeping unit
+}
+/* An order, with comments
+   spanning lines. */
+message Order {
+    Item item = 2;
+    string id = 1 [deprecated = true, json_name = "orderId"];
+}`,
+			same: true,
+		},
+		{
+			name:       "protobuf field numbers",
+			schemaType: "protobuf",
+			a:          `syntax = "proto3"; message Order { string id = 1; }`,
+			b:          `syntax = "proto3"; message Order { string id = 2; }`,
+			same:       false,
+		},
+		{
+			name:       "protobuf well-known imports",
+			schemaType: "protobuf",
+			a:          "syntax = \"proto3\";\nimport \"google/protobuf/timestamp.proto\";\nmessage Order { google.protobuf.Timestamp at = 1; }",
+			b:          `syntax="proto3"; import "google/protobuf/timestamp.proto"; message Order {google.protobuf.Timestamp at=1;}`,
+			same:       true,
+		},
+		{
+			name:       "protobuf other imports surrounding whitespace",
+			schemaType: "protobuf",
+			a:          `syntax = "proto3"; import "shop/item.proto"; message Order { shop.Item item = 1; }`,
+			b:          "\n" + `syntax = "proto3"; import "shop/item.proto"; message Order { shop.Item item = 1; }` + "\n",
+			same:       true,
+		},
+		{
+			name:       "protobuf other imports formatting",
+			schemaType: "protobuf",
+			a:          `syntax = "proto3"; import "shop/item.proto"; message Order { shop.Item item = 1; }`,
+			b:          `syntax="proto3"; import "shop/item.proto"; message Order {shop.Item item=1;}`,
+			same:       false,
+		},
+		{
+			name:       "xml formatting, comments and attribute order",
+			schemaType: "xml",
+			a: `<?xml version="1.0" encoding="UTF-8"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" elementFormDefault="qualified">
+  <!-- an order -->
+  <xs:element name="order" type="xs:string"/>
+</xs:schema>`,
+			b:    `<xs:schema elementFormDefault="qualified" xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element type="xs:string" name="order"></xs:element></xs:schema>`,
+			same: true,
+		},
+		{
+			name:       "xml redundant namespace declarations",
+			schemaType: "xml",
+			a:          `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="order"/></xs:schema>`,
+			b:          `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element xmlns:xs="http://www.w3.org/2001/XMLSchema" name="order"/></xs:schema>`,
+			same:       true,
+		},
+		{
+			name:       "xml element names",
+			schemaType: "xml",
+			a:          `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="order"/></xs:schema>`,
+			b:          `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="item"/></xs:schema>`,
+			same:       false,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			a, err := canonicalizeSchema([]byte(tc.a), tc.schemaType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := canonicalizeSchema([]byte(tc.b), tc.schemaType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (a == b) != tc.same {
+				t.Fatalf("expected same to be %v, got\n%s\nand\n%s", tc.same, a, b)
+			}
+
+			again, err := canonicalizeSchema([]byte(a), tc.schemaType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again != a {
+				t.Fatalf("canonical form isn't stable, got\n%s\nand\n%s", a, again)
+			}
+		})
+	}
+}
+
+func TestCanonicalizeInvalidSchema(t *testing.T) {
+	for schemaType, specification := range map[string]string{
+		"protobuf": `message Order {`,
+		"xml":      `<xs:schema><xs:element></xs:schema>`,
+	} {
+		if _, err := canonicalizeSchema([]byte(specification), schemaType); err == nil {
+			t.Errorf("expected %s schema to be invalid", schemaType)
+		}
+	}
+}
+
+func TestCanonicalSchemaHash(t *testing.T) {
+	registered, err := CanonicalSchemaHash([]byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="order"/></xs:schema>`), "XML")
+	if err != nil {
+		t.Fatal(err)
+	}
+	submitted, err := CanonicalSchemaHash([]byte(`<?xml version="1.0"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="order"></xs:element>
+</xs:schema>`), "xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if registered != submitted {
+		t.Errorf("expected the hashes of the same canonical form to match, got %s and %s", registered, submitted)
+	}
+
+	if _, err := CanonicalSchemaHash([]byte(`<xs:schema>`), "xml"); err == nil {
+		t.Error("expected the hash of an invalid schema to fail")
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package examples
+
+import (
+	"bytes"
+	"math/big"
+	"math/rand"
+	"strconv"
+
+	"github.com/hamba/avro/v2"
+	"github.com/pkg/errors"
+)
+
+// avroGenerator generates binary encoded Avro messages from an Avro schema.
+type avroGenerator struct {
+	schema avro.Schema
+}
+
+func newAvroGenerator(specification []byte) (*avroGenerator, error) {
+	schema, err := avro.Parse(string(specification))
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't parse avro schema")
+	}
+	return &avroGenerator{schema: schema}, nil
+}
+
+func (g *avroGenerator) Valid(r *rand.Rand) ([]byte, error) {
+	var buf bytes.Buffer
+	w := avro.NewWriter(&buf, 1024)
+	writeAvro(r, w, g.schema, 0)
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+	if w.Error != nil {
+		return nil, w.Error
+	}
+	return buf.Bytes(), nil
+}
+
+// Invalid truncates a valid message, which makes it undecodable. Since the encoding of some schemas
+// (for example a record of nulls) is empty, a stray byte is returned in that case instead.
+func (g *avroGenerator) Invalid(r *rand.Rand) ([]byte, error) {
+	payload, err := g.Valid(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) == 0 {
+		return []byte{0x02}, nil
+	}
+	return payload[:len(payload)-1-r.Intn(len(payload))/2], nil
+}
+
+func writeAvro(r *rand.Rand, w *avro.Writer, schema avro.Schema, depth int) {
+	switch s := schema.(type) {
+	case *avro.RefSchema:
+		writeAvro(r, w, s.Schema(), depth)
+	case *avro.RecordSchema:
+		for _, field := range s.Fields() {
+			writeAvro(r, w, field.Type(), depth+1)
+		}
+	case *avro.EnumSchema:
+		w.WriteInt(int32(r.Intn(len(s.Symbols()))))
+	case *avro.ArraySchema:
+		// blocks are written the same way avro.Marshal writes them, so the payload survives a re-encoding
+		if n := avroBlockLength(r, depth); n > 0 {
+			w.WriteBlockCB(func(w *avro.Writer) int64 {
+				for i := int64(0); i < n; i++ {
+					writeAvro(r, w, s.Items(), depth+1)
+				}
+				return n
+			})
+		}
+		w.WriteLong(0)
+	case *avro.MapSchema:
+		if n := avroBlockLength(r, depth); n > 0 {
+			w.WriteBlockCB(func(w *avro.Writer) int64 {
+				for i := int64(0); i < n; i++ {
+					// keys must be unique, so a counter suffix is added
+					w.WriteString(randomWord(r) + "_" + strconv.FormatInt(i, 10))
+					writeAvro(r, w, s.Values(), depth+1)
+				}
+				return n
+			})
+		}
+		w.WriteLong(0)
+	case *avro.UnionSchema:
+		index := avroUnionIndex(r, s, depth)
+		w.WriteLong(int64(index))
+		writeAvro(r, w, s.Types()[index], depth+1)
+	case *avro.FixedSchema:
+		if decimal, ok := s.Logical().(*avro.DecimalLogicalSchema); ok {
+			_, _ = w.Write(decimalBytes(r, decimal, s.Size()))
+			return
+		}
+		_, _ = w.Write(randomBytes(r, s.Size()))
+	case *avro.PrimitiveSchema:
+		writeAvroPrimitive(r, w, s)
+	}
+}
+
+func writeAvroPrimitive(r *rand.Rand, w *avro.Writer, s *avro.PrimitiveSchema) {
+	var logicalType avro.LogicalType
+	if s.Logical() != nil {
+		logicalType = s.Logical().Type()
+	}
+
+	switch s.Type() {
+	case avro.Boolean:
+		w.WriteBool(r.Intn(2) == 0)
+	case avro.Int:
+		switch logicalType {
+		case avro.Date:
+			w.WriteInt(int32(randomTime(r).Unix() / 86400))
+		case avro.TimeMillis:
+			w.WriteInt(int32(r.Intn(86400000)))
+		default:
+			w.WriteInt(int32(r.Intn(1000)))
+		}
+	case avro.Long:
+		switch logicalType {
+		case avro.TimestampMillis:
+			w.WriteLong(randomTime(r).UnixNano() / 1e6)
+		case avro.TimestampMicros:
+			w.WriteLong(randomTime(r).UnixNano() / 1e3)
+		case avro.TimeMicros:
+			w.WriteLong(r.Int63n(86400000000))
+		default:
+			w.WriteLong(r.Int63n(100000))
+		}
+	case avro.Float:
+		w.WriteFloat(float32(randomFloat(r, 0, 1000)))
+	case avro.Double:
+		w.WriteDouble(randomFloat(r, 0, 1000))
+	case avro.Bytes:
+		if decimal, ok := s.Logical().(*avro.DecimalLogicalSchema); ok {
+			w.WriteBytes(decimalBytes(r, decimal, 0))
+			return
+		}
+		w.WriteBytes(randomBytes(r, 1+r.Intn(16)))
+	case avro.String:
+		if logicalType == avro.UUID {
+			w.WriteString(randomUUID(r))
+			return
+		}
+		w.WriteString(randomWord(r))
+	default:
+		// null is encoded as zero bytes
+	}
+}
+
+// avroBlockLength returns the number of items of an array or a map, which is zero once the depth limit is reached.
+func avroBlockLength(r *rand.Rand, depth int) int64 {
+	if depth >= maxDepth {
+		return 0
+	}
+	return int64(r.Intn(4))
+}
+
+// avroUnionIndex picks a branch of the given union, preferring non-null branches and picking null once the depth
+// limit is reached (if possible).
+func avroUnionIndex(r *rand.Rand, s *avro.UnionSchema, depth int) int {
+	types := s.Types()
+	nullIndex := -1
+	for i, t := range types {
+		if t.Type() == avro.Null {
+			nullIndex = i
+		}
+	}
+	if nullIndex >= 0 && (depth >= maxDepth || len(types) == 1 || r.Intn(4) == 0) {
+		return nullIndex
+	}
+
+	index := r.Intn(len(types))
+	if index == nullIndex {
+		index = (index + 1) % len(types)
+	}
+	return index
+}
+
+// decimalBytes returns the two's-complement big-endian encoding of a random unscaled decimal value fitting the
+// given precision. If size is positive, the result is sign-extended to exactly size bytes.
+func decimalBytes(r *rand.Rand, decimal *avro.DecimalLogicalSchema, size int) []byte {
+	digits := decimal.Precision()
+	if digits > 6 {
+		digits = 6
+	}
+	max := int64(1)
+	for i := 0; i < digits; i++ {
+		max *= 10
+	}
+	unscaled := r.Int63n(max)
+
+	b := big.NewInt(unscaled).Bytes()
+	if len(b) == 0 || b[0]&0x80 != 0 {
+		// keep the value positive in two's-complement
+		b = append([]byte{0}, b...)
+	}
+	if size > len(b) {
+		b = append(make([]byte, size-len(b)), b...)
+	}
+	return b
+}
@@ -0,0 +1,343 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package examples
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// csvGenerator generates CSV documents from a CSV Schema (https://digital-preservation.github.io/csv-schema/).
+//
+// Only the most common rules are understood: is, any, not, starts, ends, regex, range, length, notEmpty, empty,
+// upperCase, lowerCase, positiveInteger, uuid4, uri and the xDate, xDateTime and xTime formats, combined with "or" and "and".
+type csvGenerator struct {
+	separator rune
+	noHeader  bool
+	columns   []csvColumn
+}
+
+type csvColumn struct {
+	name string
+	// alternatives are the top level "or" branches of the column rule, each being a list of "and" joined rules
+	alternatives [][]csvRule
+}
+
+type csvRule struct {
+	name string
+	args []string
+}
+
+// csvRulePattern matches a single rule with optional arguments, e.g. range(0, 120) or notEmpty.
+var csvRulePattern = regexp.MustCompile(`^(\w+)\s*(?:\((.*)\))?$`)
+
+func newCSVGenerator(specification []byte) (*csvGenerator, error) {
+	g := &csvGenerator{separator: ','}
+
+	for _, line := range strings.Split(string(specification), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || strings.HasPrefix(line, "//") || strings.HasPrefix(line, "version "):
+			continue
+		case strings.HasPrefix(line, "@"):
+			if err := g.parseDirective(line); err != nil {
+				return nil, err
+			}
+		default:
+			column, err := parseCSVColumn(line)
+			if err != nil {
+				return nil, err
+			}
+			g.columns = append(g.columns, column)
+		}
+	}
+
+	if len(g.columns) == 0 {
+		return nil, errors.Wrap(ErrUnsupportedSchema, "no column definitions were found in the csv schema")
+	}
+	return g, nil
+}
+
+func (g *csvGenerator) parseDirective(line string) error {
+	fields := strings.Fields(line)
+	switch fields[0] {
+	case "@noHeader":
+		g.noHeader = true
+	case "@separator":
+		if len(fields) < 2 {
+			return errors.Errorf("invalid directive %q", line)
+		}
+		separator := strings.Trim(fields[1], `'"`)
+		switch separator {
+		case "TAB", `\t`:
+			g.separator = '\t'
+		default:
+			if len(separator) != 1 {
+				return errors.Errorf("invalid separator %q", separator)
+			}
+			g.separator = rune(separator[0])
+		}
+	}
+	// the remaining directives (@totalColumns, @quoted, @ignoreColumnNameCase...) don't affect generation
+	return nil
+}
+
+func parseCSVColumn(line string) (csvColumn, error) {
+	name, rule := line, ""
+	if strings.HasPrefix(line, `"`) {
+		end := strings.Index(line[1:], `"`)
+		if end < 0 {
+			return csvColumn{}, errors.Errorf("invalid column definition %q", line)
+		}
+		name, rule = line[1:end+1], strings.TrimPrefix(strings.TrimSpace(line[end+2:]), ":")
+	} else if i := strings.Index(line, ":"); i >= 0 {
+		name, rule = line[:i], line[i+1:]
+	}
+
+	column := csvColumn{name: strings.TrimSpace(name)}
+	for _, alternative := range splitTopLevel(rule, " or ") {
+		var rules []csvRule
+		for _, part := range splitTopLevel(alternative, " and ") {
+			part = strings.TrimSpace(part)
+			// column directives such as @optional aren't rules
+			if part == "" || strings.HasPrefix(part, "@") {
+				continue
+			}
+			match := csvRulePattern.FindStringSubmatch(part)
+			if match == nil {
+				return csvColumn{}, errors.Wrapf(ErrUnsupportedSchema, "unsupported rule %q", part)
+			}
+			rules = append(rules, csvRule{name: match[1], args: splitArgs(match[2])})
+		}
+		column.alternatives = append(column.alternatives, rules)
+	}
+	return column, nil
+}
+
+func (g *csvGenerator) Valid(r *rand.Rand) ([]byte, error) {
+	rows, err := g.rows(r)
+	if err != nil {
+		return nil, err
+	}
+	return g.write(rows)
+}
+
+// Invalid adds an extra column to each row, breaking the expected number of columns.
+func (g *csvGenerator) Invalid(r *rand.Rand) ([]byte, error) {
+	rows, err := g.rows(r)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		rows[i] = append(rows[i], randomWord(r))
+	}
+	return g.write(rows)
+}
+
+func (g *csvGenerator) rows(r *rand.Rand) ([][]string, error) {
+	var rows [][]string
+	if !g.noHeader {
+		header := make([]string, len(g.columns))
+		for i, column := range g.columns {
+			header[i] = column.name
+		}
+		rows = append(rows, header)
+	}
+
+	for n := 1 + r.Intn(5); n > 0; n-- {
+		row := make([]string, len(g.columns))
+		for i, column := range g.columns {
+			value, err := column.generate(r)
+			if err != nil {
+				return nil, err
+			}
+			row[i] = value
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func (g *csvGenerator) write(rows [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = g.separator
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c csvColumn) generate(r *rand.Rand) (string, error) {
+	if len(c.alternatives) == 0 {
+		return randomWord(r), nil
+	}
+	rules := c.alternatives[r.Intn(len(c.alternatives))]
+
+	// the first rule which determines the value is used as the base, the rest are applied as modifiers
+	value, generated := "", false
+	for _, rule := range rules {
+		var err error
+		if value, generated, err = rule.generate(r); err != nil {
+			return "", err
+		}
+		if generated {
+			break
+		}
+	}
+	if !generated {
+		value = randomWord(r)
+	}
+
+	for _, rule := range rules {
+		value = rule.modify(r, value)
+	}
+	return value, nil
+}
+
+// generate returns a value satisfying the rule, if the rule determines the value.
+func (rule csvRule) generate(r *rand.Rand) (string, bool, error) {
+	switch rule.name {
+	case "is":
+		return rule.arg(0), true, nil
+	case "any":
+		return rule.args[r.Intn(len(rule.args))], true, nil
+	case "empty":
+		return "", true, nil
+	case "regex":
+		value, err := stringFromPattern(r, "^"+rule.arg(0)+"$")
+		return value, true, err
+	case "range":
+		min, minErr := strconv.ParseFloat(rule.arg(0), 64)
+		max, maxErr := strconv.ParseFloat(rule.arg(1), 64)
+		if minErr != nil {
+			min = max - 1000
+		}
+		if maxErr != nil {
+			max = min + 1000
+		}
+		if min == float64(int64(min)) && max == float64(int64(max)) {
+			return strconv.FormatInt(randomInt(r, int64(min), int64(max)), 10), true, nil
+		}
+		return strconv.FormatFloat(randomFloat(r, min, max), 'f', -1, 64), true, nil
+	case "positiveInteger":
+		return strconv.Itoa(r.Intn(1000)), true, nil
+	case "uuid4":
+		return randomUUID(r), true, nil
+	case "uri":
+		return "https://example.com/" + randomWord(r), true, nil
+	case "xDate":
+		return randomTime(r).Format("2006-01-02"), true, nil
+	case "xDateTime":
+		return randomTime(r).Format("2006-01-02T15:04:05"), true, nil
+	case "xTime":
+		return randomTime(r).Format("15:04:05"), true, nil
+	case "length":
+		min, max := 1, 10
+		if len(rule.args) == 1 {
+			min, _ = strconv.Atoi(rule.arg(0))
+			max = min
+		} else {
+			if n, err := strconv.Atoi(rule.arg(0)); err == nil {
+				min = n
+			}
+			if n, err := strconv.Atoi(rule.arg(1)); err == nil {
+				max = n
+			} else {
+				max = min + 10
+			}
+		}
+		return randomString(r, min, max), true, nil
+	default:
+		return "", false, nil
+	}
+}
+
+// modify adjusts an already generated value to satisfy the rule, if the rule only constrains the value.
+func (rule csvRule) modify(r *rand.Rand, value string) string {
+	switch rule.name {
+	case "notEmpty":
+		if value == "" {
+			return randomWord(r)
+		}
+	case "not":
+		if value == rule.arg(0) {
+			return value + randomWord(r)
+		}
+	case "starts":
+		if !strings.HasPrefix(value, rule.arg(0)) {
+			return rule.arg(0) + value
+		}
+	case "ends":
+		if !strings.HasSuffix(value, rule.arg(0)) {
+			return value + rule.arg(0)
+		}
+	case "upperCase":
+		return strings.ToUpper(value)
+	case "lowerCase":
+		return strings.ToLower(value)
+	}
+	return value
+}
+
+func (rule csvRule) arg(i int) string {
+	if i >= len(rule.args) {
+		return ""
+	}
+	return rule.args[i]
+}
+
+// splitArgs splits a rule argument list, unquoting string arguments.
+func splitArgs(args string) []string {
+	var split []string
+	for _, arg := range splitTopLevel(args, ",") {
+		arg = strings.TrimSpace(arg)
+		if unquoted, err := strconv.Unquote(arg); err == nil {
+			arg = unquoted
+		}
+		if arg != "" {
+			split = append(split, arg)
+		}
+	}
+	return split
+}
+
+// splitTopLevel splits s by sep, ignoring separators inside quotes and parentheses.
+func splitTopLevel(s, sep string) []string {
+	var parts []string
+	depth, quoted, start := 0, false, 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '"' && (i == 0 || s[i-1] != '\\'):
+			quoted = !quoted
+		case quoted:
+		case s[i] == '(':
+			depth++
+		case s[i] == ')':
+			depth--
+		case depth == 0 && strings.HasPrefix(s[i:], sep):
+			parts = append(parts, s[start:i])
+			start = i + len(sep)
+			i += len(sep) - 1
+		}
+	}
+	return append(parts, s[start:])
+}
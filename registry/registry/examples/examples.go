@@ -0,0 +1,92 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package examples generates random example payloads which conform to (or deliberately violate) a registered schema.
+package examples
+
+import (
+	"math/rand"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrUnsupportedType is returned when examples can't be generated for the given schema type.
+var ErrUnsupportedType = errors.New("example generation is not supported for the given schema type")
+
+// ErrUnsupportedSchema is returned when the given specification uses constructs the generator can't satisfy.
+var ErrUnsupportedSchema = errors.New("example generation is not supported for the given schema")
+
+// maxDepth limits how deep nested (and possibly recursive) structures are generated.
+const maxDepth = 8
+
+// Options configures a Generate call.
+type Options struct {
+	// Count is the number of payloads to generate.
+	Count int
+	// Seed is used to initialize the random source, the same seed always produces the same payloads.
+	Seed int64
+	// Invalid determines whether the payloads should deliberately violate the schema.
+	Invalid bool
+}
+
+// Generator generates payloads for a single, already parsed specification.
+type Generator interface {
+	// Valid returns a random payload which conforms to the specification.
+	Valid(r *rand.Rand) ([]byte, error)
+	// Invalid returns a random payload which violates the specification.
+	Invalid(r *rand.Rand) ([]byte, error)
+}
+
+// New returns a Generator for the given (decoded) specification of the given schema type.
+func New(specification []byte, schemaType string) (Generator, error) {
+	switch strings.ToLower(schemaType) {
+	case "json":
+		return newJSONGenerator(specification)
+	case "avro":
+		return newAvroGenerator(specification)
+	case "protobuf":
+		return newProtobufGenerator(specification)
+	case "csv":
+		return newCSVGenerator(specification)
+	case "xml":
+		return newXMLGenerator(specification)
+	default:
+		return nil, ErrUnsupportedType
+	}
+}
+
+// Generate returns options.Count payloads for the given (decoded) specification of the given schema type.
+func Generate(specification []byte, schemaType string, options Options) ([][]byte, error) {
+	generator, err := New(specification, schemaType)
+	if err != nil {
+		return nil, err
+	}
+
+	r := rand.New(rand.NewSource(options.Seed)) //nolint:gosec // examples don't need a secure source
+	payloads := make([][]byte, options.Count)
+	for i := range payloads {
+		var payload []byte
+		if options.Invalid {
+			payload, err = generator.Invalid(r)
+		} else {
+			payload, err = generator.Valid(r)
+		}
+		if err != nil {
+			return nil, err
+		}
+		payloads[i] = payload
+	}
+	return payloads, nil
+}
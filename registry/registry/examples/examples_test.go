@@ -0,0 +1,264 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package examples
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"testing"
+
+	"github.com/hamba/avro/v2"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/dynamic"
+)
+
+func readTestdata(t *testing.T, filename string) []byte {
+	_, b, _, _ := runtime.Caller(0)
+	content, err := os.ReadFile(filepath.Join(filepath.Dir(b), "testdata", filename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return content
+}
+
+func TestGenerate(t *testing.T) {
+	tt := []struct {
+		name           string
+		schemaFilename string
+		schemaType     string
+		check          func(t *testing.T, schema, payload []byte) bool
+	}{
+		{"json", "schema.json", "json", checkJSON},
+		{"avro", "schema.avsc", "avro", checkAvro},
+		{"protobuf", "schema.proto", "protobuf", checkProtobuf},
+		{"csv", "schema.csvs", "csv", checkCSV},
+		{"xml", "schema.xsd", "xml", checkXML},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			schema := readTestdata(t, tc.schemaFilename)
+
+			valid, err := Generate(schema, tc.schemaType, Options{Count: 20, Seed: 42})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(valid) != 20 {
+				t.Fatalf("expected 20 payloads, got %d", len(valid))
+			}
+			for _, payload := range valid {
+				if !tc.check(t, schema, payload) {
+					t.Errorf("expected a valid payload, got %q", payload)
+				}
+			}
+
+			invalid, err := Generate(schema, tc.schemaType, Options{Count: 20, Seed: 42, Invalid: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, payload := range invalid {
+				if tc.check(t, schema, payload) {
+					t.Errorf("expected an invalid payload, got %q", payload)
+				}
+			}
+
+			again, err := Generate(schema, tc.schemaType, Options{Count: 20, Seed: 42})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range valid {
+				if !bytes.Equal(valid[i], again[i]) {
+					t.Errorf("expected the same payloads for the same seed")
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateUnsupportedType(t *testing.T) {
+	if _, err := Generate([]byte("{}"), "parquet", Options{Count: 1}); err != ErrUnsupportedType {
+		t.Errorf("expected ErrUnsupportedType, got %v", err)
+	}
+}
+
+func TestNumericBounds(t *testing.T) {
+	tt := []struct {
+		name   string
+		schema string
+		valid  func(value float64) bool
+	}{
+		{"fractional exclusive maximum", `{"type":"number","minimum":0,"exclusiveMaximum":0.5}`, func(v float64) bool { return v >= 0 && v < 0.5 }},
+		{"fractional exclusive minimum", `{"type":"number","exclusiveMinimum":0.25,"maximum":0.75}`, func(v float64) bool { return v > 0.25 && v <= 0.75 }},
+		{"narrow exclusive bounds", `{"type":"number","exclusiveMinimum":0.1,"exclusiveMaximum":0.2}`, func(v float64) bool { return v > 0.1 && v < 0.2 }},
+		{"boolean exclusive bounds", `{"type":"number","minimum":1.5,"exclusiveMinimum":true,"maximum":1.6,"exclusiveMaximum":true}`, func(v float64) bool { return v > 1.5 && v < 1.6 }},
+		{"integer exclusive bounds", `{"type":"integer","exclusiveMinimum":1,"exclusiveMaximum":4}`, func(v float64) bool { return v > 1 && v < 4 && v == math.Trunc(v) }},
+		{"integer fractional exclusive bounds", `{"type":"integer","exclusiveMinimum":0.5,"exclusiveMaximum":2.5}`, func(v float64) bool { return v > 0.5 && v < 2.5 && v == math.Trunc(v) }},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			payloads, err := Generate([]byte(tc.schema), "json", Options{Count: 50, Seed: 7})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, payload := range payloads {
+				var value float64
+				if err = json.Unmarshal(payload, &value); err != nil {
+					t.Fatal(err)
+				}
+				if !tc.valid(value) {
+					t.Errorf("expected a value within the bounds, got %v", value)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateRequiredWithoutProperties(t *testing.T) {
+	schema := []byte(`{"type":"object","required":["a","b","c","d","e"]}`)
+
+	first, err := Generate(schema, "json", Options{Count: 5, Seed: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		again, err := Generate(schema, "json", Options{Count: 5, Seed: 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j := range first {
+			if !bytes.Equal(first[j], again[j]) {
+				t.Fatalf("expected the same payloads for the same seed, got %s and %s", first[j], again[j])
+			}
+		}
+	}
+}
+
+func TestStringFromPattern(t *testing.T) {
+	patterns := []string{`^ORD-[0-9]{6}$`, `[a-f]+@(foo|bar)\.com`, `^\d{3}-\w{2,4}$`, `[^a-z]x?`}
+
+	for _, pattern := range patterns {
+		re := regexp.MustCompile(pattern)
+		for seed := int64(0); seed < 20; seed++ {
+			generated, err := stringFromPattern(rand.New(rand.NewSource(seed)), pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !re.MatchString(generated) {
+				t.Errorf("%q doesn't match %s", generated, pattern)
+			}
+		}
+	}
+}
+
+func checkJSON(t *testing.T, _, payload []byte) bool {
+	var order map[string]interface{}
+	if err := json.Unmarshal(payload, &order); err != nil {
+		return false
+	}
+	for _, field := range []string{"id", "status", "amount", "customer"} {
+		if _, ok := order[field]; !ok {
+			return false
+		}
+	}
+	id, ok := order["id"].(string)
+	if !ok || !regexp.MustCompile(`^ORD-[0-9]{6}$`).MatchString(id) {
+		return false
+	}
+	if amount, ok := order["amount"].(float64); !ok || amount < 0 || amount >= 10000 {
+		return false
+	}
+	customer, ok := order["customer"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, ok = customer["email"].(string)
+	return ok
+}
+
+func checkAvro(t *testing.T, schema, payload []byte) bool {
+	parsed, err := avro.Parse(string(schema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data interface{}
+	if err = avro.Unmarshal(parsed, payload, &data); err != nil {
+		return false
+	}
+	// map entries are re-encoded in random order, so only the lengths are compared
+	reserialized, err := avro.Marshal(parsed, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(reserialized) == len(payload)
+}
+
+func checkProtobuf(t *testing.T, schema, payload []byte) bool {
+	parser := protoparse.Parser{
+		Accessor: protoparse.FileContentsFromMap(map[string]string{protoFilename: string(schema)}),
+	}
+	files, err := parser.ParseFiles(protoFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := dynamic.NewMessage(files[0].GetMessageTypes()[0])
+	if err = message.Unmarshal(payload); err != nil {
+		return false
+	}
+	return len(message.GetUnknownFields()) == 0
+}
+
+func checkCSV(_ *testing.T, _, payload []byte) bool {
+	reader := csv.NewReader(bytes.NewReader(payload))
+	reader.FieldsPerRecord = 4
+	rows, err := reader.ReadAll()
+	if err != nil || len(rows) < 2 {
+		return false
+	}
+	for _, row := range rows[1:] {
+		if row[0] == "" || !regexp.MustCompile(`^[mftn]$`).MatchString(row[2]) || !regexp.MustCompile(`^[A-Z]{3}-[0-9]{2}$`).MatchString(row[3]) {
+			return false
+		}
+	}
+	return true
+}
+
+func checkXML(_ *testing.T, _, payload []byte) bool {
+	decoder := xml.NewDecoder(bytes.NewReader(payload))
+	allowed := map[string]bool{"order": true, "id": true, "status": true, "quantity": true, "tag": true}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return true
+		}
+		if err != nil {
+			return false
+		}
+		if start, ok := token.(xml.StartElement); ok && !allowed[start.Name.Local] {
+			return false
+		}
+	}
+}
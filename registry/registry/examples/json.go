@@ -0,0 +1,443 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package examples
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// jsonGenerator generates JSON documents from a JSON schema.
+type jsonGenerator struct {
+	root map[string]interface{}
+}
+
+func newJSONGenerator(specification []byte) (*jsonGenerator, error) {
+	var root map[string]interface{}
+	if err := json.Unmarshal(specification, &root); err != nil {
+		return nil, errors.Wrap(err, "couldn't unmarshal json schema")
+	}
+	return &jsonGenerator{root: root}, nil
+}
+
+func (g *jsonGenerator) Valid(r *rand.Rand) ([]byte, error) {
+	value, err := g.generate(r, g.root, 0)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
+// Invalid generates a valid document and then breaks it, preferring to remove a required property, then to change
+// the type of an existing property and finally to change the type of the whole document.
+func (g *jsonGenerator) Invalid(r *rand.Rand) ([]byte, error) {
+	value, err := g.generate(r, g.root, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := g.resolve(g.root)
+	if err != nil {
+		return nil, err
+	}
+	if object, ok := value.(map[string]interface{}); ok {
+		if required := stringSlice(schema["required"]); len(required) > 0 {
+			delete(object, required[r.Intn(len(required))])
+			return json.Marshal(object)
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		for _, name := range sortedKeys(properties) {
+			propertySchema, _ := properties[name].(map[string]interface{})
+			propertySchema, err = g.resolve(propertySchema)
+			if err != nil {
+				return nil, err
+			}
+			if mismatched, ok := mismatchedValue(propertySchema); ok {
+				object[name] = mismatched
+				return json.Marshal(object)
+			}
+		}
+	}
+
+	if mismatched, ok := mismatchedValue(schema); ok {
+		return json.Marshal(mismatched)
+	}
+	return nil, errors.Wrap(ErrUnsupportedSchema, "the schema accepts every document")
+}
+
+// resolve follows local references ("#/definitions/..." or "#/$defs/...") until a concrete schema is reached.
+func (g *jsonGenerator) resolve(schema map[string]interface{}) (map[string]interface{}, error) {
+	for i := 0; i < maxDepth; i++ {
+		ref, ok := schema["$ref"].(string)
+		if !ok {
+			return schema, nil
+		}
+		if !strings.HasPrefix(ref, "#") {
+			return nil, errors.Wrapf(ErrUnsupportedSchema, "only local references are supported, got %s", ref)
+		}
+
+		var current interface{} = g.root
+		for _, token := range strings.Split(strings.TrimPrefix(ref, "#"), "/") {
+			if token == "" {
+				continue
+			}
+			token, _ = url.PathUnescape(token)
+			token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+			object, ok := current.(map[string]interface{})
+			if !ok {
+				return nil, errors.Wrapf(ErrUnsupportedSchema, "unresolvable reference %s", ref)
+			}
+			current = object[token]
+		}
+		if schema, ok = current.(map[string]interface{}); !ok {
+			return nil, errors.Wrapf(ErrUnsupportedSchema, "unresolvable reference %s", ref)
+		}
+	}
+	return nil, errors.Wrap(ErrUnsupportedSchema, "reference chain is too long")
+}
+
+func (g *jsonGenerator) generate(r *rand.Rand, schema map[string]interface{}, depth int) (interface{}, error) {
+	schema, err := g.resolve(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	if constant, ok := schema["const"]; ok {
+		return constant, nil
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		return enum[r.Intn(len(enum))], nil
+	}
+	if allOf, ok := schema["allOf"].([]interface{}); ok && len(allOf) > 0 {
+		return g.generate(r, mergeSchemas(schema, allOf), depth)
+	}
+	for _, keyword := range []string{"oneOf", "anyOf"} {
+		if options, ok := schema[keyword].([]interface{}); ok && len(options) > 0 {
+			option, _ := options[r.Intn(len(options))].(map[string]interface{})
+			return g.generate(r, mergeSchemas(schema, []interface{}{option}), depth)
+		}
+	}
+
+	switch schemaType(r, schema) {
+	case "object":
+		return g.generateObject(r, schema, depth)
+	case "array":
+		return g.generateArray(r, schema, depth)
+	case "string":
+		return generateJSONString(r, schema)
+	case "integer":
+		return generateJSONInteger(r, schema), nil
+	case "number":
+		return generateJSONNumber(r, schema), nil
+	case "boolean":
+		return r.Intn(2) == 0, nil
+	default:
+		return nil, nil
+	}
+}
+
+func (g *jsonGenerator) generateObject(r *rand.Rand, schema map[string]interface{}, depth int) (interface{}, error) {
+	object := make(map[string]interface{})
+	properties, _ := schema["properties"].(map[string]interface{})
+
+	required := make(map[string]bool)
+	for _, name := range stringSlice(schema["required"]) {
+		required[name] = true
+	}
+
+	for _, name := range sortedKeys(properties) {
+		// optional properties are skipped once the depth limit is reached, to stop recursive schemas
+		if !required[name] && (depth >= maxDepth || r.Intn(4) == 0) {
+			continue
+		}
+		propertySchema, _ := properties[name].(map[string]interface{})
+		value, err := g.generate(r, propertySchema, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		object[name] = value
+	}
+
+	// in the declared order, so the same seed draws the same words for them
+	for _, name := range stringSlice(schema["required"]) {
+		if _, ok := object[name]; !ok {
+			object[name] = randomWord(r)
+		}
+	}
+	return object, nil
+}
+
+func (g *jsonGenerator) generateArray(r *rand.Rand, schema map[string]interface{}, depth int) (interface{}, error) {
+	minItems := intKeyword(schema, "minItems", 0)
+	maxItems := intKeyword(schema, "maxItems", minItems+3)
+	if depth >= maxDepth {
+		maxItems = minItems
+	}
+
+	items, _ := schema["items"].(map[string]interface{})
+	length := int(randomInt(r, int64(minItems), int64(maxItems)))
+	array := make([]interface{}, 0, length)
+	for i := 0; i < length; i++ {
+		item, err := g.generate(r, items, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, item)
+	}
+	return array, nil
+}
+
+func generateJSONString(r *rand.Rand, schema map[string]interface{}) (interface{}, error) {
+	if pattern, ok := schema["pattern"].(string); ok {
+		return stringFromPattern(r, pattern)
+	}
+
+	switch format, _ := schema["format"].(string); format {
+	case "date-time":
+		return randomTime(r).Format("2006-01-02T15:04:05Z07:00"), nil
+	case "date":
+		return randomTime(r).Format("2006-01-02"), nil
+	case "time":
+		return randomTime(r).Format("15:04:05Z07:00"), nil
+	case "email", "idn-email":
+		return randomWord(r) + "@example.com", nil
+	case "hostname", "idn-hostname":
+		return randomWord(r) + ".example.com", nil
+	case "ipv4":
+		return fmt.Sprintf("10.%d.%d.%d", r.Intn(256), r.Intn(256), 1+r.Intn(254)), nil
+	case "uri", "iri", "uri-reference":
+		return "https://example.com/" + randomWord(r), nil
+	case "uuid":
+		return randomUUID(r), nil
+	}
+
+	minLength := intKeyword(schema, "minLength", 1)
+	maxLength := intKeyword(schema, "maxLength", minLength+10)
+	return randomString(r, minLength, maxLength), nil
+}
+
+func generateJSONInteger(r *rand.Rand, schema map[string]interface{}) int64 {
+	min, max := numericBounds(schema, true, 0, 1000)
+	lo, hi := int64(math.Ceil(min)), int64(math.Floor(max))
+
+	if multipleOf, ok := schema["multipleOf"].(float64); ok && multipleOf >= 1 {
+		step := int64(multipleOf)
+		first := int64(math.Ceil(float64(lo)/float64(step))) * step
+		if first <= hi {
+			return first + randomInt(r, 0, (hi-first)/step)*step
+		}
+	}
+	return randomInt(r, lo, hi)
+}
+
+func generateJSONNumber(r *rand.Rand, schema map[string]interface{}) float64 {
+	min, max := numericBounds(schema, false, 0, 1000)
+	return randomFloat(r, min, max)
+}
+
+// numericBounds returns the inclusive bounds of a numeric schema, supporting both the boolean (draft 4) and
+// the numeric (draft 6 and later) form of the exclusive keywords. An exclusive bound is replaced by the closest integer
+// within it for integers, and by the closest representable number within it otherwise.
+func numericBounds(schema map[string]interface{}, integer bool, defaultMin, defaultMax float64) (float64, float64) {
+	above := func(bound float64) float64 {
+		if integer {
+			return math.Floor(bound) + 1
+		}
+		return math.Nextafter(bound, math.Inf(1))
+	}
+	below := func(bound float64) float64 {
+		if integer {
+			return math.Ceil(bound) - 1
+		}
+		return math.Nextafter(bound, math.Inf(-1))
+	}
+
+	min, hasMin := schema["minimum"].(float64)
+	max, hasMax := schema["maximum"].(float64)
+
+	switch exclusive := schema["exclusiveMinimum"].(type) {
+	case bool:
+		if exclusive && hasMin {
+			min = above(min)
+		}
+	case float64:
+		min, hasMin = above(exclusive), true
+	}
+	switch exclusive := schema["exclusiveMaximum"].(type) {
+	case bool:
+		if exclusive && hasMax {
+			max = below(max)
+		}
+	case float64:
+		max, hasMax = below(exclusive), true
+	}
+
+	switch {
+	case !hasMin && !hasMax:
+		return defaultMin, defaultMax
+	case !hasMin:
+		return max - (defaultMax - defaultMin), max
+	case !hasMax:
+		return min, min + (defaultMax - defaultMin)
+	}
+	if max < min {
+		// no value satisfies both bounds, keep to the lower one
+		return min, min
+	}
+	return min, max
+}
+
+// schemaType returns the type a value should be generated for, inferring it from the other keywords if needed.
+func schemaType(r *rand.Rand, schema map[string]interface{}) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []interface{}:
+		var types []string
+		for _, option := range t {
+			if s, ok := option.(string); ok && s != "null" {
+				types = append(types, s)
+			}
+		}
+		if len(types) > 0 {
+			return types[r.Intn(len(types))]
+		}
+		return "null"
+	}
+
+	switch {
+	case schema["properties"] != nil || schema["required"] != nil:
+		return "object"
+	case schema["items"] != nil:
+		return "array"
+	case schema["minimum"] != nil || schema["maximum"] != nil:
+		return "number"
+	default:
+		return "string"
+	}
+}
+
+// mismatchedValue returns a value which doesn't conform to the type of the given schema.
+func mismatchedValue(schema map[string]interface{}) (interface{}, bool) {
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		return "not-one-of-the-enum-values", true
+	}
+
+	types := map[string]bool{}
+	switch t := schema["type"].(type) {
+	case string:
+		types[t] = true
+	case []interface{}:
+		for _, option := range t {
+			if s, ok := option.(string); ok {
+				types[s] = true
+			}
+		}
+	default:
+		return nil, false
+	}
+
+	switch {
+	case !types["string"]:
+		return "unexpected string", true
+	case !types["object"]:
+		return map[string]interface{}{"unexpected": true}, true
+	case !types["integer"] && !types["number"]:
+		return 42, true
+	default:
+		return nil, false
+	}
+}
+
+// mergeSchemas returns a shallow merge of the given schema (without the combining keywords) and the subschemas,
+// where properties and required lists are joined.
+func mergeSchemas(schema map[string]interface{}, subschemas []interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(schema))
+	for k, v := range schema {
+		if k != "allOf" && k != "oneOf" && k != "anyOf" {
+			merged[k] = v
+		}
+	}
+
+	for _, subschema := range subschemas {
+		sub, ok := subschema.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for k, v := range sub {
+			switch k {
+			case "properties":
+				properties := make(map[string]interface{})
+				if existing, ok := merged[k].(map[string]interface{}); ok {
+					for name, property := range existing {
+						properties[name] = property
+					}
+				}
+				if added, ok := v.(map[string]interface{}); ok {
+					for name, property := range added {
+						properties[name] = property
+					}
+				}
+				merged[k] = properties
+			case "required":
+				var required []interface{}
+				if existing, ok := merged[k].([]interface{}); ok {
+					required = append(required, existing...)
+				}
+				if added, ok := v.([]interface{}); ok {
+					required = append(required, added...)
+				}
+				merged[k] = required
+			default:
+				merged[k] = v
+			}
+		}
+	}
+	return merged
+}
+
+func intKeyword(schema map[string]interface{}, keyword string, defaultValue int) int {
+	if value, ok := schema[keyword].(float64); ok {
+		return int(value)
+	}
+	return defaultValue
+}
+
+func stringSlice(value interface{}) []string {
+	values, _ := value.([]interface{})
+	strs := make([]string, 0, len(values))
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			strs = append(strs, s)
+		}
+	}
+	return strs
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
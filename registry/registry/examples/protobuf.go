@@ -0,0 +1,176 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package examples
+
+import (
+	"math/rand"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/dynamic"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// protoFilename is the name under which the specification is handed to the parser.
+const protoFilename = "schema.proto"
+
+// protobufGenerator generates binary encoded Protobuf messages of the first top-level message type of a .proto file.
+type protobufGenerator struct {
+	message *desc.MessageDescriptor
+}
+
+func newProtobufGenerator(specification []byte) (*protobufGenerator, error) {
+	parser := protoparse.Parser{
+		Accessor: protoparse.FileContentsFromMap(map[string]string{protoFilename: string(specification)}),
+	}
+	files, err := parser.ParseFiles(protoFilename)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't parse protobuf schema")
+	}
+
+	messages := files[0].GetMessageTypes()
+	if len(messages) == 0 {
+		return nil, errors.Wrap(ErrUnsupportedSchema, "no message definitions were found in the .proto file")
+	}
+	return &protobufGenerator{message: messages[0]}, nil
+}
+
+func (g *protobufGenerator) Valid(r *rand.Rand) ([]byte, error) {
+	message, err := generateProtobufMessage(r, g.message, 0)
+	if err != nil {
+		return nil, err
+	}
+	return message.MarshalDeterministic()
+}
+
+// Invalid appends a field with a number which isn't defined in the message, which the validator treats as invalid.
+func (g *protobufGenerator) Invalid(r *rand.Rand) ([]byte, error) {
+	payload, err := g.Valid(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var unknown protowire.Number = 1
+	for _, field := range g.message.GetFields() {
+		if number := protowire.Number(field.GetNumber()); number >= unknown {
+			unknown = number + 1
+		}
+	}
+	if unknown >= protowire.FirstReservedNumber && unknown <= protowire.LastReservedNumber {
+		unknown = protowire.LastReservedNumber + 1
+	}
+
+	payload = protowire.AppendTag(payload, unknown, protowire.VarintType)
+	return protowire.AppendVarint(payload, uint64(1+r.Intn(100))), nil
+}
+
+func generateProtobufMessage(r *rand.Rand, descriptor *desc.MessageDescriptor, depth int) (*dynamic.Message, error) {
+	message := dynamic.NewMessage(descriptor)
+
+	for _, oneOf := range descriptor.GetOneOfs() {
+		if oneOf.IsSynthetic() || depth >= maxDepth {
+			continue
+		}
+		choices := oneOf.GetChoices()
+		if err := setProtobufField(r, message, choices[r.Intn(len(choices))], depth); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, field := range descriptor.GetFields() {
+		if oneOf := field.GetOneOf(); oneOf != nil && !oneOf.IsSynthetic() {
+			continue
+		}
+		// optional fields are skipped once the depth limit is reached, to stop recursive messages
+		if !field.IsRequired() && (depth >= maxDepth || r.Intn(5) == 0) {
+			continue
+		}
+		if err := setProtobufField(r, message, field, depth); err != nil {
+			return nil, err
+		}
+	}
+	return message, nil
+}
+
+func setProtobufField(r *rand.Rand, message *dynamic.Message, field *desc.FieldDescriptor, depth int) error {
+	switch {
+	case field.IsMap():
+		for i := 1 + r.Intn(2); i > 0; i-- {
+			key, err := protobufValue(r, field.GetMapKeyType(), depth)
+			if err != nil {
+				return err
+			}
+			value, err := protobufValue(r, field.GetMapValueType(), depth)
+			if err != nil {
+				return err
+			}
+			if err = message.TryPutMapField(field, key, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	case field.IsRepeated():
+		for i := 1 + r.Intn(3); i > 0; i-- {
+			value, err := protobufValue(r, field, depth)
+			if err != nil {
+				return err
+			}
+			if err = message.TryAddRepeatedField(field, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		value, err := protobufValue(r, field, depth)
+		if err != nil {
+			return err
+		}
+		return message.TrySetField(field, value)
+	}
+}
+
+func protobufValue(r *rand.Rand, field *desc.FieldDescriptor, depth int) (interface{}, error) {
+	switch field.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_BOOL:
+		return r.Intn(2) == 0, nil
+	case descriptorpb.FieldDescriptorProto_TYPE_INT32, descriptorpb.FieldDescriptorProto_TYPE_SINT32,
+		descriptorpb.FieldDescriptorProto_TYPE_SFIXED32:
+		return int32(r.Intn(1000)), nil
+	case descriptorpb.FieldDescriptorProto_TYPE_INT64, descriptorpb.FieldDescriptorProto_TYPE_SINT64,
+		descriptorpb.FieldDescriptorProto_TYPE_SFIXED64:
+		return r.Int63n(100000), nil
+	case descriptorpb.FieldDescriptorProto_TYPE_UINT32, descriptorpb.FieldDescriptorProto_TYPE_FIXED32:
+		return uint32(r.Intn(1000)), nil
+	case descriptorpb.FieldDescriptorProto_TYPE_UINT64, descriptorpb.FieldDescriptorProto_TYPE_FIXED64:
+		return uint64(r.Int63n(100000)), nil
+	case descriptorpb.FieldDescriptorProto_TYPE_FLOAT:
+		return float32(randomFloat(r, 0, 1000)), nil
+	case descriptorpb.FieldDescriptorProto_TYPE_DOUBLE:
+		return randomFloat(r, 0, 1000), nil
+	case descriptorpb.FieldDescriptorProto_TYPE_STRING:
+		return randomWord(r), nil
+	case descriptorpb.FieldDescriptorProto_TYPE_BYTES:
+		return randomBytes(r, 1+r.Intn(16)), nil
+	case descriptorpb.FieldDescriptorProto_TYPE_ENUM:
+		values := field.GetEnumType().GetValues()
+		return values[r.Intn(len(values))].GetNumber(), nil
+	case descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, descriptorpb.FieldDescriptorProto_TYPE_GROUP:
+		return generateProtobufMessage(r, field.GetMessageType(), depth+1)
+	default:
+		return nil, errors.Wrapf(ErrUnsupportedSchema, "unsupported field type %s", field.GetType())
+	}
+}
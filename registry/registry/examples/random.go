@@ -0,0 +1,189 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package examples
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp/syntax"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// maxRepeat bounds unbounded repetitions (*, + and {n,}) when generating strings from patterns.
+const maxRepeat = 5
+
+var words = []string{
+	"alpha", "bravo", "charlie", "delta", "echo", "foxtrot", "golf", "hotel", "india", "juliet",
+	"kilo", "lima", "mike", "november", "oscar", "papa", "quebec", "romeo", "sierra", "tango",
+}
+
+const alphanumeric = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// randomWord returns a random human-readable word.
+func randomWord(r *rand.Rand) string {
+	return words[r.Intn(len(words))]
+}
+
+// randomString returns a random alphanumeric string with a length between minLength and maxLength (inclusive).
+func randomString(r *rand.Rand, minLength, maxLength int) string {
+	if maxLength < minLength {
+		maxLength = minLength
+	}
+	length := minLength + r.Intn(maxLength-minLength+1)
+
+	// prefer readable words when they fit the requested length
+	if word := randomWord(r); len(word) >= minLength && len(word) <= maxLength {
+		return word
+	}
+
+	var sb strings.Builder
+	sb.Grow(length)
+	for i := 0; i < length; i++ {
+		sb.WriteByte(alphanumeric[r.Intn(len(alphanumeric))])
+	}
+	return sb.String()
+}
+
+// randomInt returns a random integer between min and max (inclusive).
+func randomInt(r *rand.Rand, min, max int64) int64 {
+	if max <= min {
+		return min
+	}
+	return min + r.Int63n(max-min+1)
+}
+
+// randomFloat returns a random float between min and max, rounded to two decimals whenever the bounds allow it.
+func randomFloat(r *rand.Rand, min, max float64) float64 {
+	if max <= min {
+		return min
+	}
+	value := min + r.Float64()*(max-min)
+	if rounded := float64(int64(value*100)) / 100; rounded >= min && rounded <= max {
+		return rounded
+	}
+	return value
+}
+
+// randomUUID returns a random (version 4) UUID.
+func randomUUID(r *rand.Rand) string {
+	b := make([]byte, 16)
+	_, _ = r.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// randomTime returns a random point in time during the year 2023, in UTC.
+func randomTime(r *rand.Rand) time.Time {
+	start := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+	return start.Add(time.Duration(r.Int63n(int64(365 * 24 * time.Hour))))
+}
+
+// randomBytes returns a slice of n random bytes.
+func randomBytes(r *rand.Rand, n int) []byte {
+	b := make([]byte, n)
+	_, _ = r.Read(b)
+	return b
+}
+
+// stringFromPattern returns a random string which matches the given regular expression.
+func stringFromPattern(r *rand.Rand, pattern string) (string, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to parse pattern %q", pattern)
+	}
+
+	var sb strings.Builder
+	if err = writeMatch(r, &sb, re.Simplify()); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+func writeMatch(r *rand.Rand, sb *strings.Builder, re *syntax.Regexp) error {
+	switch re.Op {
+	case syntax.OpNoMatch:
+		return errors.Wrap(ErrUnsupportedSchema, "pattern can never match")
+	case syntax.OpLiteral:
+		sb.WriteString(string(re.Rune))
+	case syntax.OpCharClass:
+		sb.WriteRune(runeFromClass(r, re.Rune))
+	case syntax.OpAnyCharNotNL, syntax.OpAnyChar:
+		sb.WriteByte(alphanumeric[r.Intn(len(alphanumeric))])
+	case syntax.OpCapture:
+		return writeMatch(r, sb, re.Sub[0])
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		min, max := repeatBounds(re)
+		for i := randomInt(r, int64(min), int64(max)); i > 0; i-- {
+			if err := writeMatch(r, sb, re.Sub[0]); err != nil {
+				return err
+			}
+		}
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if err := writeMatch(r, sb, sub); err != nil {
+				return err
+			}
+		}
+	case syntax.OpAlternate:
+		return writeMatch(r, sb, re.Sub[r.Intn(len(re.Sub))])
+	default:
+		// anchors, word boundaries and empty matches don't produce any characters
+	}
+	return nil
+}
+
+func repeatBounds(re *syntax.Regexp) (int, int) {
+	switch re.Op {
+	case syntax.OpStar:
+		return 0, maxRepeat
+	case syntax.OpPlus:
+		return 1, maxRepeat
+	case syntax.OpQuest:
+		return 0, 1
+	default:
+		if re.Max < 0 {
+			return re.Min, re.Min + maxRepeat
+		}
+		return re.Min, re.Max
+	}
+}
+
+// runeFromClass picks a random rune out of the given character class, preferring printable ASCII characters.
+func runeFromClass(r *rand.Rand, ranges []rune) rune {
+	var printable [][2]rune
+	for i := 0; i+1 < len(ranges); i += 2 {
+		lo, hi := ranges[i], ranges[i+1]
+		if lo < ' ' {
+			lo = ' '
+		}
+		if hi > '~' {
+			hi = '~'
+		}
+		if lo <= hi {
+			printable = append(printable, [2]rune{lo, hi})
+		}
+	}
+	if len(printable) > 0 {
+		chosen := printable[r.Intn(len(printable))]
+		return chosen[0] + rune(r.Intn(int(chosen[1]-chosen[0]+1)))
+	}
+
+	i := r.Intn(len(ranges)/2) * 2
+	return ranges[i] + rune(r.Intn(int(ranges[i+1]-ranges[i]+1)))
+}
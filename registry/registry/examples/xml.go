@@ -0,0 +1,533 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package examples
+
+import (
+	"bytes"
+	"encoding/xml"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// xsdNode is a generic representation of an element of an XML Schema document.
+type xsdNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Children []xsdNode  `xml:",any"`
+}
+
+func (n xsdNode) attr(name string) string {
+	for _, attr := range n.Attrs {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+func (n xsdNode) children(name string) []xsdNode {
+	var children []xsdNode
+	for _, child := range n.Children {
+		if child.XMLName.Local == name {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+func (n xsdNode) child(name string) (xsdNode, bool) {
+	for _, child := range n.Children {
+		if child.XMLName.Local == name {
+			return child, true
+		}
+	}
+	return xsdNode{}, false
+}
+
+// xmlGenerator generates XML documents from an XML Schema, using the first top-level element as the document root.
+type xmlGenerator struct {
+	namespace  string
+	qualified  bool
+	root       xsdNode
+	elements   map[string]xsdNode
+	types      map[string]xsdNode
+	groups     map[string]xsdNode
+	attrGroups map[string]xsdNode
+}
+
+func newXMLGenerator(specification []byte) (*xmlGenerator, error) {
+	var schema xsdNode
+	if err := xml.Unmarshal(specification, &schema); err != nil {
+		return nil, errors.Wrap(err, "couldn't unmarshal xml schema")
+	}
+	if schema.XMLName.Local != "schema" {
+		return nil, errors.Wrap(ErrUnsupportedSchema, "the document root isn't a schema element")
+	}
+
+	g := &xmlGenerator{
+		namespace:  schema.attr("targetNamespace"),
+		qualified:  schema.attr("elementFormDefault") == "qualified",
+		elements:   map[string]xsdNode{},
+		types:      map[string]xsdNode{},
+		groups:     map[string]xsdNode{},
+		attrGroups: map[string]xsdNode{},
+	}
+	var rootFound bool
+	for _, child := range schema.Children {
+		name := child.attr("name")
+		switch child.XMLName.Local {
+		case "element":
+			g.elements[name] = child
+			if !rootFound {
+				g.root, rootFound = child, true
+			}
+		case "complexType", "simpleType":
+			g.types[name] = child
+		case "group":
+			g.groups[name] = child
+		case "attributeGroup":
+			g.attrGroups[name] = child
+		}
+	}
+	if !rootFound {
+		return nil, errors.Wrap(ErrUnsupportedSchema, "no top-level element was found in the xml schema")
+	}
+	return g, nil
+}
+
+func (g *xmlGenerator) Valid(r *rand.Rand) ([]byte, error) {
+	return g.document(r, false)
+}
+
+// Invalid inserts an element which isn't declared in the schema as the first child of the document root.
+func (g *xmlGenerator) Invalid(r *rand.Rand) ([]byte, error) {
+	return g.document(r, true)
+}
+
+func (g *xmlGenerator) document(r *rand.Rand, invalid bool) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	if err := g.writeElement(r, &buf, g.root, 0, invalid); err != nil {
+		return nil, err
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+func (g *xmlGenerator) writeElement(r *rand.Rand, buf *bytes.Buffer, element xsdNode, depth int, invalid bool) error {
+	if ref := element.attr("ref"); ref != "" {
+		referenced, ok := g.elements[localName(ref)]
+		if !ok {
+			return errors.Wrapf(ErrUnsupportedSchema, "unresolvable element reference %s", ref)
+		}
+		element = referenced
+	}
+	if depth > maxDepth*2 {
+		return errors.Wrap(ErrUnsupportedSchema, "the schema requires elements nested too deep")
+	}
+
+	name := element.attr("name")
+	buf.WriteString("<" + name)
+	if depth == 0 && g.namespace != "" && g.qualified {
+		buf.WriteString(` xmlns="` + escapeXML(g.namespace) + `"`)
+	}
+
+	// the content is either given by a named type, an inline type or is a plain string
+	typeNode, builtin := xsdNode{}, "string"
+	if typeName := element.attr("type"); typeName != "" {
+		if named, ok := g.types[localName(typeName)]; ok && !isBuiltin(typeName) {
+			typeNode = named
+		} else {
+			builtin = localName(typeName)
+		}
+	} else if inline, ok := element.child("complexType"); ok {
+		typeNode = inline
+	} else if inline, ok := element.child("simpleType"); ok {
+		typeNode = inline
+	}
+
+	switch typeNode.XMLName.Local {
+	case "complexType":
+		return g.writeComplexContent(r, buf, name, typeNode, depth, invalid)
+	case "simpleType":
+		value, err := g.simpleValue(r, typeNode, 0)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(">" + g.invalidChild(invalid) + escapeXML(value) + "</" + name + ">")
+	default:
+		if fixed := element.attr("fixed"); fixed != "" {
+			buf.WriteString(">" + g.invalidChild(invalid) + escapeXML(fixed) + "</" + name + ">")
+			return nil
+		}
+		buf.WriteString(">" + g.invalidChild(invalid) + escapeXML(builtinValue(r, builtin)) + "</" + name + ">")
+	}
+	return nil
+}
+
+func (g *xmlGenerator) invalidChild(invalid bool) string {
+	if !invalid {
+		return ""
+	}
+	return "<undeclaredElement>unexpected</undeclaredElement>"
+}
+
+func (g *xmlGenerator) writeComplexContent(r *rand.Rand, buf *bytes.Buffer, name string, complexType xsdNode, depth int, invalid bool) error {
+	var content bytes.Buffer
+	var text string
+
+	if err := g.writeAttributes(r, buf, complexType); err != nil {
+		return err
+	}
+	if err := g.writeParticles(r, &content, complexType, depth); err != nil {
+		return err
+	}
+
+	for _, kind := range []string{"simpleContent", "complexContent"} {
+		wrapper, ok := complexType.child(kind)
+		if !ok {
+			continue
+		}
+		derivation, ok := wrapper.child("extension")
+		if !ok {
+			derivation, _ = wrapper.child("restriction")
+		}
+
+		base := derivation.attr("base")
+		if baseType, ok := g.types[localName(base)]; ok && !isBuiltin(base) {
+			if baseType.XMLName.Local == "complexType" {
+				if err := g.writeAttributes(r, buf, baseType); err != nil {
+					return err
+				}
+				if err := g.writeParticles(r, &content, baseType, depth); err != nil {
+					return err
+				}
+			} else {
+				value, err := g.simpleValue(r, baseType, 0)
+				if err != nil {
+					return err
+				}
+				text = value
+			}
+		} else if kind == "simpleContent" {
+			text = builtinValue(r, localName(base))
+		}
+
+		if err := g.writeAttributes(r, buf, derivation); err != nil {
+			return err
+		}
+		if err := g.writeParticles(r, &content, derivation, depth); err != nil {
+			return err
+		}
+	}
+
+	buf.WriteString(">" + g.invalidChild(invalid))
+	buf.WriteString(escapeXML(text))
+	buf.Write(content.Bytes())
+	buf.WriteString("</" + name + ">")
+	return nil
+}
+
+func (g *xmlGenerator) writeAttributes(r *rand.Rand, buf *bytes.Buffer, node xsdNode) error {
+	for _, group := range node.children("attributeGroup") {
+		if referenced, ok := g.attrGroups[localName(group.attr("ref"))]; ok {
+			if err := g.writeAttributes(r, buf, referenced); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, attribute := range node.children("attribute") {
+		if attribute.attr("use") == "prohibited" || (attribute.attr("use") != "required" && r.Intn(2) == 0) {
+			continue
+		}
+		name := attribute.attr("name")
+		if name == "" {
+			name = localName(attribute.attr("ref"))
+		}
+
+		var value string
+		switch {
+		case attribute.attr("fixed") != "":
+			value = attribute.attr("fixed")
+		case attribute.attr("type") != "":
+			typeName := attribute.attr("type")
+			if named, ok := g.types[localName(typeName)]; ok && !isBuiltin(typeName) {
+				generated, err := g.simpleValue(r, named, 0)
+				if err != nil {
+					return err
+				}
+				value = generated
+			} else {
+				value = builtinValue(r, localName(typeName))
+			}
+		default:
+			if inline, ok := attribute.child("simpleType"); ok {
+				generated, err := g.simpleValue(r, inline, 0)
+				if err != nil {
+					return err
+				}
+				value = generated
+			} else {
+				value = randomWord(r)
+			}
+		}
+		buf.WriteString(" " + name + `="` + escapeXML(value) + `"`)
+	}
+	return nil
+}
+
+// writeParticles writes the child elements defined by the model groups (sequence, choice, all, group) of the given node.
+func (g *xmlGenerator) writeParticles(r *rand.Rand, buf *bytes.Buffer, node xsdNode, depth int) error {
+	for _, particle := range node.Children {
+		switch particle.XMLName.Local {
+		case "sequence", "choice", "all", "group", "element":
+		default:
+			continue
+		}
+
+		for n := occurrences(r, particle, depth); n > 0; n-- {
+			switch particle.XMLName.Local {
+			case "element":
+				if err := g.writeElement(r, buf, particle, depth+1, false); err != nil {
+					return err
+				}
+			case "sequence", "all":
+				if err := g.writeParticles(r, buf, particle, depth); err != nil {
+					return err
+				}
+			case "choice":
+				var options []xsdNode
+				for _, option := range particle.Children {
+					if option.XMLName.Local != "annotation" {
+						options = append(options, option)
+					}
+				}
+				if len(options) == 0 {
+					continue
+				}
+				wrapper := xsdNode{Children: []xsdNode{options[r.Intn(len(options))]}}
+				if err := g.writeParticles(r, buf, wrapper, depth); err != nil {
+					return err
+				}
+			case "group":
+				referenced, ok := g.groups[localName(particle.attr("ref"))]
+				if !ok {
+					return errors.Wrapf(ErrUnsupportedSchema, "unresolvable group reference %s", particle.attr("ref"))
+				}
+				if err := g.writeParticles(r, buf, referenced, depth); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// occurrences returns how many times a particle should be repeated, respecting minOccurs and maxOccurs.
+func occurrences(r *rand.Rand, particle xsdNode, depth int) int {
+	min, max := 1, 1
+	if value, err := strconv.Atoi(particle.attr("minOccurs")); err == nil {
+		min = value
+	}
+	switch value := particle.attr("maxOccurs"); value {
+	case "":
+	case "unbounded":
+		max = min + 2
+	default:
+		if parsed, err := strconv.Atoi(value); err == nil {
+			max = parsed
+		}
+	}
+	if max < min {
+		max = min
+	}
+
+	// optional particles are skipped once the depth limit is reached, to stop recursive schemas
+	if depth >= maxDepth {
+		return min
+	}
+	if min == 0 && r.Intn(4) == 0 {
+		return 0
+	}
+	if min == 0 {
+		min = 1
+	}
+	return int(randomInt(r, int64(min), int64(max)))
+}
+
+// simpleValue returns a value for the given simpleType, supporting restrictions, lists and unions.
+func (g *xmlGenerator) simpleValue(r *rand.Rand, simpleType xsdNode, depth int) (string, error) {
+	if depth > maxDepth {
+		return "", errors.Wrap(ErrUnsupportedSchema, "simple type derivation chain is too long")
+	}
+
+	if restriction, ok := simpleType.child("restriction"); ok {
+		if enumerations := restriction.children("enumeration"); len(enumerations) > 0 {
+			return enumerations[r.Intn(len(enumerations))].attr("value"), nil
+		}
+		if pattern, ok := restriction.child("pattern"); ok {
+			return stringFromPattern(r, "^(?:"+pattern.attr("value")+")$")
+		}
+
+		base := restriction.attr("base")
+		if named, ok := g.types[localName(base)]; ok && !isBuiltin(base) {
+			return g.simpleValue(r, named, depth+1)
+		}
+		return restrictedBuiltinValue(r, localName(base), restriction), nil
+	}
+
+	if list, ok := simpleType.child("list"); ok {
+		var items []string
+		for n := 1 + r.Intn(3); n > 0; n-- {
+			item := builtinValue(r, localName(list.attr("itemType")))
+			if named, ok := g.types[localName(list.attr("itemType"))]; ok {
+				generated, err := g.simpleValue(r, named, depth+1)
+				if err != nil {
+					return "", err
+				}
+				item = generated
+			}
+			items = append(items, item)
+		}
+		return strings.Join(items, " "), nil
+	}
+
+	if union, ok := simpleType.child("union"); ok {
+		if members := strings.Fields(union.attr("memberTypes")); len(members) > 0 {
+			member := members[r.Intn(len(members))]
+			if named, ok := g.types[localName(member)]; ok && !isBuiltin(member) {
+				return g.simpleValue(r, named, depth+1)
+			}
+			return builtinValue(r, localName(member)), nil
+		}
+		if inline, ok := union.child("simpleType"); ok {
+			return g.simpleValue(r, inline, depth+1)
+		}
+	}
+	return randomWord(r), nil
+}
+
+// restrictedBuiltinValue returns a value of a builtin type, respecting the length and range facets of the restriction.
+func restrictedBuiltinValue(r *rand.Rand, builtin string, restriction xsdNode) string {
+	facet := func(name string) (float64, bool) {
+		node, ok := restriction.child(name)
+		if !ok {
+			return 0, false
+		}
+		value, err := strconv.ParseFloat(node.attr("value"), 64)
+		return value, err == nil
+	}
+
+	if length, ok := facet("length"); ok {
+		return randomString(r, int(length), int(length))
+	}
+	minLength, hasMinLength := facet("minLength")
+	maxLength, hasMaxLength := facet("maxLength")
+	if hasMinLength || hasMaxLength {
+		if !hasMaxLength {
+			maxLength = minLength + 10
+		}
+		return randomString(r, int(minLength), int(maxLength))
+	}
+
+	min, hasMin := facet("minInclusive")
+	if exclusive, ok := facet("minExclusive"); ok {
+		min, hasMin = exclusive+1, true
+	}
+	max, hasMax := facet("maxInclusive")
+	if exclusive, ok := facet("maxExclusive"); ok {
+		max, hasMax = exclusive-1, true
+	}
+	if !hasMin && !hasMax {
+		return builtinValue(r, builtin)
+	}
+	if !hasMin {
+		min = max - 1000
+	}
+	if !hasMax {
+		max = min + 1000
+	}
+
+	switch builtin {
+	case "decimal", "float", "double":
+		return strconv.FormatFloat(randomFloat(r, min, max), 'f', -1, 64)
+	default:
+		return strconv.FormatInt(randomInt(r, int64(min), int64(max)), 10)
+	}
+}
+
+// builtinValue returns a value of the given XML Schema builtin type.
+func builtinValue(r *rand.Rand, builtin string) string {
+	switch builtin {
+	case "int", "integer", "long", "short", "nonNegativeInteger", "unsignedInt", "unsignedLong", "unsignedShort":
+		return strconv.Itoa(r.Intn(1000))
+	case "positiveInteger":
+		return strconv.Itoa(1 + r.Intn(1000))
+	case "negativeInteger":
+		return strconv.Itoa(-1 - r.Intn(1000))
+	case "nonPositiveInteger":
+		return strconv.Itoa(-r.Intn(1000))
+	case "byte":
+		return strconv.Itoa(r.Intn(128))
+	case "unsignedByte":
+		return strconv.Itoa(r.Intn(256))
+	case "decimal", "float", "double":
+		return strconv.FormatFloat(randomFloat(r, 0, 1000), 'f', -1, 64)
+	case "boolean":
+		return strconv.FormatBool(r.Intn(2) == 0)
+	case "date":
+		return randomTime(r).Format("2006-01-02")
+	case "dateTime":
+		return randomTime(r).Format("2006-01-02T15:04:05Z")
+	case "time":
+		return randomTime(r).Format("15:04:05")
+	case "gYear":
+		return randomTime(r).Format("2006")
+	case "anyURI":
+		return "https://example.com/" + randomWord(r)
+	case "base64Binary":
+		return "ZXhhbXBsZQ=="
+	case "hexBinary":
+		return "6578616d706c65"
+	default:
+		return randomWord(r)
+	}
+}
+
+// isBuiltin checks whether the given (possibly prefixed) type name belongs to the XML Schema namespace.
+func isBuiltin(typeName string) bool {
+	prefix := ""
+	if i := strings.Index(typeName, ":"); i >= 0 {
+		prefix = typeName[:i]
+	}
+	return prefix == "xs" || prefix == "xsd"
+}
+
+func localName(name string) string {
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+func escapeXML(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
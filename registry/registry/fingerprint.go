@@ -0,0 +1,73 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+
+	"github.com/hamba/avro/v2"
+	"github.com/pkg/errors"
+)
+
+// crc64AvroEmpty is the CRC-64-AVRO fingerprint of the empty string, the initial value of the Rabin fingerprint.
+const crc64AvroEmpty uint64 = 0xc15d213aa4d7a795
+
+var crc64AvroTable = func() [256]uint64 {
+	var table [256]uint64
+	for i := range table {
+		fp := uint64(i)
+		for j := 0; j < 8; j++ {
+			fp = (fp >> 1) ^ (crc64AvroEmpty & -(fp & 1))
+		}
+		table[i] = fp
+	}
+	return table
+}()
+
+// crc64Avro calculates the CRC-64-AVRO (Rabin) fingerprint of the given data, as defined by the Avro specification.
+func crc64Avro(data []byte) uint64 {
+	fp := crc64AvroEmpty
+	for _, b := range data {
+		fp = (fp >> 8) ^ crc64AvroTable[byte(fp)^b]
+	}
+	return fp
+}
+
+// AvroFingerprint calculates the CRC-64-AVRO fingerprint of the Parsing Canonical Form of the Avro schema, encoded as
+// the hex of its 8 little-endian bytes, the way they follow the C3 01 marker of Avro single-object encoded messages.
+func AvroFingerprint(specification []byte) (string, error) {
+	schema, err := avro.Parse(string(specification))
+	if err != nil {
+		return "", errors.Wrap(err, "couldn't parse avro schema")
+	}
+	var fingerprint [8]byte
+	binary.LittleEndian.PutUint64(fingerprint[:], crc64Avro([]byte(schema.String())))
+	return hex.EncodeToString(fingerprint[:]), nil
+}
+
+// avroFingerprintOf returns the Avro fingerprint of the specification if the schema is an Avro schema which can be
+// parsed, or an empty string otherwise, since schemas aren't parsed at all under the none validity mode.
+func avroFingerprintOf(specification, schemaType string) string {
+	if strings.ToLower(schemaType) != "avro" {
+		return ""
+	}
+	fingerprint, err := AvroFingerprint([]byte(specification))
+	if err != nil {
+		return ""
+	}
+	return fingerprint
+}
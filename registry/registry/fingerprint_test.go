@@ -0,0 +1,61 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"testing"
+)
+
+func TestAvroFingerprint(t *testing.T) {
+	tt := []struct {
+		name          string
+		specification string
+		fingerprint   string
+	}{
+		// 7195948357588979594, the fingerprint of "null" given by the Avro specification, in little-endian bytes
+		{"primitive", `"null"`, "8a8f25cce724dd63"},
+		{"primitive object", `{"type": "null"}`, "8a8f25cce724dd63"},
+		{
+			name:          "record",
+			specification: `{"type":"record","name":"A","fields":[{"name":"a","type":"int"}]}`,
+			fingerprint:   "ad2c0d121235c1e8",
+		},
+		{
+			name: "record with attributes outside the parsing canonical form",
+			specification: `{
+				"name": "A",
+				"type": "record",
+				"doc": "a record",
+				"fields": [{"name": "a", "type": "int", "default": 1}]
+			}`,
+			fingerprint: "ad2c0d121235c1e8",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			fingerprint, err := AvroFingerprint([]byte(tc.specification))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fingerprint != tc.fingerprint {
+				t.Errorf("expected fingerprint %s, got %s", tc.fingerprint, fingerprint)
+			}
+		})
+	}
+
+	if _, err := AvroFingerprint([]byte(`{"type":"record"`)); err == nil {
+		t.Error("expected an error for an invalid schema")
+	}
+}
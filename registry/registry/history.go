@@ -0,0 +1,83 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"time"
+)
+
+// GetSchemaHistory gets the activations and deactivations of the versions of a schema, oldest first.
+func (service *Service) GetSchemaHistory(ctx context.Context, id string) ([]VersionEvent, error) {
+	return service.Repository.GetSchemaVersionEvents(ctx, id)
+}
+
+// GetLatestSchemaVersionAsOf gets the version of a schema which was the latest one at the given point in time, even if
+// it's deactivated since. ErrNotFound is returned if the schema had no active version at that time.
+//
+// A deactivated version which was registered again got a new version number, under which it's returned.
+func (service *Service) GetLatestSchemaVersionAsOf(ctx context.Context, id string, asOf time.Time) (VersionDetails, error) {
+	events, err := service.Repository.GetSchemaVersionEvents(ctx, id)
+	if err != nil {
+		return VersionDetails{}, err
+	}
+	latest, ok := latestVersionAt(events, asOf)
+	if !ok {
+		return VersionDetails{}, ErrNotFound
+	}
+
+	schema, err := service.Repository.GetAllSchemaVersions(ctx, id)
+	if err != nil {
+		return VersionDetails{}, err
+	}
+	for _, details := range schema.VersionDetails {
+		if details.Version == latest.Version && details.SchemaHash == latest.SchemaHash {
+			return details, nil
+		}
+	}
+	for _, details := range schema.VersionDetails {
+		if details.SchemaHash == latest.SchemaHash {
+			return details, nil
+		}
+	}
+	return VersionDetails{}, ErrNotFound
+}
+
+// latestVersionAt replays the events up to and including asOf, returning the activation of the highest version which
+// was active at that time, and false if none was.
+func latestVersionAt(events []VersionEvent, asOf time.Time) (VersionEvent, bool) {
+	active := make(map[string]VersionEvent)
+	for _, event := range events {
+		if event.Timestamp.After(asOf) {
+			break
+		}
+		switch event.Event {
+		case VersionEventActivated:
+			active[event.Version] = event
+		case VersionEventDeactivated:
+			delete(active, event.Version)
+		}
+	}
+
+	var latest VersionEvent
+	found := false
+	for version, event := range active {
+		if !found || lessNumeric(latest.Version, version) {
+			latest = event
+			found = true
+		}
+	}
+	return latest, found
+}
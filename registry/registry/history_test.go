@@ -0,0 +1,113 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// historyRepository is a mockRepository which stores the versions of a schema and their events.
+type historyRepository struct {
+	*mockRepository
+	versions []VersionDetails
+	events   []VersionEvent
+}
+
+func (r *historyRepository) GetSchemaVersionEvents(_ context.Context, _ string) ([]VersionEvent, error) {
+	return r.events, nil
+}
+
+func (r *historyRepository) GetAllSchemaVersions(_ context.Context, id string) (Schema, error) {
+	return Schema{SchemaID: id, VersionDetails: r.versions}, nil
+}
+
+func Test_latestVersionAt(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time {
+		return start.Add(time.Duration(minutes) * time.Minute)
+	}
+	events := []VersionEvent{
+		{Version: "1", Event: VersionEventActivated, SchemaHash: "a", Timestamp: at(0)},
+		{Version: "2", Event: VersionEventActivated, SchemaHash: "b", Timestamp: at(10)},
+		{Version: "10", Event: VersionEventActivated, SchemaHash: "c", Timestamp: at(20)},
+		{Version: "10", Event: VersionEventDeactivated, SchemaHash: "c", Timestamp: at(30)},
+		{Version: "1", Event: VersionEventDeactivated, SchemaHash: "a", Timestamp: at(40)},
+		{Version: "2", Event: VersionEventDeactivated, SchemaHash: "b", Timestamp: at(40)},
+	}
+
+	tt := []struct {
+		name    string
+		asOf    time.Time
+		version string
+		found   bool
+	}{
+		{"before the first version", at(-1), "", false},
+		{"at the first activation", at(0), "1", true},
+		{"numeric order", at(25), "10", true},
+		{"latest deactivated", at(35), "2", true},
+		{"all deactivated", at(45), "", false},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			latest, found := latestVersionAt(events, tc.asOf)
+			if found != tc.found || latest.Version != tc.version {
+				t.Errorf("expected version %q (%t), got %q (%t)", tc.version, tc.found, latest.Version, found)
+			}
+		})
+	}
+}
+
+func Test_GetLatestSchemaVersionAsOf(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo := &historyRepository{
+		mockRepository: NewMockRepository(),
+		versions: []VersionDetails{
+			{Version: "1", SchemaHash: "a"},
+			{Version: "3", SchemaHash: "b"},
+		},
+		events: []VersionEvent{
+			{Version: "1", Event: VersionEventActivated, SchemaHash: "a", Timestamp: start},
+			{Version: "2", Event: VersionEventActivated, SchemaHash: "b", Timestamp: start.Add(time.Minute)},
+			{Version: "2", Event: VersionEventDeactivated, SchemaHash: "b", Timestamp: start.Add(2 * time.Minute)},
+			{Version: "3", Event: VersionEventActivated, SchemaHash: "b", Timestamp: start.Add(3 * time.Minute)},
+		},
+	}
+	service := New(repo, &mockCompChecker{}, &mockValChecker{}, "none", "none")
+
+	details, err := service.GetLatestSchemaVersionAsOf(context.Background(), "1", start.Add(90*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// version 2 was registered again as version 3
+	if details.Version != "3" {
+		t.Errorf("expected the renumbered version 3, got %s", details.Version)
+	}
+
+	details, err = service.GetLatestSchemaVersionAsOf(context.Background(), "1", start.Add(150*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if details.Version != "1" {
+		t.Errorf("expected version 1, got %s", details.Version)
+	}
+
+	if _, err = service.GetLatestSchemaVersionAsOf(context.Background(), "1", start.Add(-time.Second)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound before the first version, got %v", err)
+	}
+}
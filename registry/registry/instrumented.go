@@ -0,0 +1,247 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/dataphos/schema-registry/compatibility"
+	"github.com/dataphos/schema-registry/internal/metrics"
+	"github.com/dataphos/schema-registry/validity"
+)
+
+const (
+	compatibilityCheckerName = "compatibility"
+	validityCheckerName      = "validity"
+)
+
+const (
+	schemaIDAttribute      = attribute.Key("schema.id")
+	schemaVersionAttribute = attribute.Key("schema.version")
+	checkModeAttribute     = attribute.Key("check.mode")
+)
+
+var tracer = otel.Tracer("github.com/dataphos/schema-registry/registry")
+
+// instrumented decorates Repository with a span, latency and error metrics of every query.
+type instrumented struct {
+	Repository
+}
+
+// WithInstrumentation decorates the given Repository with a span, latency and error metrics of its queries.
+func WithInstrumentation(repository Repository) Repository {
+	return &instrumented{Repository: repository}
+}
+
+// startQuery starts the span of a repository query. The returned function ends it and records the query metrics.
+// Errors caused by the request, like a missing schema, aren't counted as repository errors.
+func startQuery(ctx context.Context, operation string, attributes ...attribute.KeyValue) (context.Context, func(error)) {
+	start := time.Now()
+	ctx, span := tracer.Start(ctx, "repository "+operation, trace.WithAttributes(attributes...))
+
+	return ctx, func(err error) {
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrInvalidValueHeader) {
+			err = nil
+		}
+		endSpan(span, err)
+		metrics.RepositoryMetricUpdate(operation, start, err)
+	}
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (r *instrumented) CreateSchema(ctx context.Context, schemaRegisterRequest SchemaRegistrationRequest) (VersionDetails, bool, error) {
+	ctx, finish := startQuery(ctx, "create_schema")
+	details, added, err := r.Repository.CreateSchema(ctx, schemaRegisterRequest)
+	finish(err)
+	return details, added, err
+}
+
+func (r *instrumented) GetSchemaVersionByIdAndVersion(ctx context.Context, id string, version string) (VersionDetails, error) {
+	ctx, finish := startQuery(ctx, "get_schema_version", schemaIDAttribute.String(id), schemaVersionAttribute.String(version))
+	details, err := r.Repository.GetSchemaVersionByIdAndVersion(ctx, id, version)
+	finish(err)
+	return details, err
+}
+
+func (r *instrumented) UpdateSchemaById(ctx context.Context, id string, schemaUpdateRequest SchemaUpdateRequest) (VersionDetails, bool, error) {
+	ctx, finish := startQuery(ctx, "update_schema", schemaIDAttribute.String(id))
+	details, updated, err := r.Repository.UpdateSchemaById(ctx, id, schemaUpdateRequest)
+	finish(err)
+	return details, updated, err
+}
+
+func (r *instrumented) GetSchemaVersionsById(ctx context.Context, id string) (Schema, error) {
+	ctx, finish := startQuery(ctx, "get_schema_versions", schemaIDAttribute.String(id))
+	schema, err := r.Repository.GetSchemaVersionsById(ctx, id)
+	finish(err)
+	return schema, err
+}
+
+func (r *instrumented) GetAllSchemaVersions(ctx context.Context, id string) (Schema, error) {
+	ctx, finish := startQuery(ctx, "get_all_schema_versions", schemaIDAttribute.String(id))
+	schema, err := r.Repository.GetAllSchemaVersions(ctx, id)
+	finish(err)
+	return schema, err
+}
+
+func (r *instrumented) GetSchemaMetadata(ctx context.Context, id string) (Schema, error) {
+	ctx, finish := startQuery(ctx, "get_schema_metadata", schemaIDAttribute.String(id))
+	schema, err := r.Repository.GetSchemaMetadata(ctx, id)
+	finish(err)
+	return schema, err
+}
+
+func (r *instrumented) GetLatestSchemaVersion(ctx context.Context, id string) (VersionDetails, error) {
+	ctx, finish := startQuery(ctx, "get_latest_schema_version", schemaIDAttribute.String(id))
+	details, err := r.Repository.GetLatestSchemaVersion(ctx, id)
+	finish(err)
+	return details, err
+}
+
+func (r *instrumented) DeleteSchema(ctx context.Context, id string, usedSince time.Time) (bool, error) {
+	ctx, finish := startQuery(ctx, "delete_schema", schemaIDAttribute.String(id))
+	deleted, err := r.Repository.DeleteSchema(ctx, id, usedSince)
+	finish(err)
+	return deleted, err
+}
+
+func (r *instrumented) DeleteSchemaVersion(ctx context.Context, id, version string, usedSince time.Time) (bool, error) {
+	ctx, finish := startQuery(ctx, "delete_schema_version", schemaIDAttribute.String(id), schemaVersionAttribute.String(version))
+	deleted, err := r.Repository.DeleteSchemaVersion(ctx, id, version, usedSince)
+	finish(err)
+	return deleted, err
+}
+
+func (r *instrumented) UpdateSchemaMetadata(ctx context.Context, id string, request SchemaMetadataRequest) (Schema, error) {
+	ctx, finish := startQuery(ctx, "update_schema_metadata", schemaIDAttribute.String(id))
+	schema, err := r.Repository.UpdateSchemaMetadata(ctx, id, request)
+	finish(err)
+	return schema, err
+}
+
+func (r *instrumented) RestoreSchemaVersion(ctx context.Context, id, version string) (VersionDetails, bool, error) {
+	ctx, finish := startQuery(ctx, "restore_schema_version", schemaIDAttribute.String(id), schemaVersionAttribute.String(version))
+	details, restored, err := r.Repository.RestoreSchemaVersion(ctx, id, version)
+	finish(err)
+	return details, restored, err
+}
+
+func (r *instrumented) GetAllSchemas(ctx context.Context) ([]Schema, error) {
+	ctx, finish := startQuery(ctx, "get_all_schemas")
+	schemas, err := r.Repository.GetAllSchemas(ctx)
+	finish(err)
+	return schemas, err
+}
+
+func (r *instrumented) GetSchemas(ctx context.Context) ([]Schema, error) {
+	ctx, finish := startQuery(ctx, "get_schemas")
+	schemas, err := r.Repository.GetSchemas(ctx)
+	finish(err)
+	return schemas, err
+}
+
+func (r *instrumented) GetAuditRecords(ctx context.Context, query AuditQuery) ([]AuditRecord, error) {
+	ctx, finish := startQuery(ctx, "get_audit_records", schemaIDAttribute.String(query.SchemaID))
+	records, err := r.Repository.GetAuditRecords(ctx, query)
+	finish(err)
+	return records, err
+}
+
+func (r *instrumented) RegisterUsage(ctx context.Context, usage Usage) error {
+	ctx, finish := startQuery(ctx, "register_usage", schemaIDAttribute.String(usage.SchemaID), schemaVersionAttribute.String(usage.Version))
+	err := r.Repository.RegisterUsage(ctx, usage)
+	finish(err)
+	return err
+}
+
+func (r *instrumented) GetUsage(ctx context.Context, query UsageQuery) ([]Usage, error) {
+	ctx, finish := startQuery(ctx, "get_usage", schemaIDAttribute.String(query.SchemaID), schemaVersionAttribute.String(query.Version))
+	usage, err := r.Repository.GetUsage(ctx, query)
+	finish(err)
+	return usage, err
+}
+
+func (r *instrumented) SetAlias(ctx context.Context, id, alias, version string) (Alias, error) {
+	ctx, finish := startQuery(ctx, "set_alias", schemaIDAttribute.String(id), schemaVersionAttribute.String(version))
+	schemaAlias, err := r.Repository.SetAlias(ctx, id, alias, version)
+	finish(err)
+	return schemaAlias, err
+}
+
+func (r *instrumented) GetAliases(ctx context.Context, id string) ([]Alias, error) {
+	ctx, finish := startQuery(ctx, "get_aliases", schemaIDAttribute.String(id))
+	aliases, err := r.Repository.GetAliases(ctx, id)
+	finish(err)
+	return aliases, err
+}
+
+func (r *instrumented) DeleteAlias(ctx context.Context, id, alias string) (bool, error) {
+	ctx, finish := startQuery(ctx, "delete_alias", schemaIDAttribute.String(id))
+	deleted, err := r.Repository.DeleteAlias(ctx, id, alias)
+	finish(err)
+	return deleted, err
+}
+
+func (r *instrumented) GetSchemaVersionsByFingerprint(ctx context.Context, query FingerprintQuery) ([]SchemaMatch, error) {
+	ctx, finish := startQuery(ctx, "get_schema_versions_by_fingerprint")
+	matches, err := r.Repository.GetSchemaVersionsByFingerprint(ctx, query)
+	finish(err)
+	return matches, err
+}
+
+func (r *instrumented) GetSchemaVersionEvents(ctx context.Context, id string) ([]VersionEvent, error) {
+	ctx, finish := startQuery(ctx, "get_schema_version_events", schemaIDAttribute.String(id))
+	events, err := r.Repository.GetSchemaVersionEvents(ctx, id)
+	finish(err)
+	return events, err
+}
+
+// instrumentCompatibilityChecker records a span, the latency and errors of the given compatibility.Checker.
+func instrumentCompatibilityChecker(checker compatibility.Checker) compatibility.Checker {
+	return compatibility.CheckerFunc(func(ctx context.Context, schema string, history []string, mode string) (bool, error) {
+		start := time.Now()
+		ctx, span := tracer.Start(ctx, "check compatibility", trace.WithAttributes(checkModeAttribute.String(mode)))
+		ok, err := checker.Check(ctx, schema, history, mode)
+		endSpan(span, err)
+		metrics.CheckerMetricUpdate(compatibilityCheckerName, start, err)
+		return ok, err
+	})
+}
+
+// instrumentValidityChecker records a span, the latency and errors of the given validity.Checker.
+func instrumentValidityChecker(checker validity.Checker) validity.Checker {
+	return validity.CheckerFunc(func(ctx context.Context, schema, schemaType, mode string) (bool, error) {
+		start := time.Now()
+		ctx, span := tracer.Start(ctx, "check validity", trace.WithAttributes(checkModeAttribute.String(mode)))
+		ok, err := checker.Check(ctx, schema, schemaType, mode)
+		endSpan(span, err)
+		metrics.CheckerMetricUpdate(validityCheckerName, start, err)
+		return ok, err
+	})
+}
@@ -0,0 +1,76 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dataphos/schema-registry/validity"
+)
+
+// ViolationsError is returned when a schema isn't valid because it violates lint rules with the error severity.
+type ViolationsError struct {
+	Violations []validity.Violation
+}
+
+func (e *ViolationsError) Error() string {
+	return fmt.Sprintf("schema violates %d lint rules", len(e.Violations))
+}
+
+// Unwrap returns ErrNotValid, so callers not interested in the violations can handle it like any invalid schema.
+func (e *ViolationsError) Unwrap() error {
+	return ErrNotValid
+}
+
+// isLintMode returns true if the schema is linted in the given validity mode.
+func isLintMode(mode string) bool {
+	return strings.ToLower(mode) == validity.ModeLint
+}
+
+// lintTarget returns the target of linting a new version of the schema, holding the specifications of its
+// active versions.
+func lintTarget(schema Schema) validity.LintTarget {
+	target := validity.LintTarget{
+		SchemaID: schema.SchemaID,
+		Group:    schema.PublisherID,
+	}
+	for _, details := range schema.VersionDetails {
+		target.History = append(target.History, string(details.Specification))
+	}
+	return target
+}
+
+// notValid returns the error of a schema which isn't valid, holding the violations if there are any.
+func notValid(violations []validity.Violation) error {
+	if len(violations) > 0 {
+		return &ViolationsError{Violations: violations}
+	}
+	return ErrNotValid
+}
+
+// lookupLintTarget returns the target of linting a new version of the schema with the given id, or an empty target
+// if the id is empty.
+func (service *Service) lookupLintTarget(ctx context.Context, id string) (validity.LintTarget, error) {
+	if id == "" {
+		return validity.LintTarget{}, nil
+	}
+	schema, err := service.ListSchemaVersions(ctx, id)
+	if err != nil {
+		return validity.LintTarget{}, err
+	}
+	return lintTarget(schema), nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/dataphos/schema-registry/registry/internal/hashutils"
+)
+
+var (
+	schemaHashPattern      = regexp.MustCompile(`^[0-9a-f]{64}$`)
+	avroFingerprintPattern = regexp.MustCompile(`^[0-9a-f]{16}$`)
+)
+
+// LookupSchema returns the active schema versions whose specification is the given one, across all schemas.
+// The specification matches the versions registered with the same canonical form, the versions registered as they are
+// under the none validity mode, and, for Avro schemas, the versions with the same Parsing Canonical Form.
+// ErrNotFound is returned if no version matches.
+func (service *Service) LookupSchema(ctx context.Context, request LookupRequest) ([]SchemaMatch, error) {
+	schemaType := strings.ToLower(request.SchemaType)
+	query := FingerprintQuery{
+		SchemaType:   schemaType,
+		SchemaHashes: []string{hashutils.SHA256([]byte(request.Specification))},
+	}
+	// a specification which can't be canonicalized can still match the versions registered as they are
+	if canonicalSpec, err := canonicalizeSchema([]byte(request.Specification), schemaType); err == nil {
+		query.SchemaHashes = append(query.SchemaHashes, hashutils.SHA256([]byte(canonicalSpec)))
+	}
+	if fingerprint := avroFingerprintOf(request.Specification, schemaType); fingerprint != "" {
+		query.AvroFingerprints = []string{fingerprint}
+	}
+	return service.getSchemaVersionsByFingerprint(ctx, query)
+}
+
+// GetSchemaVersionsByFingerprint returns the active schema versions with the given fingerprint, across all schemas.
+// The fingerprint is either the SHA-256 schema hash, as 64 hex digits, or the CRC-64-AVRO fingerprint of an Avro
+// schema, as the 16 hex digits of its little-endian bytes. ErrInvalidFingerprint is returned if it is neither, and
+// ErrNotFound if no version matches.
+func (service *Service) GetSchemaVersionsByFingerprint(ctx context.Context, fingerprint string) ([]SchemaMatch, error) {
+	fingerprint = strings.ToLower(fingerprint)
+
+	var query FingerprintQuery
+	switch {
+	case schemaHashPattern.MatchString(fingerprint):
+		query.SchemaHashes = []string{fingerprint}
+	case avroFingerprintPattern.MatchString(fingerprint):
+		query.AvroFingerprints = []string{fingerprint}
+	default:
+		return nil, errors.Wrap(ErrInvalidFingerprint, "fingerprint must be either a SHA-256 schema hash or a CRC-64-AVRO fingerprint in hex")
+	}
+	return service.getSchemaVersionsByFingerprint(ctx, query)
+}
+
+func (service *Service) getSchemaVersionsByFingerprint(ctx context.Context, query FingerprintQuery) ([]SchemaMatch, error) {
+	matches, err := service.Repository.GetSchemaVersionsByFingerprint(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, ErrNotFound
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].SchemaID != matches[j].SchemaID {
+			return lessNumeric(matches[i].SchemaID, matches[j].SchemaID)
+		}
+		return lessNumeric(matches[i].Version, matches[j].Version)
+	})
+	return matches, nil
+}
+
+// lessNumeric compares the ids or versions by their numeric value, falling back to comparing them as strings.
+func lessNumeric(a, b string) bool {
+	x, errX := strconv.Atoi(a)
+	y, errY := strconv.Atoi(b)
+	if errX != nil || errY != nil {
+		return a < b
+	}
+	return x < y
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dataphos/schema-registry/compatibility"
+)
+
+// transitiveSuffix is the suffix of the compatibility modes which check a new version against all previous versions.
+const transitiveSuffix = "_TRANSITIVE"
+
+// CompatibilityMatrix checks the compatibility of every pair of active versions of the schema with the given id under
+// the given mode, or the mode of the schema if it is empty, without changing the schema.
+//
+// Every pair is checked with the non-transitive form of the mode, so the matrix shows which pairs violate it before
+// the mode of the schema is switched. Returns ErrUnknownComp if the mode isn't supported.
+func (service *Service) CompatibilityMatrix(ctx context.Context, id, mode string) (CompatibilityMatrix, error) {
+	schema, err := service.ListSchemaVersions(ctx, id)
+	if err != nil {
+		return CompatibilityMatrix{}, err
+	}
+	if mode == "" {
+		mode = schema.CompatibilityMode
+	}
+	if mode == "" {
+		mode = service.GlobalCompMode
+	}
+	if !compatibility.CheckIfValidMode(&mode) {
+		return CompatibilityMatrix{}, ErrUnknownComp
+	}
+	mode = strings.ToUpper(mode)
+	pairMode := strings.TrimSuffix(mode, transitiveSuffix)
+	transitive := pairMode != mode
+
+	// the versions may be shared with the cache, so they are sorted in a copy
+	versions := append([]VersionDetails(nil), schema.VersionDetails...)
+	sort.Slice(versions, func(i, j int) bool {
+		return versionNumber(versions[i].Version) < versionNumber(versions[j].Version)
+	})
+
+	matrix := CompatibilityMatrix{
+		SchemaID:   id,
+		Mode:       mode,
+		Versions:   make([]string, len(versions)),
+		Compatible: true,
+		Pairs:      []CompatibilityPair{},
+	}
+	for i, details := range versions {
+		matrix.Versions[i] = details.Version
+	}
+
+	for j := 1; j < len(versions); j++ {
+		schemaInfo, err := json.Marshal(map[string]string{
+			"id":     id,
+			"format": schema.SchemaType,
+			"schema": string(versions[j].Specification),
+		})
+		if err != nil {
+			return CompatibilityMatrix{}, err
+		}
+
+		for i := 0; i < j; i++ {
+			compatible, err := service.CompChecker.Check(ctx, string(schemaInfo), []string{string(versions[i].Specification)}, pairMode)
+			if err != nil {
+				return CompatibilityMatrix{}, err
+			}
+			pair := CompatibilityPair{
+				Older:      versions[i].Version,
+				Newer:      versions[j].Version,
+				Compatible: compatible,
+				Required:   transitive || i == j-1,
+			}
+			if pair.Required && !compatible {
+				matrix.Compatible = false
+			}
+			matrix.Pairs = append(matrix.Pairs, pair)
+		}
+	}
+	return matrix, nil
+}
+
+// versionNumber returns the version as a number, so versions are ordered numerically.
+func versionNumber(version string) int {
+	number, _ := strconv.Atoi(version)
+	return number
+}
@@ -0,0 +1,85 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/dataphos/schema-registry/compatibility"
+)
+
+func Test_CompatibilityMatrix(t *testing.T) {
+	schema := MockSchema("mocking")
+	schema.CompatibilityMode = "BACKWARD"
+	for _, version := range []string{"10", "2", "1"} {
+		details := MockVersionDetails(version, version)
+		details.Specification = []byte("v" + version)
+		schema.VersionDetails = append(schema.VersionDetails, details)
+	}
+	repo := NewMockRepository()
+	repo.SetGetSchemaVersionsByIdResponse("mocking", schema, nil)
+
+	// v10 can't read the data of v1, every other pair is compatible
+	checker := compatibility.CheckerFunc(func(_ context.Context, schemaInfo string, history []string, mode string) (bool, error) {
+		if mode != "BACKWARD" {
+			t.Errorf("expected the pairs to be checked in BACKWARD mode, got %s", mode)
+		}
+		var info map[string]string
+		if err := json.Unmarshal([]byte(schemaInfo), &info); err != nil {
+			t.Fatal(err)
+		}
+		return !(info["schema"] == "v10" && history[0] == "v1"), nil
+	})
+	service := New(repo, checker, &mockValChecker{}, "none", "none")
+
+	tt := []struct {
+		mode       string
+		compatible bool
+	}{
+		{"", true},
+		{"backward_transitive", false},
+	}
+	for _, tc := range tt {
+		t.Run(tc.mode, func(t *testing.T) {
+			matrix, err := service.CompatibilityMatrix(context.Background(), "mocking", tc.mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(matrix.Versions, []string{"1", "2", "10"}) {
+				t.Errorf("expected the versions to be ordered numerically, got %v", matrix.Versions)
+			}
+			if matrix.Compatible != tc.compatible {
+				t.Errorf("expected compatible to be %v", tc.compatible)
+			}
+			expected := []CompatibilityPair{
+				{Older: "1", Newer: "2", Compatible: true, Required: true},
+				{Older: "1", Newer: "10", Compatible: false, Required: !tc.compatible},
+				{Older: "2", Newer: "10", Compatible: true, Required: true},
+			}
+			if !reflect.DeepEqual(matrix.Pairs, expected) {
+				t.Errorf("expected %v, got %v", expected, matrix.Pairs)
+			}
+		})
+	}
+
+	if _, err := service.CompatibilityMatrix(context.Background(), "mocking", "sideways"); !errors.Is(err, ErrUnknownComp) {
+		t.Errorf("expected ErrUnknownComp, got %v", err)
+	}
+}
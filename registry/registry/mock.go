@@ -17,6 +17,7 @@
 package registry
 
 import (
+	"context"
 	"time"
 )
 
@@ -105,7 +106,7 @@ func MockVersionDetails(id, version string) VersionDetails {
 		VersionID:          id,
 		Version:            version,
 		SchemaID:           "mocking",
-		Specification:      "mocking",
+		Specification:      []byte("mocking"),
 		Description:        "mocking",
 		SchemaHash:         "mocking",
 		CreatedAt:          time.Time{},
@@ -128,27 +129,27 @@ func NewMockRepository() *mockRepository {
 	}
 }
 
-func (c *mockCompChecker) Check(_ string, _ []string, _ string) (bool, error) {
+func (c *mockCompChecker) Check(_ context.Context, _ string, _ []string, _ string) (bool, error) {
 	return true, nil
 }
 
-func (c *mockValChecker) Check(_, _, _ string) (bool, error) {
+func (c *mockValChecker) Check(_ context.Context, _, _, _ string) (bool, error) {
 	return true, nil
 }
 
-func (m *mockRepository) CheckCompatibility(_, _ string) (bool, error) {
+func (m *mockRepository) CheckCompatibility(_ context.Context, _, _ string) (bool, error) {
 	return true, nil
 }
 
-func (m *mockRepository) DeleteSchema(_ string) (bool, error) {
+func (m *mockRepository) DeleteSchema(_ context.Context, _ string, _ time.Time) (bool, error) {
 	return true, nil
 }
 
-func (m *mockRepository) DeleteSchemaVersion(_, _ string) (bool, error) {
+func (m *mockRepository) DeleteSchemaVersion(_ context.Context, _, _ string, _ time.Time) (bool, error) {
 	return true, nil
 }
 
-func (m *mockRepository) GetSchemas() ([]Schema, error) {
+func (m *mockRepository) GetSchemas(_ context.Context) ([]Schema, error) {
 	return []Schema{{
 		SchemaID:          "mocking",
 		SchemaType:        "mocking",
@@ -162,7 +163,7 @@ func (m *mockRepository) GetSchemas() ([]Schema, error) {
 	}}, nil
 }
 
-func (m *mockRepository) GetAllSchemas() ([]Schema, error) {
+func (m *mockRepository) GetAllSchemas(_ context.Context) ([]Schema, error) {
 	return []Schema{{
 		SchemaID:          "mocking",
 		SchemaType:        "mocking",
@@ -176,19 +177,19 @@ func (m *mockRepository) GetAllSchemas() ([]Schema, error) {
 	}}, nil
 }
 
-func (m *mockRepository) GetLatestSchemaVersion(_ string) (VersionDetails, error) {
+func (m *mockRepository) GetLatestSchemaVersion(_ context.Context, _ string) (VersionDetails, error) {
 	return MockVersionDetails("mocking", "mocking"), nil
 }
 
-func (m *mockRepository) CreateSchema(_ SchemaRegistrationRequest) (VersionDetails, bool, error) {
+func (m *mockRepository) CreateSchema(_ context.Context, _ SchemaRegistrationRequest) (VersionDetails, bool, error) {
 	return MockVersionDetails("mocking", "mocking"), true, nil
 }
 
-func (m *mockRepository) GetSchemaVersionByIdAndVersion(id string, version string) (VersionDetails, error) {
+func (m *mockRepository) GetSchemaVersionByIdAndVersion(_ context.Context, id string, version string) (VersionDetails, error) {
 	return MockVersionDetails(id, version), nil
 }
 
-func (m *mockRepository) UpdateSchemaById(id string, _ SchemaUpdateRequest) (VersionDetails, bool, error) {
+func (m *mockRepository) UpdateSchemaById(_ context.Context, id string, _ SchemaUpdateRequest) (VersionDetails, bool, error) {
 	return MockVersionDetails(id, "mocking"), true, nil
 }
 
@@ -199,12 +200,57 @@ func (m *mockRepository) SetGetSchemaVersionsByIdResponse(id string, schema Sche
 	}
 }
 
-func (m *mockRepository) GetSchemaVersionsById(id string) (Schema, error) {
+func (m *mockRepository) GetSchemaVersionsById(_ context.Context, id string) (Schema, error) {
 	response := m.getSchemaVersionsResponse[id]
 	return response.schema, response.err
 }
 
-func (m *mockRepository) GetAllSchemaVersions(id string) (Schema, error) {
+func (m *mockRepository) GetAllSchemaVersions(_ context.Context, id string) (Schema, error) {
 	response := m.getSchemaVersionsResponse[id]
 	return response.schema, response.err
 }
+
+func (m *mockRepository) GetSchemaMetadata(_ context.Context, id string) (Schema, error) {
+	response := m.getSchemaVersionsResponse[id]
+	return response.schema, response.err
+}
+
+func (m *mockRepository) GetAuditRecords(_ context.Context, _ AuditQuery) ([]AuditRecord, error) {
+	return nil, nil
+}
+
+func (m *mockRepository) RegisterUsage(_ context.Context, _ Usage) error {
+	return nil
+}
+
+func (m *mockRepository) GetUsage(_ context.Context, _ UsageQuery) ([]Usage, error) {
+	return nil, nil
+}
+
+func (m *mockRepository) SetAlias(_ context.Context, id, alias, version string) (Alias, error) {
+	return Alias{SchemaID: id, Alias: alias, Version: version}, nil
+}
+
+func (m *mockRepository) GetAliases(_ context.Context, _ string) ([]Alias, error) {
+	return nil, nil
+}
+
+func (m *mockRepository) DeleteAlias(_ context.Context, _, _ string) (bool, error) {
+	return false, nil
+}
+
+func (m *mockRepository) UpdateSchemaMetadata(_ context.Context, id string, _ SchemaMetadataRequest) (Schema, error) {
+	return Schema{SchemaID: id}, nil
+}
+
+func (m *mockRepository) RestoreSchemaVersion(_ context.Context, id, version string) (VersionDetails, bool, error) {
+	return VersionDetails{SchemaID: id, Version: version}, true, nil
+}
+
+func (m *mockRepository) GetSchemaVersionsByFingerprint(_ context.Context, _ FingerprintQuery) ([]SchemaMatch, error) {
+	return nil, nil
+}
+
+func (m *mockRepository) GetSchemaVersionEvents(_ context.Context, _ string) ([]VersionEvent, error) {
+	return nil, nil
+}
@@ -16,6 +16,8 @@ package registry
 
 import (
 	"time"
+
+	"github.com/dataphos/schema-registry/validity"
 )
 
 // Schema is a structure that defines the parent entity in the schema registry
@@ -34,15 +36,24 @@ type Schema struct {
 // VersionDetails represent the child entity in the schema registry model.
 // The schema (specification) and version with some other details is set here.
 type VersionDetails struct {
-	VersionID          string    `json:"version_id,omitempty"`
-	Version            string    `json:"version"`
-	SchemaID           string    `json:"schema_id"`
-	Specification      string    `json:"specification"`
+	VersionID string `json:"version_id,omitempty"`
+	Version   string `json:"version"`
+	SchemaID  string `json:"schema_id"`
+	// Specification holds the raw specification, which is only base64 encoded in JSON.
+	Specification      []byte    `json:"specification" swaggertype:"string" format:"base64"`
 	Description        string    `json:"description"`
 	SchemaHash         string    `json:"schema_hash"`
 	CreatedAt          time.Time `json:"created_at"`
 	VersionDeactivated bool      `json:"version_deactivated"`
 	Attributes         string    `json:"attributes"`
+	Rules              []Rule    `json:"rules,omitempty"`
+	// Semver is the semantic version of the version, derived from the change it made, empty unless semantic
+	// versioning is enabled.
+	Semver string `json:"semver,omitempty"`
+	// AvroFingerprint is the CRC-64-AVRO fingerprint of the version, empty unless it's a parsable Avro schema.
+	AvroFingerprint string `json:"avro_fingerprint,omitempty"`
+	// Violations holds the lint rule violations found while registering the version, it isn't stored.
+	Violations []validity.Violation `json:"violations,omitempty"`
 }
 
 // SchemaRegistrationRequest contains information needed to register a schema.
@@ -56,6 +67,11 @@ type SchemaRegistrationRequest struct {
 	CompatibilityMode string `json:"compatibility_mode"`
 	ValidityMode      string `json:"validity_mode"`
 	Attributes        string `json:"attributes"`
+	Rules             []Rule `json:"rules,omitempty"`
+	// Semver is set by the Service if semantic versioning is enabled, it can't be requested.
+	Semver string `json:"-"`
+	// AvroFingerprint is set by the Service for Avro schemas, it can't be requested.
+	AvroFingerprint string `json:"-"`
 }
 
 // SchemaUpdateRequest contains information needed to update a schema.
@@ -63,8 +79,39 @@ type SchemaUpdateRequest struct {
 	Description   string `json:"description"`
 	Specification string `json:"specification"`
 	Attributes    string `json:"attributes"`
+	Rules         []Rule `json:"rules,omitempty"`
+	// AllowBreakingChange allows a major change under semantic versioning, which is otherwise only allowed if the
+	// compatibility mode of the schema is none, even if the version isn't compatible under that mode.
+	AllowBreakingChange bool `json:"allow_breaking_change,omitempty"`
+	// Semver is set by the Service if semantic versioning is enabled, it can't be requested.
+	Semver string `json:"-"`
+	// AvroFingerprint is set by the Service for Avro schemas, it can't be requested.
+	AvroFingerprint string `json:"-"`
+}
+
+// SchemaMetadataRequest contains the metadata of a schema to change, without registering a new version.
+// The fields which are left out are left unchanged.
+type SchemaMetadataRequest struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+	PublisherID *string `json:"publisher_id,omitempty"`
+}
+
+// Rule is a data contract rule of a schema version. The validator evaluates its CEL expression against every message
+// which is valid under the schema version, with the decoded message bound to the message variable.
+type Rule struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+	// Severity is either RuleSeverityError, which dead-letters the messages failing the rule, or RuleSeverityWarning,
+	// which only marks them. RuleSeverityError is used if it is empty.
+	Severity string `json:"severity,omitempty"`
 }
 
+const (
+	RuleSeverityError   = "error"
+	RuleSeverityWarning = "warning"
+)
+
 // SchemaCompatibilityRequest contains information needed to check compatibility of schemas
 type SchemaCompatibilityRequest struct {
 	SchemaID  string `json:"schema_id"`
@@ -76,4 +123,172 @@ type SchemaValidityRequest struct {
 	NewSchema string `json:"new_schema"`
 	Format    string `json:"format"`
 	Mode      string `json:"mode"`
+	// SchemaID and PublisherID optionally select the lint rules and the previous versions the schema is linted against.
+	SchemaID    string `json:"schema_id,omitempty"`
+	PublisherID string `json:"publisher_id,omitempty"`
+}
+
+// LookupRequest contains the specification of a schema looked up among the registered schema versions.
+type LookupRequest struct {
+	SchemaType    string `json:"schema_type"`
+	Specification string `json:"specification"`
+}
+
+// FingerprintQuery selects the active schema versions with any of the given SHA-256 schema hashes or CRC-64-AVRO
+// fingerprints. An empty SchemaType doesn't filter.
+type FingerprintQuery struct {
+	SchemaType       string
+	SchemaHashes     []string
+	AvroFingerprints []string
+}
+
+// SchemaMatch is an active schema version matching a lookup.
+type SchemaMatch struct {
+	SchemaID        string `json:"schema_id"`
+	Name            string `json:"name"`
+	SchemaType      string `json:"schema_type"`
+	PublisherID     string `json:"publisher_id"`
+	Version         string `json:"version"`
+	Semver          string `json:"semver,omitempty"`
+	SchemaHash      string `json:"schema_hash"`
+	AvroFingerprint string `json:"avro_fingerprint,omitempty"`
+}
+
+// Specification is the specification of a schema version, along with the details needed to serve it as a file.
+type Specification struct {
+	SchemaID   string
+	Name       string
+	SchemaType string
+	Version    string
+	Content    []byte
+}
+
+// Examples contains randomly generated example payloads of a schema version.
+type Examples struct {
+	SchemaID   string   `json:"schema_id"`
+	Version    string   `json:"version"`
+	SchemaType string   `json:"schema_type"`
+	Seed       int64    `json:"seed"`
+	Valid      bool     `json:"valid"`
+	Payloads   [][]byte `json:"payloads"`
+}
+
+// CompatibilityMatrix is the pairwise compatibility of the active versions of a schema under a compatibility mode.
+// Compatible is true if the history of the schema satisfies the mode, that is, if all the required pairs are compatible.
+type CompatibilityMatrix struct {
+	SchemaID   string              `json:"schema_id"`
+	Mode       string              `json:"mode"`
+	Versions   []string            `json:"versions"`
+	Compatible bool                `json:"compatible"`
+	Pairs      []CompatibilityPair `json:"pairs"`
 }
+
+// CompatibilityPair is the compatibility of a newer version with an older one, in the direction of the mode.
+// Required is false for pairs the mode doesn't check, like non-consecutive versions in non-transitive modes.
+type CompatibilityPair struct {
+	Older      string `json:"older"`
+	Newer      string `json:"newer"`
+	Compatible bool   `json:"compatible"`
+	Required   bool   `json:"required"`
+}
+
+// Alias is a named, movable pointer to a version of a schema, accepted anywhere a version is.
+type Alias struct {
+	SchemaID  string    `json:"schema_id"`
+	Alias     string    `json:"alias"`
+	Version   string    `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AliasRequest contains information needed to point an alias at a schema version.
+type AliasRequest struct {
+	// Version is the version number, or another alias, the alias is pointed at.
+	Version string `json:"version"`
+}
+
+// Usage is a registration of a service which produces or consumes a schema version on a topic.
+// Services keep it alive with heartbeats, registering it again before the usage TTL passes since LastSeen.
+type Usage struct {
+	SchemaID string    `json:"schema_id"`
+	Version  string    `json:"version"`
+	Topic    string    `json:"topic"`
+	Role     string    `json:"role"`
+	ClientID string    `json:"client_id"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// The roles a service can use a schema version in.
+const (
+	UsageRoleProducer = "producer"
+	UsageRoleConsumer = "consumer"
+)
+
+// UsageRegistrationRequest contains information needed to register the usage of a schema version.
+type UsageRegistrationRequest struct {
+	Topic string `json:"topic"`
+	Role  string `json:"role"`
+	// ClientID identifies the service instance, the actor of the request is used if it is empty.
+	ClientID string `json:"client_id"`
+}
+
+// UsageQuery filters usages by schema id, version and the time they were last seen. Zero values don't filter.
+type UsageQuery struct {
+	SchemaID string
+	Version  string
+	Since    time.Time
+}
+
+// AuditRecord is an immutable record of a mutation of the registry.
+// BeforeHash and AfterHash are the hashes of the affected specification before and after the mutation,
+// empty if there was none. For alias mutations, they are the hashes of the versions the alias pointed at, and for
+// metadata changes, the hash of the latest version, which the change doesn't affect. Changes lists the metadata fields
+// a metadata change changed.
+//
+// Actor is the identity the server verified, ClaimedActor the one the client stated without proof.
+type AuditRecord struct {
+	ID           string           `json:"id"`
+	SchemaID     string           `json:"schema_id"`
+	Version      string           `json:"version,omitempty"`
+	Alias        string           `json:"alias,omitempty"`
+	Action       string           `json:"action"`
+	Actor        string           `json:"actor"`
+	ClaimedActor string           `json:"claimed_actor,omitempty"`
+	RequestID    string           `json:"request_id"`
+	BeforeHash   string           `json:"before_hash"`
+	AfterHash    string           `json:"after_hash"`
+	Changes      []MetadataChange `json:"changes,omitempty"`
+	Timestamp    time.Time        `json:"timestamp"`
+}
+
+// MetadataChange is the change of one metadata field of a schema, such as its name or publisher id.
+type MetadataChange struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// AuditQuery filters audit records by schema id and time. Zero values don't filter.
+//
+// At most Limit records are returned, DefaultAuditLimit if it's zero, starting after the record with the id After.
+type AuditQuery struct {
+	SchemaID string
+	Since    time.Time
+	After    string
+	Limit    int
+}
+
+// VersionEvent records that a schema version became active or was deactivated. Together, the events of a schema tell
+// which of its versions were active, and so which one was the latest, at any point in time.
+type VersionEvent struct {
+	SchemaID   string    `json:"schema_id"`
+	Version    string    `json:"version"`
+	Event      string    `json:"event"`
+	SchemaHash string    `json:"schema_hash"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// The events in the history of a schema version.
+const (
+	VersionEventActivated   = "activated"
+	VersionEventDeactivated = "deactivated"
+)
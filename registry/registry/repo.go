@@ -15,6 +15,9 @@
 package registry
 
 import (
+	"context"
+	"time"
+
 	"github.com/pkg/errors"
 )
 
@@ -25,21 +28,70 @@ var ErrUnknownFormat = errors.New("unknown value for schema format")
 var ErrNotValid = errors.New("schema is not valid")
 var ErrNotComp = errors.New("schemas are not compatible")
 var ErrInvalidValueHeader = errors.New("invalid header value")
+var ErrInvalidRules = errors.New("invalid data contract rules")
+var ErrInvalidUsage = errors.New("invalid usage registration")
+var ErrInUse = errors.New("schema version is in use")
+var ErrInvalidAlias = errors.New("invalid alias")
+var ErrBreakingChange = errors.New("breaking change")
+var ErrNotCanonicalizable = errors.New("schema can't be canonicalized")
+var ErrInvalidFingerprint = errors.New("invalid fingerprint")
+var ErrInvalidMetadata = errors.New("invalid schema metadata")
+var ErrAdmissionDenied = errors.New("denied by admission webhook")
+var ErrAdmissionUnavailable = errors.New("admission webhook unavailable")
 
 type Repository interface {
-	CreateSchema(schemaRegisterRequest SchemaRegistrationRequest) (VersionDetails, bool, error)
-	GetSchemaVersionByIdAndVersion(id string, version string) (VersionDetails, error)
-	UpdateSchemaById(id string, schemaUpdateRequest SchemaUpdateRequest) (VersionDetails, bool, error)
-	GetSchemaVersionsById(id string) (Schema, error)
-	GetAllSchemaVersions(id string) (Schema, error)
-	GetLatestSchemaVersion(id string) (VersionDetails, error)
-	DeleteSchema(id string) (bool, error)
-	DeleteSchemaVersion(id, version string) (bool, error)
-	GetAllSchemas() ([]Schema, error)
-	GetSchemas() ([]Schema, error)
+	CreateSchema(ctx context.Context, schemaRegisterRequest SchemaRegistrationRequest) (VersionDetails, bool, error)
+	GetSchemaVersionByIdAndVersion(ctx context.Context, id string, version string) (VersionDetails, error)
+	UpdateSchemaById(ctx context.Context, id string, schemaUpdateRequest SchemaUpdateRequest) (VersionDetails, bool, error)
+	// UpdateSchemaMetadata changes the metadata of the schema, returning the schema without its versions.
+	UpdateSchemaMetadata(ctx context.Context, id string, request SchemaMetadataRequest) (Schema, error)
+	GetSchemaVersionsById(ctx context.Context, id string) (Schema, error)
+	GetAllSchemaVersions(ctx context.Context, id string) (Schema, error)
+	// GetSchemaMetadata returns the schema with all of its versions, active and deactivated, without their
+	// specifications, for the operations which don't need them.
+	GetSchemaMetadata(ctx context.Context, id string) (Schema, error)
+	GetLatestSchemaVersion(ctx context.Context, id string) (VersionDetails, error)
+	// DeleteSchema and DeleteSchemaVersion deactivate the active versions of the schema, or the given version. Unless
+	// usedSince is zero, they fail with an InUseError instead if any of them has a usage seen since then, which is
+	// checked within the deactivation itself.
+	DeleteSchema(ctx context.Context, id string, usedSince time.Time) (bool, error)
+	DeleteSchemaVersion(ctx context.Context, id, version string, usedSince time.Time) (bool, error)
+	// RestoreSchemaVersion reactivates the deactivated version of the schema under its original version number,
+	// returning false if the version is already active.
+	RestoreSchemaVersion(ctx context.Context, id, version string) (VersionDetails, bool, error)
+	GetAllSchemas(ctx context.Context) ([]Schema, error)
+	GetSchemas(ctx context.Context) ([]Schema, error)
+	GetAuditRecords(ctx context.Context, query AuditQuery) ([]AuditRecord, error)
+	RegisterUsage(ctx context.Context, usage Usage) error
+	GetUsage(ctx context.Context, query UsageQuery) ([]Usage, error)
+	SetAlias(ctx context.Context, id, alias, version string) (Alias, error)
+	GetAliases(ctx context.Context, id string) ([]Alias, error)
+	DeleteAlias(ctx context.Context, id, alias string) (bool, error)
+	// GetSchemaVersionsByFingerprint returns the active schema versions matching the query, across all schemas.
+	GetSchemaVersionsByFingerprint(ctx context.Context, query FingerprintQuery) ([]SchemaMatch, error)
+	// GetSchemaVersionEvents returns the activations and deactivations of the versions of the schema, oldest first.
+	GetSchemaVersionEvents(ctx context.Context, id string) ([]VersionEvent, error)
+}
+
+// Notifier broadcasts cache invalidations between registry replicas which share the same database.
+type Notifier interface {
+	// Notify tells every replica, including this one, that the cached entries of the given schema are stale.
+	Notify(ctx context.Context, schemaID string) error
+	// Listen calls invalidate with the schema id of every notification, until the context is cancelled or the
+	// connection is lost, in which case an error is returned.
+	Listen(ctx context.Context, invalidate func(schemaID string)) error
+}
+
+// CacheSettings defines the size of the in-memory cache, how long its entries live and how its invalidations are
+// broadcast to other replicas. If Notifier is nil, the invalidations aren't broadcast.
+type CacheSettings struct {
+	Size     int
+	TTL      time.Duration
+	Notifier Notifier
 }
 
-// WithCache decorates the given Repository with an in-memory cache of the given size.
-func WithCache(repository Repository, size int) (Repository, error) {
-	return newCache(repository, size)
+// WithCache decorates the given Repository with an in-memory cache with the given settings. The invalidations broadcast
+// by other replicas are applied until the context is cancelled.
+func WithCache(ctx context.Context, repository Repository, settings CacheSettings) (Repository, error) {
+	return newCache(ctx, repository, settings)
 }
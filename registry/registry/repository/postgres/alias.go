@@ -0,0 +1,181 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/dataphos/schema-registry/registry"
+)
+
+// SchemaAlias is a named, movable pointer to a version of a schema.
+type SchemaAlias struct {
+	SchemaID  uint      `gorm:"primaryKey;column:schema_id"`
+	Alias     string    `gorm:"primaryKey;column:alias;type:varchar(64)"`
+	Version   string    `gorm:"column:version;type:varchar(8)"`
+	UpdatedAt time.Time `gorm:"column:updated_at"`
+}
+
+// intoRegistryAlias maps SchemaAlias from repository to service layer.
+func intoRegistryAlias(alias SchemaAlias) registry.Alias {
+	return registry.Alias{
+		SchemaID:  strconv.Itoa(int(alias.SchemaID)),
+		Alias:     alias.Alias,
+		Version:   alias.Version,
+		UpdatedAt: alias.UpdatedAt,
+	}
+}
+
+// SetAlias points the alias at the given active version of the schema, creating the alias if it doesn't exist.
+// Returns registry.ErrNotFound in case there's no active schema version under the given id and version.
+func (r *Repository) SetAlias(ctx context.Context, id, alias, version string) (registry.Alias, error) {
+	if _, err := strconv.Atoi(id); err != nil {
+		return registry.Alias{}, registry.ErrInvalidValueHeader
+	}
+	if _, err := strconv.Atoi(version); err != nil {
+		return registry.Alias{}, registry.ErrInvalidValueHeader
+	}
+
+	var details VersionDetails
+	if err := r.primary(ctx).Where("schema_id = ? and version = ? and version_deactivated = ?", id, version, false).Take(&details).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return registry.Alias{}, registry.ErrNotFound
+		}
+		return registry.Alias{}, err
+	}
+
+	schemaAlias := SchemaAlias{
+		SchemaID:  details.SchemaID,
+		Alias:     alias,
+		Version:   details.Version,
+		UpdatedAt: time.Now().UTC(),
+	}
+	var moved bool
+	var record registry.AuditRecord
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var previous SchemaAlias
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("schema_id = ? and alias = ?", details.SchemaID, alias).Take(&previous).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil && previous.Version == details.Version {
+			// the alias already points at the version, there's nothing to record
+			schemaAlias = previous
+			return nil
+		}
+
+		var beforeHash string
+		if err == nil {
+			if beforeHash, err = versionHash(tx, details.SchemaID, previous.Version); err != nil {
+				return err
+			}
+		}
+		if err = tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "schema_id"}, {Name: "alias"}},
+			DoUpdates: clause.AssignmentColumns([]string{"version", "updated_at"}),
+		}).Create(&schemaAlias).Error; err != nil {
+			return err
+		}
+
+		moved = true
+		record, err = auditAlias(ctx, tx, registry.AuditSetAlias, details.SchemaID, alias, details.Version, beforeHash, details.SchemaHash)
+		return err
+	})
+	if err != nil {
+		return registry.Alias{}, err
+	}
+	if moved {
+		r.publish(record)
+	}
+	return intoRegistryAlias(schemaAlias), nil
+}
+
+// GetAliases returns the aliases of the schema, ordered by name.
+func (r *Repository) GetAliases(ctx context.Context, id string) ([]registry.Alias, error) {
+	if _, err := strconv.Atoi(id); err != nil {
+		return nil, registry.ErrInvalidValueHeader
+	}
+
+	var aliases []SchemaAlias
+	if err := r.reader(ctx, id).Where("schema_id = ?", id).Order("alias").Find(&aliases).Error; err != nil {
+		return nil, err
+	}
+
+	registryAliases := make([]registry.Alias, len(aliases))
+	for i, alias := range aliases {
+		registryAliases[i] = intoRegistryAlias(alias)
+	}
+	return registryAliases, nil
+}
+
+// DeleteAlias deletes the alias of the schema.
+// Returns a boolean flag indicating if the alias existed before this call.
+func (r *Repository) DeleteAlias(ctx context.Context, id, alias string) (bool, error) {
+	if _, err := strconv.Atoi(id); err != nil {
+		return false, registry.ErrInvalidValueHeader
+	}
+
+	var deleted bool
+	var record registry.AuditRecord
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var previous SchemaAlias
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("schema_id = ? and alias = ?", id, alias).Take(&previous).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+		beforeHash, err := versionHash(tx, previous.SchemaID, previous.Version)
+		if err != nil {
+			return err
+		}
+
+		result := tx.Where("schema_id = ? and alias = ?", previous.SchemaID, previous.Alias).Delete(&SchemaAlias{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if deleted = result.RowsAffected > 0; !deleted {
+			return nil
+		}
+
+		record, err = auditAlias(ctx, tx, registry.AuditDeleteAlias, previous.SchemaID, alias, "", beforeHash, "")
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	if deleted {
+		r.publish(record)
+	}
+	return deleted, nil
+}
+
+// versionHash returns the hash of the given version of the schema, active or not, or an empty string if it doesn't exist.
+func versionHash(tx *gorm.DB, schemaID uint, version string) (string, error) {
+	var details VersionDetails
+	if err := tx.Where("schema_id = ? and version = ?", schemaID, version).Take(&details).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	return details.SchemaHash, nil
+}
@@ -0,0 +1,186 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/dataphos/schema-registry/registry"
+)
+
+// AuditRecord is an immutable record of a mutation of the schema registry. Changes holds the metadata changes encoded
+// as JSON, or an empty string if there are none.
+type AuditRecord struct {
+	AuditID      uint      `gorm:"primaryKey;column:audit_id;autoIncrement"`
+	SchemaID     uint      `gorm:"column:schema_id;index:audit_schema_idx"`
+	Version      string    `gorm:"column:version;type:varchar(8)"`
+	Alias        string    `gorm:"column:alias;type:varchar(64)"`
+	Action       string    `gorm:"column:action;type:varchar(32)"`
+	Actor        string    `gorm:"column:actor;type:varchar(256)"`
+	ClaimedActor string    `gorm:"column:claimed_actor;type:varchar(256)"`
+	RequestID    string    `gorm:"column:request_id;type:varchar(256)"`
+	BeforeHash   string    `gorm:"column:before_hash;type:varchar(256)"`
+	AfterHash    string    `gorm:"column:after_hash;type:varchar(256)"`
+	Changes      string    `gorm:"column:changes;type:text"`
+	CreatedAt    time.Time `gorm:"column:created_at;index:audit_created_idx"`
+}
+
+// intoRegistryAuditRecord maps AuditRecord from repository to service layer.
+func intoRegistryAuditRecord(record AuditRecord) registry.AuditRecord {
+	return registry.AuditRecord{
+		ID:           strconv.Itoa(int(record.AuditID)),
+		SchemaID:     strconv.Itoa(int(record.SchemaID)),
+		Version:      record.Version,
+		Alias:        record.Alias,
+		Action:       record.Action,
+		Actor:        record.Actor,
+		ClaimedActor: record.ClaimedActor,
+		RequestID:    record.RequestID,
+		BeforeHash:   record.BeforeHash,
+		AfterHash:    record.AfterHash,
+		Changes:      decodeChanges(record.Changes),
+		Timestamp:    record.CreatedAt,
+	}
+}
+
+// encodeChanges encodes the metadata changes as JSON, returning an empty string if there are none.
+func encodeChanges(changes []registry.MetadataChange) (string, error) {
+	if len(changes) == 0 {
+		return "", nil
+	}
+	encoded, err := json.Marshal(changes)
+	if err != nil {
+		return "", errors.Wrap(err, "couldn't encode metadata changes")
+	}
+	return string(encoded), nil
+}
+
+// decodeChanges decodes the metadata changes written by encodeChanges.
+func decodeChanges(encoded string) []registry.MetadataChange {
+	if encoded == "" {
+		return nil
+	}
+	var changes []registry.MetadataChange
+	if err := json.Unmarshal([]byte(encoded), &changes); err != nil {
+		return nil
+	}
+	return changes
+}
+
+// audit inserts the AuditRecord of the given mutation within the transaction of the mutation itself.
+func audit(ctx context.Context, tx *gorm.DB, action string, schemaID uint, version, beforeHash, afterHash string) (registry.AuditRecord, error) {
+	return insertAuditRecord(ctx, tx, AuditRecord{
+		SchemaID:   schemaID,
+		Version:    version,
+		Action:     action,
+		BeforeHash: beforeHash,
+		AfterHash:  afterHash,
+	})
+}
+
+// auditMetadata inserts the AuditRecord of the given metadata changes within the transaction of the changes themselves.
+// The hash is the one of the latest version of the schema.
+func auditMetadata(ctx context.Context, tx *gorm.DB, schemaID uint, hash string, changes []registry.MetadataChange) (registry.AuditRecord, error) {
+	encoded, err := encodeChanges(changes)
+	if err != nil {
+		return registry.AuditRecord{}, err
+	}
+	return insertAuditRecord(ctx, tx, AuditRecord{
+		SchemaID:   schemaID,
+		Action:     registry.AuditUpdateSchemaMetadata,
+		BeforeHash: hash,
+		AfterHash:  hash,
+		Changes:    encoded,
+	})
+}
+
+// auditAlias inserts the AuditRecord of the given alias mutation within the transaction of the mutation itself.
+// The version is the one the alias points at after the mutation, empty if it was deleted.
+func auditAlias(ctx context.Context, tx *gorm.DB, action string, schemaID uint, alias, version, beforeHash, afterHash string) (registry.AuditRecord, error) {
+	return insertAuditRecord(ctx, tx, AuditRecord{
+		SchemaID:   schemaID,
+		Version:    version,
+		Alias:      alias,
+		Action:     action,
+		BeforeHash: beforeHash,
+		AfterHash:  afterHash,
+	})
+}
+
+// insertAuditRecord sets the actor, request id and time of the record and inserts it.
+func insertAuditRecord(ctx context.Context, tx *gorm.DB, record AuditRecord) (registry.AuditRecord, error) {
+	info := registry.AuditInfoFromContext(ctx)
+	record.Actor = info.Actor
+	record.ClaimedActor = info.ClaimedActor
+	record.RequestID = info.RequestID
+	record.CreatedAt = time.Now().UTC()
+	if err := tx.Create(&record).Error; err != nil {
+		return registry.AuditRecord{}, err
+	}
+	return intoRegistryAuditRecord(record), nil
+}
+
+// publish writes the committed AuditRecord to the audit sink, if there is one, and routes the reads of the mutated
+// schema to the primary for readAfterWriteWindow.
+//
+// The mutation is already committed at this point, so failing to write to the sink is only logged.
+func (r *Repository) publish(record registry.AuditRecord) {
+	r.writes.mark(record.SchemaID)
+	if r.sink == nil {
+		return
+	}
+	if err := r.sink.Write(record); err != nil {
+		log.Println("Couldn't write to the audit sink:", err)
+	}
+}
+
+// GetAuditRecords returns at most query.Limit audit records matching the query, oldest first, after the record with
+// the id query.After.
+func (r *Repository) GetAuditRecords(ctx context.Context, query registry.AuditQuery) ([]registry.AuditRecord, error) {
+	tx := r.reader(ctx, query.SchemaID)
+	if query.SchemaID != "" {
+		if _, err := strconv.Atoi(query.SchemaID); err != nil {
+			return nil, registry.ErrInvalidValueHeader
+		}
+		tx = tx.Where("schema_id = ?", query.SchemaID)
+	}
+	if !query.Since.IsZero() {
+		tx = tx.Where("created_at >= ?", query.Since)
+	}
+	if query.After != "" {
+		if _, err := strconv.Atoi(query.After); err != nil {
+			return nil, registry.ErrInvalidValueHeader
+		}
+		tx = tx.Where("audit_id > ?", query.After)
+	}
+
+	var records []AuditRecord
+	if err := tx.Order("audit_id").Limit(query.Limit).Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	registryRecords := make([]registry.AuditRecord, len(records))
+	for i, record := range records {
+		registryRecords[i] = intoRegistryAuditRecord(record)
+	}
+	return registryRecords, nil
+}
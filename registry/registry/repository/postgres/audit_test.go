@@ -0,0 +1,97 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/dataphos/schema-registry/registry"
+)
+
+// recordingSink keeps the audit records written to it.
+type recordingSink struct {
+	records []registry.AuditRecord
+}
+
+func (s *recordingSink) Write(record registry.AuditRecord) error {
+	s.records = append(s.records, record)
+	return nil
+}
+
+func TestUpdateSchemaMetadataAudit(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := &recordingSink{}
+	repo := New(db, WithAuditSink(sink))
+
+	changes := `[{"field":"name","before":"person","after":"human"},{"field":"publisher_id","before":"a","after":"b"}]`
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "schemas" .* FOR UPDATE`).
+		WillReturnRows(sqlmock.NewRows([]string{"schema_id", "name", "description", "publisher_id"}).AddRow(1, "person", "people", "a"))
+	mock.ExpectExec(`UPDATE "schemas"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT "schema_hash" FROM "version_details" WHERE schema_id = \$1 and version_deactivated = \$2 ORDER BY version_id desc`).
+		WithArgs(1, false).
+		WillReturnRows(sqlmock.NewRows([]string{"schema_hash"}).AddRow("latest-hash"))
+	mock.ExpectQuery(`INSERT INTO "audit_records"`).
+		WithArgs(1, "", "", registry.AuditUpdateSchemaMetadata, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "latest-hash", "latest-hash", changes, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"audit_id"}).AddRow(7))
+	mock.ExpectCommit()
+
+	name, description, publisherID := "human", "people", "b"
+	schema, err := repo.UpdateSchemaMetadata(context.Background(), "1", registry.SchemaMetadataRequest{
+		Name:        &name,
+		Description: &description,
+		PublisherID: &publisherID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema.Name != name || schema.PublisherID != publisherID {
+		t.Errorf("expected the updated metadata, got %+v", schema)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sink.records) != 1 {
+		t.Fatalf("expected one audit record, got %d", len(sink.records))
+	}
+	record := sink.records[0]
+	expected := []registry.MetadataChange{
+		{Field: "name", Before: "person", After: "human"},
+		{Field: "publisher_id", Before: "a", After: "b"},
+	}
+	if !reflect.DeepEqual(record.Changes, expected) {
+		t.Errorf("expected the changes %+v, got %+v", expected, record.Changes)
+	}
+	if record.BeforeHash != "latest-hash" || record.AfterHash != "latest-hash" {
+		t.Errorf("expected the hashes of the latest version, got %q and %q", record.BeforeHash, record.AfterHash)
+	}
+}
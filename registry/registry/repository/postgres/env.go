@@ -16,6 +16,11 @@ package postgres
 
 import (
 	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
 
 	"github.com/dataphos/schema-registry/internal/errtemplates"
 )
@@ -26,14 +31,35 @@ type DatabaseConfig struct {
 	User         string
 	Password     string
 	DatabaseName string
+
+	// ReplicaHosts are the hosts of the read replicas, which share the credentials and the database name of the
+	// primary. Reads are routed to them if there are any.
+	ReplicaHosts []string
+	Pool         PoolConfig
+}
+
+// PoolConfig configures the connection pools of the primary and of every replica.
+// The zero value of a field keeps the database/sql default.
+type PoolConfig struct {
+	MaxOpenConns     int
+	MaxIdleConns     int
+	ConnMaxLifetime  time.Duration
+	ConnMaxIdleTime  time.Duration
+	StatementTimeout time.Duration
 }
 
 const (
-	tablePrefixEnvKey  = "SR_TABLE_PREFIX"
-	hostEnvKey         = "SR_HOST"
-	userEnvKey         = "SR_USER"
-	passwordEnvKey     = "SR_PASSWORD"
-	databaseNameEnvKey = "SR_DBNAME"
+	tablePrefixEnvKey      = "SR_TABLE_PREFIX"
+	hostEnvKey             = "SR_HOST"
+	userEnvKey             = "SR_USER"
+	passwordEnvKey         = "SR_PASSWORD"
+	databaseNameEnvKey     = "SR_DBNAME"
+	replicaHostsEnvKey     = "SR_REPLICA_HOSTS"
+	maxOpenConnsEnvKey     = "SR_MAX_OPEN_CONNS"
+	maxIdleConnsEnvKey     = "SR_MAX_IDLE_CONNS"
+	connMaxLifetimeEnvKey  = "SR_CONN_MAX_LIFETIME"
+	connMaxIdleTimeEnvKey  = "SR_CONN_MAX_IDLE_TIME"
+	statementTimeoutEnvKey = "SR_STATEMENT_TIMEOUT"
 )
 
 func LoadDatabaseConfigFromEnv() (DatabaseConfig, error) {
@@ -62,11 +88,75 @@ func LoadDatabaseConfigFromEnv() (DatabaseConfig, error) {
 		return DatabaseConfig{}, errtemplates.EnvVariableNotDefined(databaseNameEnvKey)
 	}
 
+	var replicaHosts []string
+	for _, replicaHost := range strings.Split(os.Getenv(replicaHostsEnvKey), ",") {
+		if replicaHost = strings.TrimSpace(replicaHost); replicaHost != "" {
+			replicaHosts = append(replicaHosts, replicaHost)
+		}
+	}
+
+	pool, err := loadPoolConfigFromEnv()
+	if err != nil {
+		return DatabaseConfig{}, err
+	}
+
 	return DatabaseConfig{
 		TablePrefix:  tablePrefix,
 		Host:         host,
 		User:         user,
 		Password:     password,
 		DatabaseName: dbName,
+		ReplicaHosts: replicaHosts,
+		Pool:         pool,
 	}, nil
 }
+
+func loadPoolConfigFromEnv() (PoolConfig, error) {
+	var pool PoolConfig
+	var err error
+	if pool.MaxOpenConns, err = intFromEnv(maxOpenConnsEnvKey); err != nil {
+		return PoolConfig{}, err
+	}
+	if pool.MaxIdleConns, err = intFromEnv(maxIdleConnsEnvKey); err != nil {
+		return PoolConfig{}, err
+	}
+	if pool.ConnMaxLifetime, err = durationFromEnv(connMaxLifetimeEnvKey); err != nil {
+		return PoolConfig{}, err
+	}
+	if pool.ConnMaxIdleTime, err = durationFromEnv(connMaxIdleTimeEnvKey); err != nil {
+		return PoolConfig{}, err
+	}
+	if pool.StatementTimeout, err = durationFromEnv(statementTimeoutEnvKey); err != nil {
+		return PoolConfig{}, err
+	}
+	return pool, nil
+}
+
+// intFromEnv reads a non-negative int from the given environment variable, or returns 0 if it isn't set.
+func intFromEnv(key string) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, errtemplates.ExpectedInt(key, value)
+	}
+	return n, nil
+}
+
+// durationFromEnv reads a non-negative duration from the given environment variable, or returns 0 if it isn't set.
+func durationFromEnv(key string) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, errors.Wrap(err, errtemplates.ParsingEnvVariableFailed(key))
+	}
+	if d < 0 {
+		return 0, errors.Errorf("%s: duration must not be negative", errtemplates.ParsingEnvVariableFailed(key))
+	}
+	return d, nil
+}
@@ -0,0 +1,152 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/dataphos/schema-registry/registry"
+)
+
+// backfillBatchSize is the number of schema versions fingerprinted, rehashed or compressed in a single batch by
+// BackfillAvroFingerprints, BackfillSchemaHashes and CompressSpecifications.
+const backfillBatchSize = 100
+
+// schemaMatch is a row of the lookup of schema versions by fingerprint.
+type schemaMatch struct {
+	SchemaID        uint   `gorm:"column:schema_id"`
+	Name            string `gorm:"column:name"`
+	SchemaType      string `gorm:"column:schema_type"`
+	PublisherID     string `gorm:"column:publisher_id"`
+	Version         string `gorm:"column:version"`
+	Semver          string `gorm:"column:semver"`
+	SchemaHash      string `gorm:"column:schema_hash"`
+	AvroFingerprint string `gorm:"column:avro_fingerprint"`
+}
+
+// GetSchemaVersionsByFingerprint returns the active schema versions with any of the schema hashes or Avro fingerprints
+// of the query, ordered by schema id and version.
+func (r *Repository) GetSchemaVersionsByFingerprint(ctx context.Context, query registry.FingerprintQuery) ([]registry.SchemaMatch, error) {
+	if len(query.SchemaHashes) == 0 && len(query.AvroFingerprints) == 0 {
+		return nil, nil
+	}
+
+	fingerprints := r.db.Where("syntio_schema.version_details.schema_hash in ?", nonEmpty(query.SchemaHashes))
+	if len(query.AvroFingerprints) > 0 {
+		fingerprints = fingerprints.Or("syntio_schema.version_details.avro_fingerprint in ?", query.AvroFingerprints)
+	}
+	tx := r.reader(ctx, "").Table("syntio_schema.version_details").
+		Select("syntio_schema.schema.schema_id, syntio_schema.schema.name, syntio_schema.schema.schema_type, syntio_schema.schema.publisher_id, "+
+			"syntio_schema.version_details.version, syntio_schema.version_details.semver, syntio_schema.version_details.schema_hash, syntio_schema.version_details.avro_fingerprint").
+		Joins("JOIN syntio_schema.schema ON syntio_schema.schema.schema_id = syntio_schema.version_details.schema_id").
+		Where("syntio_schema.version_details.version_deactivated = ?", false).
+		Where(fingerprints)
+	if query.SchemaType != "" {
+		tx = tx.Where("syntio_schema.schema.schema_type = ?", query.SchemaType)
+	}
+
+	var rows []schemaMatch
+	if err := tx.Order("syntio_schema.schema.schema_id, syntio_schema.version_details.version").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	matches := make([]registry.SchemaMatch, len(rows))
+	for i, row := range rows {
+		matches[i] = registry.SchemaMatch{
+			SchemaID:        strconv.Itoa(int(row.SchemaID)),
+			Name:            row.Name,
+			SchemaType:      row.SchemaType,
+			PublisherID:     row.PublisherID,
+			Version:         row.Version,
+			Semver:          row.Semver,
+			SchemaHash:      row.SchemaHash,
+			AvroFingerprint: row.AvroFingerprint,
+		}
+	}
+	return matches, nil
+}
+
+// nonEmpty returns the values, or a single empty string if there are none, since an empty list can't be used in SQL.
+func nonEmpty(values []string) []string {
+	if len(values) == 0 {
+		return []string{""}
+	}
+	return values
+}
+
+// BackfillAvroFingerprints fills in the Avro fingerprints of the Avro schema versions registered before the
+// fingerprints were stored, returning the number of versions fingerprinted. Versions with specifications which can't
+// be parsed, which is possible under the none validity mode, are left without a fingerprint.
+func BackfillAvroFingerprints(db *gorm.DB) (int, error) {
+	var count int
+	var batch []VersionDetails
+	err := db.Table("syntio_schema.version_details").
+		Select("syntio_schema.version_details.version_id, syntio_schema.version_details.specification").
+		Joins("JOIN syntio_schema.schema ON syntio_schema.schema.schema_id = syntio_schema.version_details.schema_id").
+		Where("syntio_schema.schema.schema_type = ? and syntio_schema.version_details.avro_fingerprint = ?", "avro", "").
+		FindInBatches(&batch, backfillBatchSize, func(tx *gorm.DB, _ int) error {
+			for _, details := range batch {
+				fingerprint, err := registry.AvroFingerprint(details.Specification)
+				if err != nil {
+					continue
+				}
+				if err := db.Model(&VersionDetails{VersionID: details.VersionID}).Update("avro_fingerprint", fingerprint).Error; err != nil {
+					return errors.Wrapf(err, "couldn't store the avro fingerprint of version %d", details.VersionID)
+				}
+				count++
+			}
+			return nil
+		}).Error
+	return count, err
+}
+
+// hashedSpecification is a row of the schema versions rehashed by BackfillSchemaHashes.
+type hashedSpecification struct {
+	VersionID     uint                    `gorm:"primaryKey;column:version_id"`
+	SchemaType    string                  `gorm:"column:schema_type"`
+	Specification compressedSpecification `gorm:"column:specification"`
+	SchemaHash    string                  `gorm:"column:schema_hash"`
+}
+
+// BackfillSchemaHashes replaces the schema hashes of the Protobuf and XML schema versions registered before their
+// canonical forms were introduced with the hashes of their canonical forms, so submitting them again is recognized as a
+// duplicate, returning the number of versions rehashed. The specifications are kept as they were registered. Versions
+// of schemas under the none validity mode, which aren't canonicalized on registration, and versions with specifications
+// which can't be parsed are left as they are.
+func BackfillSchemaHashes(db *gorm.DB) (int, error) {
+	var count int
+	var batch []hashedSpecification
+	err := db.Table("syntio_schema.version_details").
+		Select("syntio_schema.version_details.version_id, syntio_schema.schema.schema_type, syntio_schema.version_details.specification, syntio_schema.version_details.schema_hash").
+		Joins("JOIN syntio_schema.schema ON syntio_schema.schema.schema_id = syntio_schema.version_details.schema_id").
+		Where("syntio_schema.schema.schema_type in ? and lower(syntio_schema.schema.validity_mode) <> ?", []string{"protobuf", "xml"}, "none").
+		FindInBatches(&batch, backfillBatchSize, func(tx *gorm.DB, _ int) error {
+			for _, row := range batch {
+				hash, err := registry.CanonicalSchemaHash(row.Specification, row.SchemaType)
+				if err != nil || hash == row.SchemaHash {
+					continue
+				}
+				if err := db.Model(&VersionDetails{VersionID: row.VersionID}).Update("schema_hash", hash).Error; err != nil {
+					return errors.Wrapf(err, "couldn't store the schema hash of version %d", row.VersionID)
+				}
+				count++
+			}
+			return nil
+		}).Error
+	return count, err
+}
@@ -15,10 +15,13 @@
 package postgres
 
 import (
+	"database/sql"
 	"fmt"
+
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/schema"
+	"gorm.io/plugin/dbresolver"
 )
 
 func InitializeGormFromEnv() (*gorm.DB, error) {
@@ -30,12 +33,10 @@ func InitializeGormFromEnv() (*gorm.DB, error) {
 	return InitializeGorm(config)
 }
 
+// InitializeGorm connects to the primary and, if there are any, to the read replicas of the database.
+// Reads are routed to the replicas, while writes and transactions go to the primary.
 func InitializeGorm(config DatabaseConfig) (*gorm.DB, error) {
-	connectionString := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=5432 sslmode=disable",
-		config.Host, config.User, config.Password, config.DatabaseName,
-	)
-	dialector := postgres.Open(connectionString)
+	dialector := postgres.Open(config.connectionString(config.Host))
 	gcfg := &gorm.Config{
 		NamingStrategy: schema.NamingStrategy{
 			TablePrefix:   config.TablePrefix,
@@ -47,6 +48,64 @@ func InitializeGorm(config DatabaseConfig) (*gorm.DB, error) {
 	if err != nil {
 		return nil, err
 	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	config.Pool.apply(sqlDB)
+
+	if len(config.ReplicaHosts) > 0 {
+		replicas := make([]gorm.Dialector, len(config.ReplicaHosts))
+		for i, host := range config.ReplicaHosts {
+			replicas[i] = postgres.Open(config.connectionString(host))
+		}
+		resolver := dbresolver.Register(dbresolver.Config{
+			Replicas: replicas,
+			Policy:   dbresolver.RandomPolicy{},
+		})
+		// the callback runs for the primary too once the resolver is compiled, which is harmless
+		_ = resolver.Call(func(connPool gorm.ConnPool) error {
+			if replicaDB, ok := connPool.(*sql.DB); ok {
+				config.Pool.apply(replicaDB)
+			}
+			return nil
+		})
+		if err = db.Use(resolver); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = db.Use(tracingPlugin{}); err != nil {
+		return nil, err
+	}
 
 	return db, nil
 }
+
+func (config DatabaseConfig) connectionString(host string) string {
+	connectionString := fmt.Sprintf(
+		"host=%s user=%s password=%s dbname=%s port=5432 sslmode=disable",
+		host, config.User, config.Password, config.DatabaseName,
+	)
+	if config.Pool.StatementTimeout > 0 {
+		// unknown keys of the connection string are sent to the server as run-time parameters
+		connectionString += fmt.Sprintf(" statement_timeout=%d", config.Pool.StatementTimeout.Milliseconds())
+	}
+	return connectionString
+}
+
+// apply configures the connection pool, keeping the database/sql defaults of the unset fields.
+func (pool PoolConfig) apply(sqlDB *sql.DB) {
+	if pool.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(pool.MaxOpenConns)
+	}
+	if pool.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(pool.MaxIdleConns)
+	}
+	if pool.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	}
+	if pool.ConnMaxIdleTime > 0 {
+		sqlDB.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+	}
+}
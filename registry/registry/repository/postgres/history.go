@@ -0,0 +1,88 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/dataphos/schema-registry/registry"
+)
+
+// VersionEvent records that a schema version became active or was deactivated.
+//
+// version_deactivated only tells whether a version is active now, so the events are what the state of a schema at an
+// earlier point in time is reconstructed from.
+type VersionEvent struct {
+	EventID    uint      `gorm:"primaryKey;column:event_id;autoIncrement"`
+	SchemaID   uint      `gorm:"column:schema_id;index:version_event_schema_idx,priority:1"`
+	Version    string    `gorm:"column:version;type:varchar(8)"`
+	Event      string    `gorm:"column:event;type:varchar(16)"`
+	SchemaHash string    `gorm:"column:schema_hash;type:varchar(256)"`
+	CreatedAt  time.Time `gorm:"column:created_at;index:version_event_schema_idx,priority:2"`
+}
+
+// intoRegistryVersionEvent maps VersionEvent from repository to service layer.
+func intoRegistryVersionEvent(event VersionEvent) registry.VersionEvent {
+	return registry.VersionEvent{
+		SchemaID:   strconv.Itoa(int(event.SchemaID)),
+		Version:    event.Version,
+		Event:      event.Event,
+		SchemaHash: event.SchemaHash,
+		Timestamp:  event.CreatedAt,
+	}
+}
+
+// recordVersionEvent inserts the VersionEvent of the given version within the transaction which activates or
+// deactivates it.
+func recordVersionEvent(tx *gorm.DB, event string, schemaID uint, version, schemaHash string) error {
+	return tx.Create(&VersionEvent{
+		SchemaID:   schemaID,
+		Version:    version,
+		Event:      event,
+		SchemaHash: schemaHash,
+		CreatedAt:  time.Now().UTC(),
+	}).Error
+}
+
+// GetSchemaVersionEvents returns the activations and deactivations of the versions of the schema, oldest first.
+// Returns registry.ErrNotFound in case there's no schema under the given id.
+func (r *Repository) GetSchemaVersionEvents(ctx context.Context, id string) ([]registry.VersionEvent, error) {
+	if _, err := strconv.Atoi(id); err != nil {
+		return nil, registry.ErrInvalidValueHeader
+	}
+
+	if err := r.reader(ctx, id).Select("schema_id").Take(&Schema{}, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, registry.ErrNotFound
+		}
+		return nil, err
+	}
+
+	var events []VersionEvent
+	if err := r.reader(ctx, id).Where("schema_id = ?", id).Order("created_at, event_id").Find(&events).Error; err != nil {
+		return nil, err
+	}
+
+	registryEvents := make([]registry.VersionEvent, len(events))
+	for i, event := range events {
+		registryEvents[i] = intoRegistryVersionEvent(event)
+	}
+	return registryEvents, nil
+}
@@ -18,12 +18,22 @@ import (
 	"gorm.io/gorm"
 )
 
-// Initdb initializes the schema registry database.
+// Initdb initializes the schema registry database, by applying every migration it is missing, filling in the Avro
+// fingerprints of the schema versions registered before they were stored, rehashing the Protobuf and XML schema
+// versions registered before they were canonicalized and compressing the specifications stored before they were
+// compressed.
 func Initdb(db *gorm.DB) error {
-	if err := db.Exec("create schema if not exists syntio_schema authorization postgres").Error; err != nil {
+	if _, err := MigrateUp(db); err != nil {
 		return err
 	}
-	return db.AutoMigrate(&Schema{}, &VersionDetails{})
+	if _, err := BackfillAvroFingerprints(db); err != nil {
+		return err
+	}
+	if _, err := BackfillSchemaHashes(db); err != nil {
+		return err
+	}
+	_, err := CompressSpecifications(db)
+	return err
 }
 
 // HealthCheck checks if the necessary tables exist.
@@ -31,5 +41,5 @@ func Initdb(db *gorm.DB) error {
 // Note that this function returns false in case of network issues as well, acting like a health check of sorts.
 func HealthCheck(db *gorm.DB) bool {
 	migrator := db.Migrator()
-	return migrator.HasTable(&Schema{}) && migrator.HasTable(&VersionDetails{})
+	return migrator.HasTable(&Schema{}) && migrator.HasTable(&VersionDetails{}) && migrator.HasTable(&AuditRecord{})
 }
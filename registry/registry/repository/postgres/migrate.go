@@ -0,0 +1,233 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// ErrMigrationsPending is returned by CheckMigrations if the database is behind the migrations of this build.
+var ErrMigrationsPending = errors.New("database migrations pending")
+
+// migrationLockKey is the key of the advisory lock which serializes concurrent migrations of the same database.
+const migrationLockKey = 5_328_107
+
+// Migration is a versioned change of the database schema, together with the statements which revert it.
+type Migration struct {
+	Version     int
+	Description string
+	Up          []string
+	Down        []string
+}
+
+// MigrationStatus is a Migration, together with the time it was applied to the database.
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// schemaMigration is a row of the table which records the applied migrations.
+type schemaMigration struct {
+	Version     int       `gorm:"primaryKey;column:version;autoIncrement:false"`
+	Description string    `gorm:"column:description;type:text"`
+	AppliedAt   time.Time `gorm:"column:applied_at"`
+}
+
+// TableName returns the name of the table of the applied migrations, which doesn't depend on the table prefix.
+func (schemaMigration) TableName() string {
+	return "syntio_schema.schema_migrations"
+}
+
+// LatestMigrationVersion returns the version the database is at after all migrations of this build are applied.
+func LatestMigrationVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// MigrateUp applies every migration the database is missing, each in its own transaction.
+// Returns the applied migrations.
+func MigrateUp(db *gorm.DB) ([]Migration, error) {
+	if err := createMigrationsTable(db); err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, migration := range migrations {
+		done := false
+		err := db.Transaction(func(tx *gorm.DB) error {
+			current, err := lockMigrations(tx)
+			if err != nil {
+				return err
+			}
+			if current >= migration.Version {
+				return nil
+			}
+
+			for _, statement := range migration.Up {
+				if err = tx.Exec(statement).Error; err != nil {
+					return err
+				}
+			}
+			done = true
+			return tx.Create(&schemaMigration{
+				Version:     migration.Version,
+				Description: migration.Description,
+				AppliedAt:   time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return applied, errors.Wrapf(err, "migration %d failed", migration.Version)
+		}
+		if done {
+			applied = append(applied, migration)
+		}
+	}
+	return applied, nil
+}
+
+// MigrateDown reverts the given number of the latest applied migrations, each in its own transaction.
+// Returns the reverted migrations.
+func MigrateDown(db *gorm.DB, steps int) ([]Migration, error) {
+	if err := createMigrationsTable(db); err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+	for i := 0; i < steps; i++ {
+		var current int
+		var migration Migration
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			if current, err = lockMigrations(tx); err != nil || current == 0 {
+				return err
+			}
+
+			var ok bool
+			if migration, ok = findMigration(current); !ok {
+				return errors.Errorf("database is at migration %d, which is unknown to this build", current)
+			}
+			for _, statement := range migration.Down {
+				if err = tx.Exec(statement).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Delete(&schemaMigration{Version: current}).Error
+		})
+		if err != nil {
+			return reverted, errors.Wrapf(err, "reverting migration %d failed", current)
+		}
+		if current == 0 {
+			break
+		}
+		reverted = append(reverted, migration)
+	}
+	return reverted, nil
+}
+
+// GetMigrationStatus returns every migration of this build, marking the ones applied to the database.
+func GetMigrationStatus(db *gorm.DB) ([]MigrationStatus, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, len(migrations))
+	for i, migration := range migrations {
+		row, ok := applied[migration.Version]
+		statuses[i] = MigrationStatus{
+			Migration: migration,
+			Applied:   ok,
+			AppliedAt: row.AppliedAt,
+		}
+	}
+	return statuses, nil
+}
+
+// CheckMigrations returns ErrMigrationsPending if the database is behind the migrations of this build.
+//
+// A database ahead of this build is accepted, so an older registry keeps working during a rollout of a newer one.
+func CheckMigrations(db *gorm.DB) error {
+	current, err := currentMigrationVersion(db)
+	if err != nil {
+		return err
+	}
+	if latest := LatestMigrationVersion(); current < latest {
+		return errors.Wrap(ErrMigrationsPending, fmt.Sprintf("database is at migration %d, expected %d", current, latest))
+	}
+	return nil
+}
+
+// createMigrationsTable creates the table of the applied migrations, together with the database schema.
+func createMigrationsTable(db *gorm.DB) error {
+	if err := db.Exec("create schema if not exists syntio_schema authorization postgres").Error; err != nil {
+		return err
+	}
+	return db.Exec(`create table if not exists syntio_schema.schema_migrations (
+		version integer primary key,
+		description text not null,
+		applied_at timestamptz not null
+	)`).Error
+}
+
+// lockMigrations waits for any concurrent migration to finish and returns the version the database is at.
+// The lock is held until the end of the transaction.
+func lockMigrations(tx *gorm.DB) (int, error) {
+	if err := tx.Exec("select pg_advisory_xact_lock(?)", migrationLockKey).Error; err != nil {
+		return 0, err
+	}
+	return currentMigrationVersion(tx)
+}
+
+// currentMigrationVersion returns the version of the latest applied migration, 0 if none were applied.
+func currentMigrationVersion(db *gorm.DB) (int, error) {
+	if !db.Migrator().HasTable(&schemaMigration{}) {
+		return 0, nil
+	}
+	var current int
+	if err := db.Model(&schemaMigration{}).Select("coalesce(max(version), 0)").Scan(&current).Error; err != nil {
+		return 0, err
+	}
+	return current, nil
+}
+
+// appliedMigrations returns the applied migrations, by version.
+func appliedMigrations(db *gorm.DB) (map[int]schemaMigration, error) {
+	applied := map[int]schemaMigration{}
+	if !db.Migrator().HasTable(&schemaMigration{}) {
+		return applied, nil
+	}
+	var rows []schemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// findMigration returns the migration with the given version.
+func findMigration(version int) (Migration, bool) {
+	for _, migration := range migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
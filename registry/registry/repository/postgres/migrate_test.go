@@ -0,0 +1,75 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pkg/errors"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestMigrationsAreVersionedInOrder(t *testing.T) {
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			t.Errorf("migration %d has version %d, versions must increase by one", i, migration.Version)
+		}
+		if migration.Description == "" || len(migration.Up) == 0 || len(migration.Down) == 0 {
+			t.Errorf("migration %d must have a description, up and down statements", migration.Version)
+		}
+	}
+}
+
+func TestCheckMigrations(t *testing.T) {
+	tt := []struct {
+		name     string
+		current  int
+		expected error
+	}{
+		{"behind", LatestMigrationVersion() - 1, ErrMigrationsPending},
+		{"up to date", LatestMigrationVersion(), nil},
+		{"ahead", LatestMigrationVersion() + 1, nil},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			sqlDB, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer sqlDB.Close()
+			db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			mock.ExpectQuery(`information_schema.tables`).
+				WithArgs("syntio_schema", "schema_migrations", "BASE TABLE").
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+			mock.ExpectQuery(`SELECT coalesce\(max\(version\), 0\) FROM "syntio_schema"."schema_migrations"`).
+				WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow(tc.current))
+
+			err = CheckMigrations(db)
+			if !errors.Is(err, tc.expected) {
+				t.Errorf("expected error %v, got %v", tc.expected, err)
+			}
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+// migrations are the versioned changes of the database schema, in the order they are applied.
+//
+// Applied migrations must never be changed, every change of the database schema needs a new migration instead. The
+// first two migrations create the tables only if they don't exist, so databases initialized with GORM AutoMigrate
+// before the migrations were introduced can be migrated as well.
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "create the schema and version_details tables",
+		Up: []string{
+			`create table if not exists syntio_schema.schema (
+				schema_id bigserial primary key,
+				schema_type varchar(8),
+				name varchar(256),
+				description text,
+				last_created varchar(8),
+				publisher_id varchar(256),
+				compatibility_mode varchar(256),
+				validity_mode varchar(256)
+			)`,
+			`create table if not exists syntio_schema.version_details (
+				version_id bigserial primary key,
+				version integer,
+				schema_id bigint references syntio_schema.schema (schema_id),
+				description text,
+				specification text,
+				schema_hash varchar(256),
+				created_at timestamptz,
+				version_deactivated boolean,
+				attributes text
+			)`,
+			`create index if not exists idver_idx on syntio_schema.version_details (version, schema_id)`,
+		},
+		Down: []string{
+			`drop table if exists syntio_schema.version_details`,
+			`drop table if exists syntio_schema.schema`,
+		},
+	},
+	{
+		Version:     2,
+		Description: "create the audit_record table",
+		Up: []string{
+			`create table if not exists syntio_schema.audit_record (
+				audit_id bigserial primary key,
+				schema_id bigint,
+				version varchar(8),
+				action varchar(32),
+				actor varchar(256),
+				request_id varchar(256),
+				before_hash varchar(256),
+				after_hash varchar(256),
+				created_at timestamptz
+			)`,
+			`create index if not exists audit_schema_idx on syntio_schema.audit_record (schema_id)`,
+			`create index if not exists audit_created_idx on syntio_schema.audit_record (created_at)`,
+		},
+		Down: []string{
+			`drop table if exists syntio_schema.audit_record`,
+		},
+	},
+	{
+		Version:     3,
+		Description: "widen schema_type to 16 characters",
+		Up: []string{
+			`alter table syntio_schema.schema alter column schema_type type varchar(16)`,
+		},
+		Down: []string{
+			`alter table syntio_schema.schema alter column schema_type type varchar(8)`,
+		},
+	},
+	{
+		Version:     4,
+		Description: "add the data contract rules of schema versions",
+		Up: []string{
+			`alter table syntio_schema.version_details add column if not exists rules text not null default ''`,
+		},
+		Down: []string{
+			`alter table syntio_schema.version_details drop column if exists rules`,
+		},
+	},
+	{
+		Version:     5,
+		Description: "create the version_usage table",
+		Up: []string{
+			`create table if not exists syntio_schema.version_usage (
+				schema_id bigint,
+				version varchar(8),
+				topic varchar(256),
+				role varchar(16),
+				client_id varchar(256),
+				last_seen timestamptz,
+				primary key (schema_id, version, topic, role, client_id)
+			)`,
+			`create index if not exists usage_last_seen_idx on syntio_schema.version_usage (last_seen)`,
+		},
+		Down: []string{
+			`drop table if exists syntio_schema.version_usage`,
+		},
+	},
+	{
+		Version:     6,
+		Description: "create the schema_alias table and add the alias of audit records",
+		Up: []string{
+			`create table if not exists syntio_schema.schema_alias (
+				schema_id bigint,
+				alias varchar(64),
+				version varchar(8),
+				updated_at timestamptz,
+				primary key (schema_id, alias)
+			)`,
+			`alter table syntio_schema.audit_record add column if not exists alias varchar(64) not null default ''`,
+		},
+		Down: []string{
+			`alter table syntio_schema.audit_record drop column if exists alias`,
+			`drop table if exists syntio_schema.schema_alias`,
+		},
+	},
+	{
+		Version:     7,
+		Description: "add the semantic versions of schema versions",
+		Up: []string{
+			`alter table syntio_schema.version_details add column if not exists semver varchar(32) not null default ''`,
+		},
+		Down: []string{
+			`alter table syntio_schema.version_details drop column if exists semver`,
+		},
+	},
+	{
+		Version:     8,
+		Description: "store schema specifications as raw, compressed bytes",
+		Up: []string{
+			// the existing specifications are stored uncompressed, which compressedSpecification reads as well
+			`alter table syntio_schema.version_details alter column specification type bytea using decode(specification, 'base64')`,
+		},
+		Down: []string{
+			// Postgres can't decompress gzip, so the specifications compressed since are refused instead of corrupted
+			`do $$
+			begin
+				if exists (select 1 from syntio_schema.version_details where substring(specification from 1 for 2) = '\x1f8b'::bytea) then
+					raise exception 'compressed schema specifications can''t be reverted to base64';
+				end if;
+			end
+			$$`,
+			`alter table syntio_schema.version_details alter column specification type text using replace(encode(specification, 'base64'), E'\n', '')`,
+		},
+	},
+	{
+		Version:     9,
+		Description: "add the avro fingerprints of schema versions and index the lookups by fingerprint",
+		Up: []string{
+			// the fingerprints of the existing avro schemas are filled in by BackfillAvroFingerprints
+			`alter table syntio_schema.version_details add column if not exists avro_fingerprint varchar(16) not null default ''`,
+			`create index if not exists version_hash_idx on syntio_schema.version_details (schema_hash)`,
+			`create index if not exists version_avro_fingerprint_idx on syntio_schema.version_details (avro_fingerprint)`,
+		},
+		Down: []string{
+			`drop index if exists syntio_schema.version_avro_fingerprint_idx`,
+			`drop index if exists syntio_schema.version_hash_idx`,
+			`alter table syntio_schema.version_details drop column if exists avro_fingerprint`,
+		},
+	},
+	{
+		Version:     10,
+		Description: "create the version_event table and backfill it from the existing versions and audit records",
+		Up: []string{
+			`create table if not exists syntio_schema.version_event (
+				event_id bigserial primary key,
+				schema_id bigint,
+				version varchar(8),
+				event varchar(16),
+				schema_hash varchar(256),
+				created_at timestamptz
+			)`,
+			`create index if not exists version_event_schema_idx on syntio_schema.version_event (schema_id, created_at)`,
+			`insert into syntio_schema.version_event (schema_id, version, event, schema_hash, created_at)
+				select schema_id, version::text, 'activated', schema_hash, created_at
+				from syntio_schema.version_details`,
+			// the deactivations before the audit log was introduced, or without a matching audit record, are dated to
+			// the migration, which is the earliest point in time they are known to have happened by
+			`insert into syntio_schema.version_event (schema_id, version, event, schema_hash, created_at)
+				select v.schema_id, v.version::text, 'deactivated', v.schema_hash, coalesce((
+					select max(a.created_at)
+					from syntio_schema.audit_record a
+					where a.schema_id = v.schema_id
+						and a.created_at >= v.created_at
+						and (a.action = 'delete_schema' or a.action = 'delete_schema_version' and a.version = v.version::text)
+				), now())
+				from syntio_schema.version_details v
+				where v.version_deactivated`,
+		},
+		Down: []string{
+			`drop table if exists syntio_schema.version_event`,
+		},
+	},
+	{
+		Version:     11,
+		Description: "add the unverified, claimed actor of audit records",
+		Up: []string{
+			`alter table syntio_schema.audit_record add column if not exists claimed_actor varchar(256) not null default ''`,
+		},
+		Down: []string{
+			`alter table syntio_schema.audit_record drop column if exists claimed_actor`,
+		},
+	},
+	{
+		Version:     12,
+		Description: "add the metadata changes of audit records",
+		Up: []string{
+			`alter table syntio_schema.audit_record add column if not exists changes text not null default ''`,
+		},
+		Down: []string{
+			`alter table syntio_schema.audit_record drop column if exists changes`,
+		},
+	},
+}
@@ -15,16 +15,19 @@
 package postgres
 
 import (
+	"encoding/json"
 	"strconv"
 	"time"
 
+	"github.com/pkg/errors"
+
 	"github.com/dataphos/schema-registry/registry"
 )
 
 // Schema is a structure that defines the parent entity in the schema registry.
 type Schema struct {
 	SchemaID          uint             `gorm:"primaryKey;column:schema_id;autoIncrement"`
-	SchemaType        string           `gorm:"column:schema_type;type:varchar(8)"`
+	SchemaType        string           `gorm:"column:schema_type;type:varchar(16)"`
 	Name              string           `gorm:"column:name;type:varchar(256)"`
 	Description       string           `gorm:"column:description;type:text"`
 	LastCreated       string           `gorm:"column:last_created;type:varchar(8)"`
@@ -36,15 +39,21 @@ type Schema struct {
 
 // VersionDetails represents the child entity in the schema registry model.
 type VersionDetails struct {
-	VersionID          uint      `gorm:"primaryKey;column:version_id;autoIncrement"`
-	Version            string    `gorm:"column:version;type:int;index:idver_idx"`
-	SchemaID           uint      `gorm:"column:schema_id;index:idver_idx"`
-	Description        string    `gorm:"column:description;type:text"`
-	Specification      string    `gorm:"column:specification;type:text"`
-	SchemaHash         string    `gorm:"column:schema_hash;type:varchar(256)"`
-	CreatedAt          time.Time `gorm:"column:created_at"`
-	VersionDeactivated bool      `gorm:"column:version_deactivated;type:boolean"`
-	Attributes         string    `gorm:"column:attributes;type:text"`
+	VersionID          uint                    `gorm:"primaryKey;column:version_id;autoIncrement"`
+	Version            string                  `gorm:"column:version;type:int;index:idver_idx"`
+	SchemaID           uint                    `gorm:"column:schema_id;index:idver_idx"`
+	Description        string                  `gorm:"column:description;type:text"`
+	Specification      compressedSpecification `gorm:"column:specification;type:bytea"`
+	SchemaHash         string                  `gorm:"column:schema_hash;type:varchar(256)"`
+	CreatedAt          time.Time               `gorm:"column:created_at"`
+	VersionDeactivated bool                    `gorm:"column:version_deactivated;type:boolean"`
+	Attributes         string                  `gorm:"column:attributes;type:text"`
+	// Rules holds the data contract rules encoded as JSON, or an empty string if the version has none.
+	Rules string `gorm:"column:rules;type:text"`
+	// Semver is the semantic version of the version, or an empty string if semantic versioning wasn't enabled.
+	Semver string `gorm:"column:semver;type:varchar(32)"`
+	// AvroFingerprint is the CRC-64-AVRO fingerprint of the version, or an empty string if it isn't an Avro schema.
+	AvroFingerprint string `gorm:"column:avro_fingerprint;type:varchar(16)"`
 }
 
 // intoRegistrySchema maps Schema from repository to service layer.
@@ -78,5 +87,32 @@ func intoRegistryVersionDetails(VersionDetails VersionDetails) registry.VersionD
 		CreatedAt:          VersionDetails.CreatedAt,
 		VersionDeactivated: VersionDetails.VersionDeactivated,
 		Attributes:         VersionDetails.Attributes,
+		Rules:              decodeRules(VersionDetails.Rules),
+		Semver:             VersionDetails.Semver,
+		AvroFingerprint:    VersionDetails.AvroFingerprint,
+	}
+}
+
+// encodeRules encodes the data contract rules as JSON, returning an empty string if there are none.
+func encodeRules(rules []registry.Rule) (string, error) {
+	if len(rules) == 0 {
+		return "", nil
+	}
+	encoded, err := json.Marshal(rules)
+	if err != nil {
+		return "", errors.Wrap(err, "couldn't encode data contract rules")
+	}
+	return string(encoded), nil
+}
+
+// decodeRules decodes the data contract rules written by encodeRules.
+func decodeRules(encoded string) []registry.Rule {
+	if encoded == "" {
+		return nil
+	}
+	var rules []registry.Rule
+	if err := json.Unmarshal([]byte(encoded), &rules); err != nil {
+		return nil
 	}
+	return rules
 }
@@ -0,0 +1,69 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pkg/errors"
+)
+
+// invalidationChannel is the channel on which the replicas announce which schema's cached entries are stale.
+const invalidationChannel = "schema_registry_cache"
+
+// Notify publishes the given schema id on the invalidation channel.
+func (r *Repository) Notify(ctx context.Context, schemaID string) error {
+	// the statement starts with SELECT, so it would be routed to a read replica, where NOTIFY isn't allowed
+	return r.primary(ctx).Exec("SELECT pg_notify(?, ?)", invalidationChannel, schemaID).Error
+}
+
+// Listen subscribes a dedicated connection to the invalidation channel and calls invalidate for every notification.
+// The reads of the notified schema are routed to the primary for readAfterWriteWindow, so the entries invalidated
+// aren't cached again from a lagging read replica.
+// It blocks until the context is cancelled or the connection is lost.
+func (r *Repository) Listen(ctx context.Context, invalidate func(schemaID string)) error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn interface{}) error {
+		stdlibConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return errors.Errorf("unsupported driver connection %T", driverConn)
+		}
+		pgConn := stdlibConn.Conn()
+
+		if _, err := pgConn.Exec(ctx, "LISTEN "+invalidationChannel); err != nil {
+			// the connection is in an unknown state, so it mustn't be returned to the pool
+			return errors.Wrapf(driver.ErrBadConn, "listen failed: %v", err)
+		}
+		for {
+			notification, err := pgConn.WaitForNotification(ctx)
+			if err != nil {
+				return errors.Wrapf(driver.ErrBadConn, "waiting for notification failed: %v", err)
+			}
+			r.writes.mark(notification.Payload)
+			invalidate(notification.Payload)
+		}
+	})
+}
@@ -15,8 +15,9 @@
 package postgres
 
 import (
-	"encoding/base64"
+	"context"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"strconv"
 	"strings"
 	"time"
@@ -28,19 +29,36 @@ import (
 )
 
 type Repository struct {
-	db *gorm.DB
+	db     *gorm.DB
+	sink   registry.AuditSink
+	writes *recentWrites
+}
+
+// Option configures a Repository.
+type Option func(*Repository)
+
+// WithAuditSink sets the sink every committed audit record is written to, in addition to the database.
+func WithAuditSink(sink registry.AuditSink) Option {
+	return func(r *Repository) {
+		r.sink = sink
+	}
 }
 
 // New returns a new instance of Repository.
-func New(db *gorm.DB) *Repository {
-	return &Repository{
-		db: db,
+func New(db *gorm.DB, options ...Option) *Repository {
+	r := &Repository{
+		db:     db,
+		writes: newRecentWrites(),
 	}
+	for _, option := range options {
+		option(r)
+	}
+	return r
 }
 
 // GetSchemaVersionByIdAndVersion retrieves a schema version by its id and version.
 // Returns registry.ErrNotFound in case there's no schema under the given id and version.
-func (r *Repository) GetSchemaVersionByIdAndVersion(id, version string) (registry.VersionDetails, error) {
+func (r *Repository) GetSchemaVersionByIdAndVersion(ctx context.Context, id, version string) (registry.VersionDetails, error) {
 	var details VersionDetails
 	var err error
 	_, err = strconv.Atoi(id)
@@ -51,7 +69,7 @@ func (r *Repository) GetSchemaVersionByIdAndVersion(id, version string) (registr
 	if err != nil {
 		return registry.VersionDetails{}, registry.ErrInvalidValueHeader
 	}
-	if err = r.db.Where("schema_id = ? and version = ? and version_deactivated = ?", id, version, false).Take(&details).Error; err != nil {
+	if err = r.reader(ctx, id).Where("schema_id = ? and version = ? and version_deactivated = ?", id, version, false).Take(&details).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return registry.VersionDetails{}, registry.ErrNotFound
 		}
@@ -62,9 +80,9 @@ func (r *Repository) GetSchemaVersionByIdAndVersion(id, version string) (registr
 
 // GetSchemaVersionsById returns a Schema with all active versions.
 // Returns registry.ErrNotFound in case there's no schema under the given id.
-func (r *Repository) GetSchemaVersionsById(id string) (registry.Schema, error) {
+func (r *Repository) GetSchemaVersionsById(ctx context.Context, id string) (registry.Schema, error) {
 	var schema Schema
-	err := r.db.Preload("VersionDetails", "version_deactivated = ?", false).Take(&schema, id).Error
+	err := r.reader(ctx, id).Preload("VersionDetails", "version_deactivated = ?", false).Take(&schema, id).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) || len(schema.VersionDetails) == 0 {
 		return registry.Schema{}, registry.ErrNotFound
 	}
@@ -76,9 +94,25 @@ func (r *Repository) GetSchemaVersionsById(id string) (registry.Schema, error) {
 
 // GetAllSchemaVersions returns a Schema with all versions.
 // Returns registry.ErrNotFound in case there's no schema under the given id.
-func (r *Repository) GetAllSchemaVersions(id string) (registry.Schema, error) {
+func (r *Repository) GetAllSchemaVersions(ctx context.Context, id string) (registry.Schema, error) {
+	var schema Schema
+	if err := r.reader(ctx, id).Preload("VersionDetails").Take(&schema, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return registry.Schema{}, registry.ErrNotFound
+		}
+		return registry.Schema{}, err
+	}
+	return intoRegistrySchema(schema), nil
+}
+
+// GetSchemaMetadata returns a Schema with all versions, without loading their specifications.
+// Returns registry.ErrNotFound in case there's no schema under the given id.
+func (r *Repository) GetSchemaMetadata(ctx context.Context, id string) (registry.Schema, error) {
 	var schema Schema
-	if err := r.db.Preload("VersionDetails").Take(&schema, id).Error; err != nil {
+	omitSpecification := func(tx *gorm.DB) *gorm.DB {
+		return tx.Omit("specification")
+	}
+	if err := r.reader(ctx, id).Preload("VersionDetails", omitSpecification).Take(&schema, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return registry.Schema{}, registry.ErrNotFound
 		}
@@ -89,9 +123,9 @@ func (r *Repository) GetAllSchemaVersions(id string) (registry.Schema, error) {
 
 // GetLatestSchemaVersion returns the latest active version of selected schema.
 // Returns registry.ErrNotFound in case there's no schema under the given id.
-func (r *Repository) GetLatestSchemaVersion(id string) (registry.VersionDetails, error) {
+func (r *Repository) GetLatestSchemaVersion(ctx context.Context, id string) (registry.VersionDetails, error) {
 	var details VersionDetails
-	if err := r.db.Where("schema_id = ? and version_deactivated = ?", id, false).Last(&details).Error; err != nil {
+	if err := r.reader(ctx, id).Where("schema_id = ? and version_deactivated = ?", id, false).Last(&details).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return registry.VersionDetails{}, registry.ErrNotFound
 		}
@@ -102,10 +136,10 @@ func (r *Repository) GetLatestSchemaVersion(id string) (registry.VersionDetails,
 
 // GetSchemas returns all active Schema instances.
 // Returns registry.ErrNotFound in case there's no schemas.
-func (r *Repository) GetSchemas() ([]registry.Schema, error) {
+func (r *Repository) GetSchemas(ctx context.Context) ([]registry.Schema, error) {
 	var schemaList []Schema
 	// This query examines if there is at least one active version of the schema and based on that, it determines whether to retrieve the schema.
-	tx := r.db.Preload("VersionDetails", "version_deactivated = ?", false).Where("EXISTS (SELECT 1 FROM syntio_schema.version_details WHERE syntio_schema.version_details.schema_id = syntio_schema.schema.schema_id AND syntio_schema.version_details.version_deactivated = 'false')").Find(&schemaList)
+	tx := r.reader(ctx, "").Preload("VersionDetails", "version_deactivated = ?", false).Where("EXISTS (SELECT 1 FROM syntio_schema.version_details WHERE syntio_schema.version_details.schema_id = syntio_schema.schema.schema_id AND syntio_schema.version_details.version_deactivated = 'false')").Find(&schemaList)
 	if tx.Error != nil {
 		return nil, tx.Error
 	}
@@ -122,9 +156,9 @@ func (r *Repository) GetSchemas() ([]registry.Schema, error) {
 
 // GetAllSchemas returns all Schema instances.
 // Returns registry.ErrNotFound in case there's no schemas.
-func (r *Repository) GetAllSchemas() ([]registry.Schema, error) {
+func (r *Repository) GetAllSchemas(ctx context.Context) ([]registry.Schema, error) {
 	var schemaList []Schema
-	tx := r.db.Preload("VersionDetails").Find(&schemaList)
+	tx := r.reader(ctx, "").Preload("VersionDetails").Find(&schemaList)
 	if tx.Error != nil {
 		return nil, tx.Error
 	}
@@ -141,7 +175,7 @@ func (r *Repository) GetAllSchemas() ([]registry.Schema, error) {
 
 // CreateSchema inserts a new Schema structure.
 // Returns a new VersionDetails structure and a bool flag indicating if a new version of schema was added or if it already existed.
-func (r *Repository) CreateSchema(schemaRegisterRequest registry.SchemaRegistrationRequest) (registry.VersionDetails, bool, error) {
+func (r *Repository) CreateSchema(ctx context.Context, schemaRegisterRequest registry.SchemaRegistrationRequest) (registry.VersionDetails, bool, error) {
 	specification := []byte(schemaRegisterRequest.Specification)
 	hash := hashutils.SHA256(specification)
 
@@ -150,8 +184,12 @@ func (r *Repository) CreateSchema(schemaRegisterRequest registry.SchemaRegistrat
 	// while also filtering the schemas with the specified schema hash and publisher ID. If the query does not return a schema,
 	// it means that a schema with the given criteria does not exist in the database and a new one needs to be created.
 	var schema Schema
-	if err := r.db.Table("syntio_schema.schema").Preload("VersionDetails", "schema_hash = ? and version_deactivated = ?", hash, false).Joins("JOIN syntio_schema.version_details ON syntio_schema.version_details.schema_id = syntio_schema.schema.schema_id AND syntio_schema.version_details.schema_hash = ? and syntio_schema.version_details.version_deactivated = ?", hash, false).Where("syntio_schema.schema.publisher_id = ?", schemaRegisterRequest.PublisherID).Take(&schema).Error; err != nil {
+	if err := r.primary(ctx).Table("syntio_schema.schema").Preload("VersionDetails", "schema_hash = ? and version_deactivated = ?", hash, false).Joins("JOIN syntio_schema.version_details ON syntio_schema.version_details.schema_id = syntio_schema.schema.schema_id AND syntio_schema.version_details.schema_hash = ? and syntio_schema.version_details.version_deactivated = ?", hash, false).Where("syntio_schema.schema.publisher_id = ?", schemaRegisterRequest.PublisherID).Take(&schema).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
+			rules, err := encodeRules(schemaRegisterRequest.Rules)
+			if err != nil {
+				return registry.VersionDetails{}, false, err
+			}
 			schema := Schema{
 				SchemaType:        strings.ToLower(schemaRegisterRequest.SchemaType),
 				Name:              schemaRegisterRequest.Name,
@@ -163,18 +201,34 @@ func (r *Repository) CreateSchema(schemaRegisterRequest registry.SchemaRegistrat
 				VersionDetails: []VersionDetails{
 					{
 						Version:            "1",
-						Specification:      base64.StdEncoding.EncodeToString(specification),
+						Specification:      specification,
 						Description:        schemaRegisterRequest.Description,
 						SchemaHash:         hash,
 						CreatedAt:          time.Now(),
 						VersionDeactivated: false,
 						Attributes:         schemaRegisterRequest.Attributes,
+						Rules:              rules,
+						Semver:             schemaRegisterRequest.Semver,
+						AvroFingerprint:    schemaRegisterRequest.AvroFingerprint,
 					},
 				},
 			}
-			if err := r.db.Create(&schema).Error; err != nil {
+			var record registry.AuditRecord
+			err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+				if err := tx.Create(&schema).Error; err != nil {
+					return err
+				}
+				if err := recordVersionEvent(tx, registry.VersionEventActivated, schema.SchemaID, "1", hash); err != nil {
+					return err
+				}
+				var err error
+				record, err = audit(ctx, tx, registry.AuditCreateSchema, schema.SchemaID, "1", "", hash)
+				return err
+			})
+			if err != nil {
 				return registry.VersionDetails{}, false, err
 			}
+			r.publish(record)
 			return intoRegistryVersionDetails(schema.VersionDetails[0]), true, nil
 		}
 		return registry.VersionDetails{}, false, err
@@ -185,7 +239,7 @@ func (r *Repository) CreateSchema(schemaRegisterRequest registry.SchemaRegistrat
 
 // UpdateSchemaById updates the schema specification and description if sent.
 // Returns the new VersionDetails and a flag indicating if a new version of schema was added.
-func (r *Repository) UpdateSchemaById(id string, schemaUpdateRequest registry.SchemaUpdateRequest) (registry.VersionDetails, bool, error) {
+func (r *Repository) UpdateSchemaById(ctx context.Context, id string, schemaUpdateRequest registry.SchemaUpdateRequest) (registry.VersionDetails, bool, error) {
 	schemaId, err := strconv.Atoi(id)
 	if err != nil {
 		return registry.VersionDetails{}, false, errors.Wrap(err, "wrong type of schemaID")
@@ -195,10 +249,11 @@ func (r *Repository) UpdateSchemaById(id string, schemaUpdateRequest registry.Sc
 	hash := hashutils.SHA256(specification)
 
 	var details VersionDetails
-	if err = r.db.Where("schema_hash = ? and schema_id = ?", hash, id).Take(&details).Error; err != nil {
+	if err = r.primary(ctx).Where("schema_hash = ? and schema_id = ?", hash, id).Take(&details).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			updated := VersionDetails{}
-			err = r.db.Transaction(func(tx *gorm.DB) error {
+			var record registry.AuditRecord
+			err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 
 				schema := &Schema{SchemaID: uint(schemaId)}
 				if err := tx.Select("last_created").Take(&schema).Error; err != nil {
@@ -214,12 +269,24 @@ func (r *Repository) UpdateSchemaById(id string, schemaUpdateRequest registry.Sc
 				}
 				incrementedLastCreated := strconv.Itoa(lastCreated + 1)
 
+				beforeHash, err := latestHash(tx, schema.SchemaID)
+				if err != nil {
+					return err
+				}
+				rules, err := encodeRules(schemaUpdateRequest.Rules)
+				if err != nil {
+					return err
+				}
+
 				updated = VersionDetails{
-					Version:       incrementedLastCreated,
-					Specification: base64.StdEncoding.EncodeToString(specification),
-					SchemaHash:    hash,
-					Description:   schemaUpdateRequest.Description,
-					Attributes:    schemaUpdateRequest.Attributes,
+					Version:         incrementedLastCreated,
+					Specification:   specification,
+					SchemaHash:      hash,
+					Description:     schemaUpdateRequest.Description,
+					Attributes:      schemaUpdateRequest.Attributes,
+					Rules:           rules,
+					Semver:          schemaUpdateRequest.Semver,
+					AvroFingerprint: schemaUpdateRequest.AvroFingerprint,
 				}
 
 				// append the new version to the VersionDetails array
@@ -232,11 +299,17 @@ func (r *Repository) UpdateSchemaById(id string, schemaUpdateRequest registry.Sc
 					return errors.Wrap(err, "could not update schema")
 				}
 
-				return nil
+				if err = recordVersionEvent(tx, registry.VersionEventActivated, schema.SchemaID, incrementedLastCreated, hash); err != nil {
+					return err
+				}
+
+				record, err = audit(ctx, tx, registry.AuditUpdateSchema, schema.SchemaID, incrementedLastCreated, beforeHash, hash)
+				return err
 			})
 			if err != nil {
 				return registry.VersionDetails{}, false, err
 			}
+			r.publish(record)
 			return intoRegistryVersionDetails(updated), true, nil
 		}
 		return registry.VersionDetails{}, false, err
@@ -245,7 +318,7 @@ func (r *Repository) UpdateSchemaById(id string, schemaUpdateRequest registry.Sc
 	if details.VersionDeactivated {
 		//Activates already existing Schema
 		var schema Schema
-		if err := r.db.Take(&schema, id).Error; err != nil {
+		if err := r.primary(ctx).Take(&schema, id).Error; err != nil {
 			return registry.VersionDetails{}, false, err
 		}
 		lastCreated, err := strconv.Atoi(schema.LastCreated)
@@ -254,21 +327,43 @@ func (r *Repository) UpdateSchemaById(id string, schemaUpdateRequest registry.Sc
 		}
 		incrementedLastCreated := strconv.Itoa(lastCreated + 1)
 
-		// updating description and last_created values in schema table
-		if err = r.db.Model(&Schema{SchemaID: uint(schemaId)}).Updates(Schema{Description: schemaUpdateRequest.Description, LastCreated: incrementedLastCreated}).Error; err != nil {
-			return registry.VersionDetails{}, false, errors.Wrap(err, "could not update schema")
-		}
+		var record registry.AuditRecord
+		err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			beforeHash, err := latestHash(tx, uint(schemaId))
+			if err != nil {
+				return err
+			}
 
-		// activating the schema version with a new creation time and version number
-		if err = r.db.Model(&details).Updates(map[string]interface{}{
-			"created_at":          time.Now(),
-			"version_deactivated": false,
-			"version":             incrementedLastCreated,
-		}).Error; err != nil {
-			return registry.VersionDetails{}, false, errors.Wrap(err, "could not update version details")
+			// updating description and last_created values in schema table
+			if err = tx.Model(&Schema{SchemaID: uint(schemaId)}).Updates(Schema{Description: schemaUpdateRequest.Description, LastCreated: incrementedLastCreated}).Error; err != nil {
+				return errors.Wrap(err, "could not update schema")
+			}
+
+			// activating the schema version with a new creation time and version number
+			if err = tx.Model(&details).Updates(map[string]interface{}{
+				"created_at":          time.Now(),
+				"version_deactivated": false,
+				"version":             incrementedLastCreated,
+				"semver":              schemaUpdateRequest.Semver,
+			}).Error; err != nil {
+				return errors.Wrap(err, "could not update version details")
+			}
+
+			if err = recordVersionEvent(tx, registry.VersionEventActivated, uint(schemaId), incrementedLastCreated, hash); err != nil {
+				return err
+			}
+
+			record, err = audit(ctx, tx, registry.AuditUpdateSchema, uint(schemaId), incrementedLastCreated, beforeHash, hash)
+			return err
+		})
+		if err != nil {
+			return registry.VersionDetails{}, false, err
 		}
+		r.publish(record)
 
 		details.VersionDeactivated = false
+		details.Version = incrementedLastCreated
+		details.Semver = schemaUpdateRequest.Semver
 		return intoRegistryVersionDetails(details), true, nil
 	}
 	return intoRegistryVersionDetails(details), false, nil
@@ -276,9 +371,9 @@ func (r *Repository) UpdateSchemaById(id string, schemaUpdateRequest registry.Sc
 
 // DeleteSchema deactivates a schema.
 // Returns a boolean flag indicating if a schema with the given id existed before this call.
-func (r *Repository) DeleteSchema(id string) (bool, error) {
+func (r *Repository) DeleteSchema(ctx context.Context, id string, usedSince time.Time) (bool, error) {
 	var schema Schema
-	if err := r.db.Preload("VersionDetails", "version_deactivated = ?", false).Take(&schema, id).Error; err != nil {
+	if err := r.primary(ctx).Preload("VersionDetails", "version_deactivated = ?", false).Take(&schema, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return false, nil
 		}
@@ -287,28 +382,211 @@ func (r *Repository) DeleteSchema(id string) (bool, error) {
 	if len(schema.VersionDetails) == 0 {
 		return false, nil
 	}
-	// deactivation of all active versions
-	tx := r.db.Model(&schema.VersionDetails).Update("version_deactivated", true)
-	if tx.Error != nil {
-		return false, tx.Error
+	latest := schema.VersionDetails[0]
+	for _, details := range schema.VersionDetails {
+		if details.VersionID > latest.VersionID {
+			latest = details
+		}
+	}
+
+	var deleted bool
+	var record registry.AuditRecord
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// deactivation of all active versions
+		result := tx.Model(&schema.VersionDetails).Update("version_deactivated", true)
+		if result.Error != nil {
+			return result.Error
+		}
+		if deleted = result.RowsAffected > 0; !deleted {
+			return nil
+		}
+
+		versions := make([]string, len(schema.VersionDetails))
+		for i, details := range schema.VersionDetails {
+			versions[i] = details.Version
+		}
+		if err := checkNotInUse(tx, schema.SchemaID, versions, usedSince); err != nil {
+			return err
+		}
+
+		for _, details := range schema.VersionDetails {
+			if err := recordVersionEvent(tx, registry.VersionEventDeactivated, schema.SchemaID, details.Version, details.SchemaHash); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		record, err = audit(ctx, tx, registry.AuditDeleteSchema, schema.SchemaID, "", latest.SchemaHash, "")
+		return err
+	})
+	if err != nil {
+		return false, err
 	}
-	return tx.RowsAffected > 0, nil
+	if deleted {
+		r.publish(record)
+	}
+	return deleted, nil
 }
 
 // DeleteSchemaVersion deactivates the specified schema version.
 // Returns a boolean flag indicating if a schema with the given id and version existed before this call.
-func (r *Repository) DeleteSchemaVersion(id, version string) (bool, error) {
+func (r *Repository) DeleteSchemaVersion(ctx context.Context, id, version string, usedSince time.Time) (bool, error) {
 	var details VersionDetails
-	if err := r.db.Where("schema_id = ? and version = ? and version_deactivated = ?", id, version, false).Take(&details).Error; err != nil {
+	if err := r.primary(ctx).Where("schema_id = ? and version = ? and version_deactivated = ?", id, version, false).Take(&details).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return false, nil
 		}
 		return false, err
 	}
 
-	tx := r.db.Model(&details).Update("version_deactivated", true)
-	if tx.Error != nil {
-		return false, tx.Error
+	var deleted bool
+	var record registry.AuditRecord
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&details).Update("version_deactivated", true)
+		if result.Error != nil {
+			return result.Error
+		}
+		if deleted = result.RowsAffected > 0; !deleted {
+			return nil
+		}
+
+		if err := checkNotInUse(tx, details.SchemaID, []string{details.Version}, usedSince); err != nil {
+			return err
+		}
+
+		if err := recordVersionEvent(tx, registry.VersionEventDeactivated, details.SchemaID, details.Version, details.SchemaHash); err != nil {
+			return err
+		}
+
+		var err error
+		record, err = audit(ctx, tx, registry.AuditDeleteSchemaVersion, details.SchemaID, details.Version, details.SchemaHash, "")
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	if deleted {
+		r.publish(record)
+	}
+	return deleted, nil
+}
+
+// UpdateSchemaMetadata changes the name, description and publisher id of the schema which are set in the request.
+// Returns registry.ErrNotFound in case there's no schema under the given id.
+func (r *Repository) UpdateSchemaMetadata(ctx context.Context, id string, request registry.SchemaMetadataRequest) (registry.Schema, error) {
+	if _, err := strconv.Atoi(id); err != nil {
+		return registry.Schema{}, registry.ErrInvalidValueHeader
+	}
+
+	var schema Schema
+	var record registry.AuditRecord
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(&schema, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return registry.ErrNotFound
+			}
+			return err
+		}
+
+		updates := map[string]interface{}{}
+		var changes []registry.MetadataChange
+		update := func(column string, field *string, value *string) {
+			if value == nil {
+				return
+			}
+			if *field != *value {
+				changes = append(changes, registry.MetadataChange{Field: column, Before: *field, After: *value})
+			}
+			*field = *value
+			updates[column] = *value
+		}
+		update("name", &schema.Name, request.Name)
+		update("description", &schema.Description, request.Description)
+		update("publisher_id", &schema.PublisherID, request.PublisherID)
+		if err := tx.Model(&Schema{SchemaID: schema.SchemaID}).Updates(updates).Error; err != nil {
+			return errors.Wrap(err, "could not update schema")
+		}
+
+		// the metadata belongs to every version, so the record ties it to the latest one
+		var latest VersionDetails
+		err := tx.Select("schema_hash").
+			Where("schema_id = ? and version_deactivated = ?", schema.SchemaID, false).
+			Order("version_id desc").
+			Take(&latest).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		record, err = auditMetadata(ctx, tx, schema.SchemaID, latest.SchemaHash, changes)
+		return err
+	})
+	if err != nil {
+		return registry.Schema{}, err
+	}
+	r.publish(record)
+	return intoRegistrySchema(schema), nil
+}
+
+// RestoreSchemaVersion activates the deactivated schema version again, keeping its version number.
+// Returns registry.ErrNotFound in case there's no schema version under the given id and version, and a false flag if
+// the version is already active.
+func (r *Repository) RestoreSchemaVersion(ctx context.Context, id, version string) (registry.VersionDetails, bool, error) {
+	if _, err := strconv.Atoi(id); err != nil {
+		return registry.VersionDetails{}, false, registry.ErrInvalidValueHeader
+	}
+	if _, err := strconv.Atoi(version); err != nil {
+		return registry.VersionDetails{}, false, registry.ErrInvalidValueHeader
+	}
+
+	var details VersionDetails
+	if err := r.primary(ctx).Where("schema_id = ? and version = ?", id, version).Take(&details).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return registry.VersionDetails{}, false, registry.ErrNotFound
+		}
+		return registry.VersionDetails{}, false, err
+	}
+	if !details.VersionDeactivated {
+		return intoRegistryVersionDetails(details), false, nil
+	}
+
+	var restored bool
+	var record registry.AuditRecord
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&details).Where("version_deactivated = ?", true).Update("version_deactivated", false)
+		if result.Error != nil {
+			return result.Error
+		}
+		if restored = result.RowsAffected > 0; !restored {
+			return nil
+		}
+
+		if err := recordVersionEvent(tx, registry.VersionEventActivated, details.SchemaID, details.Version, details.SchemaHash); err != nil {
+			return err
+		}
+
+		var err error
+		record, err = audit(ctx, tx, registry.AuditRestoreSchemaVersion, details.SchemaID, details.Version, "", details.SchemaHash)
+		return err
+	})
+	if err != nil {
+		return registry.VersionDetails{}, false, err
+	}
+	if restored {
+		r.publish(record)
+	}
+
+	details.VersionDeactivated = false
+	return intoRegistryVersionDetails(details), restored, nil
+}
+
+// latestHash returns the hash of the latest active version of the schema, or an empty string if it has none.
+func latestHash(tx *gorm.DB, schemaID uint) (string, error) {
+	var details VersionDetails
+	if err := tx.Where("schema_id = ? and version_deactivated = ?", schemaID, false).Last(&details).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil
+		}
+		return "", err
 	}
-	return tx.RowsAffected > 0, nil
+	return details.SchemaHash, nil
 }
@@ -15,6 +15,7 @@
 package postgres
 
 import (
+	"context"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"testing"
@@ -48,7 +49,8 @@ func TestGetSchemaVersionByIdAndVersion(t *testing.T) {
 	defer db.Close()
 
 	pdb := Repository{
-		db: dbInstance,
+		db:     dbInstance,
+		writes: newRecentWrites(),
 	}
 	resultRow := sqlmock.NewRows([]string{"version_id", "version", "schema_id", "specification", "description", "schema_hash", "created_at", "version_deactivated"}).
 		AddRow(1, "1", 1, "test_spec", "a description", "9f8f1a88fdc11bf262095a82a607a61086641ad8da16ab4b6e104dd32920d20f", time.Now(), false)
@@ -57,7 +59,7 @@ func TestGetSchemaVersionByIdAndVersion(t *testing.T) {
 		WithArgs(1, "1", false).
 		WillReturnRows(resultRow)
 
-	sd, err := pdb.GetSchemaVersionByIdAndVersion("1", "1")
+	sd, err := pdb.GetSchemaVersionByIdAndVersion(context.Background(), "1", "1")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -0,0 +1,80 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// readAfterWriteWindow is how long the reads of a schema go to the primary after it's written, so that neither the
+// writer nor the replicas of the registry invalidating their caches read it from a lagging read replica.
+const readAfterWriteWindow = 10 * time.Second
+
+// recentWrites tracks which schemas were written within readAfterWriteWindow.
+type recentWrites struct {
+	mu      sync.Mutex
+	last    time.Time
+	schemas map[string]time.Time
+}
+
+func newRecentWrites() *recentWrites {
+	return &recentWrites{schemas: map[string]time.Time{}}
+}
+
+// mark records that the schema was just written.
+func (w *recentWrites) mark(schemaID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	for id, written := range w.schemas {
+		if now.Sub(written) >= readAfterWriteWindow {
+			delete(w.schemas, id)
+		}
+	}
+	w.last = now
+	w.schemas[schemaID] = now
+}
+
+// recent returns true if the schema was written within readAfterWriteWindow. An empty schema id stands for any schema.
+func (w *recentWrites) recent(schemaID string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	written := w.last
+	if schemaID != "" {
+		written = w.schemas[schemaID]
+	}
+	return time.Since(written) < readAfterWriteWindow
+}
+
+// primary returns a handle which always uses the primary, for writes and the reads they depend on.
+func (r *Repository) primary(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Clauses(dbresolver.Write)
+}
+
+// reader returns a handle for reading the given schema, which uses a read replica unless the schema was written
+// within readAfterWriteWindow. An empty schema id stands for reads spanning all schemas.
+func (r *Repository) reader(ctx context.Context, schemaID string) *gorm.DB {
+	if r.writes.recent(schemaID) {
+		return r.primary(ctx)
+	}
+	return r.db.WithContext(ctx)
+}
@@ -0,0 +1,82 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+func TestReadReplicaRouting(t *testing.T) {
+	primarySQL, primary, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer primarySQL.Close()
+	replicaSQL, replica, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer replicaSQL.Close()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: primarySQL}), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.Use(dbresolver.Register(dbresolver.Config{
+		Replicas: []gorm.Dialector{postgres.New(postgres.Config{Conn: replicaSQL})},
+	})); err != nil {
+		t.Fatal(err)
+	}
+	repo := New(db)
+	ctx := context.Background()
+
+	latest := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"version_id", "version", "schema_id"}).AddRow(1, "1", 1)
+	}
+
+	replica.ExpectQuery(`version_details`).WillReturnRows(latest())
+	if _, err = repo.GetLatestSchemaVersion(ctx, "1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// once the schema is written, its reads go to the primary, while the reads of other schemas don't
+	repo.writes.mark("1")
+	primary.ExpectQuery(`version_details`).WillReturnRows(latest())
+	if _, err = repo.GetLatestSchemaVersion(ctx, "1"); err != nil {
+		t.Fatal(err)
+	}
+	replica.ExpectQuery(`version_details`).WillReturnRows(latest())
+	if _, err = repo.GetLatestSchemaVersion(ctx, "2"); err != nil {
+		t.Fatal(err)
+	}
+
+	primary.ExpectExec(`pg_notify`).WillReturnResult(sqlmock.NewResult(0, 1))
+	if err = repo.Notify(ctx, "1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = primary.ExpectationsWereMet(); err != nil {
+		t.Errorf("primary: %v", err)
+	}
+	if err = replica.ExpectationsWereMet(); err != nil {
+		t.Errorf("replica: %v", err)
+	}
+}
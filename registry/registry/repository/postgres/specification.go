@@ -0,0 +1,118 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"bytes"
+	"compress/gzip"
+	"database/sql/driver"
+	"io"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// compressionThreshold is the size under which specifications are stored as they are, since compressing them barely
+// saves anything.
+const compressionThreshold = 256
+
+// gzipMagic starts every gzip stream. Specifications are text, which never starts with these bytes, so stored
+// specifications are recognized as compressed by them.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// compressedSpecification is a specification stored in a bytea column, gzip compressed unless it's small or
+// compressing it doesn't make it smaller.
+type compressedSpecification []byte
+
+// Value implements driver.Valuer, compressing the specification.
+func (s compressedSpecification) Value() (driver.Value, error) {
+	if len(s) < compressionThreshold {
+		return []byte(s), nil
+	}
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(s); err != nil {
+		return nil, errors.Wrap(err, "couldn't compress schema specification")
+	}
+	if err := w.Close(); err != nil {
+		return nil, errors.Wrap(err, "couldn't compress schema specification")
+	}
+	if buf.Len() >= len(s) {
+		return []byte(s), nil
+	}
+	return buf.Bytes(), nil
+}
+
+// Scan implements sql.Scanner, decompressing the specification if it's compressed.
+func (s *compressedSpecification) Scan(value interface{}) error {
+	var stored []byte
+	switch v := value.(type) {
+	case nil:
+		*s = nil
+		return nil
+	case []byte:
+		stored = v
+	case string:
+		stored = []byte(v)
+	default:
+		return errors.Errorf("unsupported schema specification of type %T", value)
+	}
+
+	if !bytes.HasPrefix(stored, gzipMagic) {
+		// the driver may reuse the scanned buffer, so it's copied
+		*s = append(compressedSpecification(nil), stored...)
+		return nil
+	}
+	r, err := gzip.NewReader(bytes.NewReader(stored))
+	if err != nil {
+		return errors.Wrap(err, "couldn't decompress schema specification")
+	}
+	defer r.Close()
+	decompressed, err := io.ReadAll(r)
+	if err != nil {
+		return errors.Wrap(err, "couldn't decompress schema specification")
+	}
+	*s = decompressed
+	return nil
+}
+
+// CompressSpecifications compresses the specifications stored before they were compressed, which migration 8 converted
+// to raw bytes as they were, returning the number of versions compressed. Specifications which compressing doesn't
+// make smaller are left as they are.
+func CompressSpecifications(db *gorm.DB) (int, error) {
+	var count int
+	var batch []VersionDetails
+	err := db.Table("syntio_schema.version_details").
+		Select("version_id, specification").
+		Where("length(specification) >= ? and substring(specification from 1 for 2) <> ?", compressionThreshold, gzipMagic).
+		FindInBatches(&batch, backfillBatchSize, func(tx *gorm.DB, _ int) error {
+			for _, details := range batch {
+				compressed, err := details.Specification.Value()
+				if err != nil {
+					return errors.Wrapf(err, "couldn't compress the specification of version %d", details.VersionID)
+				}
+				if !bytes.HasPrefix(compressed.([]byte), gzipMagic) {
+					continue
+				}
+				if err := db.Model(&VersionDetails{VersionID: details.VersionID}).Update("specification", compressed).Error; err != nil {
+					return errors.Wrapf(err, "couldn't store the compressed specification of version %d", details.VersionID)
+				}
+				count++
+			}
+			return nil
+		}).Error
+	return count, err
+}
@@ -0,0 +1,72 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCompressedSpecificationRoundTrip(t *testing.T) {
+	tt := []struct {
+		name       string
+		spec       []byte
+		compressed bool
+	}{
+		{"small", []byte(`{"type":"string"}`), false},
+		{"large", []byte(`{"type":"object","properties":{` + strings.Repeat(`"field":{"type":"string"},`, 100) + `"last":{"type":"string"}}}`), true},
+		{"empty", []byte{}, false},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			value, err := compressedSpecification(tc.spec).Value()
+			if err != nil {
+				t.Fatal(err)
+			}
+			stored := value.([]byte)
+			if compressed := bytes.HasPrefix(stored, gzipMagic); compressed != tc.compressed {
+				t.Fatalf("expected compressed to be %v, got %v", tc.compressed, compressed)
+			}
+			if tc.compressed && len(stored) >= len(tc.spec) {
+				t.Fatalf("compressed specification of %d bytes isn't smaller than %d bytes", len(stored), len(tc.spec))
+			}
+
+			var scanned compressedSpecification
+			if err = scanned.Scan(stored); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(scanned, tc.spec) {
+				t.Fatalf("expected %q, got %q", tc.spec, scanned)
+			}
+		})
+	}
+}
+
+func TestCompressedSpecificationScanUncompressed(t *testing.T) {
+	// rows written before compression was introduced hold the raw specification
+	stored := []byte(`syntax = "proto3";`)
+
+	var scanned compressedSpecification
+	if err := scanned.Scan(stored); err != nil {
+		t.Fatal(err)
+	}
+	stored[0] = 'X'
+	if string(scanned) != `syntax = "proto3";` {
+		t.Fatalf("scanned specification shares memory with the driver buffer: %q", scanned)
+	}
+}
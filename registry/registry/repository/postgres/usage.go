@@ -0,0 +1,120 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/dataphos/schema-registry/registry"
+)
+
+// VersionUsage is a registration of a service which produces or consumes a schema version on a topic.
+type VersionUsage struct {
+	SchemaID uint      `gorm:"primaryKey;column:schema_id"`
+	Version  string    `gorm:"primaryKey;column:version;type:varchar(8)"`
+	Topic    string    `gorm:"primaryKey;column:topic;type:varchar(256)"`
+	Role     string    `gorm:"primaryKey;column:role;type:varchar(16)"`
+	ClientID string    `gorm:"primaryKey;column:client_id;type:varchar(256)"`
+	LastSeen time.Time `gorm:"column:last_seen;index:usage_last_seen_idx"`
+}
+
+// intoRegistryUsage maps VersionUsage from repository to service layer.
+func intoRegistryUsage(usage VersionUsage) registry.Usage {
+	return registry.Usage{
+		SchemaID: strconv.Itoa(int(usage.SchemaID)),
+		Version:  usage.Version,
+		Topic:    usage.Topic,
+		Role:     usage.Role,
+		ClientID: usage.ClientID,
+		LastSeen: usage.LastSeen,
+	}
+}
+
+// RegisterUsage inserts the usage, or updates the time it was last seen if it's already registered.
+func (r *Repository) RegisterUsage(ctx context.Context, usage registry.Usage) error {
+	schemaID, err := strconv.Atoi(usage.SchemaID)
+	if err != nil {
+		return registry.ErrInvalidValueHeader
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "schema_id"}, {Name: "version"}, {Name: "topic"}, {Name: "role"}, {Name: "client_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_seen"}),
+	}).Create(&VersionUsage{
+		SchemaID: uint(schemaID),
+		Version:  usage.Version,
+		Topic:    usage.Topic,
+		Role:     usage.Role,
+		ClientID: usage.ClientID,
+		LastSeen: usage.LastSeen,
+	}).Error
+}
+
+// GetUsage returns the usages matching the query, ordered by version, topic, role and client id.
+func (r *Repository) GetUsage(ctx context.Context, query registry.UsageQuery) ([]registry.Usage, error) {
+	tx := r.reader(ctx, query.SchemaID)
+	if query.SchemaID != "" {
+		if _, err := strconv.Atoi(query.SchemaID); err != nil {
+			return nil, registry.ErrInvalidValueHeader
+		}
+		tx = tx.Where("schema_id = ?", query.SchemaID)
+	}
+	if query.Version != "" {
+		tx = tx.Where("version = ?", query.Version)
+	}
+	if !query.Since.IsZero() {
+		tx = tx.Where("last_seen >= ?", query.Since)
+	}
+
+	var usages []VersionUsage
+	if err := tx.Order("schema_id, version, topic, role, client_id").Find(&usages).Error; err != nil {
+		return nil, err
+	}
+
+	registryUsages := make([]registry.Usage, len(usages))
+	for i, usage := range usages {
+		registryUsages[i] = intoRegistryUsage(usage)
+	}
+	return registryUsages, nil
+}
+
+// checkNotInUse returns a registry.InUseError if any of the given versions of the schema has a usage seen since
+// usedSince. It's called within the transaction deactivating the versions, so the check runs on the primary and a
+// failure rolls the deactivation back. A zero usedSince skips the check.
+func checkNotInUse(tx *gorm.DB, schemaID uint, versions []string, usedSince time.Time) error {
+	if usedSince.IsZero() || len(versions) == 0 {
+		return nil
+	}
+
+	var usages []VersionUsage
+	if err := tx.Where("schema_id = ? and version in ? and last_seen >= ?", schemaID, versions, usedSince).
+		Order("version, topic, role, client_id").
+		Find(&usages).Error; err != nil {
+		return err
+	}
+	if len(usages) == 0 {
+		return nil
+	}
+
+	registryUsages := make([]registry.Usage, len(usages))
+	for i, usage := range usages {
+		registryUsages[i] = intoRegistryUsage(usage)
+	}
+	return &registry.InUseError{Usage: registryUsages}
+}
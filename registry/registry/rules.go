@@ -0,0 +1,106 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/pkg/errors"
+)
+
+// ruleSchemaTypes are the schema types whose messages the validator can evaluate data contract rules against.
+var ruleSchemaTypes = []string{"json", "avro"}
+
+// ruleMessageVariable is the name of the CEL variable the validator binds the decoded message to.
+const ruleMessageVariable = "message"
+
+// ruleEnv is the CEL environment of the validator, with the message of either supported schema type decoded into
+// maps and lists, so the expressions are compiled the way the validator compiles them.
+var ruleEnv *cel.Env
+
+func init() {
+	var err error
+	ruleEnv, err = cel.NewEnv(
+		cel.Variable(ruleMessageVariable, cel.DynType),
+		cel.CrossTypeNumericComparisons(true),
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// normalizeRules checks the data contract rules of a schema of the given type, returning them with their severities
+// lowercased and defaulted. The returned error wraps ErrInvalidRules if the rules can't be evaluated.
+func normalizeRules(rules []Rule, schemaType string) ([]Rule, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	if !containsString(ruleSchemaTypes, strings.ToLower(schemaType)) {
+		return nil, errors.Wrapf(ErrInvalidRules, "rules aren't supported for %s schemas", schemaType)
+	}
+
+	normalized := make([]Rule, 0, len(rules))
+	names := make(map[string]struct{}, len(rules))
+	for _, rule := range rules {
+		if rule.Name == "" {
+			return nil, errors.Wrap(ErrInvalidRules, "every rule must have a name")
+		}
+		if _, ok := names[rule.Name]; ok {
+			return nil, errors.Wrapf(ErrInvalidRules, "rule %q is defined more than once", rule.Name)
+		}
+		names[rule.Name] = struct{}{}
+		if strings.TrimSpace(rule.Expression) == "" {
+			return nil, errors.Wrapf(ErrInvalidRules, "rule %q must have an expression", rule.Name)
+		}
+		if err := compileRule(rule.Expression); err != nil {
+			return nil, errors.Wrapf(ErrInvalidRules, "rule %q doesn't compile: %v", rule.Name, err)
+		}
+
+		switch strings.ToLower(rule.Severity) {
+		case "", RuleSeverityError:
+			rule.Severity = RuleSeverityError
+		case RuleSeverityWarning:
+			rule.Severity = RuleSeverityWarning
+		default:
+			return nil, errors.Wrapf(ErrInvalidRules, "rule %q has an unknown severity %q", rule.Name, rule.Severity)
+		}
+		normalized = append(normalized, rule)
+	}
+	return normalized, nil
+}
+
+// compileRule checks that the expression compiles to a boolean, since a rule the validator can't compile fails every
+// message.
+func compileRule(expression string) error {
+	ast, issues := ruleEnv.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return issues.Err()
+	}
+	if outputType := ast.OutputType(); outputType != cel.BoolType && outputType != cel.DynType {
+		return errors.Errorf("the expression evaluates to %s instead of bool", outputType)
+	}
+	_, err := ruleEnv.Program(ast)
+	return err
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func Test_normalizeRules(t *testing.T) {
+	tt := []struct {
+		name       string
+		rules      []Rule
+		schemaType string
+		expected   []Rule
+		valid      bool
+	}{
+		{"no rules", nil, "xml", nil, true},
+		{
+			"default severity",
+			[]Rule{{Name: "positive", Expression: "message.amount > 0"}, {Name: "dates", Expression: "message.end >= message.start", Severity: "WARNING"}},
+			"avro",
+			[]Rule{{Name: "positive", Expression: "message.amount > 0", Severity: RuleSeverityError}, {Name: "dates", Expression: "message.end >= message.start", Severity: RuleSeverityWarning}},
+			true,
+		},
+		{"unsupported type", []Rule{{Name: "positive", Expression: "true"}}, "csv", nil, false},
+		{"missing name", []Rule{{Expression: "true"}}, "json", nil, false},
+		{"duplicate name", []Rule{{Name: "a", Expression: "true"}, {Name: "a", Expression: "false"}}, "json", nil, false},
+		{"missing expression", []Rule{{Name: "a", Expression: " "}}, "json", nil, false},
+		{"unknown severity", []Rule{{Name: "a", Expression: "true", Severity: "fatal"}}, "json", nil, false},
+		{"syntax error", []Rule{{Name: "a", Expression: "message.amount >"}}, "json", nil, false},
+		{"undeclared variable", []Rule{{Name: "a", Expression: "msg.amount > 0"}}, "avro", nil, false},
+		{"not a condition", []Rule{{Name: "a", Expression: "message.amount + 1 > 0 ? 1 : 2"}}, "json", nil, false},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rules, err := normalizeRules(tc.rules, tc.schemaType)
+			if tc.valid {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(rules, tc.expected) {
+					t.Errorf("expected %v, got %v", tc.expected, rules)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidRules) {
+				t.Errorf("expected ErrInvalidRules, got %v", err)
+			}
+		})
+	}
+}
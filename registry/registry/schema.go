@@ -15,19 +15,21 @@
 package registry
 
 import (
+	"context"
 	"encoding/json"
 	"log"
-	"os"
 	"reflect"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
 	"github.com/hamba/avro/v2"
 	"github.com/pkg/errors"
 
 	"github.com/dataphos/schema-registry/compatibility"
+	"github.com/dataphos/schema-registry/registry/examples"
 	"github.com/dataphos/schema-registry/validity"
 )
 
@@ -37,13 +39,66 @@ type Service struct {
 	ValChecker     validity.Checker
 	GlobalCompMode string
 	GlobalValMode  string
+	Linter         *validity.Linter
+	// UsageTTL is how long a registered usage keeps a schema version in use without a heartbeat.
+	UsageTTL time.Duration
+	// SemanticVersioning enables deriving a semantic version of every new schema version from the change it makes.
+	SemanticVersioning bool
+	// AdmissionWebhooks are called in order before a new schema or schema version is registered.
+	AdmissionWebhooks []AdmissionWebhook
 }
 
 // Attribute search depth limit to prevent infinite recursion
 const attSearchDepth = 10
 
-const cacheSizeEnv = "CACHE_SIZE"
-const defaultCacheSizeEnv = 0
+const defaultCacheTTL = 5 * time.Minute
+
+// Option configures the Service set up by New.
+type Option func(*options)
+
+type options struct {
+	ctx                context.Context
+	cacheSize          int
+	cacheTTL           time.Duration
+	usageTTL           time.Duration
+	semanticVersioning bool
+}
+
+// WithContext sets the context which bounds the background work of the Service, such as listening for the cache
+// invalidations of other replicas. It's never cancelled by default.
+func WithContext(ctx context.Context) Option {
+	return func(o *options) {
+		o.ctx = ctx
+	}
+}
+
+// WithRepositoryCache caches the reads of the repository in memory, in at most size entries which live for the ttl,
+// 5 minutes if it isn't positive. The cache is disabled if the size isn't positive.
+func WithRepositoryCache(size int, ttl time.Duration) Option {
+	return func(o *options) {
+		o.cacheSize = size
+		if ttl > 0 {
+			o.cacheTTL = ttl
+		}
+	}
+}
+
+// WithUsageTTL sets how long a registered usage keeps a schema version in use without a heartbeat, 5 minutes by
+// default.
+func WithUsageTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		if ttl > 0 {
+			o.usageTTL = ttl
+		}
+	}
+}
+
+// WithSemanticVersioning enables deriving a semantic version of every new schema version from the change it makes.
+func WithSemanticVersioning(enabled bool) Option {
+	return func(o *options) {
+		o.semanticVersioning = enabled
+	}
+}
 
 type QueryParams struct {
 	Id         string
@@ -56,23 +111,42 @@ type QueryParams struct {
 	Attributes []string
 }
 
-func New(Repository Repository, CompChecker compatibility.Checker, ValChecker validity.Checker, GlobalCompMode, GlobalValMode string) *Service {
-	var size int
-	var err error
-	cacheSize := os.Getenv(cacheSizeEnv)
-	if cacheSize == "" {
-		size = defaultCacheSizeEnv
-	} else {
-		size, err = strconv.Atoi(cacheSize)
-		if err != nil {
-			log.Println("Cache size can not hold non-numeric data.")
-			return &Service{}
-		}
+// New returns a new instance of Service. The repository isn't cached and semantic versioning is disabled unless the
+// options say so.
+func New(Repository Repository, CompChecker compatibility.Checker, ValChecker validity.Checker, GlobalCompMode, GlobalValMode string, opts ...Option) *Service {
+	o := options{ctx: context.Background(), cacheTTL: defaultCacheTTL, usageTTL: defaultUsageTTL}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	linter, err := validity.NewLinterFromEnv()
+	if err != nil {
+		log.Println("Lint config is not valid:", err)
+		return &Service{}
+	}
+
+	// replicas sharing the database broadcast invalidations through it, if the repository supports it
+	notifier, _ := Repository.(Notifier)
+
+	Repository = WithInstrumentation(Repository)
+	if CompChecker != nil {
+		CompChecker = instrumentCompatibilityChecker(CompChecker)
+	}
+	if ValChecker != nil {
+		ValChecker = instrumentValidityChecker(ValChecker)
 	}
 
-	if size > 0 {
-		log.Println("Using in-memory cache for repository")
-		Repository, err = WithCache(Repository, size)
+	if o.cacheSize > 0 {
+		if notifier != nil {
+			log.Println("Using in-memory cache for repository, invalidated across replicas")
+		} else {
+			log.Println("Using in-memory cache for repository")
+		}
+		Repository, err = WithCache(o.ctx, Repository, CacheSettings{
+			Size:     o.cacheSize,
+			TTL:      o.cacheTTL,
+			Notifier: notifier,
+		})
 		if err != nil {
 			log.Println("Encountered error while trying to create cache.")
 			return &Service{}
@@ -80,47 +154,92 @@ func New(Repository Repository, CompChecker compatibility.Checker, ValChecker va
 	}
 
 	return &Service{
-		Repository:     Repository,
-		CompChecker:    CompChecker,
-		ValChecker:     ValChecker,
-		GlobalCompMode: GlobalCompMode,
-		GlobalValMode:  GlobalValMode,
+		Repository:         Repository,
+		CompChecker:        CompChecker,
+		ValChecker:         ValChecker,
+		GlobalCompMode:     GlobalCompMode,
+		GlobalValMode:      GlobalValMode,
+		Linter:             linter,
+		UsageTTL:           o.usageTTL,
+		SemanticVersioning: o.semanticVersioning,
 	}
 }
 
 // GetSchemaVersion gets the schema version with the specific id and version.
-func (service *Service) GetSchemaVersion(id, version string) (VersionDetails, error) {
-	return service.Repository.GetSchemaVersionByIdAndVersion(id, version)
+// The version can also be an alias or a semantic version.
+func (service *Service) GetSchemaVersion(ctx context.Context, id, version string) (VersionDetails, error) {
+	version, err := service.resolveVersion(ctx, id, version)
+	if err != nil {
+		return VersionDetails{}, err
+	}
+	return service.Repository.GetSchemaVersionByIdAndVersion(ctx, id, version)
+}
+
+// GetSpecification gets the specification of a schema version, in its canonical form if canonical is set.
+func (service *Service) GetSpecification(ctx context.Context, id, version string, canonical bool) (Specification, error) {
+	details, err := service.GetSchemaVersion(ctx, id, version)
+	if err != nil {
+		return Specification{}, err
+	}
+	schema, err := service.Repository.GetSchemaMetadata(ctx, id)
+	if err != nil {
+		return Specification{}, err
+	}
+
+	content := details.Specification
+	if canonical {
+		canonicalSpec, err := canonicalizeSchema(content, strings.ToLower(schema.SchemaType))
+		if err != nil {
+			return Specification{}, errors.Wrap(ErrNotCanonicalizable, err.Error())
+		}
+		content = []byte(canonicalSpec)
+	}
+
+	return Specification{
+		SchemaID:   id,
+		Name:       schema.Name,
+		SchemaType: strings.ToLower(schema.SchemaType),
+		Version:    details.Version,
+		Content:    content,
+	}, nil
 }
 
 // ListSchemaVersions lists all active schema versions of a specific schema.
-func (service *Service) ListSchemaVersions(id string) (Schema, error) {
-	return service.Repository.GetSchemaVersionsById(id)
+func (service *Service) ListSchemaVersions(ctx context.Context, id string) (Schema, error) {
+	return service.Repository.GetSchemaVersionsById(ctx, id)
 }
 
 // ListAllSchemaVersions lists all schema versions of a specific schema.
-func (service *Service) ListAllSchemaVersions(id string) (Schema, error) {
-	return service.Repository.GetAllSchemaVersions(id)
+func (service *Service) ListAllSchemaVersions(ctx context.Context, id string) (Schema, error) {
+	return service.Repository.GetAllSchemaVersions(ctx, id)
 }
 
 // GetLatestSchemaVersion gets the latest version of a certain schema.
-func (service *Service) GetLatestSchemaVersion(id string) (VersionDetails, error) {
-	return service.Repository.GetLatestSchemaVersion(id)
+func (service *Service) GetLatestSchemaVersion(ctx context.Context, id string) (VersionDetails, error) {
+	return service.Repository.GetLatestSchemaVersion(ctx, id)
 }
 
 // GetSchemas gets all active schemas.
-func (service *Service) GetSchemas() ([]Schema, error) {
-	return service.Repository.GetSchemas()
+func (service *Service) GetSchemas(ctx context.Context) ([]Schema, error) {
+	return service.Repository.GetSchemas(ctx)
 }
 
 // GetAllSchemas gets all schemas.
-func (service *Service) GetAllSchemas() ([]Schema, error) {
-	return service.Repository.GetAllSchemas()
+func (service *Service) GetAllSchemas(ctx context.Context) ([]Schema, error) {
+	return service.Repository.GetAllSchemas(ctx)
+}
+
+// GetAuditRecords gets a page of the audit records matching the query, oldest first.
+func (service *Service) GetAuditRecords(ctx context.Context, query AuditQuery) ([]AuditRecord, error) {
+	if query.Limit <= 0 || query.Limit > MaxAuditLimit {
+		query.Limit = DefaultAuditLimit
+	}
+	return service.Repository.GetAuditRecords(ctx, query)
 }
 
 // SearchSchemas gets filtered schemas.
-func (service *Service) SearchSchemas(params QueryParams) ([]Schema, error) {
-	schemas, err := service.Repository.GetSchemas()
+func (service *Service) SearchSchemas(ctx context.Context, params QueryParams) ([]Schema, error) {
+	schemas, err := service.Repository.GetSchemas(ctx)
 	if err != nil {
 		return schemas, errors.Wrap(err, "couldn't retrieve schemas")
 	}
@@ -236,22 +355,28 @@ func containsAttributes(details VersionDetails, attributes []string) bool {
 }
 
 // CreateSchema creates a new schema.
-func (service *Service) CreateSchema(schemaRegisterRequest SchemaRegistrationRequest) (VersionDetails, bool, error) {
+func (service *Service) CreateSchema(ctx context.Context, schemaRegisterRequest SchemaRegistrationRequest) (VersionDetails, bool, error) {
 	if !compatibility.CheckIfValidMode(&schemaRegisterRequest.CompatibilityMode) {
 		return VersionDetails{}, false, ErrUnknownComp
 	}
 	if !validity.CheckIfValidMode(&schemaRegisterRequest.ValidityMode) {
 		return VersionDetails{}, false, ErrUnknownVal
 	}
-	valid, err := service.CheckValidity(schemaRegisterRequest.SchemaType, schemaRegisterRequest.Specification, schemaRegisterRequest.ValidityMode)
+	rules, err := normalizeRules(schemaRegisterRequest.Rules, schemaRegisterRequest.SchemaType)
+	if err != nil {
+		return VersionDetails{}, false, err
+	}
+	schemaRegisterRequest.Rules = rules
+	target := validity.LintTarget{Group: schemaRegisterRequest.PublisherID}
+	valid, violations, err := service.checkValidity(ctx, schemaRegisterRequest.SchemaType, schemaRegisterRequest.Specification, schemaRegisterRequest.ValidityMode, target)
 	if err != nil {
 		return VersionDetails{}, false, err
 	}
 	if !valid {
-		return VersionDetails{}, false, ErrNotValid
+		return VersionDetails{}, false, notValid(violations)
 	}
 	//cannot canonicalize schema that is invalid
-	if strings.ToLower(schemaRegisterRequest.ValidityMode) == "syntax-only" || strings.ToLower(schemaRegisterRequest.ValidityMode) == "full" {
+	if strings.ToLower(schemaRegisterRequest.ValidityMode) == "syntax-only" || strings.ToLower(schemaRegisterRequest.ValidityMode) == "full" || isLintMode(schemaRegisterRequest.ValidityMode) {
 		canonicalSpec, err := canonicalizeSchema([]byte(schemaRegisterRequest.Specification), strings.ToLower(schemaRegisterRequest.SchemaType))
 		if err != nil {
 			return VersionDetails{}, false, err
@@ -264,8 +389,21 @@ func (service *Service) CreateSchema(schemaRegisterRequest SchemaRegistrationReq
 		return VersionDetails{}, false, errors.Wrap(err, "unable to extract attributes")
 	}
 	schemaRegisterRequest.Attributes = attributes
+	schemaRegisterRequest.AvroFingerprint = avroFingerprintOf(schemaRegisterRequest.Specification, schemaRegisterRequest.SchemaType)
+	if service.SemanticVersioning {
+		schemaRegisterRequest.Semver = semver{major: 1}.String()
+	}
+
+	if err = service.admitCreate(ctx, &schemaRegisterRequest); err != nil {
+		return VersionDetails{}, false, err
+	}
 
-	return service.Repository.CreateSchema(schemaRegisterRequest)
+	details, added, err := service.Repository.CreateSchema(ctx, schemaRegisterRequest)
+	if err != nil {
+		return details, added, err
+	}
+	details.Violations = violations
+	return details, added, nil
 }
 
 // canonicalizeSchema converts the given schema to its canonical form
@@ -302,6 +440,10 @@ func canonicalizeSchema(specification []byte, schemaType string) (string, error)
 			return "", err
 		}
 		return strings.TrimSpace(schema.String()), nil
+	case "protobuf":
+		return canonicalizeProtobuf(specification)
+	case "xml":
+		return canonicalizeXML(specification)
 	default:
 		return strings.TrimSpace(string(specification)), nil
 	}
@@ -376,28 +518,39 @@ func flattenJSON(prefix string, nested interface{}, flat map[string]interface{},
 }
 
 // UpdateSchema updates the schemas by assigning a new version to it.
-func (service *Service) UpdateSchema(id string, schemaUpdateRequest SchemaUpdateRequest) (VersionDetails, bool, error) {
-	schemas, err := service.ListSchemaVersions(id)
+func (service *Service) UpdateSchema(ctx context.Context, id string, schemaUpdateRequest SchemaUpdateRequest) (VersionDetails, bool, error) {
+	schemas, err := service.ListSchemaVersions(ctx, id)
 	if err != nil {
 		return VersionDetails{}, false, err
 	}
 
-	valid, err := service.CheckValidity(schemas.SchemaType, schemaUpdateRequest.Specification, schemas.ValidityMode)
+	rules, err := normalizeRules(schemaUpdateRequest.Rules, schemas.SchemaType)
+	if err != nil {
+		return VersionDetails{}, false, err
+	}
+	schemaUpdateRequest.Rules = rules
+
+	var target validity.LintTarget
+	if isLintMode(schemas.ValidityMode) || schemas.ValidityMode == "" && isLintMode(service.GlobalValMode) {
+		target = lintTarget(schemas)
+	}
+	valid, violations, err := service.checkValidity(ctx, schemas.SchemaType, schemaUpdateRequest.Specification, schemas.ValidityMode, target)
 	if err != nil {
 		return VersionDetails{}, false, err
 	}
 	if !valid {
-		return VersionDetails{}, false, ErrNotValid
+		return VersionDetails{}, false, notValid(violations)
 	}
 
-	compatible, err := service.CheckCompatibility(schemaUpdateRequest.Specification, id)
+	compatible, err := service.CheckCompatibility(ctx, schemaUpdateRequest.Specification, id)
 	if err != nil {
 		return VersionDetails{}, false, err
 	}
-	if !compatible {
+	// under semantic versioning, an incompatible version can be let through as a breaking change, bumping the major
+	if !compatible && !(service.SemanticVersioning && schemaUpdateRequest.AllowBreakingChange) {
 		return VersionDetails{}, false, ErrNotComp
 	}
-	if strings.ToLower(schemas.ValidityMode) == "syntax-only" || strings.ToLower(schemas.ValidityMode) == "full" {
+	if strings.ToLower(schemas.ValidityMode) == "syntax-only" || strings.ToLower(schemas.ValidityMode) == "full" || isLintMode(schemas.ValidityMode) {
 		canonicalSpec, err := canonicalizeSchema([]byte(schemaUpdateRequest.Specification), strings.ToLower(schemas.SchemaType))
 		if err != nil {
 			return VersionDetails{}, false, err
@@ -410,23 +563,71 @@ func (service *Service) UpdateSchema(id string, schemaUpdateRequest SchemaUpdate
 		return VersionDetails{}, false, errors.Wrap(err, "unable to extract attributes")
 	}
 	schemaUpdateRequest.Attributes = attributes
+	schemaUpdateRequest.AvroFingerprint = avroFingerprintOf(schemaUpdateRequest.Specification, schemas.SchemaType)
+	if service.SemanticVersioning {
+		schemaUpdateRequest.Semver, err = service.nextSemver(ctx, id, schemas, schemaUpdateRequest.Specification, schemaUpdateRequest.AllowBreakingChange, !compatible)
+		if err != nil {
+			return VersionDetails{}, false, err
+		}
+	}
+
+	if err = service.admitUpdate(ctx, id, schemas, &schemaUpdateRequest); err != nil {
+		return VersionDetails{}, false, err
+	}
 
-	return service.Repository.UpdateSchemaById(id, schemaUpdateRequest)
+	details, updated, err := service.Repository.UpdateSchemaById(ctx, id, schemaUpdateRequest)
+	if err != nil {
+		return details, updated, err
+	}
+	details.Violations = violations
+	return details, updated, nil
+}
+
+// UpdateSchemaMetadata changes the name, description or publisher id of the schema, without registering a new version.
+// ErrInvalidMetadata is returned if the request changes nothing or leaves the schema without a name.
+func (service *Service) UpdateSchemaMetadata(ctx context.Context, id string, request SchemaMetadataRequest) (Schema, error) {
+	if request.Name == nil && request.Description == nil && request.PublisherID == nil {
+		return Schema{}, errors.Wrap(ErrInvalidMetadata, "at least one of name, description and publisher_id must be provided")
+	}
+	if request.Name != nil && strings.TrimSpace(*request.Name) == "" {
+		return Schema{}, errors.Wrap(ErrInvalidMetadata, "name must not be empty")
+	}
+	return service.Repository.UpdateSchemaMetadata(ctx, id, request)
+}
+
+// RestoreSchemaVersion reactivates a deactivated version of the schema under its original version number, unlike
+// registering its specification again, which reactivates it as a new version. The version can also be an alias.
+// The restored version isn't checked for compatibility, since it already was when it was registered.
+func (service *Service) RestoreSchemaVersion(ctx context.Context, id, version string) (VersionDetails, bool, error) {
+	version, err := service.resolveVersion(ctx, id, version)
+	if err != nil {
+		return VersionDetails{}, false, err
+	}
+	return service.Repository.RestoreSchemaVersion(ctx, id, version)
 }
 
 // DeleteSchema deletes the schema and its versions.
-func (service *Service) DeleteSchema(id string) (bool, error) {
-	return service.Repository.DeleteSchema(id)
+// Unless force is set, an InUseError is returned if any of its versions is in use.
+func (service *Service) DeleteSchema(ctx context.Context, id string, force bool) (bool, error) {
+	return service.Repository.DeleteSchema(ctx, id, service.usedSince(force))
 }
 
-// DeleteSchemaVersion deletes a specific version of a schema.
-func (service *Service) DeleteSchemaVersion(id, version string) (bool, error) {
-	return service.Repository.DeleteSchemaVersion(id, version)
+// DeleteSchemaVersion deletes a specific version of a schema. The version can also be an alias or a semantic version.
+// Unless force is set, an InUseError is returned if the version is in use.
+func (service *Service) DeleteSchemaVersion(ctx context.Context, id, version string, force bool) (bool, error) {
+	version, err := service.resolveVersion(ctx, id, version)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return service.Repository.DeleteSchemaVersion(ctx, id, version, service.usedSince(force))
 }
 
 // CheckCompatibility checks if schemas are compatible
-func (service *Service) CheckCompatibility(newSchema, id string) (bool, error) {
-	schemas, err := service.ListSchemaVersions(id)
+func (service *Service) CheckCompatibility(ctx context.Context, newSchema, id string) (bool, error) {
+	schemas, err := service.ListSchemaVersions(ctx, id)
 	if err != nil {
 		return false, err
 	}
@@ -442,20 +643,77 @@ func (service *Service) CheckCompatibility(newSchema, id string) (bool, error) {
 
 	var stringHistory []string
 	for _, el := range schemas.VersionDetails {
-		stringHistory = append(stringHistory, el.Specification)
+		stringHistory = append(stringHistory, string(el.Specification))
 	}
 	mode := schemas.CompatibilityMode
 	if schemas.CompatibilityMode == "" {
 		mode = service.GlobalCompMode
 	}
 
-	return service.CompChecker.Check(string(jsonMessage), stringHistory, mode)
+	return service.CompChecker.Check(ctx, string(jsonMessage), stringHistory, mode)
+}
+
+// GenerateExamples generates random example payloads of the schema version with the specific id and version.
+func (service *Service) GenerateExamples(ctx context.Context, id, version string, options examples.Options) (Examples, error) {
+	details, err := service.GetSchemaVersion(ctx, id, version)
+	if err != nil {
+		return Examples{}, err
+	}
+	schema, err := service.Repository.GetSchemaMetadata(ctx, id)
+	if err != nil {
+		return Examples{}, err
+	}
+
+	payloads, err := examples.Generate(details.Specification, strings.ToLower(schema.SchemaType), options)
+	if err != nil {
+		return Examples{}, err
+	}
+
+	return Examples{
+		SchemaID:   id,
+		Version:    details.Version,
+		SchemaType: schema.SchemaType,
+		Seed:       options.Seed,
+		Valid:      !options.Invalid,
+		Payloads:   payloads,
+	}, nil
+}
+
+// CheckValidity checks if a schema is valid. In the lint mode, the violations of the lint rules enabled for the schema
+// with the id and publisher id of the request are returned as well, with the schema being invalid if any of them is an error.
+func (service *Service) CheckValidity(ctx context.Context, request SchemaValidityRequest) (bool, []validity.Violation, error) {
+	mode := request.Mode
+	if mode == "" {
+		mode = service.GlobalValMode
+	}
+
+	var target validity.LintTarget
+	if isLintMode(mode) {
+		var err error
+		target, err = service.lookupLintTarget(ctx, request.SchemaID)
+		if err != nil {
+			return false, nil, err
+		}
+		if request.PublisherID != "" {
+			target.Group = request.PublisherID
+		}
+	}
+	return service.checkValidity(ctx, request.Format, request.NewSchema, mode, target)
 }
 
-// CheckValidity checks if a schema is valid
-func (service *Service) CheckValidity(schemaType, newSchema, mode string) (bool, error) {
+func (service *Service) checkValidity(ctx context.Context, schemaType, newSchema, mode string, target validity.LintTarget) (bool, []validity.Violation, error) {
 	if mode == "" {
 		mode = service.GlobalValMode
 	}
-	return service.ValChecker.Check(newSchema, schemaType, mode)
+	valid, err := service.ValChecker.Check(ctx, newSchema, schemaType, mode)
+	if err != nil || !valid || !isLintMode(mode) || service.Linter == nil {
+		return valid, nil, err
+	}
+
+	violations, err := service.Linter.Lint(newSchema, schemaType, target)
+	if err != nil {
+		// the checker accepted a schema the linter can't parse, so it is invalid with the message of the parser
+		return false, []validity.Violation{validity.ParseViolation(err)}, nil
+	}
+	return !validity.HasErrors(violations), violations, nil
 }
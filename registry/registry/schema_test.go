@@ -15,13 +15,19 @@
 package registry
 
 import (
+	"context"
+	"strings"
 	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/dataphos/schema-registry/validity"
 )
 
 func Test_DeleteSchema(t *testing.T) {
 	repo := NewMockRepository()
 	repo.SetGetSchemaVersionsByIdResponse("mocking", MockSchema("mocking"), nil)
-	deleted, err := (*Service).DeleteSchema(New(repo, &mockCompChecker{}, &mockValChecker{}, "none", "none"), "mocking")
+	deleted, err := (*Service).DeleteSchema(New(repo, &mockCompChecker{}, &mockValChecker{}, "none", "none"), context.Background(), "mocking", false)
 	if err != nil {
 		t.Errorf("returned error")
 	}
@@ -31,7 +37,7 @@ func Test_DeleteSchema(t *testing.T) {
 }
 
 func Test_DeleteSchemaVersion(t *testing.T) {
-	deleted, err := (*Service).DeleteSchemaVersion(New(&mockRepository{}, &mockCompChecker{}, &mockValChecker{}, "none", "none"), "mocking", "mocking")
+	deleted, err := (*Service).DeleteSchemaVersion(New(&mockRepository{}, &mockCompChecker{}, &mockValChecker{}, "none", "none"), context.Background(), "mocking", "mocking", false)
 	if err != nil {
 		t.Errorf("returned error")
 	}
@@ -41,21 +47,21 @@ func Test_DeleteSchemaVersion(t *testing.T) {
 }
 
 func Test_GetAllSchemas(t *testing.T) {
-	schemas, _ := (*Service).GetAllSchemas(New(&mockRepository{}, &mockCompChecker{}, &mockValChecker{}, "none", "none"))
+	schemas, _ := (*Service).GetAllSchemas(New(&mockRepository{}, &mockCompChecker{}, &mockValChecker{}, "none", "none"), context.Background())
 	if schemas[0].SchemaID != "mocking" {
 		t.Errorf("wrong schemaId returned")
 	}
 }
 
 func Test_GetSchemas(t *testing.T) {
-	schemas, _ := (*Service).GetAllSchemas(New(&mockRepository{}, &mockCompChecker{}, &mockValChecker{}, "none", "none"))
+	schemas, _ := (*Service).GetAllSchemas(New(&mockRepository{}, &mockCompChecker{}, &mockValChecker{}, "none", "none"), context.Background())
 	if schemas[0].SchemaID != "mocking" {
 		t.Errorf("wrong schemaId returned")
 	}
 }
 
 func Test_GetLatestSchemaVersion(t *testing.T) {
-	VersionDetails, _ := (*Service).GetLatestSchemaVersion(New(&mockRepository{}, &mockCompChecker{}, &mockValChecker{}, "none", "none"), "mocking")
+	VersionDetails, _ := (*Service).GetLatestSchemaVersion(New(&mockRepository{}, &mockCompChecker{}, &mockValChecker{}, "none", "none"), context.Background(), "mocking")
 	if VersionDetails.VersionID != "mocking" {
 		t.Errorf("wrong schemaId returned")
 	}
@@ -71,7 +77,7 @@ func Test_CreateSchema(t *testing.T) {
 		ValidityMode:      "none",
 		CompatibilityMode: "none",
 	}
-	VersionDetails, added, err := (*Service).CreateSchema(New(&mockRepository{}, &mockCompChecker{}, &mockValChecker{}, "none", "none"), sdto)
+	VersionDetails, added, err := (*Service).CreateSchema(New(&mockRepository{}, &mockCompChecker{}, &mockValChecker{}, "none", "none"), context.Background(), sdto)
 	if err != nil {
 		t.Errorf("returned error")
 	}
@@ -85,8 +91,62 @@ func Test_CreateSchema(t *testing.T) {
 	}
 }
 
+func Test_CreateSchemaLint(t *testing.T) {
+	service := New(&mockRepository{}, &mockCompChecker{}, &mockValChecker{}, "none", "none")
+	sdto := SchemaRegistrationRequest{
+		Specification:     `{"type":"object","properties":{"orderId":{"type":"string"}}}`,
+		Name:              "mocking",
+		SchemaType:        "json",
+		ValidityMode:      "lint",
+		CompatibilityMode: "none",
+	}
+	_, _, err := service.CreateSchema(context.Background(), sdto)
+	var violationsErr *ViolationsError
+	if !errors.As(err, &violationsErr) || !errors.Is(err, ErrNotValid) {
+		t.Fatalf("expected a violations error, got %v", err)
+	}
+	if len(violationsErr.Violations) != 2 {
+		t.Errorf("expected 2 violations, got %v", violationsErr.Violations)
+	}
+
+	sdto.Specification = `{"type":"object","properties":{"order_id":{"type":"string"}}}`
+	details, added, err := service.CreateSchema(context.Background(), sdto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !added {
+		t.Errorf("could not add schema")
+	}
+	if len(details.Violations) != 1 || details.Violations[0].Rule != "field-description" {
+		t.Errorf("expected a field-description warning, got %v", details.Violations)
+	}
+}
+
+func Test_CreateSchemaLintUnparsable(t *testing.T) {
+	service := New(&mockRepository{}, &mockCompChecker{}, &mockValChecker{}, "none", "none")
+	sdto := SchemaRegistrationRequest{
+		Specification:     `{"type":"object",`,
+		Name:              "mocking",
+		SchemaType:        "json",
+		ValidityMode:      "lint",
+		CompatibilityMode: "none",
+	}
+	_, _, err := service.CreateSchema(context.Background(), sdto)
+	var violationsErr *ViolationsError
+	if !errors.As(err, &violationsErr) || !errors.Is(err, ErrNotValid) {
+		t.Fatalf("expected a violations error, got %v", err)
+	}
+	if len(violationsErr.Violations) != 1 {
+		t.Fatalf("expected 1 violation, got %v", violationsErr.Violations)
+	}
+	violation := violationsErr.Violations[0]
+	if violation.Rule != validity.RuleParse || violation.Severity != validity.SeverityError || !strings.Contains(violation.Message, "couldn't parse json schema") {
+		t.Errorf("expected a parse error with the message of the parser, got %v", violation)
+	}
+}
+
 func Test_GetSchemaVersion(t *testing.T) {
-	VersionDetails, _ := (*Service).GetSchemaVersion(New(&mockRepository{}, &mockCompChecker{}, &mockValChecker{}, "none", "none"), "mocking", "mocking")
+	VersionDetails, _ := (*Service).GetSchemaVersion(New(&mockRepository{}, &mockCompChecker{}, &mockValChecker{}, "none", "none"), context.Background(), "mocking", "mocking")
 	if VersionDetails.SchemaID != "mocking" {
 		t.Errorf("wrong schemaId returned")
 	}
@@ -97,7 +157,7 @@ func Test_UpdateSchema(t *testing.T) {
 		Description:   "mocking",
 		Specification: "mocking",
 	}
-	VersionDetails, added, err := (*Service).UpdateSchema(New(&mockRepository{}, &mockCompChecker{}, &mockValChecker{}, "none", "none"), "mocking", sdto)
+	VersionDetails, added, err := (*Service).UpdateSchema(New(&mockRepository{}, &mockCompChecker{}, &mockValChecker{}, "none", "none"), context.Background(), "mocking", sdto)
 	if err != nil {
 		t.Errorf("returned error")
 	}
@@ -114,7 +174,7 @@ func Test_UpdateSchema(t *testing.T) {
 func Test_GetSchemaVersionsById(t *testing.T) {
 	repo := NewMockRepository()
 	repo.SetGetSchemaVersionsByIdResponse("mocking", MockSchema("mocking"), nil)
-	schema, _ := (*Service).ListSchemaVersions(New(repo, &mockCompChecker{}, &mockValChecker{}, "none", "none"), "mocking")
+	schema, _ := (*Service).ListSchemaVersions(New(repo, &mockCompChecker{}, &mockValChecker{}, "none", "none"), context.Background(), "mocking")
 	if schema.SchemaID != "mocking" {
 		t.Errorf("wrong schema ID returned")
 	}
@@ -123,7 +183,7 @@ func Test_GetSchemaVersionsById(t *testing.T) {
 func Test_GetAllSchemaVersions(t *testing.T) {
 	repo := NewMockRepository()
 	repo.SetGetSchemaVersionsByIdResponse("mocking", MockSchema("mocking"), nil)
-	schema, _ := (*Service).ListAllSchemaVersions(New(repo, &mockCompChecker{}, &mockValChecker{}, "none", "none"), "mocking")
+	schema, _ := (*Service).ListAllSchemaVersions(New(repo, &mockCompChecker{}, &mockValChecker{}, "none", "none"), context.Background(), "mocking")
 	if schema.SchemaID != "mocking" {
 		t.Errorf("wrong schema ID returned")
 	}
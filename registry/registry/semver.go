@@ -0,0 +1,283 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The kinds of change between two schema versions, which determine the part of the semantic version that is bumped.
+const (
+	ChangeMajor = "major"
+	ChangeMinor = "minor"
+	ChangePatch = "patch"
+)
+
+// semverMode is the compatibility mode a change must satisfy not to be a major change.
+const semverMode = "BACKWARD"
+
+var semverPattern = regexp.MustCompile(`^(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)$`)
+
+// IsSemver returns true if the version is a semantic version, like 1.4.2, rather than a version number.
+func IsSemver(version string) bool {
+	return semverPattern.MatchString(version)
+}
+
+// semver is a parsed semantic version.
+type semver struct {
+	major, minor, patch int
+}
+
+func parseSemver(version string) (semver, bool) {
+	match := semverPattern.FindStringSubmatch(version)
+	if match == nil {
+		return semver{}, false
+	}
+	major, _ := strconv.Atoi(match[1])
+	minor, _ := strconv.Atoi(match[2])
+	patch, _ := strconv.Atoi(match[3])
+	return semver{major: major, minor: minor, patch: patch}, true
+}
+
+func (v semver) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.major, v.minor, v.patch)
+}
+
+func (v semver) less(other semver) bool {
+	if v.major != other.major {
+		return v.major < other.major
+	}
+	if v.minor != other.minor {
+		return v.minor < other.minor
+	}
+	return v.patch < other.patch
+}
+
+// bump returns the version following v after a change of the given kind.
+func (v semver) bump(change string) semver {
+	switch change {
+	case ChangeMajor:
+		return semver{major: v.major + 1}
+	case ChangeMinor:
+		return semver{major: v.major, minor: v.minor + 1}
+	default:
+		return semver{major: v.major, minor: v.minor, patch: v.patch + 1}
+	}
+}
+
+// nextSemver returns the semantic version of the given specification, registered as a new version of the schema.
+//
+// The change is classified against the latest active version: a change of the documentation only is a patch, a change
+// compatible in the BACKWARD mode is a minor change, and any other change is a major change, which is refused with
+// ErrBreakingChange unless the compatibility mode of the schema is none or allowBreaking is set. A specification which
+// failed the compatibility check of the schema is always a major change. The bumped version is the highest semantic
+// version the schema ever had, so deactivated versions are never reused.
+func (service *Service) nextSemver(ctx context.Context, id string, schema Schema, specification string, allowBreaking, incompatible bool) (string, error) {
+	all, err := service.Repository.GetSchemaMetadata(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	var highest semver
+	for _, details := range all.VersionDetails {
+		current, ok := parseSemver(details.Semver)
+		if !ok {
+			// versions registered before semantic versioning was enabled count as major versions
+			current = semver{major: versionNumber(details.Version)}
+		}
+		if highest.less(current) {
+			highest = current
+		}
+	}
+
+	latest := latestVersion(schema)
+	change := ChangeMajor
+	if !incompatible {
+		change, err = service.classifyChange(ctx, id, schema.SchemaType, latest, specification)
+		if err != nil {
+			return "", err
+		}
+	}
+	if change == ChangeMajor && !allowBreaking && !isNoneMode(schema.CompatibilityMode, service.GlobalCompMode) {
+		return "", errors.Wrapf(ErrBreakingChange, "the change isn't %s compatible with version %s, which requires the compatibility mode none or allow_breaking_change", semverMode, latest.Version)
+	}
+	return highest.bump(change).String(), nil
+}
+
+// classifyChange returns the kind of change the specification makes to the given version.
+func (service *Service) classifyChange(ctx context.Context, id, schemaType string, previous VersionDetails, specification string) (string, error) {
+	if sameStructure(string(previous.Specification), specification, strings.ToLower(schemaType)) {
+		return ChangePatch, nil
+	}
+
+	schemaInfo, err := json.Marshal(map[string]string{
+		"id":     id,
+		"format": schemaType,
+		"schema": specification,
+	})
+	if err != nil {
+		return "", err
+	}
+	compatible, err := service.CompChecker.Check(ctx, string(schemaInfo), []string{string(previous.Specification)}, semverMode)
+	if err != nil {
+		return "", err
+	}
+	if compatible {
+		return ChangeMinor, nil
+	}
+	return ChangeMajor, nil
+}
+
+// latestVersion returns the version of the schema with the highest version number, the schema must have versions.
+func latestVersion(schema Schema) VersionDetails {
+	latest := schema.VersionDetails[0]
+	for _, details := range schema.VersionDetails {
+		if versionNumber(details.Version) > versionNumber(latest.Version) {
+			latest = details
+		}
+	}
+	return latest
+}
+
+// resolveSemver returns the number of the active version of the schema with the given semantic version.
+func (service *Service) resolveSemver(ctx context.Context, id, version string) (string, error) {
+	if _, err := strconv.Atoi(id); err != nil {
+		return "", ErrInvalidValueHeader
+	}
+	schema, err := service.Repository.GetSchemaMetadata(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	for _, details := range schema.VersionDetails {
+		if !details.VersionDeactivated && details.Semver == version {
+			return details.Version, nil
+		}
+	}
+	return "", ErrNotFound
+}
+
+func isNoneMode(mode, globalMode string) bool {
+	if mode == "" {
+		mode = globalMode
+	}
+	return strings.EqualFold(mode, "none")
+}
+
+// sameStructure returns true if the specifications differ only in their documentation and formatting.
+func sameStructure(previous, specification, schemaType string) bool {
+	switch schemaType {
+	case "json", "avro":
+		var previousSchema, schema interface{}
+		if json.Unmarshal([]byte(previous), &previousSchema) != nil || json.Unmarshal([]byte(specification), &schema) != nil {
+			return false
+		}
+		strip := stripJSONDocumentation
+		if schemaType == "avro" {
+			strip = stripAvroDocumentation
+		}
+		previousEncoded, err := json.Marshal(strip(previousSchema))
+		if err != nil {
+			return false
+		}
+		encoded, err := json.Marshal(strip(schema))
+		if err != nil {
+			return false
+		}
+		return string(previousEncoded) == string(encoded)
+	case "protobuf":
+		return strings.Join(strings.Fields(protobufComment.ReplaceAllString(previous, " ")), " ") ==
+			strings.Join(strings.Fields(protobufComment.ReplaceAllString(specification, " ")), " ")
+	default:
+		return strings.Join(strings.Fields(previous), " ") == strings.Join(strings.Fields(specification), " ")
+	}
+}
+
+// jsonDocumentationKeywords are the JSON Schema keywords which don't affect validation.
+var jsonDocumentationKeywords = map[string]bool{
+	"title":       true,
+	"description": true,
+	"$comment":    true,
+	"examples":    true,
+}
+
+// jsonSchemaMaps are the JSON Schema keywords whose values map names to subschemas, so their keys aren't keywords.
+var jsonSchemaMaps = map[string]bool{
+	"properties":        true,
+	"patternProperties": true,
+	"definitions":       true,
+	"$defs":             true,
+	"dependentSchemas":  true,
+}
+
+// stripJSONDocumentation returns the JSON schema without its documentation keywords.
+func stripJSONDocumentation(schema interface{}) interface{} {
+	switch node := schema.(type) {
+	case map[string]interface{}:
+		stripped := make(map[string]interface{}, len(node))
+		for key, value := range node {
+			if jsonDocumentationKeywords[key] {
+				continue
+			}
+			if named, ok := value.(map[string]interface{}); ok && jsonSchemaMaps[key] {
+				subschemas := make(map[string]interface{}, len(named))
+				for name, subschema := range named {
+					subschemas[name] = stripJSONDocumentation(subschema)
+				}
+				stripped[key] = subschemas
+				continue
+			}
+			stripped[key] = stripJSONDocumentation(value)
+		}
+		return stripped
+	case []interface{}:
+		stripped := make([]interface{}, len(node))
+		for i, value := range node {
+			stripped[i] = stripJSONDocumentation(value)
+		}
+		return stripped
+	default:
+		return schema
+	}
+}
+
+// stripAvroDocumentation returns the Avro schema without its doc attributes.
+func stripAvroDocumentation(schema interface{}) interface{} {
+	switch node := schema.(type) {
+	case map[string]interface{}:
+		stripped := make(map[string]interface{}, len(node))
+		for key, value := range node {
+			if key != "doc" {
+				stripped[key] = stripAvroDocumentation(value)
+			}
+		}
+		return stripped
+	case []interface{}:
+		stripped := make([]interface{}, len(node))
+		for i, value := range node {
+			stripped[i] = stripAvroDocumentation(value)
+		}
+		return stripped
+	default:
+		return schema
+	}
+}
+
+var protobufComment = regexp.MustCompile(`(?s)//[^\n]*|/\*.*?\*/`)
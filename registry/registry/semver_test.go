@@ -0,0 +1,170 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// breakingCompChecker reports every schema containing "breaking" as incompatible.
+type breakingCompChecker struct{}
+
+func (breakingCompChecker) Check(_ context.Context, schemaInfo string, _ []string, _ string) (bool, error) {
+	return !strings.Contains(schemaInfo, "breaking"), nil
+}
+
+func TestIsSemver(t *testing.T) {
+	tt := []struct {
+		version string
+		semver  bool
+	}{
+		{"1.0.0", true},
+		{"10.20.30", true},
+		{"1", false},
+		{"1.0", false},
+		{"01.0.0", false},
+		{"1.0.0-rc.1", false},
+		{"prod", false},
+	}
+
+	for _, tc := range tt {
+		if got := IsSemver(tc.version); got != tc.semver {
+			t.Errorf("IsSemver(%q): expected %t, got %t", tc.version, tc.semver, got)
+		}
+	}
+}
+
+func TestSameStructure(t *testing.T) {
+	tt := []struct {
+		name          string
+		schemaType    string
+		previous      string
+		specification string
+		same          bool
+	}{
+		{
+			name:          "json description",
+			schemaType:    "json",
+			previous:      `{"type":"object","properties":{"a":{"type":"string"}}}`,
+			specification: `{"type": "object", "description": "a thing", "properties": {"a": {"type": "string", "title": "A"}}}`,
+			same:          true,
+		},
+		{
+			name:          "json property named description",
+			schemaType:    "json",
+			previous:      `{"type":"object","properties":{"a":{"type":"string"}}}`,
+			specification: `{"type":"object","properties":{"a":{"type":"string"},"description":{"type":"string"}}}`,
+			same:          false,
+		},
+		{
+			name:          "avro doc",
+			schemaType:    "avro",
+			previous:      `{"type":"record","name":"r","fields":[{"name":"a","type":"int"}]}`,
+			specification: `{"type":"record","name":"r","doc":"a record","fields":[{"name":"a","type":"int","doc":"a field"}]}`,
+			same:          true,
+		},
+		{
+			name:          "avro field",
+			schemaType:    "avro",
+			previous:      `{"type":"record","name":"r","fields":[{"name":"a","type":"int"}]}`,
+			specification: `{"type":"record","name":"r","fields":[{"name":"a","type":"int"},{"name":"b","type":["null","int"],"default":null}]}`,
+			same:          false,
+		},
+		{
+			name:          "protobuf comments",
+			schemaType:    "protobuf",
+			previous:      "syntax = \"proto3\";\nmessage M {\n  string a = 1;\n}",
+			specification: "// a message\nsyntax = \"proto3\";\nmessage M { /* the a */ string a = 1; }",
+			same:          true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := sameStructure(tc.previous, tc.specification, tc.schemaType); got != tc.same {
+				t.Errorf("expected %t, got %t", tc.same, got)
+			}
+		})
+	}
+}
+
+func Test_NextSemver(t *testing.T) {
+	previous := `{"type":"object","properties":{"a":{"type":"string"}}}`
+	schema := Schema{
+		SchemaID:          "1",
+		SchemaType:        "json",
+		CompatibilityMode: "BACKWARD",
+		VersionDetails: []VersionDetails{
+			{VersionID: "1", Version: "1", SchemaID: "1", Semver: "1.0.0", Specification: []byte(`{}`)},
+			{VersionID: "2", Version: "2", SchemaID: "1", Semver: "1.1.0", Specification: []byte(previous)},
+		},
+	}
+	repo := NewMockRepository()
+	repo.SetGetSchemaVersionsByIdResponse("1", schema, nil)
+	service := New(repo, breakingCompChecker{}, &mockValChecker{}, "BACKWARD", "none")
+	ctx := context.Background()
+
+	tt := []struct {
+		name          string
+		specification string
+		allowBreaking bool
+		incompatible  bool
+		semver        string
+		err           error
+	}{
+		{"patch", `{"type":"object","description":"documented","properties":{"a":{"type":"string"}}}`, false, false, "1.1.1", nil},
+		{"minor", `{"type":"object","properties":{"a":{"type":"string"},"b":{"type":"string"}}}`, false, false, "1.2.0", nil},
+		{"refused major", `{"type":"object","properties":{"breaking":{"type":"string"}}}`, false, false, "", ErrBreakingChange},
+		{"allowed major", `{"type":"object","properties":{"breaking":{"type":"string"}}}`, true, false, "2.0.0", nil},
+		{"incompatible", `{"type":"object","properties":{"a":{"type":"string"},"b":{"type":"string"}}}`, true, true, "2.0.0", nil},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			semver, err := service.nextSemver(ctx, "1", schema, tc.specification, tc.allowBreaking, tc.incompatible)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected error %v, got %v", tc.err, err)
+			}
+			if semver != tc.semver {
+				t.Errorf("expected semver %q, got %q", tc.semver, semver)
+			}
+		})
+	}
+
+	noneSchema := schema
+	noneSchema.CompatibilityMode = "none"
+	semver, err := service.nextSemver(ctx, "1", noneSchema, `{"type":"object","properties":{"breaking":{"type":"string"}}}`, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if semver != "2.0.0" {
+		t.Errorf("expected semver 2.0.0 in the none mode, got %q", semver)
+	}
+
+	version, err := service.resolveSemver(ctx, "1", "1.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != "2" {
+		t.Errorf("expected 1.1.0 to resolve to version 2, got %q", version)
+	}
+	if _, err = service.resolveSemver(ctx, "1", "3.0.0"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an unknown semver, got %v", err)
+	}
+}
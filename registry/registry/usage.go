@@ -0,0 +1,113 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const defaultUsageTTL = 5 * time.Minute
+
+// InUseError is returned when a schema or a schema version can't be deleted because services still use it.
+type InUseError struct {
+	Usage []Usage
+}
+
+func (e *InUseError) Error() string {
+	return fmt.Sprintf("schema is used by %d services", len(e.Usage))
+}
+
+// Unwrap returns ErrInUse, so callers not interested in the usage can handle it like any other refusal.
+func (e *InUseError) Unwrap() error {
+	return ErrInUse
+}
+
+// RegisterUsage registers that a service produces or consumes the given schema version on a topic, or refreshes the
+// registration if it already exists. Services send it periodically as a heartbeat, since the usage expires after
+// the usage TTL. The version can be an alias, the usage is registered for the version it points at.
+func (service *Service) RegisterUsage(ctx context.Context, id, version string, request UsageRegistrationRequest) (Usage, error) {
+	role := strings.ToLower(request.Role)
+	if role != UsageRoleProducer && role != UsageRoleConsumer {
+		return Usage{}, errors.Wrapf(ErrInvalidUsage, "role must be either %s or %s", UsageRoleProducer, UsageRoleConsumer)
+	}
+	if request.Topic == "" {
+		return Usage{}, errors.Wrap(ErrInvalidUsage, "topic must be provided")
+	}
+
+	details, err := service.GetSchemaVersion(ctx, id, version)
+	if err != nil {
+		return Usage{}, err
+	}
+
+	clientID := request.ClientID
+	if clientID == "" {
+		// the client id only tells the usages apart, so a claimed actor is good enough if none was verified
+		info := AuditInfoFromContext(ctx)
+		clientID = info.Actor
+		if clientID == AnonymousActor && info.ClaimedActor != "" {
+			clientID = info.ClaimedActor
+		}
+	}
+	usage := Usage{
+		SchemaID: id,
+		Version:  details.Version,
+		Topic:    request.Topic,
+		Role:     role,
+		ClientID: clientID,
+		LastSeen: time.Now().UTC(),
+	}
+	if err = service.Repository.RegisterUsage(ctx, usage); err != nil {
+		return Usage{}, err
+	}
+	return usage, nil
+}
+
+// GetUsage returns the live usage of the schema, or of one of its versions if the version isn't empty, that is,
+// the usage registered or refreshed within the usage TTL.
+func (service *Service) GetUsage(ctx context.Context, id, version string) ([]Usage, error) {
+	version, err := service.resolveVersion(ctx, id, version)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return service.Repository.GetUsage(ctx, UsageQuery{
+		SchemaID: id,
+		Version:  version,
+		Since:    time.Now().Add(-service.usageTTL()),
+	})
+}
+
+// usedSince returns the time since which a usage keeps a schema version in use, so it can't be deleted, or the zero
+// time if the deletion is forced.
+func (service *Service) usedSince(force bool) time.Time {
+	if force {
+		return time.Time{}
+	}
+	return time.Now().Add(-service.usageTTL())
+}
+
+func (service *Service) usageTTL() time.Duration {
+	if service.UsageTTL > 0 {
+		return service.UsageTTL
+	}
+	return defaultUsageTTL
+}
@@ -0,0 +1,144 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// usageRepository is a mockRepository which stores usage.
+type usageRepository struct {
+	*mockRepository
+	usage []Usage
+}
+
+func (r *usageRepository) RegisterUsage(_ context.Context, usage Usage) error {
+	r.usage = append(r.usage, usage)
+	return nil
+}
+
+func (r *usageRepository) GetUsage(_ context.Context, query UsageQuery) ([]Usage, error) {
+	var usages []Usage
+	for _, usage := range r.usage {
+		if (query.Version == "" || usage.Version == query.Version) && !usage.LastSeen.Before(query.Since) {
+			usages = append(usages, usage)
+		}
+	}
+	return usages, nil
+}
+
+// DeleteSchema and DeleteSchemaVersion fail like the repositories do if an active version was used since usedSince.
+func (r *usageRepository) DeleteSchema(ctx context.Context, id string, usedSince time.Time) (bool, error) {
+	return r.DeleteSchemaVersion(ctx, id, "", usedSince)
+}
+
+func (r *usageRepository) DeleteSchemaVersion(_ context.Context, id, version string, usedSince time.Time) (bool, error) {
+	if usedSince.IsZero() {
+		return true, nil
+	}
+	var usages []Usage
+	for _, details := range r.getSchemaVersionsResponse[id].schema.VersionDetails {
+		if version != "" && details.Version != version {
+			continue
+		}
+		for _, usage := range r.usage {
+			if usage.Version == details.Version && !usage.LastSeen.Before(usedSince) {
+				usages = append(usages, usage)
+			}
+		}
+	}
+	if len(usages) > 0 {
+		return false, &InUseError{Usage: usages}
+	}
+	return true, nil
+}
+
+func Test_RegisterUsage(t *testing.T) {
+	repo := &usageRepository{mockRepository: NewMockRepository()}
+	service := New(repo, &mockCompChecker{}, &mockValChecker{}, "none", "none")
+	ctx := ContextWithAuditInfo(context.Background(), AuditInfo{Actor: "orders-service"})
+
+	if _, err := service.RegisterUsage(ctx, "mocking", "1", UsageRegistrationRequest{Topic: "orders", Role: "relay"}); !errors.Is(err, ErrInvalidUsage) {
+		t.Errorf("expected ErrInvalidUsage for an unknown role, got %v", err)
+	}
+	if _, err := service.RegisterUsage(ctx, "mocking", "1", UsageRegistrationRequest{Role: UsageRoleProducer}); !errors.Is(err, ErrInvalidUsage) {
+		t.Errorf("expected ErrInvalidUsage without a topic, got %v", err)
+	}
+
+	usage, err := service.RegisterUsage(ctx, "mocking", "1", UsageRegistrationRequest{Topic: "orders", Role: "Producer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Role != UsageRoleProducer || usage.ClientID != "orders-service" {
+		t.Errorf("expected the role to be lowercased and the actor to be the client id, got %+v", usage)
+	}
+
+	claimed := ContextWithAuditInfo(context.Background(), AuditInfo{ClaimedActor: "billing-service"})
+	usage, err = service.RegisterUsage(claimed, "mocking", "1", UsageRegistrationRequest{Topic: "invoices", Role: UsageRoleConsumer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.ClientID != "billing-service" {
+		t.Errorf("expected the claimed actor to be the client id without a verified one, got %+v", usage)
+	}
+	repo.usage = repo.usage[:1]
+
+	// usage which wasn't refreshed within the TTL isn't live anymore
+	repo.usage = append(repo.usage, Usage{SchemaID: "mocking", Version: "2", LastSeen: time.Now().Add(-2 * service.UsageTTL)})
+	live, err := service.GetUsage(ctx, "mocking", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(live) != 1 || live[0].Version != "1" {
+		t.Errorf("expected only the usage of version 1 to be live, got %+v", live)
+	}
+}
+
+func Test_DeleteSchemaVersionInUse(t *testing.T) {
+	repo := &usageRepository{mockRepository: NewMockRepository()}
+	schema := MockSchema("mocking")
+	schema.VersionDetails = []VersionDetails{MockVersionDetails("1", "1")}
+	repo.SetGetSchemaVersionsByIdResponse("mocking", schema, nil)
+	service := New(repo, &mockCompChecker{}, &mockValChecker{}, "none", "none")
+
+	// version 2 is deactivated, so its usage doesn't prevent anything
+	repo.usage = []Usage{
+		{SchemaID: "mocking", Version: "1", Topic: "orders", Role: UsageRoleConsumer, LastSeen: time.Now()},
+		{SchemaID: "mocking", Version: "2", Topic: "orders", Role: UsageRoleProducer, LastSeen: time.Now()},
+	}
+
+	_, err := service.DeleteSchemaVersion(context.Background(), "mocking", "1", false)
+	var inUseErr *InUseError
+	if !errors.As(err, &inUseErr) || len(inUseErr.Usage) != 1 {
+		t.Fatalf("expected an InUseError with the usage of version 1, got %v", err)
+	}
+	if !errors.Is(err, ErrInUse) {
+		t.Error("expected the InUseError to unwrap to ErrInUse")
+	}
+
+	if _, err = service.DeleteSchemaVersion(context.Background(), "mocking", "2", false); err != nil {
+		t.Errorf("expected the usage of a deactivated version to be ignored, got %v", err)
+	}
+	if _, err = service.DeleteSchema(context.Background(), "mocking", false); !errors.Is(err, ErrInUse) {
+		t.Errorf("expected the schema to be in use, got %v", err)
+	}
+	if deleted, err := service.DeleteSchema(context.Background(), "mocking", true); err != nil || !deleted {
+		t.Errorf("expected the forced deletion to succeed, got %t, %v", deleted, err)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dataphos/schema-registry/admission"
+	"github.com/dataphos/schema-registry/compatibility"
+	"github.com/dataphos/schema-registry/registry"
+	"github.com/dataphos/schema-registry/validity"
+)
+
+func TestAdmissionWebhooks(t *testing.T) {
+	// the stub only admits schemas of the payments publisher, and marks the reviewed ones in their description
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var review registry.AdmissionReview
+		if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if review.PublisherID != "payments" {
+			_ = json.NewEncoder(w).Encode(registry.AdmissionResponse{Reason: "publisher isn't allowed in the payments domain"})
+			return
+		}
+		description := review.Description + " (reviewed)"
+		_ = json.NewEncoder(w).Encode(registry.AdmissionResponse{Allowed: true, Metadata: &registry.SchemaMetadataRequest{Description: &description}})
+	}))
+	defer stub.Close()
+
+	compChecker := compatibility.CheckerFunc(func(_ context.Context, _ string, _ []string, _ string) (bool, error) {
+		return true, nil
+	})
+	valChecker := validity.CheckerFunc(func(_ context.Context, _, _, _ string) (bool, error) {
+		return true, nil
+	})
+	service := registry.New(newMemoryRepository(), compChecker, valChecker, "BACKWARD", "none")
+	service.AdmissionWebhooks = []registry.AdmissionWebhook{admission.New("domains", stub.URL, time.Second, false)}
+	srv := httptest.NewServer(New(NewHandler(service, newTestLogger())))
+	defer srv.Close()
+
+	do := func(method, path, body string, status int) string {
+		request, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer response.Body.Close()
+		encoded, _ := io.ReadAll(response.Body)
+		if response.StatusCode != status {
+			t.Fatalf("%s %s: expected status %d, got %d: %s", method, path, status, response.StatusCode, encoded)
+		}
+		return string(encoded)
+	}
+
+	denied := do(http.MethodPost, "/schemas", `{"name":"campaign","schema_type":"json","specification":"{}","publisher_id":"marketing","compatibility_mode":"none","validity_mode":"none"}`, http.StatusForbidden)
+	if !strings.Contains(denied, "publisher isn't allowed in the payments domain") {
+		t.Errorf("expected the denial reason in the response, got %s", denied)
+	}
+
+	do(http.MethodPost, "/schemas", `{"name":"order","description":"orders","schema_type":"json","specification":"{}","publisher_id":"payments","compatibility_mode":"none","validity_mode":"none"}`, http.StatusCreated)
+	var details registry.VersionDetails
+	if err := json.Unmarshal([]byte(do(http.MethodGet, "/schemas/1/versions/1", "", http.StatusOK)), &details); err != nil {
+		t.Fatal(err)
+	}
+	if details.Description != "orders (reviewed)" {
+		t.Errorf("expected the description changed by the webhook, got %q", details.Description)
+	}
+
+	stub.Close()
+	do(http.MethodPut, "/schemas/1", `{"specification":"{\"type\":\"object\"}"}`, http.StatusServiceUnavailable)
+}
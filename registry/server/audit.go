@@ -0,0 +1,65 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/dataphos/schema-registry/registry"
+)
+
+// actorHeader is the header identifying the actor of a request which isn't authenticated.
+const actorHeader = "X-Actor"
+
+// RequestAudit passes the actor and the id of the request down to the registry, so they end up in the audit log.
+// The actor is taken only from an identity the server verified, the subject of the client certificate when mutual TLS
+// is enabled. The X-Actor header, or else the user of HTTP basic authentication, which the registry doesn't check, is
+// recorded separately as the claimed actor.
+//
+// The request id is set by middleware.RequestID and echoed back in the X-Request-Id header.
+func RequestAudit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claimedActor := r.Header.Get(actorHeader)
+		if claimedActor == "" {
+			claimedActor, _, _ = r.BasicAuth()
+		}
+		requestID := middleware.GetReqID(r.Context())
+		if requestID != "" {
+			w.Header().Set(middleware.RequestIDHeader, requestID)
+		}
+
+		ctx := registry.ContextWithAuditInfo(r.Context(), registry.AuditInfo{
+			Actor:        verifiedActor(r),
+			ClaimedActor: claimedActor,
+			RequestID:    requestID,
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// verifiedActor returns the common name of the client certificate if the TLS handshake verified it, otherwise the
+// subject as a whole if it has no common name, and an empty string if the client wasn't verified.
+func verifiedActor(r *http.Request) string {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	subject := r.TLS.VerifiedChains[0][0].Subject
+	if subject.CommonName != "" {
+		return subject.CommonName
+	}
+	return subject.String()
+}
@@ -0,0 +1,209 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dataphos/schema-registry/registry"
+)
+
+func TestRequestAudit(t *testing.T) {
+	srv := newTestServer(t)
+
+	do := func(method, path, body string, configure func(*http.Request)) *http.Response {
+		request, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		configure(request)
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return response
+	}
+
+	register := `{"name":"person","schema_type":"json","specification":"{}","publisher_id":"publisher","compatibility_mode":"none","validity_mode":"none"}`
+	response := do(http.MethodPost, "/schemas", register, func(r *http.Request) {
+		r.Header.Set(actorHeader, "ci-pipeline")
+		r.Header.Set("X-Request-Id", "request-1")
+	})
+	response.Body.Close()
+	if got := response.Header.Get("X-Request-Id"); got != "request-1" {
+		t.Errorf("expected the request id to be echoed back, got %q", got)
+	}
+
+	response = do(http.MethodPut, "/schemas/1", `{"specification":"{\"type\":\"object\"}"}`, func(r *http.Request) {
+		r.SetBasicAuth("alice", "secret")
+	})
+	response.Body.Close()
+
+	response = do(http.MethodDelete, "/schemas/1/versions/1", "", func(*http.Request) {})
+	response.Body.Close()
+
+	response = do(http.MethodGet, "/audit?schema_id=1", "", func(*http.Request) {})
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", response.StatusCode)
+	}
+	var records []registry.AuditRecord
+	if err := json.NewDecoder(response.Body).Decode(&records); err != nil {
+		t.Fatal(err)
+	}
+
+	// none of the requests authenticate, so the actors are only claimed
+	expected := []struct {
+		action, claimedActor, requestID string
+	}{
+		{registry.AuditCreateSchema, "ci-pipeline", "request-1"},
+		{registry.AuditUpdateSchema, "alice", ""},
+		{registry.AuditDeleteSchemaVersion, "", ""},
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("expected %d records, got %v", len(expected), records)
+	}
+	for i, record := range records {
+		name := fmt.Sprintf("record %d", i)
+		if record.Action != expected[i].action || record.Actor != registry.AnonymousActor {
+			t.Errorf("%s: expected %s by %s, got %s by %s", name, expected[i].action, registry.AnonymousActor, record.Action, record.Actor)
+		}
+		if record.ClaimedActor != expected[i].claimedActor {
+			t.Errorf("%s: expected claimed actor %q, got %q", name, expected[i].claimedActor, record.ClaimedActor)
+		}
+		if expected[i].requestID != "" && record.RequestID != expected[i].requestID {
+			t.Errorf("%s: expected request id %s, got %s", name, expected[i].requestID, record.RequestID)
+		}
+		if record.RequestID == "" {
+			t.Errorf("%s: request id not recorded", name)
+		}
+	}
+	if records[1].BeforeHash != records[0].AfterHash || records[1].AfterHash == records[1].BeforeHash {
+		t.Errorf("update hashes not as expected: %+v", records[1])
+	}
+	if records[2].BeforeHash != records[0].AfterHash || records[2].AfterHash != "" {
+		t.Errorf("delete hashes not as expected: %+v", records[2])
+	}
+}
+
+func TestRequestAuditTakesActorFromVerifiedCertificate(t *testing.T) {
+	certificate := &x509.Certificate{Subject: pkix.Name{CommonName: "release-pipeline"}}
+
+	tt := []struct {
+		name                string
+		state               *tls.ConnectionState
+		actor, claimedActor string
+	}{
+		{"no tls", nil, registry.AnonymousActor, "alice"},
+		{"unverified certificate", &tls.ConnectionState{PeerCertificates: []*x509.Certificate{certificate}}, registry.AnonymousActor, "alice"},
+		{"verified certificate", &tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{certificate},
+			VerifiedChains:   [][]*x509.Certificate{{certificate}},
+		}, "release-pipeline", "alice"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var info registry.AuditInfo
+			handler := RequestAudit(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				info = registry.AuditInfoFromContext(r.Context())
+			}))
+
+			request := httptest.NewRequest(http.MethodPost, "/schemas", nil)
+			request.TLS = tc.state
+			request.Header.Set(actorHeader, "alice")
+			handler.ServeHTTP(httptest.NewRecorder(), request)
+
+			if info.Actor != tc.actor || info.ClaimedActor != tc.claimedActor {
+				t.Errorf("expected actor %q claiming %q, got %q claiming %q", tc.actor, tc.claimedActor, info.Actor, info.ClaimedActor)
+			}
+		})
+	}
+}
+
+func TestGetAuditPages(t *testing.T) {
+	srv := newTestServer(t)
+
+	register := `{"name":"person","schema_type":"json","specification":"{}","publisher_id":"publisher","compatibility_mode":"none","validity_mode":"none"}`
+	response, err := http.Post(srv.URL+"/schemas", "application/json", strings.NewReader(register))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	for i := 0; i < 4; i++ {
+		update := fmt.Sprintf(`{"specification":"{\"title\":\"%d\"}"}`, i)
+		request, err := http.NewRequest(http.MethodPut, srv.URL+"/schemas/1", strings.NewReader(update))
+		if err != nil {
+			t.Fatal(err)
+		}
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+	}
+
+	var ids []string
+	next := "/audit?schema_id=1&limit=2"
+	for pages := 0; next != ""; pages++ {
+		if pages == 4 {
+			t.Fatalf("expected the pages to end, got the records %v", ids)
+		}
+		response, err := http.Get(srv.URL + next)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var records []registry.AuditRecord
+		err = json.NewDecoder(response.Body).Decode(&records)
+		response.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) > 2 {
+			t.Fatalf("expected at most 2 records per page, got %d", len(records))
+		}
+		for _, record := range records {
+			ids = append(ids, record.ID)
+		}
+
+		next = ""
+		if link := response.Header.Get("Link"); link != "" {
+			next = strings.TrimSuffix(strings.TrimPrefix(link, "<"), `>; rel="next"`)
+		}
+	}
+
+	if strings.Join(ids, ",") != "1,2,3,4,5" {
+		t.Errorf("expected the records 1 to 5 in order, got %v", ids)
+	}
+
+	for _, query := range []string{"limit=0", "limit=1001", "limit=many", "after=first"} {
+		response, err := http.Get(srv.URL + "/audit?" + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		if response.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, response.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dataphos/schema-registry/compatibility"
+	"github.com/dataphos/schema-registry/registry"
+	"github.com/dataphos/schema-registry/validity"
+)
+
+// TestSchemaCompatibilityArguments guards against the schema id and the new schema of the request being swapped on
+// their way to the compatibility checker.
+func TestSchemaCompatibilityArguments(t *testing.T) {
+	var checked struct {
+		ID     string `json:"id"`
+		Format string `json:"format"`
+		Schema string `json:"schema"`
+	}
+	var history []string
+	compChecker := compatibility.CheckerFunc(func(_ context.Context, schema string, schemaHistory []string, _ string) (bool, error) {
+		history = schemaHistory
+		return true, json.Unmarshal([]byte(schema), &checked)
+	})
+	service := registry.New(newMemoryRepository(), compChecker, validity.CheckerFunc(func(context.Context, string, string, string) (bool, error) {
+		return true, nil
+	}), "BACKWARD", "none")
+	srv := httptest.NewServer(New(NewHandler(service, newTestLogger())))
+	defer srv.Close()
+
+	post := func(path, body string, expected int) {
+		response, err := http.Post(srv.URL+path, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer response.Body.Close()
+		if response.StatusCode != expected {
+			t.Fatalf("POST %s: expected status %d, got %d", path, expected, response.StatusCode)
+		}
+	}
+
+	post("/schemas", `{"name":"person","schema_type":"json","specification":"{\"title\":\"v1\"}","compatibility_mode":"none","validity_mode":"none"}`, http.StatusCreated)
+	post("/check/compatibility", `{"schema_id":"1","new_schema":"{\"title\":\"v2\"}"}`, http.StatusOK)
+
+	if checked.ID != "1" || checked.Format != "json" || checked.Schema != `{"title":"v2"}` {
+		t.Errorf("expected the checker to get schema 1 of type json with the new schema, got %+v", checked)
+	}
+	if len(history) != 1 || history[0] != `{"title":"v1"}` {
+		t.Errorf("expected the checker to get the registered version as the history, got %q", history)
+	}
+
+	post("/check/compatibility", `{"schema_id":"2","new_schema":"{\"title\":\"v2\"}"}`, http.StatusNotFound)
+}
@@ -15,7 +15,6 @@
 package server
 
 import (
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -30,11 +29,14 @@ import (
 	"github.com/dataphos/lib-logger/logger"
 	"github.com/dataphos/schema-registry/internal/metrics"
 	"github.com/dataphos/schema-registry/registry"
+	"github.com/dataphos/schema-registry/validity"
 )
 
 type Handler struct {
 	Service *registry.Service
 	log     logger.Log
+	// maxSchemaSize is the size of the largest accepted schema in bytes, unlimited if it isn't positive.
+	maxSchemaSize int
 }
 
 // report is a simple wrapper of the system's message for the user.
@@ -44,9 +46,11 @@ type report struct {
 
 // insertInfo represents a schema registry/evolution response for methods other than GET.
 type insertInfo struct {
-	Id      string `json:"identification"`
-	Version string `json:"version"`
-	Message string `json:"message"`
+	Id         string               `json:"identification"`
+	Version    string               `json:"version"`
+	Semver     string               `json:"semver,omitempty"`
+	Message    string               `json:"message"`
+	Violations []validity.Violation `json:"violations,omitempty"`
 }
 
 // NewHandler is a convenience function which returns a new instance of Handler.
@@ -58,7 +62,7 @@ func NewHandler(Service *registry.Service, log logger.Log) *Handler {
 }
 
 // GetSchemaVersionByIdAndVersion is a GET method that expects parameters "id" and "version" for
-// retrieving the schema version from the underlying repository.
+// retrieving the schema version from the underlying repository. The version can be an alias of the schema.
 //
 // It currently writes back either:
 //   - status 200 with a schema version in JSON format, if the schema is registered and active
@@ -69,7 +73,7 @@ func NewHandler(Service *registry.Service, log logger.Log) *Handler {
 // @Summary      Get schema version by schema id and version
 // @Produce      json
 // @Param        id path string true "schema id"
-// @Param        version path string true "version"
+// @Param        version path string true "version, alias or semantic version"
 // @Success      200
 // @Failure      404
 // @Failure      500
@@ -78,32 +82,12 @@ func (h Handler) GetSchemaVersionByIdAndVersion(w http.ResponseWriter, r *http.R
 	id := chi.URLParam(r, "id")
 	version := chi.URLParam(r, "version")
 
-	details, err := h.Service.GetSchemaVersion(id, version)
+	details, err := h.Service.GetSchemaVersion(r.Context(), id, version)
 	if err != nil {
-		if errors.Is(err, registry.ErrNotFound) {
-			body, _ := json.Marshal(report{
-				Message: fmt.Sprintf("Schema with id=%v and version=%v is not registered", id, version),
-			})
-			writeResponse(w, responseBodyAndCode{
-				Body: body,
-				Code: http.StatusNotFound,
-			})
-			return
-		} else if errors.Is(err, registry.ErrInvalidValueHeader) {
-			body, _ := json.Marshal(report{
-				Message: fmt.Sprintf("Id=%v and/or version=%v are not of supported data types", id, version),
-			})
-			writeResponse(w, responseBodyAndCode{
-				Body: body,
-				Code: http.StatusUnprocessableEntity,
-			})
-			return
-		}
-
-		writeResponse(w, responseBodyAndCode{
-			Body: serializeErrorMessage(http.StatusText(http.StatusInternalServerError)),
-			Code: http.StatusInternalServerError,
-		})
+		writeError(w, r, err,
+			detail(registry.ErrNotFound, "Schema with id=%v and version=%v is not registered", id, version),
+			detail(registry.ErrInvalidValueHeader, "Id=%v and/or version=%v are not of supported data types", id, version).withStatus(http.StatusUnprocessableEntity),
+		)
 		return
 	}
 
@@ -115,52 +99,159 @@ func (h Handler) GetSchemaVersionByIdAndVersion(w http.ResponseWriter, r *http.R
 }
 
 // GetSpecificationByIdAndVersion is a GET method that expects parameters "id" and "version" for
-// retrieving the specification of schema version from the underlying repository.
+// retrieving the specification of schema version from the underlying repository. The specification is served as
+// application/json by default, or as a file of the media type of the schema type if the "format" query parameter is
+// "raw" or the Accept header asks for that media type. The optional query parameter "canonical" requests the canonical
+// form of the specification.
 //
 // It currently writes back either:
-//   - status 200 with a schema in JSON format, if the schema version is registered and active
+//   - status 200 with the specification, if the schema version is registered and active
+//   - status 400 with error message, if a bad query parameter was given
 //   - status 404 with error message, if the schema version is not registered or registered but deactivated
+//   - status 406 with error message, if none of the media types of the Accept header can be served
+//   - status 422 with error message, if the version isn't supported or the specification can't be canonicalized
 //   - status 500 with error message, if an internal server error occurred
 //
 // @Title        Get schema specification by schema id and version
 // @Summary      Get schema specification by schema id and version
 // @Produce      json
+// @Produce      application/schema+json
+// @Produce      application/vnd.apache.avro+json
+// @Produce      text/x-protobuf
+// @Produce      application/xml
+// @Produce      text/csv
 // @Param        id path string true "schema id"
-// @Param        version path string true "version"
+// @Param        version path string true "version, alias or semantic version"
+// @Param        format query string false "json or raw, overrides the Accept header"
+// @Param        canonical query bool false "return the canonical form of the specification"
 // @Success 	 200
+// @Failure 	 400
 // @Failure 	 404
+// @Failure 	 406
+// @Failure 	 422
 // @Failure 	 500
 // @Router       /schemas/{id}/versions/{version}/spec [get]
 func (h Handler) GetSpecificationByIdAndVersion(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	version := chi.URLParam(r, "version")
 
-	details, err := h.Service.GetSchemaVersion(id, version)
+	w.Header().Set("Vary", "Accept")
+	options, err := readSpecificationOptions(r)
 	if err != nil {
-		if errors.Is(err, registry.ErrNotFound) {
-			body, _ := json.Marshal(report{
-				Message: fmt.Sprintf("Schema with id=%v and version=%v is not registered", id, version),
-			})
-			writeResponse(w, responseBodyAndCode{
-				Body: body,
-				Code: http.StatusNotFound,
-			})
-			return
-		}
+		writeProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
 
-		writeResponse(w, responseBodyAndCode{
-			Body: serializeErrorMessage(http.StatusText(http.StatusInternalServerError)),
-			Code: http.StatusInternalServerError,
-		})
+	specification, err := h.Service.GetSpecification(r.Context(), id, version, options.canonical)
+	if err != nil {
+		writeError(w, r, err,
+			detail(registry.ErrNotFound, "Schema with id=%v and version=%v is not registered", id, version),
+			detail(registry.ErrInvalidValueHeader, "Id=%v and/or version=%v are not of supported data types", id, version).withStatus(http.StatusUnprocessableEntity),
+		)
+		return
+	}
+
+	mediaType, ok := negotiateSpecification(r.Header.Get("Accept"), options.format, specification.SchemaType)
+	if !ok {
+		writeProblem(w, r, http.StatusNotAcceptable, fmt.Sprintf("The specification can only be served as application/json or %s", specificationFileOf(specification.SchemaType).mediaType))
+		return
+	}
+	if mediaType != "application/json" {
+		writeSpecificationFile(w, specification, mediaType)
+		return
+	}
+	writeResponse(w, responseBodyAndCode{
+		Body: specification.Content,
+		Code: http.StatusOK,
+	})
+}
+
+// GetExamples is a GET method that expects parameters "id" and "version" for generating random example
+// payloads of the schema version. The optional query parameters "count", "seed" and "invalid" control how many
+// payloads are generated, make the generation reproducible and request payloads which violate the schema.
+//
+// It currently writes back either:
+//   - status 200 with the generated payloads in JSON format, encoded as base64 strings
+//   - status 400 with error message, if a bad query parameter was given
+//   - status 404 with error message, if the schema version is not registered or registered but deactivated
+//   - status 422 with error message, if examples can't be generated for the schema
+//   - status 500 with error message, if an internal server error occurred
+//
+// @Title        Generate example payloads of a schema version
+// @Summary      Generate example payloads of a schema version
+// @Produce      json
+// @Param        id path string true "schema id"
+// @Param        version path string true "version, alias or semantic version"
+// @Param        count query int false "number of payloads to generate, between 1 and 100"
+// @Param        seed query int false "seed of the random generator"
+// @Param        invalid query bool false "generate payloads which violate the schema"
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      422
+// @Failure      500
+// @Router       /schemas/{id}/versions/{version}/examples [get]
+func (h Handler) GetExamples(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	version := chi.URLParam(r, "version")
+
+	options, err := readExamplesOptions(r)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	generated, err := h.Service.GenerateExamples(r.Context(), id, version, options)
+	if err != nil {
+		writeError(w, r, err,
+			detail(registry.ErrNotFound, "Schema with id=%v and version=%v is not registered", id, version),
+			detail(registry.ErrInvalidValueHeader, "Id=%v and/or version=%v are not of supported data types", id, version).withStatus(http.StatusUnprocessableEntity),
+		)
 		return
 	}
-	specification, err := base64.StdEncoding.DecodeString(details.Specification)
+
+	body, _ := json.Marshal(generated)
+	writeResponse(w, responseBodyAndCode{
+		Body: body,
+		Code: http.StatusOK,
+	})
+}
+
+// GetCompatibilityMatrix is a GET method that expects the "id" of a schema and checks the compatibility of every pair
+// of its active versions, under the mode given by the optional "mode" query parameter or the mode of the schema.
+// The schema isn't changed, so the matrix shows which versions would violate a mode before switching to it.
+//
+// It currently writes back either:
+//   - status 200 with the compatibility matrix in JSON format
+//   - status 400 with error message, if the mode is unknown
+//   - status 404 with error message, if there is no registered or active schema version under the given id
+//   - status 500 with error message, if an internal server error occurred
+//
+// @Title        Get the compatibility matrix of a schema
+// @Summary      Get the compatibility matrix of a schema
+// @Produce      json
+// @Param        id path string true "schema id"
+// @Param        mode query string false "compatibility mode, the mode of the schema is used if empty"
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Router       /schemas/{id}/compatibility-matrix [get]
+func (h Handler) GetCompatibilityMatrix(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	matrix, err := h.Service.CompatibilityMatrix(r.Context(), id, r.URL.Query().Get("mode"))
 	if err != nil {
-		log.Println(err)
+		writeError(w, r, err,
+			detail(registry.ErrNotFound, "Schema with id=%v is not registered", id),
+			detail(registry.ErrUnknownComp, "Unknown compatibility mode"),
+		)
+		return
 	}
 
+	body, _ := json.Marshal(matrix)
 	writeResponse(w, responseBodyAndCode{
-		Body: specification,
+		Body: body,
 		Code: http.StatusOK,
 	})
 }
@@ -183,19 +274,9 @@ func (h Handler) GetSpecificationByIdAndVersion(w http.ResponseWriter, r *http.R
 func (h Handler) GetSchemaVersionsById(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
-	schemas, err := h.Service.ListSchemaVersions(id)
+	schemas, err := h.Service.ListSchemaVersions(r.Context(), id)
 	if err != nil {
-		if errors.Is(err, registry.ErrNotFound) {
-			writeResponse(w, responseBodyAndCode{
-				Body: serializeErrorMessage(http.StatusText(http.StatusNotFound)),
-				Code: http.StatusNotFound,
-			})
-			return
-		}
-		writeResponse(w, responseBodyAndCode{
-			Body: serializeErrorMessage(http.StatusText(http.StatusInternalServerError)),
-			Code: http.StatusInternalServerError,
-		})
+		writeError(w, r, err, detail(registry.ErrNotFound, "Schema with id=%v is not registered", id))
 		return
 	}
 
@@ -224,23 +305,9 @@ func (h Handler) GetSchemaVersionsById(w http.ResponseWriter, r *http.Request) {
 func (h Handler) GetAllSchemaVersionsById(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
-	schemas, err := h.Service.ListAllSchemaVersions(id)
+	schemas, err := h.Service.ListAllSchemaVersions(r.Context(), id)
 	if err != nil {
-		if errors.Is(err, registry.ErrNotFound) {
-			body, _ := json.Marshal(report{
-				Message: fmt.Sprintf("Schema with id=%v is not registered", id),
-			})
-			writeResponse(w, responseBodyAndCode{
-				Body: body,
-				Code: http.StatusNotFound,
-			})
-			return
-		}
-
-		writeResponse(w, responseBodyAndCode{
-			Body: serializeErrorMessage(http.StatusText(http.StatusInternalServerError)),
-			Code: http.StatusInternalServerError,
-		})
+		writeError(w, r, err, detail(registry.ErrNotFound, "Schema with id=%v is not registered", id))
 		return
 	}
 
@@ -252,41 +319,46 @@ func (h Handler) GetAllSchemaVersionsById(w http.ResponseWriter, r *http.Request
 }
 
 // GetLatestSchemaVersionById  is a GET method that expects "id" of the wanted schema and returns
-// the latest versions of the schema
+// the latest versions of the schema. The optional query parameter "as_of" (RFC 3339 timestamp) returns the version
+// which was the latest one at that point in time instead, even if it's deactivated since.
 //
 // It currently gives the following responses:
 //   - status 200 with the latest schema version in JSON format, if the schema is registered
-//   - status 404 if there is no registered or active schema under the given id
+//   - status 400 with error message, if a bad query parameter was given
+//   - status 404 if there is no registered or active schema under the given id, or none was active at the given time
 //   - status 500 with error message, if an internal server error occurred
 //
 // @Title        Get the latest schema version by schema id
 // @Summary      Get the latest schema version by schema id
 // @Produce      json
 // @Param        id path string true "schema id"
+// @Param        as_of query string false "RFC 3339 timestamp the version was the latest one at"
 // @Success      200
+// @Failure      400
 // @Failure      404
 // @Failure      500
 // @Router       /schemas/{id}/versions/latest [get]
 func (h Handler) GetLatestSchemaVersionById(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
-	details, err := h.Service.GetLatestSchemaVersion(id)
+	asOf, err := readAsOf(r)
 	if err != nil {
-		if errors.Is(err, registry.ErrNotFound) {
-			body, _ := json.Marshal(report{
-				Message: fmt.Sprintf("Schema with id=%v is not registered", id),
-			})
-			writeResponse(w, responseBodyAndCode{
-				Body: body,
-				Code: http.StatusNotFound,
-			})
-			return
-		}
+		writeProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
 
-		writeResponse(w, responseBodyAndCode{
-			Body: serializeErrorMessage(http.StatusText(http.StatusInternalServerError)),
-			Code: http.StatusInternalServerError,
-		})
+	var details registry.VersionDetails
+	if asOf.IsZero() {
+		details, err = h.Service.GetLatestSchemaVersion(r.Context(), id)
+	} else {
+		details, err = h.Service.GetLatestSchemaVersionAsOf(r.Context(), id, asOf)
+	}
+	if err != nil {
+		notFound := detail(registry.ErrNotFound, "Schema with id=%v is not registered", id)
+		if !asOf.IsZero() {
+			notFound = detail(registry.ErrNotFound, "Schema with id=%v had no active version at %v", id, r.URL.Query().Get("as_of"))
+		}
+		writeError(w, r, err, notFound)
 		return
 	}
 
@@ -297,6 +369,43 @@ func (h Handler) GetLatestSchemaVersionById(w http.ResponseWriter, r *http.Reque
 	})
 }
 
+// GetSchemaHistory is a GET method that returns the activations and deactivations of the versions of a schema,
+// oldest first. It expects the "id" of the schema.
+//
+// It currently writes back either:
+//   - status 200 with the version events in JSON format
+//   - status 400 with error message, if the id isn't valid
+//   - status 404 with error message, if there is no schema under the given id
+//   - status 500 with error message, if an internal server error occurred
+//
+// @Title        Get the version history of a schema
+// @Summary      Get the version history of a schema
+// @Produce      json
+// @Param        id path string true "schema id"
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Router       /schemas/{id}/history [get]
+func (h Handler) GetSchemaHistory(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	events, err := h.Service.GetSchemaHistory(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err, detail(registry.ErrNotFound, "Schema with id=%v is not registered", id))
+		return
+	}
+	if events == nil {
+		events = []registry.VersionEvent{}
+	}
+
+	body, _ := json.Marshal(events)
+	writeResponse(w, responseBodyAndCode{
+		Body: body,
+		Code: http.StatusOK,
+	})
+}
+
 // GetAllSchemas is a GET method that retrieves all schemas
 //
 // It currently writes back either:
@@ -311,24 +420,10 @@ func (h Handler) GetLatestSchemaVersionById(w http.ResponseWriter, r *http.Reque
 // @Failure 404
 // @Failure 500
 // @Router  /schemas/all [get]
-func (h Handler) GetAllSchemas(w http.ResponseWriter, _ *http.Request) {
-	schemas, err := h.Service.GetAllSchemas()
+func (h Handler) GetAllSchemas(w http.ResponseWriter, r *http.Request) {
+	schemas, err := h.Service.GetAllSchemas(r.Context())
 	if err != nil {
-		if errors.Is(err, registry.ErrNotFound) {
-			body, _ := json.Marshal(report{
-				Message: "There are no schemas in Registry",
-			})
-			writeResponse(w, responseBodyAndCode{
-				Body: body,
-				Code: http.StatusNotFound,
-			})
-			return
-		}
-
-		writeResponse(w, responseBodyAndCode{
-			Body: serializeErrorMessage(http.StatusText(http.StatusInternalServerError)),
-			Code: http.StatusInternalServerError,
-		})
+		writeError(w, r, err, detail(registry.ErrNotFound, "There are no schemas in Registry"))
 		return
 	}
 
@@ -353,8 +448,8 @@ func (h Handler) GetAllSchemas(w http.ResponseWriter, _ *http.Request) {
 // @Failure 404
 // @Failure 500
 // @Router  /schemas [get]
-func (h Handler) GetSchemas(w http.ResponseWriter, _ *http.Request) {
-	schemas, err := h.Service.GetSchemas()
+func (h Handler) GetSchemas(w http.ResponseWriter, r *http.Request) {
+	schemas, err := h.Service.GetSchemas(r.Context())
 	if err != nil {
 		if errors.Is(err, registry.ErrNotFound) {
 			body, _ := json.Marshal(report{Message: "No active schemas registered in the Registry"})
@@ -364,10 +459,7 @@ func (h Handler) GetSchemas(w http.ResponseWriter, _ *http.Request) {
 			})
 			return
 		}
-		writeResponse(w, responseBodyAndCode{
-			Body: serializeErrorMessage(http.StatusText(http.StatusInternalServerError)),
-			Code: http.StatusInternalServerError,
-		})
+		writeError(w, r, err)
 		return
 	}
 
@@ -414,26 +506,14 @@ func (h Handler) SearchSchemas(w http.ResponseWriter, r *http.Request) {
 	if orderBy == "" && sort != "" {
 		orderBy = "id"
 	} else if orderBy != "" && orderBy != "name" && orderBy != "id" && orderBy != "type" && orderBy != "version" {
-		body, _ := json.Marshal(report{
-			Message: "Bad request: unknown value for orderBy",
-		})
-		writeResponse(w, responseBodyAndCode{
-			Body: body,
-			Code: http.StatusBadRequest,
-		})
+		writeProblem(w, r, http.StatusBadRequest, "Unknown value for orderBy")
 		return
 	}
 
 	if sort == "" && orderBy != "" {
 		sort = "asc"
 	} else if sort != "" && sort != "asc" && sort != "desc" {
-		body, _ := json.Marshal(report{
-			Message: "Bad request: unknown value for sort",
-		})
-		writeResponse(w, responseBodyAndCode{
-			Body: body,
-			Code: http.StatusBadRequest,
-		})
+		writeProblem(w, r, http.StatusBadRequest, "Unknown value for sort")
 		return
 	}
 
@@ -443,13 +523,7 @@ func (h Handler) SearchSchemas(w http.ResponseWriter, r *http.Request) {
 	if limitStr != "" {
 		limit, err = strconv.Atoi(limitStr)
 		if err != nil {
-			body, _ := json.Marshal(report{
-				Message: "Bad request: limit must be integer",
-			})
-			writeResponse(w, responseBodyAndCode{
-				Body: body,
-				Code: http.StatusBadRequest,
-			})
+			writeProblem(w, r, http.StatusBadRequest, "limit must be an integer")
 			return
 		}
 	}
@@ -470,27 +544,14 @@ func (h Handler) SearchSchemas(w http.ResponseWriter, r *http.Request) {
 		Attributes: attributes,
 	}
 
-	schemas, err := h.Service.SearchSchemas(queryParams)
+	schemas, err := h.Service.SearchSchemas(r.Context(), queryParams)
 	if err != nil {
-		if errors.Is(err, registry.ErrNotFound) {
-			writeResponse(w, responseBodyAndCode{
-				Body: serializeErrorMessage(http.StatusText(http.StatusNotFound)),
-				Code: http.StatusNotFound,
-			})
-			return
-		}
-		writeResponse(w, responseBodyAndCode{
-			Body: serializeErrorMessage(http.StatusText(http.StatusInternalServerError)),
-			Code: http.StatusInternalServerError,
-		})
+		writeError(w, r, err, detail(registry.ErrNotFound, "No schema matches the search criteria"))
 		return
 	}
 
 	if schemas == nil {
-		writeResponse(w, responseBodyAndCode{
-			Body: serializeErrorMessage(http.StatusText(http.StatusNotFound)),
-			Code: http.StatusNotFound,
-		})
+		writeProblem(w, r, http.StatusNotFound, "No schema matches the search criteria")
 		return
 	}
 
@@ -516,8 +577,11 @@ func (h Handler) SearchSchemas(w http.ResponseWriter, r *http.Request) {
 // It currently writes back either:
 //   - status 201 with newly created version details in JSON format
 //   - status 400 with error message, if the schema isn't valid or the values for validity and/or compatibility mode are missing
+//   - status 403 with error message, if an admission webhook denied the schema
+//   - status 413 with error message, if the request body or the schema is too large
 //   - status 409 with error message, if the schema already exists
 //   - status 500 with error message, if an internal server error occurred
+//   - status 503 with error message, if an admission webhook couldn't review the schema
 //
 // In case of correct invocation the function writes back a JSON with fields:
 // - Identification int64
@@ -531,69 +595,34 @@ func (h Handler) SearchSchemas(w http.ResponseWriter, r *http.Request) {
 // @Param        data body registry.SchemaRegistrationRequest false "schema registration request"
 // @Success      201
 // @Failure      400
+// @Failure      403
+// @Failure      413
 // @Failure      409
 // @Failure      500
+// @Failure      503
 // @Router       /schemas [post]
 func (h Handler) PostSchema(w http.ResponseWriter, r *http.Request) {
 
 	registerRequest, err := readSchemaRegisterRequest(r.Body)
 	if err != nil {
 		if errors.Is(err, registry.ErrUnknownFormat) {
-			body, _ := json.Marshal(report{
-				Message: "Bad request: unknown format value",
-			})
-			writeResponse(w, responseBodyAndCode{
-				Body: body,
-				Code: http.StatusBadRequest,
-			})
+			writeError(w, r, err, detail(registry.ErrUnknownFormat, "Unknown format value"))
 			return
 		}
-		writeResponse(w, responseBodyAndCode{
-			Body: serializeErrorMessage(http.StatusText(http.StatusBadRequest)),
-			Code: http.StatusBadRequest,
-		})
+		writeProblem(w, r, http.StatusBadRequest, undecodableBody)
+		return
+	}
+	if h.schemaTooLarge(w, r, registerRequest.Specification) {
 		return
 	}
 
-	details, added, err := h.Service.CreateSchema(registerRequest)
+	details, added, err := h.Service.CreateSchema(r.Context(), registerRequest)
 	if err != nil {
-		if errors.Is(err, registry.ErrUnknownComp) {
-			body, _ := json.Marshal(report{
-				Message: "Bad request: unknown compatibility_mode value",
-			})
-			writeResponse(w, responseBodyAndCode{
-				Body: body,
-				Code: http.StatusBadRequest,
-			})
-			return
-		}
-
-		if errors.Is(err, registry.ErrUnknownVal) {
-			body, _ := json.Marshal(report{
-				Message: "Bad request: unknown validity_mode value",
-			})
-			writeResponse(w, responseBodyAndCode{
-				Body: body,
-				Code: http.StatusBadRequest,
-			})
-			return
-		}
-
-		if errors.Is(err, registry.ErrNotValid) {
-			body, _ := json.Marshal(report{
-				Message: "Schema is not valid",
-			})
-			writeResponse(w, responseBodyAndCode{
-				Body: body,
-				Code: http.StatusBadRequest,
-			})
-			return
-		}
-
-		writeResponse(w, responseBodyAndCode{
-			Body: serializeErrorMessage(http.StatusText(http.StatusInternalServerError)),
-			Code: http.StatusInternalServerError,
-		})
+		writeError(w, r, err,
+			detail(registry.ErrUnknownComp, "Unknown compatibility_mode value"),
+			detail(registry.ErrUnknownVal, "Unknown validity_mode value"),
+			detail(registry.ErrNotValid, "Schema is not valid"),
+		)
 		return
 	}
 
@@ -611,30 +640,36 @@ func (h Handler) PostSchema(w http.ResponseWriter, r *http.Request) {
 	}
 
 	body, _ := json.Marshal(insertInfo{
-		Id:      details.SchemaID,
-		Version: details.Version,
-		Message: "Schema successfully created",
+		Id:         details.SchemaID,
+		Version:    details.Version,
+		Semver:     details.Semver,
+		Message:    "Schema successfully created",
+		Violations: details.Violations,
 	})
 	writeResponse(w, responseBodyAndCode{
 		Body: body,
 		Code: http.StatusCreated,
 	})
-	metrics.AddedSchemaMetricUpdate(details.SchemaID, details.Version)
+	metrics.AddedSchemaMetricUpdate()
 }
 
 // PutSchema registers a new schema version in the Schema Registry. The new version is connected to other schemas
 // by schema id from the request URL.
 // The expected input schema JSON should contain the following field:
 // - Specification    string
-// The input can also include the following field:
-// - Description      string
+// The input can also include the following fields:
+// - Description          string
+// - AllowBreakingChange  bool
 //
 // It currently writes back either:
 //   - status 200 with updated version details in JSON format
-//   - status 400 with error message, if the schemas aren't compatible
+//   - status 400 with error message, if the schemas aren't compatible or the change is a refused breaking change
+//   - status 403 with error message, if an admission webhook denied the schema version
+//   - status 413 with error message, if the request body or the schema is too large
 //   - status 404 if there is no registered or active schema version under the given id
 //   - status 409 with error message, if the schema already exists
 //   - status 500 with error message, if an internal server error occurred
+//   - status 503 with error message, if an admission webhook couldn't review the schema version
 //
 // In case of correct invocation the function writes back a JSON with fields:
 // - Identification int64
@@ -650,57 +685,32 @@ func (h Handler) PostSchema(w http.ResponseWriter, r *http.Request) {
 // @Param        data body registry.SchemaUpdateRequest true "schema update request"
 // @Success      200
 // @Failure      400
+// @Failure      403
+// @Failure      413
 // @Failure      404
 // @Failure      409
 // @Failure      500
+// @Failure      503
 // @Router       /schemas/{id} [put]
 func (h Handler) PutSchema(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
 	updateRequest, err := readSchemaUpdateRequest(r.Body)
 	if err != nil {
-		writeResponse(w, responseBodyAndCode{
-			Body: serializeErrorMessage(http.StatusText(http.StatusBadRequest)),
-			Code: http.StatusBadRequest,
-		})
+		writeProblem(w, r, http.StatusBadRequest, undecodableBody)
+		return
+	}
+	if h.schemaTooLarge(w, r, updateRequest.Specification) {
 		return
 	}
 
-	details, updated, err := h.Service.UpdateSchema(id, updateRequest)
+	details, updated, err := h.Service.UpdateSchema(r.Context(), id, updateRequest)
 	if err != nil {
-		if errors.Is(err, registry.ErrNotFound) {
-			body, _ := json.Marshal(report{
-				Message: fmt.Sprintf("Schema with id=%v doesn't exist", id),
-			})
-			writeResponse(w, responseBodyAndCode{
-				Body: body,
-				Code: http.StatusNotFound,
-			})
-			return
-		} else if errors.Is(err, registry.ErrNotValid) {
-			body, _ := json.Marshal(report{
-				Message: "Schema is not valid",
-			})
-			writeResponse(w, responseBodyAndCode{
-				Body: body,
-				Code: http.StatusBadRequest,
-			})
-			return
-		} else if errors.Is(err, registry.ErrNotComp) {
-			body, _ := json.Marshal(report{
-				Message: "Schemas are not compatible",
-			})
-			writeResponse(w, responseBodyAndCode{
-				Body: body,
-				Code: http.StatusBadRequest,
-			})
-			return
-		}
-
-		writeResponse(w, responseBodyAndCode{
-			Body: serializeErrorMessage(http.StatusText(http.StatusInternalServerError)),
-			Code: http.StatusInternalServerError,
-		})
+		writeError(w, r, err,
+			detail(registry.ErrNotFound, "Schema with id=%v doesn't exist", id),
+			detail(registry.ErrNotValid, "Schema is not valid"),
+			detail(registry.ErrNotComp, "Schemas are not compatible"),
+		)
 		return
 	}
 
@@ -718,108 +728,585 @@ func (h Handler) PutSchema(w http.ResponseWriter, r *http.Request) {
 	}
 
 	body, _ := json.Marshal(insertInfo{
-		Id:      details.SchemaID,
-		Version: details.Version,
-		Message: "Schema successfully updated",
+		Id:         details.SchemaID,
+		Version:    details.Version,
+		Semver:     details.Semver,
+		Message:    "Schema successfully updated",
+		Violations: details.Violations,
 	})
 	writeResponse(w, responseBodyAndCode{
 		Body: body,
 		Code: http.StatusOK,
 	})
-	metrics.UpdateSchemaMetricUpdate(details.SchemaID, details.Version)
+	metrics.UpdateSchemaMetricUpdate()
 }
 
-// DeleteSchema is a DELETE method that deactivates a schema.
-// It expects the "id" of the wanted schema
+// PatchSchema is a PATCH method that changes the name, description or publisher id of the schema with the given "id",
+// without registering a new version. The fields left out of the request are left unchanged.
 //
-// It currently gives the following responses:
-//   - status 200 for a successful invocation along with an instance of the schema structure
-//   - status 400 if the deletion caused an error
-//   - status 404 if the schema does not exist or is already deactivated
+// It currently writes back either:
+//   - status 200 with the schema, without its versions, in JSON format
+//   - status 400 with error message, if the request isn't valid or changes nothing
+//   - status 404 with error message, if the schema does not exist
+//   - status 413 with error message, if the request body is too large
+//   - status 422 with error message, if the id isn't of a supported data type
+//   - status 500 with error message, if an internal server error occurred
 //
-// @Title        Delete schema by schema id
-// @Summary      Delete schema by schema id
+// @Title        Change the metadata of a schema
+// @Summary      Change the metadata of a schema
+// @Accept       json
 // @Produce      json
 // @Param        id path string true "schema id"
+// @Param        data body registry.SchemaMetadataRequest true "schema metadata request"
 // @Success      200
 // @Failure      400
 // @Failure      404
-// @Router       /schemas/{id} [delete]
-func (h Handler) DeleteSchema(w http.ResponseWriter, r *http.Request) {
+// @Failure      413
+// @Failure      422
+// @Failure      500
+// @Router       /schemas/{id} [patch]
+func (h Handler) PatchSchema(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
-	deleted, err := h.Service.DeleteSchema(id)
+	metadataRequest, err := readSchemaMetadataRequest(r.Body)
 	if err != nil {
-		writeResponse(w, responseBodyAndCode{
-			Body: serializeErrorMessage(http.StatusText(http.StatusBadRequest)),
-			Code: http.StatusBadRequest,
-		})
+		writeProblem(w, r, http.StatusBadRequest, undecodableBody)
 		return
 	}
 
-	if !deleted {
-		body, _ := json.Marshal(report{Message: fmt.Sprintf("Schema with id=%s doesn't exist", id)})
-		writeResponse(w, responseBodyAndCode{
-			Body: body,
-			Code: http.StatusNotFound,
-		})
+	schema, err := h.Service.UpdateSchemaMetadata(r.Context(), id, metadataRequest)
+	if err != nil {
+		writeError(w, r, err,
+			detail(registry.ErrNotFound, "Schema with id=%v is not registered", id),
+			detail(registry.ErrInvalidValueHeader, "Id=%v is not of a supported data type", id).withStatus(http.StatusUnprocessableEntity),
+		)
 		return
 	}
 
-	body, _ := json.Marshal(report{Message: fmt.Sprintf("Schema with id=%s successfully deleted", id)})
+	body, _ := json.Marshal(schema)
 	writeResponse(w, responseBodyAndCode{
 		Body: body,
 		Code: http.StatusOK,
 	})
-	metrics.DeletedSchemaMetricUpdate(id)
+	metrics.UpdateSchemaMetadataMetricUpdate()
 }
 
-// DeleteSchemaVersion is a DELETE method that deletes a schema version.
-// It expects the "id" and "version" of the wanted schema
+// DeleteSchema is a DELETE method that deactivates a schema.
+// It expects the "id" of the wanted schema
+//
+// The deletion is refused while any of its versions is in use, unless the "force" query parameter is set.
 //
 // It currently gives the following responses:
 //   - status 200 for a successful invocation along with an instance of the schema structure
 //   - status 400 if the deletion caused an error
-//   - status 404 if the schema version does not exist or is already deactivated
+//   - status 404 if the schema does not exist or is already deactivated
+//   - status 409 with the usage of the schema, if it is in use
+//
+// @Title        Delete schema by schema id
+// @Summary      Delete schema by schema id
+// @Produce      json
+// @Param        id path string true "schema id"
+// @Param        force query bool false "delete the schema even if it is in use"
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      409
+// @Router       /schemas/{id} [delete]
+func (h Handler) DeleteSchema(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	force, err := readForce(r)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	deleted, err := h.Service.DeleteSchema(r.Context(), id, force)
+	if err != nil {
+		writeError(w, r, err,
+			detail(registry.ErrInUse, "Schema with id=%s is in use", id),
+			detail(registry.ErrInvalidValueHeader, "Id=%v is not of a supported data type", id),
+			detail(registry.ErrNotFound, "Schema with id=%s doesn't exist", id),
+		)
+		return
+	}
+
+	if !deleted {
+		writeProblem(w, r, http.StatusNotFound, fmt.Sprintf("Schema with id=%s doesn't exist", id))
+		return
+	}
+
+	body, _ := json.Marshal(report{Message: fmt.Sprintf("Schema with id=%s successfully deleted", id)})
+	writeResponse(w, responseBodyAndCode{
+		Body: body,
+		Code: http.StatusOK,
+	})
+	metrics.DeletedSchemaMetricUpdate()
+}
+
+// DeleteSchemaVersion is a DELETE method that deletes a schema version.
+// It expects the "id" and "version" of the wanted schema
+//
+// The deletion is refused while the version is in use, unless the "force" query parameter is set.
+//
+// It currently gives the following responses:
+//   - status 200 for a successful invocation along with an instance of the schema structure
+//   - status 400 if the deletion caused an error
+//   - status 404 if the schema version does not exist or is already deactivated
+//   - status 409 with the usage of the version, if it is in use
 //
 // @Title        Delete schema version by schema id and version
 // @Summary      Delete schema version by schema id and version
 // @Accept       json
 // @Produce      json
 // @Param        id path string true "schema id"
-// @Param        version path string  true "version"
+// @Param        version path string true "version, alias or semantic version"
+// @Param        force query bool false "delete the version even if it is in use"
 // @Success      200
 // @Failure      400
 // @Failure      404
+// @Failure      409
 // @Router       /schemas/{id}/versions/{version} [delete]
 func (h Handler) DeleteSchemaVersion(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	version := chi.URLParam(r, "version")
 
-	deleted, err := h.Service.DeleteSchemaVersion(id, version)
+	force, err := readForce(r)
 	if err != nil {
-		writeResponse(w, responseBodyAndCode{
-			Body: serializeErrorMessage(http.StatusText(http.StatusBadRequest)),
-			Code: http.StatusBadRequest,
-		})
+		writeProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	deleted, err := h.Service.DeleteSchemaVersion(r.Context(), id, version, force)
+	if err != nil {
+		writeError(w, r, err,
+			detail(registry.ErrInUse, "Schema with id=%s and version=%s is in use", id, version),
+			detail(registry.ErrInvalidValueHeader, "Id=%v and/or version=%v are not of supported data types", id, version),
+			detail(registry.ErrNotFound, "Schema with id=%s and version=%s doesn't exist", id, version),
+		)
 		return
 	}
 
 	if !deleted {
-		body, _ := json.Marshal(report{Message: fmt.Sprintf("Schema with id=%s and version=%s doesn't exist", id, version)})
+		writeProblem(w, r, http.StatusNotFound, fmt.Sprintf("Schema with id=%s and version=%s doesn't exist", id, version))
+		return
+	}
+
+	body, _ := json.Marshal(report{Message: fmt.Sprintf("Schema with id=%s and version=%s successfully deleted", id, version)})
+	writeResponse(w, responseBodyAndCode{
+		Body: body,
+		Code: http.StatusOK,
+	})
+	metrics.DeleteSchemaVersionMetricUpdate()
+}
+
+// RestoreSchemaVersion is a POST method that reactivates a deactivated schema version under its original version
+// number. It expects the "id" and "version" of the schema version, where the version can also be an alias.
+// Registering the same specification again also reactivates the version, but as a new version.
+//
+// It currently writes back either:
+//   - status 200 with the details of the restored version, if it was deactivated
+//   - status 404 with error message, if the schema version does not exist
+//   - status 409 with error message, if the schema version is already active
+//   - status 413 with error message, if the request body is too large
+//   - status 422 with error message, if the id or the version isn't of a supported data type
+//   - status 500 with error message, if an internal server error occurred
+//
+// @Title        Restore a deactivated schema version
+// @Summary      Restore a deactivated schema version
+// @Produce      json
+// @Param        id path string true "schema id"
+// @Param        version path string true "version or alias"
+// @Success      200
+// @Failure      404
+// @Failure      409
+// @Failure      413
+// @Failure      422
+// @Failure      500
+// @Router       /schemas/{id}/versions/{version}/restore [post]
+func (h Handler) RestoreSchemaVersion(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	version := chi.URLParam(r, "version")
+
+	details, restored, err := h.Service.RestoreSchemaVersion(r.Context(), id, version)
+	if err != nil {
+		writeError(w, r, err,
+			detail(registry.ErrNotFound, "Schema with id=%s and version=%s doesn't exist", id, version),
+			detail(registry.ErrInvalidValueHeader, "Id=%v and/or version=%v are not of supported data types", id, version).withStatus(http.StatusUnprocessableEntity),
+		)
+		return
+	}
+
+	if !restored {
+		body, _ := json.Marshal(insertInfo{
+			Id:      details.SchemaID,
+			Version: details.Version,
+			Semver:  details.Semver,
+			Message: "Schema version is already active",
+		})
 		writeResponse(w, responseBodyAndCode{
 			Body: body,
-			Code: http.StatusNotFound,
+			Code: http.StatusConflict,
 		})
 		return
 	}
 
-	body, _ := json.Marshal(report{Message: fmt.Sprintf("Schema with id=%s and version=%s successfully deleted", id, version)})
+	body, _ := json.Marshal(insertInfo{
+		Id:      details.SchemaID,
+		Version: details.Version,
+		Semver:  details.Semver,
+		Message: "Schema version successfully restored",
+	})
+	writeResponse(w, responseBodyAndCode{
+		Body: body,
+		Code: http.StatusOK,
+	})
+	metrics.RestoreSchemaVersionMetricUpdate()
+}
+
+// PostUsage is a POST method that registers the usage of a schema version by a producer or a consumer of a topic.
+// Registering the same usage again refreshes it, so services send it periodically as a heartbeat.
+// It expects the "id" and "version" of the used schema version and a body with the "topic", the "role"
+// ("producer" or "consumer") and optionally the "client_id" of the service, which defaults to the actor.
+//
+// It currently writes back either:
+//   - status 200 with the registered usage in JSON format
+//   - status 400 with error message, if the request isn't valid
+//   - status 404 with error message, if the schema version does not exist or is deactivated
+//   - status 500 with error message, if an internal server error occurred
+//
+// @Title        Register the usage of a schema version
+// @Summary      Register the usage of a schema version
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "schema id"
+// @Param        version path string true "version, alias or semantic version"
+// @Param        data body registry.UsageRegistrationRequest true "usage registration request"
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Router       /schemas/{id}/versions/{version}/usage [post]
+func (h Handler) PostUsage(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	version := chi.URLParam(r, "version")
+
+	request, err := readUsageRegistrationRequest(r.Body)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, undecodableBody)
+		return
+	}
+
+	usage, err := h.Service.RegisterUsage(r.Context(), id, version, request)
+	if err != nil {
+		writeError(w, r, err,
+			detail(registry.ErrInvalidValueHeader, "Id=%v and/or version=%v are not of supported data types", id, version),
+			detail(registry.ErrNotFound, "Schema with id=%s and version=%s is not registered", id, version),
+		)
+		return
+	}
+
+	body, _ := json.Marshal(usage)
+	writeResponse(w, responseBodyAndCode{
+		Body: body,
+		Code: http.StatusOK,
+	})
+}
+
+// GetUsage is a GET method that returns the live usage of a schema, that is, the usage registered or refreshed within
+// the usage TTL. It expects the "id" of the schema, the optional query parameter "version" limits it to one version.
+//
+// It currently writes back either:
+//   - status 200 with the usage in JSON format
+//   - status 400 with error message, if the id isn't valid
+//   - status 500 with error message, if an internal server error occurred
+//
+// @Title        Get the usage of a schema
+// @Summary      Get the usage of a schema
+// @Produce      json
+// @Param        id path string true "schema id"
+// @Param        version query string false "version"
+// @Success      200
+// @Failure      400
+// @Failure      500
+// @Router       /schemas/{id}/usage [get]
+func (h Handler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	usage, err := h.Service.GetUsage(r.Context(), id, r.URL.Query().Get("version"))
+	if err != nil {
+		writeError(w, r, err, detail(registry.ErrInvalidValueHeader, "Id=%v and/or version are not of supported data types", id))
+		return
+	}
+	if usage == nil {
+		usage = []registry.Usage{}
+	}
+
+	body, _ := json.Marshal(usage)
+	writeResponse(w, responseBodyAndCode{
+		Body: body,
+		Code: http.StatusOK,
+	})
+}
+
+// PutAlias is a PUT method that points an alias of a schema at a version, creating the alias if it doesn't exist.
+// It expects the "id" of the schema, the "alias" and a body with the "version" the alias is pointed at, which can be
+// another alias. Aliases are accepted anywhere a version is, so moving an alias moves every client using it.
+//
+// It currently writes back either:
+//   - status 200 with the alias in JSON format
+//   - status 400 with error message, if the alias or the request isn't valid
+//   - status 404 with error message, if the schema version does not exist or is deactivated
+//   - status 500 with error message, if an internal server error occurred
+//
+// @Title        Point an alias at a schema version
+// @Summary      Point an alias at a schema version
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "schema id"
+// @Param        alias path string true "alias"
+// @Param        data body registry.AliasRequest true "alias request"
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Router       /schemas/{id}/aliases/{alias} [put]
+func (h Handler) PutAlias(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	alias := chi.URLParam(r, "alias")
+
+	request, err := readAliasRequest(r.Body)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, undecodableBody)
+		return
+	}
+
+	schemaAlias, err := h.Service.SetAlias(r.Context(), id, alias, request)
+	if err != nil {
+		writeError(w, r, err,
+			detail(registry.ErrInvalidValueHeader, "Id=%v and/or version=%v are not of supported data types", id, request.Version),
+			detail(registry.ErrNotFound, "Schema with id=%s and version=%s is not registered", id, request.Version),
+		)
+		return
+	}
+
+	body, _ := json.Marshal(schemaAlias)
+	writeResponse(w, responseBodyAndCode{
+		Body: body,
+		Code: http.StatusOK,
+	})
+}
+
+// GetAliases is a GET method that returns the aliases of a schema, ordered by name.
+// It expects the "id" of the schema.
+//
+// It currently writes back either:
+//   - status 200 with the aliases in JSON format
+//   - status 400 with error message, if the id isn't valid
+//   - status 404 with error message, if the schema does not exist
+//   - status 500 with error message, if an internal server error occurred
+//
+// @Title        Get the aliases of a schema
+// @Summary      Get the aliases of a schema
+// @Produce      json
+// @Param        id path string true "schema id"
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Router       /schemas/{id}/aliases [get]
+func (h Handler) GetAliases(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	aliases, err := h.Service.GetAliases(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err,
+			detail(registry.ErrInvalidValueHeader, "Id=%v is not of a supported data type", id),
+			detail(registry.ErrNotFound, "Schema with id=%s is not registered", id),
+		)
+		return
+	}
+	if aliases == nil {
+		aliases = []registry.Alias{}
+	}
+
+	body, _ := json.Marshal(aliases)
+	writeResponse(w, responseBodyAndCode{
+		Body: body,
+		Code: http.StatusOK,
+	})
+}
+
+// DeleteAlias is a DELETE method that deletes an alias of a schema, leaving the version it pointed at untouched.
+// It expects the "id" of the schema and the "alias".
+//
+// It currently writes back either:
+//   - status 200 with a message that the alias was deleted
+//   - status 400 with error message, if the id isn't valid
+//   - status 404 with error message, if the alias does not exist
+//   - status 500 with error message, if an internal server error occurred
+//
+// @Title        Delete an alias of a schema
+// @Summary      Delete an alias of a schema
+// @Produce      json
+// @Param        id path string true "schema id"
+// @Param        alias path string true "alias"
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Router       /schemas/{id}/aliases/{alias} [delete]
+func (h Handler) DeleteAlias(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	alias := chi.URLParam(r, "alias")
+
+	deleted, err := h.Service.DeleteAlias(r.Context(), id, alias)
+	if err != nil {
+		writeError(w, r, err, detail(registry.ErrInvalidValueHeader, "Id=%v is not of a supported data type", id))
+		return
+	}
+
+	if !deleted {
+		writeProblem(w, r, http.StatusNotFound, fmt.Sprintf("Alias %s of schema with id=%s doesn't exist", alias, id))
+		return
+	}
+
+	body, _ := json.Marshal(report{Message: fmt.Sprintf("Alias %s of schema with id=%s successfully deleted", alias, id)})
+	writeResponse(w, responseBodyAndCode{
+		Body: body,
+		Code: http.StatusOK,
+	})
+}
+
+// LookupSchema is a POST method that looks up the given specification among the active versions of all schemas,
+// for clients which know a schema but not the id and version it is registered under. The specification matches
+// the versions with the same canonical form, as well as the ones registered as they are under the none validity mode.
+//
+// It currently writes back either:
+//   - status 200 with the matching schema versions in JSON format
+//   - status 400 with error message, if the request isn't valid or the schema type is unknown
+//   - status 404 with error message, if no active schema version matches the specification
+//   - status 413 with error message, if the request body or the schema is too large
+//   - status 500 with error message, if an internal server error occurred
+//
+// @Title        Look up a schema by its specification
+// @Summary      Look up a schema by its specification
+// @Accept       json
+// @Produce      json
+// @Param        data body registry.LookupRequest true "schema lookup request"
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      413
+// @Failure      500
+// @Router       /schemas/lookup [post]
+func (h Handler) LookupSchema(w http.ResponseWriter, r *http.Request) {
+	lookupRequest, err := readLookupRequest(r.Body)
+	if err != nil {
+		if errors.Is(err, registry.ErrUnknownFormat) {
+			writeError(w, r, err, detail(registry.ErrUnknownFormat, "Unknown format value"))
+			return
+		}
+		writeProblem(w, r, http.StatusBadRequest, undecodableBody)
+		return
+	}
+	if h.schemaTooLarge(w, r, lookupRequest.Specification) {
+		return
+	}
+
+	matches, err := h.Service.LookupSchema(r.Context(), lookupRequest)
+	if err != nil {
+		writeError(w, r, err, detail(registry.ErrNotFound, "Schema is not registered"))
+		return
+	}
+
+	body, _ := json.Marshal(matches)
+	writeResponse(w, responseBodyAndCode{
+		Body: body,
+		Code: http.StatusOK,
+	})
+}
+
+// GetFingerprint is a GET method that expects the parameter "fingerprint" and returns the active schema versions
+// with that fingerprint, across all schemas. The fingerprint is either the SHA-256 schema hash, as 64 hex digits, or
+// the CRC-64-AVRO fingerprint of an Avro schema, as the 16 hex digits of the bytes following the C3 01 marker of an
+// Avro single-object encoded message.
+//
+// It currently writes back either:
+//   - status 200 with the matching schema versions in JSON format
+//   - status 400 with error message, if the fingerprint is neither a schema hash nor an Avro fingerprint
+//   - status 404 with error message, if no active schema version has the fingerprint
+//   - status 500 with error message, if an internal server error occurred
+//
+// @Title        Get schema versions by fingerprint
+// @Summary      Get schema versions by fingerprint
+// @Produce      json
+// @Param        fingerprint path string true "SHA-256 schema hash or CRC-64-AVRO fingerprint, in hex"
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Router       /fingerprints/{fingerprint} [get]
+func (h Handler) GetFingerprint(w http.ResponseWriter, r *http.Request) {
+	fingerprint := chi.URLParam(r, "fingerprint")
+
+	matches, err := h.Service.GetSchemaVersionsByFingerprint(r.Context(), fingerprint)
+	if err != nil {
+		writeError(w, r, err, detail(registry.ErrNotFound, "No schema version with fingerprint=%v is registered", fingerprint))
+		return
+	}
+
+	body, _ := json.Marshal(matches)
+	writeResponse(w, responseBodyAndCode{
+		Body: body,
+		Code: http.StatusOK,
+	})
+}
+
+// GetAudit is a GET method that returns the audit records of the registry mutations, oldest first.
+// The optional query parameters "schema_id" and "since" (RFC 3339 timestamp) filter the records.
+//
+// It currently writes back either:
+//   - status 200 with a page of the matching audit records in JSON format, and the link to the next page in the Link
+//     header if the page is full
+//   - status 400 with error message, if a bad query parameter was given
+//   - status 500 with error message, if an internal server error occurred
+//
+// @Title        Get audit records
+// @Summary      Get audit records
+// @Produce      json
+// @Param        schema_id query string false "schema id"
+// @Param        since query string false "RFC 3339 timestamp of the oldest record"
+// @Param        after query string false "id of the record the page starts after"
+// @Param        limit query int false "maximum number of records, 100 by default and at most 1000"
+// @Success      200
+// @Failure      400
+// @Failure      500
+// @Router       /audit [get]
+func (h Handler) GetAudit(w http.ResponseWriter, r *http.Request) {
+	query, err := readAuditQuery(r)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	records, err := h.Service.GetAuditRecords(r.Context(), query)
+	if err != nil {
+		writeError(w, r, err, detail(registry.ErrInvalidValueHeader, "schema_id must be an integer"))
+		return
+	}
+	if records == nil {
+		records = []registry.AuditRecord{}
+	}
+	if next := nextAuditPage(r, query, records); next != "" {
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next))
+	}
+
+	body, _ := json.Marshal(records)
 	writeResponse(w, responseBodyAndCode{
 		Body: body,
 		Code: http.StatusOK,
 	})
-	metrics.DeleteSchemaVersionMetricUpdate(id, version)
 }
 
 // HealthCheck is a GET method that gives the response status 200 to signalize
@@ -832,10 +1319,10 @@ func (h Handler) SchemaCompatibility(w http.ResponseWriter, r *http.Request) {
 	compRequest, err := readSchemaCompatibilityRequest(r.Body)
 
 	if err != nil {
-		writeResponse(w, responseBodyAndCode{
-			Body: serializeErrorMessage(http.StatusText(http.StatusBadRequest)),
-			Code: http.StatusBadRequest,
-		})
+		writeProblem(w, r, http.StatusBadRequest, undecodableBody)
+		return
+	}
+	if h.schemaTooLarge(w, r, compRequest.NewSchema) {
 		return
 	}
 
@@ -847,20 +1334,9 @@ func (h Handler) SchemaCompatibility(w http.ResponseWriter, r *http.Request) {
 		}
 	}(r.Body)
 
-	compatible, err := h.Service.CheckCompatibility(compRequest.SchemaID, compRequest.NewSchema)
+	compatible, err := h.Service.CheckCompatibility(r.Context(), compRequest.NewSchema, compRequest.SchemaID)
 	if err != nil {
-		if errors.Is(err, registry.ErrNotFound) {
-			writeResponse(w, responseBodyAndCode{
-				Body: serializeErrorMessage(http.StatusText(http.StatusNotFound)),
-				Code: http.StatusNotFound,
-			})
-			return
-		}
-
-		writeResponse(w, responseBodyAndCode{
-			Body: serializeErrorMessage(http.StatusText(http.StatusInternalServerError)),
-			Code: http.StatusInternalServerError,
-		})
+		writeError(w, r, err, detail(registry.ErrNotFound, "Schema with id=%v is not registered", compRequest.SchemaID))
 		return
 	}
 
@@ -885,37 +1361,39 @@ func (h Handler) SchemaCompatibility(w http.ResponseWriter, r *http.Request) {
 func (h Handler) SchemaValidity(w http.ResponseWriter, r *http.Request) {
 	valRequest, err := readSchemaValidityRequest(r.Body)
 	if err != nil {
-		writeResponse(w, responseBodyAndCode{
-			Body: serializeErrorMessage(http.StatusText(http.StatusBadRequest)),
-			Code: http.StatusBadRequest,
-		})
+		writeProblem(w, r, http.StatusBadRequest, undecodableBody)
+		return
+	}
+	if h.schemaTooLarge(w, r, valRequest.NewSchema) {
 		return
 	}
 
-	valid, err := h.Service.CheckValidity(valRequest.Format, valRequest.NewSchema, valRequest.Mode)
+	valid, violations, err := h.Service.CheckValidity(r.Context(), valRequest)
 	if err != nil {
-		if errors.Is(err, registry.ErrNotFound) {
-			writeResponse(w, responseBodyAndCode{
-				Body: serializeErrorMessage(http.StatusText(http.StatusNotFound)),
-				Code: http.StatusNotFound,
-			})
-			return
-		}
+		writeError(w, r, err, detail(registry.ErrNotFound, "Schema with id=%v is not registered", valRequest.SchemaID))
+		return
+	}
 
+	if !valid {
+		body, _ := json.Marshal(insertInfo{
+			Message:    "Schema is not valid",
+			Violations: violations,
+		})
 		writeResponse(w, responseBodyAndCode{
-			Body: serializeErrorMessage(http.StatusText(http.StatusInternalServerError)),
-			Code: http.StatusInternalServerError,
+			Body: body,
+			Code: http.StatusConflict,
 		})
 		return
 	}
 
-	if !valid {
+	if len(violations) > 0 {
 		body, _ := json.Marshal(insertInfo{
-			Message: "Schema is not valid",
+			Message:    "Schema is valid with warnings",
+			Violations: violations,
 		})
 		writeResponse(w, responseBodyAndCode{
 			Body: body,
-			Code: http.StatusConflict,
+			Code: http.StatusOK,
 		})
 		return
 	}
@@ -0,0 +1,81 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dataphos/schema-registry/registry"
+)
+
+func TestLatestAsOfAndHistory(t *testing.T) {
+	srv := newTestServer(t)
+
+	do := func(method, path, body string, status int, v interface{}) {
+		request, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer response.Body.Close()
+		encoded, _ := io.ReadAll(response.Body)
+		if response.StatusCode != status {
+			t.Fatalf("%s %s: expected status %d, got %d: %s", method, path, status, response.StatusCode, encoded)
+		}
+		if v != nil {
+			if err = json.Unmarshal(encoded, v); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	latestAsOf := func(asOf time.Time) string {
+		return "/schemas/1/versions/latest?as_of=" + url.QueryEscape(asOf.Format(time.RFC3339Nano))
+	}
+
+	do(http.MethodPost, "/schemas", `{"name":"person","schema_type":"json","specification":"{}","publisher_id":"a","compatibility_mode":"none","validity_mode":"none"}`, http.StatusCreated, nil)
+	do(http.MethodPut, "/schemas/1", `{"specification":"{\"type\":\"object\"}"}`, http.StatusOK, nil)
+	do(http.MethodDelete, "/schemas/1/versions/2", "", http.StatusOK, nil)
+
+	var events []registry.VersionEvent
+	do(http.MethodGet, "/schemas/1/history", "", http.StatusOK, &events)
+	var history []string
+	for _, event := range events {
+		history = append(history, event.Version+" "+event.Event)
+	}
+	expected := []string{"1 activated", "2 activated", "2 deactivated"}
+	if strings.Join(history, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected history %v, got %v", expected, history)
+	}
+
+	var details registry.VersionDetails
+	do(http.MethodGet, latestAsOf(events[1].Timestamp), "", http.StatusOK, &details)
+	if details.Version != "2" || !details.VersionDeactivated {
+		t.Errorf("expected the since deactivated version 2, got version %s", details.Version)
+	}
+	do(http.MethodGet, latestAsOf(events[2].Timestamp), "", http.StatusOK, &details)
+	if details.Version != "1" {
+		t.Errorf("expected version 1 after version 2 was deactivated, got version %s", details.Version)
+	}
+	do(http.MethodGet, latestAsOf(events[0].Timestamp.Add(-time.Second)), "", http.StatusNotFound, nil)
+}
@@ -0,0 +1,65 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// LimitBody rejects the requests with bodies larger than maxSize bytes with status 413. The body is read before the
+// request is passed on, so the handlers never see a truncated body.
+func LimitBody(maxSize int64) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > maxSize {
+				writeBodyTooLarge(w, r, maxSize)
+				return
+			}
+			if r.Body != nil && r.Body != http.NoBody {
+				body, err := io.ReadAll(io.LimitReader(r.Body, maxSize+1))
+				_ = r.Body.Close()
+				if err != nil {
+					writeProblem(w, r, http.StatusBadRequest, "The request body couldn't be read")
+					return
+				}
+				if int64(len(body)) > maxSize {
+					writeBodyTooLarge(w, r, maxSize)
+					return
+				}
+				r.Body = io.NopCloser(bytes.NewReader(body))
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+func writeBodyTooLarge(w http.ResponseWriter, r *http.Request, maxSize int64) {
+	writeProblem(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body is larger than the maximum of %d bytes", maxSize))
+}
+
+// schemaTooLarge writes back status 413 if the schema is larger than the maximum schema size of the handler.
+func (h Handler) schemaTooLarge(w http.ResponseWriter, r *http.Request, schema string) bool {
+	if h.maxSchemaSize <= 0 || len(schema) <= h.maxSchemaSize {
+		return false
+	}
+	writeProblem(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("Schema is larger than the maximum of %d bytes", h.maxSchemaSize))
+	return true
+}
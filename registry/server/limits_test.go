@@ -0,0 +1,118 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dataphos/schema-registry/compatibility"
+	"github.com/dataphos/schema-registry/registry"
+	"github.com/dataphos/schema-registry/validity"
+)
+
+func TestLimits(t *testing.T) {
+	compChecker := compatibility.CheckerFunc(func(context.Context, string, []string, string) (bool, error) {
+		return true, nil
+	})
+	valChecker := validity.CheckerFunc(func(context.Context, string, string, string) (bool, error) {
+		return true, nil
+	})
+	service := registry.New(newMemoryRepository(), compChecker, valChecker, "BACKWARD", "none")
+	srv := httptest.NewServer(New(NewHandler(service, newTestLogger()),
+		WithMaxBodySize(512),
+		WithMaxSchemaSize(64),
+		WithRateLimit(5, time.Minute),
+	))
+	defer srv.Close()
+
+	register := func(specification string) string {
+		return `{"name":"person","schema_type":"json","specification":` + strconv.Quote(specification) + `,"compatibility_mode":"none","validity_mode":"none"}`
+	}
+	largeSchema := `{"type":"object","description":"` + strings.Repeat("a", 64) + `"}`
+
+	tt := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"accepted", register(`{"type":"object"}`), http.StatusCreated},
+		{"schema too large", register(largeSchema), http.StatusRequestEntityTooLarge},
+		{"body too large", register(`{"type":"object","description":"` + strings.Repeat("a", 512) + `"}`), http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tc := range tt {
+		response, err := http.Post(srv.URL+"/schemas", "application/json", strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = response.Body.Close()
+		if response.StatusCode != tc.status {
+			t.Errorf("%s: expected status %d, got %d", tc.name, tc.status, response.StatusCode)
+		}
+	}
+
+	// three requests were made so far, so the limit of five is reached after two more
+	for i := 0; i < 3; i++ {
+		response, err := http.Get(srv.URL + "/schemas")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = response.Body.Close()
+		expected := http.StatusOK
+		if i == 2 {
+			expected = http.StatusTooManyRequests
+		}
+		if response.StatusCode != expected {
+			t.Errorf("request %d: expected status %d, got %d", i, expected, response.StatusCode)
+		}
+	}
+}
+
+func TestRateLimitIgnoresForwardedHeaders(t *testing.T) {
+	service := registry.New(newMemoryRepository(), nil, nil, "BACKWARD", "none")
+	srv := httptest.NewServer(New(NewHandler(service, newTestLogger()), WithRateLimit(2, time.Minute)))
+	defer srv.Close()
+
+	for i := 0; i < 3; i++ {
+		request, err := http.NewRequest(http.MethodGet, srv.URL+"/health", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// a new spoofed address on every request must not start a new limit
+		address := "203.0.113." + strconv.Itoa(i+1)
+		request.Header.Set("X-Forwarded-For", address)
+		request.Header.Set("X-Real-IP", address)
+		request.Header.Set("True-Client-IP", address)
+
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = response.Body.Close()
+		expected := http.StatusOK
+		if i == 2 {
+			expected = http.StatusTooManyRequests
+		}
+		if response.StatusCode != expected {
+			t.Errorf("request %d: expected status %d, got %d", i, expected, response.StatusCode)
+		}
+	}
+}
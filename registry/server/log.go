@@ -33,6 +33,7 @@ func RequestLogger(log logger.Log) func(next http.Handler) http.Handler {
 			t1 := time.Now()
 			defer func() {
 				fields := logger.F{
+					"request_id":     middleware.GetReqID(r.Context()),
 					"method":         r.Method,
 					"path":           r.URL.Path,
 					"remote_adrr":    r.RemoteAddr,
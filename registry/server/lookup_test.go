@@ -0,0 +1,93 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dataphos/schema-registry/registry"
+)
+
+func TestLookup(t *testing.T) {
+	srv := newTestServer(t)
+
+	do := func(method, path, body string, status int) []registry.SchemaMatch {
+		request, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer response.Body.Close()
+		encoded, _ := io.ReadAll(response.Body)
+		if response.StatusCode != status {
+			t.Fatalf("%s %s: expected status %d, got %d: %s", method, path, status, response.StatusCode, encoded)
+		}
+		var matches []registry.SchemaMatch
+		if status == http.StatusOK {
+			if err = json.Unmarshal(encoded, &matches); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return matches
+	}
+	register := func(name, schemaType, validityMode, specification string) {
+		do(http.MethodPost, "/schemas", `{"name":"`+name+`","schema_type":"`+schemaType+`","specification":`+strconv.Quote(specification)+
+			`,"compatibility_mode":"none","validity_mode":"`+validityMode+`"}`, http.StatusCreated)
+	}
+	lookup := func(schemaType, specification string) string {
+		return `{"schema_type":"` + schemaType + `","specification":` + strconv.Quote(specification) + `}`
+	}
+
+	register("order", "avro", "syntax-only", `{"type":"record","name":"Order","fields":[{"name":"id","type":"string"}]}`)
+	register("person", "json", "none", `{"type":"object"}`)
+
+	matches := do(http.MethodPost, "/schemas/lookup", lookup("avro", `{"name": "Order", "type": "record", "fields": [{"type": "string", "name": "id"}]}`), http.StatusOK)
+	if len(matches) != 1 || matches[0].SchemaID != "1" || matches[0].Version != "1" || matches[0].AvroFingerprint == "" {
+		t.Fatalf("expected the reformatted avro schema to match version 1 of schema 1, got %+v", matches)
+	}
+	order := matches[0]
+
+	matches = do(http.MethodPost, "/schemas/lookup", lookup("json", `{"type":"object"}`), http.StatusOK)
+	if len(matches) != 1 || matches[0].SchemaID != "2" {
+		t.Fatalf("expected the json schema registered as is to match schema 2, got %+v", matches)
+	}
+	do(http.MethodPost, "/schemas/lookup", lookup("json", `{"type":"array"}`), http.StatusNotFound)
+	do(http.MethodPost, "/schemas/lookup", lookup("yaml", `{}`), http.StatusBadRequest)
+
+	matches = do(http.MethodGet, "/fingerprints/"+order.SchemaHash, "", http.StatusOK)
+	if len(matches) != 1 || matches[0].SchemaID != "1" {
+		t.Fatalf("expected the schema hash to match schema 1, got %+v", matches)
+	}
+
+	// an avro single-object encoded message carries the fingerprint after its C3 01 marker
+	fingerprint, _ := hex.DecodeString(order.AvroFingerprint)
+	message := append(append([]byte{0xC3, 0x01}, fingerprint...), 0x02, 'a')
+	matches = do(http.MethodGet, "/fingerprints/"+strings.ToUpper(hex.EncodeToString(message[2:10])), "", http.StatusOK)
+	if len(matches) != 1 || matches[0].SchemaID != "1" || matches[0].Version != "1" {
+		t.Fatalf("expected the avro fingerprint to match version 1 of schema 1, got %+v", matches)
+	}
+
+	do(http.MethodGet, "/fingerprints/0000000000000000", "", http.StatusNotFound)
+	do(http.MethodGet, "/fingerprints/not-a-fingerprint", "", http.StatusBadRequest)
+}
@@ -0,0 +1,611 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dataphos/schema-registry/registry"
+)
+
+// memoryRepository is an in-memory registry.Repository, which behaves like the Postgres one for the purposes of
+// testing the handlers.
+type memoryRepository struct {
+	mu      sync.Mutex
+	schemas map[string]*registry.Schema
+	lastID  int
+	audit   []registry.AuditRecord
+	usage   []registry.Usage
+	aliases map[string]map[string]registry.Alias
+	events  []registry.VersionEvent
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{
+		schemas: map[string]*registry.Schema{},
+		aliases: map[string]map[string]registry.Alias{},
+	}
+}
+
+func (m *memoryRepository) CreateSchema(ctx context.Context, request registry.SchemaRegistrationRequest) (registry.VersionDetails, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	hash := hashOf([]byte(request.Specification))
+	for _, schema := range m.schemas {
+		if schema.PublisherID != request.PublisherID {
+			continue
+		}
+		for _, details := range schema.VersionDetails {
+			if details.SchemaHash == hash && !details.VersionDeactivated {
+				return details, false, nil
+			}
+		}
+	}
+
+	m.lastID++
+	id := strconv.Itoa(m.lastID)
+	details := registry.VersionDetails{
+		VersionID:       id,
+		Version:         "1",
+		SchemaID:        id,
+		Specification:   []byte(request.Specification),
+		Description:     request.Description,
+		SchemaHash:      hash,
+		CreatedAt:       time.Now(),
+		Attributes:      request.Attributes,
+		Rules:           request.Rules,
+		Semver:          request.Semver,
+		AvroFingerprint: request.AvroFingerprint,
+	}
+	m.schemas[id] = &registry.Schema{
+		SchemaID:          id,
+		SchemaType:        request.SchemaType,
+		Name:              request.Name,
+		VersionDetails:    []registry.VersionDetails{details},
+		Description:       request.Description,
+		LastCreated:       "1",
+		PublisherID:       request.PublisherID,
+		CompatibilityMode: request.CompatibilityMode,
+		ValidityMode:      request.ValidityMode,
+	}
+	m.event(registry.VersionEventActivated, id, "1", hash)
+	m.record(ctx, registry.AuditCreateSchema, id, "1", "", hash)
+	return details, true, nil
+}
+
+func (m *memoryRepository) GetSchemaVersionByIdAndVersion(_ context.Context, id string, version string) (registry.VersionDetails, error) {
+	if _, err := strconv.Atoi(id); err != nil {
+		return registry.VersionDetails{}, registry.ErrInvalidValueHeader
+	}
+	if _, err := strconv.Atoi(version); err != nil {
+		return registry.VersionDetails{}, registry.ErrInvalidValueHeader
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if schema, ok := m.schemas[id]; ok {
+		for _, details := range schema.VersionDetails {
+			if details.Version == version && !details.VersionDeactivated {
+				return details, nil
+			}
+		}
+	}
+	return registry.VersionDetails{}, registry.ErrNotFound
+}
+
+func (m *memoryRepository) UpdateSchemaById(ctx context.Context, id string, request registry.SchemaUpdateRequest) (registry.VersionDetails, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	schema, ok := m.schemas[id]
+	if !ok {
+		return registry.VersionDetails{}, false, registry.ErrNotFound
+	}
+
+	hash := hashOf([]byte(request.Specification))
+	for _, details := range schema.VersionDetails {
+		if details.SchemaHash == hash {
+			return details, false, nil
+		}
+	}
+
+	beforeHash := ""
+	if active := activeSchema(*schema); len(active.VersionDetails) > 0 {
+		beforeHash = active.VersionDetails[len(active.VersionDetails)-1].SchemaHash
+	}
+
+	lastCreated, _ := strconv.Atoi(schema.LastCreated)
+	schema.LastCreated = strconv.Itoa(lastCreated + 1)
+	details := registry.VersionDetails{
+		VersionID:       strconv.Itoa(len(schema.VersionDetails) + 1),
+		Version:         schema.LastCreated,
+		SchemaID:        id,
+		Specification:   []byte(request.Specification),
+		Description:     request.Description,
+		SchemaHash:      hash,
+		CreatedAt:       time.Now(),
+		Attributes:      request.Attributes,
+		Rules:           request.Rules,
+		Semver:          request.Semver,
+		AvroFingerprint: request.AvroFingerprint,
+	}
+	schema.VersionDetails = append(schema.VersionDetails, details)
+	m.event(registry.VersionEventActivated, id, details.Version, hash)
+	m.record(ctx, registry.AuditUpdateSchema, id, details.Version, beforeHash, hash)
+	return details, true, nil
+}
+
+func (m *memoryRepository) GetSchemaVersionsById(_ context.Context, id string) (registry.Schema, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	schema, ok := m.schemas[id]
+	if !ok {
+		return registry.Schema{}, registry.ErrNotFound
+	}
+	active := activeSchema(*schema)
+	if len(active.VersionDetails) == 0 {
+		return registry.Schema{}, registry.ErrNotFound
+	}
+	return active, nil
+}
+
+func (m *memoryRepository) GetAllSchemaVersions(_ context.Context, id string) (registry.Schema, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	schema, ok := m.schemas[id]
+	if !ok {
+		return registry.Schema{}, registry.ErrNotFound
+	}
+	return *schema, nil
+}
+
+func (m *memoryRepository) GetSchemaMetadata(ctx context.Context, id string) (registry.Schema, error) {
+	schema, err := m.GetAllSchemaVersions(ctx, id)
+	if err != nil {
+		return registry.Schema{}, err
+	}
+	versions := make([]registry.VersionDetails, len(schema.VersionDetails))
+	for i, details := range schema.VersionDetails {
+		details.Specification = nil
+		versions[i] = details
+	}
+	schema.VersionDetails = versions
+	return schema, nil
+}
+
+func (m *memoryRepository) GetLatestSchemaVersion(ctx context.Context, id string) (registry.VersionDetails, error) {
+	schema, err := m.GetSchemaVersionsById(ctx, id)
+	if err != nil {
+		return registry.VersionDetails{}, err
+	}
+	return schema.VersionDetails[len(schema.VersionDetails)-1], nil
+}
+
+func (m *memoryRepository) DeleteSchema(ctx context.Context, id string, usedSince time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	schema, ok := m.schemas[id]
+	if !ok {
+		return false, nil
+	}
+	if err := m.checkNotInUse(schema, "", usedSince); err != nil {
+		return false, err
+	}
+	deleted := false
+	beforeHash := ""
+	for i := range schema.VersionDetails {
+		if !schema.VersionDetails[i].VersionDeactivated {
+			schema.VersionDetails[i].VersionDeactivated = true
+			deleted = true
+			m.event(registry.VersionEventDeactivated, id, schema.VersionDetails[i].Version, schema.VersionDetails[i].SchemaHash)
+			beforeHash = schema.VersionDetails[i].SchemaHash
+		}
+	}
+	if deleted {
+		m.record(ctx, registry.AuditDeleteSchema, id, "", beforeHash, "")
+	}
+	return deleted, nil
+}
+
+func (m *memoryRepository) DeleteSchemaVersion(ctx context.Context, id, version string, usedSince time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	schema, ok := m.schemas[id]
+	if !ok {
+		return false, nil
+	}
+	if err := m.checkNotInUse(schema, version, usedSince); err != nil {
+		return false, err
+	}
+	for i := range schema.VersionDetails {
+		if schema.VersionDetails[i].Version == version && !schema.VersionDetails[i].VersionDeactivated {
+			schema.VersionDetails[i].VersionDeactivated = true
+			m.event(registry.VersionEventDeactivated, id, version, schema.VersionDetails[i].SchemaHash)
+			m.record(ctx, registry.AuditDeleteSchemaVersion, id, version, schema.VersionDetails[i].SchemaHash, "")
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// checkNotInUse returns a registry.InUseError if an active version of the schema, or the given one, was used since
+// usedSince. Expects the lock to be held.
+func (m *memoryRepository) checkNotInUse(schema *registry.Schema, version string, usedSince time.Time) error {
+	if usedSince.IsZero() {
+		return nil
+	}
+	var usages []registry.Usage
+	for _, details := range schema.VersionDetails {
+		if details.VersionDeactivated || (version != "" && details.Version != version) {
+			continue
+		}
+		for _, usage := range m.usage {
+			if usage.SchemaID == schema.SchemaID && usage.Version == details.Version && !usage.LastSeen.Before(usedSince) {
+				usages = append(usages, usage)
+			}
+		}
+	}
+	if len(usages) > 0 {
+		return &registry.InUseError{Usage: usages}
+	}
+	return nil
+}
+
+func (m *memoryRepository) UpdateSchemaMetadata(ctx context.Context, id string, request registry.SchemaMetadataRequest) (registry.Schema, error) {
+	if _, err := strconv.Atoi(id); err != nil {
+		return registry.Schema{}, registry.ErrInvalidValueHeader
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	schema, ok := m.schemas[id]
+	if !ok {
+		return registry.Schema{}, registry.ErrNotFound
+	}
+	var changes []registry.MetadataChange
+	update := func(field string, current *string, value *string) {
+		if value == nil {
+			return
+		}
+		if *current != *value {
+			changes = append(changes, registry.MetadataChange{Field: field, Before: *current, After: *value})
+		}
+		*current = *value
+	}
+	update("name", &schema.Name, request.Name)
+	update("description", &schema.Description, request.Description)
+	update("publisher_id", &schema.PublisherID, request.PublisherID)
+
+	latestHash := ""
+	if active := activeSchema(*schema); len(active.VersionDetails) > 0 {
+		latestHash = active.VersionDetails[len(active.VersionDetails)-1].SchemaHash
+	}
+	m.record(ctx, registry.AuditUpdateSchemaMetadata, id, "", latestHash, latestHash)
+	m.audit[len(m.audit)-1].Changes = changes
+
+	metadata := *schema
+	metadata.VersionDetails = nil
+	return metadata, nil
+}
+
+func (m *memoryRepository) RestoreSchemaVersion(ctx context.Context, id, version string) (registry.VersionDetails, bool, error) {
+	if _, err := strconv.Atoi(id); err != nil {
+		return registry.VersionDetails{}, false, registry.ErrInvalidValueHeader
+	}
+	if _, err := strconv.Atoi(version); err != nil {
+		return registry.VersionDetails{}, false, registry.ErrInvalidValueHeader
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	schema, ok := m.schemas[id]
+	if !ok {
+		return registry.VersionDetails{}, false, registry.ErrNotFound
+	}
+	for i := range schema.VersionDetails {
+		details := &schema.VersionDetails[i]
+		if details.Version != version {
+			continue
+		}
+		if !details.VersionDeactivated {
+			return *details, false, nil
+		}
+		details.VersionDeactivated = false
+		m.event(registry.VersionEventActivated, id, version, details.SchemaHash)
+		m.record(ctx, registry.AuditRestoreSchemaVersion, id, version, "", details.SchemaHash)
+		return *details, true, nil
+	}
+	return registry.VersionDetails{}, false, registry.ErrNotFound
+}
+
+func (m *memoryRepository) GetAllSchemas(_ context.Context) ([]registry.Schema, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var schemas []registry.Schema
+	for _, schema := range m.schemas {
+		schemas = append(schemas, *schema)
+	}
+	if len(schemas) == 0 {
+		return nil, registry.ErrNotFound
+	}
+	sortSchemas(schemas)
+	return schemas, nil
+}
+
+func (m *memoryRepository) GetSchemas(_ context.Context) ([]registry.Schema, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var schemas []registry.Schema
+	for _, schema := range m.schemas {
+		if active := activeSchema(*schema); len(active.VersionDetails) > 0 {
+			schemas = append(schemas, active)
+		}
+	}
+	if len(schemas) == 0 {
+		return nil, registry.ErrNotFound
+	}
+	sortSchemas(schemas)
+	return schemas, nil
+}
+
+func (m *memoryRepository) GetAuditRecords(_ context.Context, query registry.AuditQuery) ([]registry.AuditRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// the ids are the positions of the records, starting at 1
+	after, _ := strconv.Atoi(query.After)
+	if after > len(m.audit) {
+		after = len(m.audit)
+	}
+	var records []registry.AuditRecord
+	for _, record := range m.audit[after:] {
+		if query.SchemaID != "" && record.SchemaID != query.SchemaID {
+			continue
+		}
+		if record.Timestamp.Before(query.Since) {
+			continue
+		}
+		if len(records) == query.Limit {
+			break
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (m *memoryRepository) RegisterUsage(_ context.Context, usage registry.Usage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, registered := range m.usage {
+		registered.LastSeen = usage.LastSeen
+		if registered == usage {
+			m.usage[i] = usage
+			return nil
+		}
+	}
+	m.usage = append(m.usage, usage)
+	return nil
+}
+
+func (m *memoryRepository) GetUsage(_ context.Context, query registry.UsageQuery) ([]registry.Usage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := strconv.Atoi(query.SchemaID); err != nil {
+		return nil, registry.ErrInvalidValueHeader
+	}
+	var usages []registry.Usage
+	for _, usage := range m.usage {
+		if usage.SchemaID != query.SchemaID || (query.Version != "" && usage.Version != query.Version) {
+			continue
+		}
+		if usage.LastSeen.Before(query.Since) {
+			continue
+		}
+		usages = append(usages, usage)
+	}
+	return usages, nil
+}
+
+func (m *memoryRepository) SetAlias(ctx context.Context, id, alias, version string) (registry.Alias, error) {
+	details, err := m.GetSchemaVersionByIdAndVersion(ctx, id, version)
+	if err != nil {
+		return registry.Alias{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	previous, ok := m.aliases[id][alias]
+	if ok && previous.Version == version {
+		return previous, nil
+	}
+	var beforeHash string
+	if ok {
+		beforeHash = m.hashOfVersion(id, previous.Version)
+	}
+	if m.aliases[id] == nil {
+		m.aliases[id] = map[string]registry.Alias{}
+	}
+	schemaAlias := registry.Alias{SchemaID: id, Alias: alias, Version: version, UpdatedAt: time.Now().UTC()}
+	m.aliases[id][alias] = schemaAlias
+	m.record(ctx, registry.AuditSetAlias, id, version, beforeHash, details.SchemaHash)
+	m.audit[len(m.audit)-1].Alias = alias
+	return schemaAlias, nil
+}
+
+func (m *memoryRepository) GetAliases(_ context.Context, id string) ([]registry.Alias, error) {
+	if _, err := strconv.Atoi(id); err != nil {
+		return nil, registry.ErrInvalidValueHeader
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var aliases []registry.Alias
+	for _, alias := range m.aliases[id] {
+		aliases = append(aliases, alias)
+	}
+	sort.Slice(aliases, func(i, j int) bool {
+		return aliases[i].Alias < aliases[j].Alias
+	})
+	return aliases, nil
+}
+
+func (m *memoryRepository) DeleteAlias(ctx context.Context, id, alias string) (bool, error) {
+	if _, err := strconv.Atoi(id); err != nil {
+		return false, registry.ErrInvalidValueHeader
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	previous, ok := m.aliases[id][alias]
+	if !ok {
+		return false, nil
+	}
+	delete(m.aliases[id], alias)
+	m.record(ctx, registry.AuditDeleteAlias, id, "", m.hashOfVersion(id, previous.Version), "")
+	m.audit[len(m.audit)-1].Alias = alias
+	return true, nil
+}
+
+func (m *memoryRepository) GetSchemaVersionsByFingerprint(_ context.Context, query registry.FingerprintQuery) ([]registry.SchemaMatch, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var matches []registry.SchemaMatch
+	for _, schema := range m.schemas {
+		if query.SchemaType != "" && schema.SchemaType != query.SchemaType {
+			continue
+		}
+		for _, details := range activeSchema(*schema).VersionDetails {
+			if !contains(query.SchemaHashes, details.SchemaHash) && (details.AvroFingerprint == "" || !contains(query.AvroFingerprints, details.AvroFingerprint)) {
+				continue
+			}
+			matches = append(matches, registry.SchemaMatch{
+				SchemaID:        schema.SchemaID,
+				Name:            schema.Name,
+				SchemaType:      schema.SchemaType,
+				PublisherID:     schema.PublisherID,
+				Version:         details.Version,
+				Semver:          details.Semver,
+				SchemaHash:      details.SchemaHash,
+				AvroFingerprint: details.AvroFingerprint,
+			})
+		}
+	}
+	return matches, nil
+}
+
+func (m *memoryRepository) GetSchemaVersionEvents(_ context.Context, id string) ([]registry.VersionEvent, error) {
+	if _, err := strconv.Atoi(id); err != nil {
+		return nil, registry.ErrInvalidValueHeader
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.schemas[id]; !ok {
+		return nil, registry.ErrNotFound
+	}
+	var events []registry.VersionEvent
+	for _, event := range m.events {
+		if event.SchemaID == id {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// hashOfVersion returns the hash of the given version of the schema, the caller must hold the lock.
+func (m *memoryRepository) hashOfVersion(id, version string) string {
+	if schema, ok := m.schemas[id]; ok {
+		for _, details := range schema.VersionDetails {
+			if details.Version == version {
+				return details.SchemaHash
+			}
+		}
+	}
+	return ""
+}
+
+// record appends the audit record of a mutation, the caller must hold the lock.
+func (m *memoryRepository) record(ctx context.Context, action, id, version, beforeHash, afterHash string) {
+	record := registry.NewAuditRecord(ctx, action, id, version, beforeHash, afterHash)
+	record.ID = strconv.Itoa(len(m.audit) + 1)
+	m.audit = append(m.audit, record)
+}
+
+// event appends the activation or deactivation of a version, the caller must hold the lock.
+func (m *memoryRepository) event(event, id, version, schemaHash string) {
+	m.events = append(m.events, registry.VersionEvent{
+		SchemaID:   id,
+		Version:    version,
+		Event:      event,
+		SchemaHash: schemaHash,
+		Timestamp:  time.Now().UTC(),
+	})
+}
+
+func activeSchema(schema registry.Schema) registry.Schema {
+	var active []registry.VersionDetails
+	for _, details := range schema.VersionDetails {
+		if !details.VersionDeactivated {
+			active = append(active, details)
+		}
+	}
+	schema.VersionDetails = active
+	return schema
+}
+
+func sortSchemas(schemas []registry.Schema) {
+	sort.Slice(schemas, func(i, j int) bool {
+		first, _ := strconv.Atoi(schemas[i].SchemaID)
+		second, _ := strconv.Atoi(schemas[j].SchemaID)
+		return first < second
+	})
+}
+
+func hashOf(specification []byte) string {
+	sum := sha256.Sum256(specification)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,105 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dataphos/schema-registry/registry"
+)
+
+func TestMetadataAndRestore(t *testing.T) {
+	srv := newTestServer(t)
+
+	do := func(method, path, body string, status int, v interface{}) {
+		request, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer response.Body.Close()
+		encoded, _ := io.ReadAll(response.Body)
+		if response.StatusCode != status {
+			t.Fatalf("%s %s: expected status %d, got %d: %s", method, path, status, response.StatusCode, encoded)
+		}
+		if v != nil {
+			if err = json.Unmarshal(encoded, v); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	do(http.MethodPost, "/schemas", `{"name":"person","description":"v1","schema_type":"json","specification":"{}","publisher_id":"a","compatibility_mode":"none","validity_mode":"none"}`, http.StatusCreated, nil)
+	do(http.MethodPut, "/schemas/1", `{"description":"v2","specification":"{\"type\":\"object\"}"}`, http.StatusOK, nil)
+
+	var before, schema registry.Schema
+	do(http.MethodGet, "/schemas/1/versions", "", http.StatusOK, &before)
+	do(http.MethodPatch, "/schemas/1", `{"name":"human","publisher_id":"b"}`, http.StatusOK, &schema)
+	if schema.Name != "human" || schema.PublisherID != "b" || schema.Description != before.Description {
+		t.Errorf("expected only the name and publisher id to change, got %+v", schema)
+	}
+	schema = registry.Schema{}
+	do(http.MethodGet, "/schemas/1/versions", "", http.StatusOK, &schema)
+	if schema.Name != "human" || len(schema.VersionDetails) != 2 {
+		t.Errorf("expected the metadata change to keep both versions, got %+v", schema)
+	}
+
+	do(http.MethodDelete, "/schemas/1/versions/1", "", http.StatusOK, nil)
+	var info insertInfo
+	do(http.MethodPost, "/schemas/1/versions/1/restore", "", http.StatusOK, &info)
+	if info.Version != "1" {
+		t.Errorf("expected the version to be restored as version 1, got %s", info.Version)
+	}
+	var details registry.VersionDetails
+	do(http.MethodGet, "/schemas/1/versions/1", "", http.StatusOK, &details)
+	if details.VersionDeactivated {
+		t.Error("expected the restored version to be active")
+	}
+	do(http.MethodGet, "/schemas/1/versions/latest", "", http.StatusOK, &details)
+	if details.Version != "2" {
+		t.Errorf("expected version 2 to stay the latest, got %s", details.Version)
+	}
+
+	var records []registry.AuditRecord
+	do(http.MethodGet, "/audit?schema_id=1", "", http.StatusOK, &records)
+	var actions []string
+	for _, record := range records {
+		actions = append(actions, record.Action)
+	}
+	expected := []string{registry.AuditCreateSchema, registry.AuditUpdateSchema, registry.AuditUpdateSchemaMetadata, registry.AuditDeleteSchemaVersion, registry.AuditRestoreSchemaVersion}
+	if strings.Join(actions, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected audit actions %v, got %v", expected, actions)
+	}
+
+	metadataRecord := records[2]
+	expectedChanges := []registry.MetadataChange{
+		{Field: "name", Before: "person", After: "human"},
+		{Field: "publisher_id", Before: "a", After: "b"},
+	}
+	if !reflect.DeepEqual(metadataRecord.Changes, expectedChanges) {
+		t.Errorf("expected the metadata changes %+v, got %+v", expectedChanges, metadataRecord.Changes)
+	}
+	if metadataRecord.BeforeHash == "" || metadataRecord.BeforeHash != records[1].AfterHash || metadataRecord.AfterHash != records[1].AfterHash {
+		t.Errorf("expected the hashes of the latest version, got %q and %q", metadataRecord.BeforeHash, metadataRecord.AfterHash)
+	}
+}
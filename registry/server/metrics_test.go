@@ -0,0 +1,106 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// counterValue returns the value of the counter with the given name and labels, or 0 if it wasn't recorded yet.
+func counterValue(t *testing.T, name string, labels map[string]string) float64 {
+	t.Helper()
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			if hasLabels(metric, labels) {
+				return metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+func hasLabels(metric *dto.Metric, labels map[string]string) bool {
+	matched := 0
+	for _, pair := range metric.GetLabel() {
+		if value, ok := labels[pair.GetName()]; ok {
+			if value != pair.GetValue() {
+				return false
+			}
+			matched++
+		}
+	}
+	return matched == len(labels)
+}
+
+func TestRequestMetrics(t *testing.T) {
+	srv := newTestServer(t)
+
+	tt := []struct {
+		name   string
+		path   string
+		route  string
+		status int
+	}{
+		{"matched route", "/schemas/1/versions/1", "/schemas/{id}/versions/{version}", http.StatusNotFound},
+		{"health", "/health", "/health", http.StatusOK},
+		{"unmatched route", "/unknown/path", unmatchedRoute, http.StatusNotFound},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			requestLabels := map[string]string{"method": http.MethodGet, "route": tc.route}
+			errorsBefore := counterValue(t, "schema_registry_http_request_errors_total", requestLabels)
+
+			response, err := http.Get(srv.URL + tc.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			response.Body.Close()
+			if response.StatusCode != tc.status {
+				t.Fatalf("expected status %d, got %d", tc.status, response.StatusCode)
+			}
+
+			requests := counterValue(t, "schema_registry_http_requests_total", map[string]string{
+				"method": http.MethodGet,
+				"route":  tc.route,
+				"code":   strconv.Itoa(tc.status),
+			})
+			if requests == 0 {
+				t.Errorf("request to %s not recorded under route %s", tc.path, tc.route)
+			}
+
+			errorsAfter := counterValue(t, "schema_registry_http_request_errors_total", requestLabels)
+			if tc.status >= 400 && errorsAfter != errorsBefore+1 {
+				t.Errorf("expected the error to be counted, got %v errors (%v before)", errorsAfter, errorsBefore)
+			}
+			if tc.status < 400 && errorsAfter != errorsBefore {
+				t.Errorf("successful request counted as an error")
+			}
+		})
+	}
+}
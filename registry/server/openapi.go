@@ -0,0 +1,41 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+
+	"github.com/dataphos/schema-registry/docs"
+)
+
+const (
+	defaultBaseUrl = "/"
+)
+
+// getOpenAPI returns a GET method which writes back the OpenAPI 3.1 document of the registry, with baseUrl as its server.
+func getOpenAPI(baseUrl string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		document, err := docs.OpenAPI(baseUrl)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		writeResponse(w, responseBodyAndCode{
+			Body: document,
+			Code: http.StatusOK,
+		})
+	}
+}
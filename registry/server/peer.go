@@ -0,0 +1,49 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/httprate"
+)
+
+type peerAddressKey struct{}
+
+// PeerAddress stores the address of the peer of the connection in the request context, as it's seen before any
+// middleware replaces the remote address of the request.
+func PeerAddress(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), peerAddressKey{}, r.RemoteAddr)))
+	}
+	return http.HandlerFunc(fn)
+}
+
+// peerAddressOf returns the peer address stored by PeerAddress, or the remote address of the request if there's none.
+func peerAddressOf(r *http.Request) string {
+	if address, ok := r.Context().Value(peerAddressKey{}).(string); ok {
+		return address
+	}
+	return r.RemoteAddr
+}
+
+// keyByPeerIP keys the rate limit by the IP address of the peer of the connection. Unlike the remote address set by
+// RealIP, it can't be chosen by the client with the X-Forwarded-For, X-Real-IP or True-Client-IP headers.
+func keyByPeerIP(r *http.Request) (string, error) {
+	peer := *r
+	peer.RemoteAddr = peerAddressOf(r)
+	return httprate.KeyByIP(&peer)
+}
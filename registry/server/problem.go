@@ -0,0 +1,177 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/pkg/errors"
+
+	"github.com/dataphos/schema-registry/internal/errcodes"
+	"github.com/dataphos/schema-registry/registry"
+	"github.com/dataphos/schema-registry/registry/examples"
+	"github.com/dataphos/schema-registry/validity"
+)
+
+// problemContentType is the media type of the problem details of RFC 7807.
+const problemContentType = "application/problem+json"
+
+// problemTypePrefix prefixes the name of a problem type into its URI.
+const problemTypePrefix = "urn:schema-registry:problem:"
+
+// undecodableBody is the detail of the problem written back if the body of a request can't be decoded.
+const undecodableBody = "The request body isn't valid JSON of the expected request"
+
+// problem is the body of every error response, following RFC 7807. Code identifies the kind of the problem with one of
+// the API codes of the errcodes package, while Detail explains the occurrence of the problem to a human.
+type problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	Code      int    `json:"code"`
+	RequestID string `json:"request_id,omitempty"`
+	// Violations are the lint rule violations which made a schema invalid.
+	Violations []validity.Violation `json:"violations,omitempty"`
+	// Usage is the usage which prevented a deletion.
+	Usage []registry.Usage `json:"usage,omitempty"`
+}
+
+// problemType is a kind of problem, written back with its status unless the route overrides it.
+type problemType struct {
+	name   string
+	title  string
+	status int
+	code   int
+}
+
+// The generic problem types, by their status.
+var statusProblemTypes = map[int]problemType{
+	http.StatusBadRequest:            {"bad-request", "Bad request", http.StatusBadRequest, errcodes.BadRequest},
+	http.StatusNotFound:              {"not-found", "Not found", http.StatusNotFound, errcodes.NotFound},
+	http.StatusMethodNotAllowed:      {"method-not-allowed", "Method not allowed", http.StatusMethodNotAllowed, errcodes.MethodNotAllowed},
+	http.StatusNotAcceptable:         {"not-acceptable", "Not acceptable", http.StatusNotAcceptable, errcodes.NotAcceptable},
+	http.StatusRequestEntityTooLarge: {"payload-too-large", "Payload too large", http.StatusRequestEntityTooLarge, errcodes.PayloadTooLarge},
+	http.StatusTooManyRequests:       {"too-many-requests", "Too many requests", http.StatusTooManyRequests, errcodes.TooManyRequests},
+	http.StatusInternalServerError:   {"internal-server-error", "Internal server error", http.StatusInternalServerError, errcodes.InternalServerError},
+}
+
+// errorProblemTypes map the errors of the registry to the problems they cause.
+var errorProblemTypes = []struct {
+	err error
+	problemType
+}{
+	{registry.ErrNotFound, statusProblemTypes[http.StatusNotFound]},
+	{registry.ErrInvalidValueHeader, problemType{"invalid-value", "Invalid value", http.StatusBadRequest, errcodes.InvalidValue}},
+	{registry.ErrUnknownComp, problemType{"unknown-compatibility-mode", "Unknown compatibility mode", http.StatusBadRequest, errcodes.UnknownCompatibilityMode}},
+	{registry.ErrUnknownVal, problemType{"unknown-validity-mode", "Unknown validity mode", http.StatusBadRequest, errcodes.UnknownValidityMode}},
+	{registry.ErrUnknownFormat, problemType{"unknown-schema-format", "Unknown schema format", http.StatusBadRequest, errcodes.UnknownSchemaFormat}},
+	{registry.ErrNotValid, problemType{"schema-not-valid", "Schema is not valid", http.StatusBadRequest, errcodes.SchemaNotValid}},
+	{registry.ErrNotComp, problemType{"schema-not-compatible", "Schemas are not compatible", http.StatusBadRequest, errcodes.SchemaNotCompatible}},
+	{registry.ErrBreakingChange, problemType{"breaking-change", "Breaking change", http.StatusBadRequest, errcodes.BreakingChange}},
+	{registry.ErrInvalidRules, problemType{"invalid-rules", "Invalid data contract rules", http.StatusBadRequest, errcodes.InvalidRules}},
+	{registry.ErrInvalidUsage, problemType{"invalid-usage", "Invalid usage registration", http.StatusBadRequest, errcodes.InvalidUsage}},
+	{registry.ErrInvalidAlias, problemType{"invalid-alias", "Invalid alias", http.StatusBadRequest, errcodes.InvalidAlias}},
+	{registry.ErrInvalidFingerprint, problemType{"invalid-fingerprint", "Invalid fingerprint", http.StatusBadRequest, errcodes.InvalidFingerprint}},
+	{registry.ErrInvalidMetadata, problemType{"invalid-metadata", "Invalid schema metadata", http.StatusBadRequest, errcodes.InvalidMetadata}},
+	{registry.ErrAdmissionDenied, problemType{"admission-denied", "Denied by admission webhook", http.StatusForbidden, errcodes.AdmissionDenied}},
+	{registry.ErrInUse, problemType{"schema-in-use", "Schema is in use", http.StatusConflict, errcodes.SchemaInUse}},
+	{registry.ErrNotCanonicalizable, problemType{"not-canonicalizable", "Schema can't be canonicalized", http.StatusUnprocessableEntity, errcodes.NotCanonicalizable}},
+	{examples.ErrUnsupportedType, problemType{"examples-unsupported", "Examples can't be generated", http.StatusUnprocessableEntity, errcodes.ExamplesUnsupported}},
+	{examples.ErrUnsupportedSchema, problemType{"examples-unsupported", "Examples can't be generated", http.StatusUnprocessableEntity, errcodes.ExamplesUnsupported}},
+	{registry.ErrAdmissionUnavailable, problemType{"admission-unavailable", "Admission webhook unavailable", http.StatusServiceUnavailable, errcodes.AdmissionUnavailable}},
+}
+
+// problemDetail describes the errors matching err from the point of view of a route.
+type problemDetail struct {
+	err    error
+	detail string
+	// status overrides the status of the problem type if it isn't zero.
+	status int
+}
+
+// detail returns a problemDetail explaining the errors matching err with the formatted message.
+func detail(err error, format string, args ...interface{}) problemDetail {
+	return problemDetail{err: err, detail: fmt.Sprintf(format, args...)}
+}
+
+// withStatus returns the problemDetail, written back with the given status.
+func (d problemDetail) withStatus(status int) problemDetail {
+	d.status = status
+	return d
+}
+
+// writeError writes back the problem the error causes. The details describe the errors in the context of the route,
+// otherwise the message of a known error is its detail. Unknown errors are internal server errors, written back
+// without a detail, so nothing internal is leaked.
+func writeError(w http.ResponseWriter, r *http.Request, err error, details ...problemDetail) {
+	for _, known := range errorProblemTypes {
+		if !errors.Is(err, known.err) {
+			continue
+		}
+		p := newProblem(r, known.problemType, err.Error())
+		for _, d := range details {
+			if errors.Is(err, d.err) {
+				p.Detail = d.detail
+				if d.status != 0 {
+					p.Status = d.status
+				}
+				break
+			}
+		}
+		p.Violations = violationsOf(err)
+		p.Usage = usageOf(err)
+		writeProblemBody(w, p)
+		return
+	}
+
+	writeProblem(w, r, http.StatusInternalServerError, "")
+}
+
+// writeProblem writes back the generic problem with the given status.
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	pt, ok := statusProblemTypes[status]
+	if !ok {
+		pt = problemType{title: http.StatusText(status), status: status, code: int(errcodes.FromHttpStatusCode(status))}
+	}
+	writeProblemBody(w, newProblem(r, pt, detail))
+}
+
+func newProblem(r *http.Request, pt problemType, detail string) problem {
+	p := problem{
+		Type:      "about:blank",
+		Title:     pt.title,
+		Status:    pt.status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		Code:      pt.code,
+		RequestID: middleware.GetReqID(r.Context()),
+	}
+	if pt.name != "" {
+		p.Type = problemTypePrefix + pt.name
+	}
+	return p
+}
+
+func writeProblemBody(w http.ResponseWriter, p problem) {
+	body, _ := json.Marshal(p)
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(p.Status)
+	_, _ = w.Write(body)
+}
@@ -0,0 +1,123 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dataphos/schema-registry/internal/errcodes"
+)
+
+func TestProblems(t *testing.T) {
+	srv := newTestServer(t)
+
+	post := func(path, body string) {
+		response, err := http.Post(srv.URL+path, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+	}
+	post("/schemas", `{"name":"person","schema_type":"json","specification":"{}","publisher_id":"a","compatibility_mode":"none","validity_mode":"none"}`)
+
+	tt := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		status   int
+		typ      string
+		code     int
+		detail   string
+		instance string
+	}{
+		{"unknown version", http.MethodGet, "/schemas/1/versions/2", "", http.StatusNotFound, "urn:schema-registry:problem:not-found", errcodes.NotFound, "Schema with id=1 and version=2 is not registered", "/schemas/1/versions/2"},
+		{"invalid version", http.MethodGet, "/schemas/1/versions/first", "", http.StatusUnprocessableEntity, "urn:schema-registry:problem:invalid-value", errcodes.InvalidValue, "Id=1 and/or version=first are not of supported data types", "/schemas/1/versions/first"},
+		{"undecodable body", http.MethodPost, "/schemas", "{", http.StatusBadRequest, "urn:schema-registry:problem:bad-request", errcodes.BadRequest, undecodableBody, "/schemas"},
+		{"unknown format", http.MethodPost, "/schemas", `{"name":"person","schema_type":"yaml","specification":"{}"}`, http.StatusBadRequest, "urn:schema-registry:problem:unknown-schema-format", errcodes.UnknownSchemaFormat, "Unknown format value", "/schemas"},
+		{"unknown compatibility mode", http.MethodGet, "/schemas/1/compatibility-matrix?mode=sideways", "", http.StatusBadRequest, "urn:schema-registry:problem:unknown-compatibility-mode", errcodes.UnknownCompatibilityMode, "Unknown compatibility mode", "/schemas/1/compatibility-matrix"},
+		{"unknown route", http.MethodGet, "/subjects", "", http.StatusNotFound, "urn:schema-registry:problem:not-found", errcodes.NotFound, "", "/subjects"},
+		{"method not allowed", http.MethodPatch, "/schemas/1/versions", "", http.StatusMethodNotAllowed, "urn:schema-registry:problem:method-not-allowed", errcodes.MethodNotAllowed, "", "/schemas/1/versions"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			request, err := http.NewRequest(tc.method, srv.URL+tc.path, strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			response, err := http.DefaultClient.Do(request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer response.Body.Close()
+			encoded, _ := io.ReadAll(response.Body)
+
+			if response.StatusCode != tc.status {
+				t.Fatalf("expected status %d, got %d: %s", tc.status, response.StatusCode, encoded)
+			}
+			if contentType := response.Header.Get("Content-Type"); contentType != problemContentType {
+				t.Errorf("expected content type %s, got %s", problemContentType, contentType)
+			}
+			var p problem
+			if err = json.Unmarshal(encoded, &p); err != nil {
+				t.Fatal(err)
+			}
+			if p.Type != tc.typ || p.Status != tc.status || p.Code != tc.code || p.Detail != tc.detail || p.Instance != tc.instance {
+				t.Errorf("unexpected problem %+v", p)
+			}
+			if p.Title == "" || p.RequestID == "" {
+				t.Errorf("expected a title and a request id, got %+v", p)
+			}
+		})
+	}
+}
+
+func TestMethodNotAllowedListsAllowedMethods(t *testing.T) {
+	srv := newTestServer(t)
+
+	tt := []struct {
+		method  string
+		path    string
+		allowed string
+	}{
+		{http.MethodPost, "/schemas/1/versions/1", "GET,DELETE"},
+		{http.MethodDelete, "/fingerprints/0000000000000000", "GET"},
+		{http.MethodGet, "/schemas/lookup", "POST"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			request, err := http.NewRequest(tc.method, srv.URL+tc.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			response, err := http.DefaultClient.Do(request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			response.Body.Close()
+
+			if response.StatusCode != http.StatusMethodNotAllowed {
+				t.Fatalf("expected status 405, got %d", response.StatusCode)
+			}
+			if allowed := strings.Join(response.Header.Values("Allow"), ","); allowed != tc.allowed {
+				t.Errorf("expected %s to be allowed, got %q", tc.allowed, allowed)
+			}
+		})
+	}
+}
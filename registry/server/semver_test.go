@@ -0,0 +1,141 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dataphos/schema-registry/compatibility"
+	"github.com/dataphos/schema-registry/registry"
+	"github.com/dataphos/schema-registry/validity"
+)
+
+func TestSemanticVersioning(t *testing.T) {
+	// unlike the checker of newTestServer, this one respects the none mode, like the real checker does
+	compChecker := compatibility.CheckerFunc(func(_ context.Context, schema string, _ []string, mode string) (bool, error) {
+		return strings.EqualFold(mode, "none") || !strings.Contains(schema, "incompatible"), nil
+	})
+	service := registry.New(newMemoryRepository(), compChecker, validity.CheckerFunc(func(context.Context, string, string, string) (bool, error) {
+		return true, nil
+	}), "BACKWARD", "none", registry.WithSemanticVersioning(true))
+	srv := httptest.NewServer(New(NewHandler(service, newTestLogger())))
+	defer srv.Close()
+
+	do := func(method, path, body string, v interface{}) {
+		request, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer response.Body.Close()
+		if response.StatusCode >= http.StatusBadRequest {
+			body, _ := io.ReadAll(response.Body)
+			t.Fatalf("%s %s: unexpected status %d: %s", method, path, response.StatusCode, body)
+		}
+		if err = json.NewDecoder(response.Body).Decode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	update := func(specification string) string {
+		return `{"specification":` + strconv.Quote(specification) + `}`
+	}
+
+	var info insertInfo
+	do(http.MethodPost, "/schemas", `{"name":"person","schema_type":"json","specification":"{\"type\":\"object\",\"properties\":{\"name\":{\"type\":\"string\"}}}","compatibility_mode":"none","validity_mode":"none"}`, &info)
+	if info.Semver != "1.0.0" {
+		t.Fatalf("expected semver 1.0.0, got %q", info.Semver)
+	}
+
+	tt := []struct {
+		name          string
+		specification string
+		semver        string
+	}{
+		{"description", `{"type":"object","description":"a person","properties":{"name":{"type":"string"}}}`, "1.0.1"},
+		{"optional field", `{"type":"object","properties":{"name":{"type":"string"},"age":{"type":"integer"}}}`, "1.1.0"},
+		{"breaking change", `{"type":"object","properties":{"incompatible":{"type":"string"}}}`, "2.0.0"},
+	}
+	for _, tc := range tt {
+		do(http.MethodPut, "/schemas/1", update(tc.specification), &info)
+		if info.Semver != tc.semver {
+			t.Errorf("%s: expected semver %s, got %q", tc.name, tc.semver, info.Semver)
+		}
+	}
+
+	var details registry.VersionDetails
+	do(http.MethodGet, "/schemas/1/versions/1.1.0", "", &details)
+	if details.Version != "3" || details.Semver != "1.1.0" {
+		t.Errorf("expected 1.1.0 to resolve to version 3, got version %s with semver %s", details.Version, details.Semver)
+	}
+}
+
+func TestAllowBreakingChange(t *testing.T) {
+	compChecker := compatibility.CheckerFunc(func(_ context.Context, schema string, _ []string, mode string) (bool, error) {
+		return strings.EqualFold(mode, "none") || !strings.Contains(schema, "incompatible"), nil
+	})
+	service := registry.New(newMemoryRepository(), compChecker, validity.CheckerFunc(func(context.Context, string, string, string) (bool, error) {
+		return true, nil
+	}), "BACKWARD", "none", registry.WithSemanticVersioning(true))
+	srv := httptest.NewServer(New(NewHandler(service, newTestLogger())))
+	defer srv.Close()
+
+	do := func(method, path, body string) (int, insertInfo) {
+		request, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer response.Body.Close()
+		var info insertInfo
+		_ = json.NewDecoder(response.Body).Decode(&info)
+		return response.StatusCode, info
+	}
+
+	for _, mode := range []string{"BACKWARD", "FORWARD_TRANSITIVE", "FULL"} {
+		t.Run(mode, func(t *testing.T) {
+			status, info := do(http.MethodPost, "/schemas", `{"name":"person","schema_type":"json","specification":"{\"title\":\"`+mode+`\"}","compatibility_mode":"`+mode+`","validity_mode":"none"}`)
+			if status != http.StatusCreated {
+				t.Fatalf("expected status 201, got %d", status)
+			}
+			path := "/schemas/" + info.Id
+			breaking := `{"type":"object","properties":{"incompatible":{"type":"string"},"mode":{"const":"` + mode + `"}}}`
+
+			if status, _ = do(http.MethodPut, path, `{"specification":`+strconv.Quote(breaking)+`}`); status != http.StatusBadRequest {
+				t.Fatalf("expected an incompatible version to be refused with status 400, got %d", status)
+			}
+
+			status, info = do(http.MethodPut, path, `{"specification":`+strconv.Quote(breaking)+`,"allow_breaking_change":true}`)
+			if status != http.StatusOK {
+				t.Fatalf("expected allow_breaking_change to let the version through, got status %d", status)
+			}
+			if info.Version != "2" || info.Semver != "2.0.0" {
+				t.Errorf("expected version 2 with semver 2.0.0, got version %s with semver %q", info.Version, info.Semver)
+			}
+		})
+	}
+}
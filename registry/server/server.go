@@ -18,23 +18,128 @@ package server
 import (
 	httpSwagger "github.com/swaggo/http-swagger"
 	"net/http"
+	"strings"
 	"time"
 
 	_ "github.com/dataphos/schema-registry/docs"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/cors"
+	"github.com/go-chi/httprate"
 )
 
-// New sets up the schema registry endpoints.
-func New(h *Handler) http.Handler {
+// Option configures the endpoints set up by New.
+type Option func(*options)
+
+// routeMethods are the methods listed in the Allow header of the 405 responses.
+var routeMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+type options struct {
+	requestTimeout  time.Duration
+	maxBodySize     int64
+	maxSchemaSize   int
+	cors            *cors.Options
+	rateLimit       int
+	rateLimitWindow time.Duration
+	baseUrl         string
+}
+
+// WithRequestTimeout sets the time after which the context of a request is canceled, 30 seconds by default.
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.requestTimeout = timeout
+	}
+}
+
+// WithMaxBodySize rejects the requests with bodies larger than the given number of bytes.
+func WithMaxBodySize(size int64) Option {
+	return func(o *options) {
+		o.maxBodySize = size
+	}
+}
+
+// WithMaxSchemaSize rejects the schemas larger than the given number of bytes.
+func WithMaxSchemaSize(size int) Option {
+	return func(o *options) {
+		o.maxSchemaSize = size
+	}
+}
+
+// WithCORS answers the cross-origin requests as configured by the given options.
+func WithCORS(corsOptions cors.Options) Option {
+	return func(o *options) {
+		o.cors = &corsOptions
+	}
+}
+
+// WithRateLimit limits every client, identified by its IP address, to the given number of requests in the window.
+func WithRateLimit(requests int, window time.Duration) Option {
+	return func(o *options) {
+		o.rateLimit = requests
+		o.rateLimitWindow = window
+	}
+}
+
+// WithBaseURL sets the URL the registry is reachable at, as seen by the clients, which the OpenAPI document advertises.
+// Unless it's set, a relative URL is used, so the document works behind a proxy and on any port.
+func WithBaseURL(baseUrl string) Option {
+	return func(o *options) {
+		if baseUrl != "" {
+			o.baseUrl = baseUrl
+		}
+	}
+}
+
+// New sets up the schema registry endpoints. The body and schema sizes and the request rate aren't limited unless
+// the options say so.
+func New(handler *Handler, opts ...Option) http.Handler {
+	o := options{requestTimeout: 30 * time.Second, baseUrl: defaultBaseUrl}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	h := *handler
+	h.maxSchemaSize = o.maxSchemaSize
+
 	router := chi.NewRouter()
 
 	router.Use(middleware.StripSlashes)
+	router.Use(middleware.RequestID)
+	// the peer address is kept before RealIP overwrites it with the headers, which any client can set
+	router.Use(PeerAddress)
 	router.Use(middleware.RealIP)
 	router.Use(middleware.Recoverer)
-	router.Use(middleware.Timeout(30 * time.Second))
+	if o.cors != nil {
+		router.Use(cors.Handler(*o.cors))
+	}
+	router.Use(middleware.Timeout(o.requestTimeout))
 
+	router.Use(RequestTracing)
 	router.Use(RequestLogger(h.log))
+	router.Use(RequestMetrics)
+	router.Use(RequestAudit)
+	// the limits are applied after the logging and metrics middleware, so the rejected requests are observed as well
+	if o.rateLimit > 0 {
+		router.Use(httprate.Limit(o.rateLimit, o.rateLimitWindow,
+			httprate.WithKeyFuncs(keyByPeerIP),
+			httprate.WithLimitHandler(func(w http.ResponseWriter, r *http.Request) {
+				writeProblem(w, r, http.StatusTooManyRequests, "")
+			}),
+		))
+	}
+	if o.maxBodySize > 0 {
+		router.Use(LimitBody(o.maxBodySize))
+	}
+
+	router.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		writeProblem(w, r, http.StatusNotFound, "")
+	})
+	router.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		// a custom handler replaces the one of chi which lists the allowed methods, so they're found again
+		for _, method := range allowedMethods(router, r.URL.Path) {
+			w.Header().Add("Allow", method)
+		}
+		writeProblem(w, r, http.StatusMethodNotAllowed, "")
+	})
 
 	router.Route("/schemas", func(router chi.Router) {
 		router.Get("/", h.GetSchemas)
@@ -44,6 +149,16 @@ func New(h *Handler) http.Handler {
 		router.Route("/{id}", func(router chi.Router) {
 			router.Delete("/", h.DeleteSchema)
 			router.Put("/", h.PutSchema)
+			router.Patch("/", h.PatchSchema)
+			router.Get("/compatibility-matrix", h.GetCompatibilityMatrix)
+			router.Get("/usage", h.GetUsage)
+			router.Get("/history", h.GetSchemaHistory)
+
+			router.Route("/aliases", func(router chi.Router) {
+				router.Get("/", h.GetAliases)
+				router.Put("/{alias}", h.PutAlias)
+				router.Delete("/{alias}", h.DeleteAlias)
+			})
 
 			router.Route("/versions", func(router chi.Router) {
 				router.Get("/", h.GetSchemaVersionsById)
@@ -57,13 +172,22 @@ func New(h *Handler) http.Handler {
 					router.Route("/spec", func(router chi.Router) {
 						router.Get("/", h.GetSpecificationByIdAndVersion)
 					})
+
+					router.Get("/examples", h.GetExamples)
+					router.Post("/usage", h.PostUsage)
+					router.Post("/restore", h.RestoreSchemaVersion)
 				})
 			})
 		})
 
 		router.Get("/search", h.SearchSchemas)
+		router.Post("/lookup", h.LookupSchema)
 	})
 
+	router.Get("/fingerprints/{fingerprint}", h.GetFingerprint)
+
+	router.Get("/audit", h.GetAudit)
+
 	router.Get("/health", h.HealthCheck)
 
 	router.Post("/check/compatibility", h.SchemaCompatibility)
@@ -72,9 +196,63 @@ func New(h *Handler) http.Handler {
 	router.Post("/check/validity", h.SchemaValidity)
 	router.Get("/check/validity/health", h.HealthCheck)
 
+	router.Get("/openapi.json", getOpenAPI(o.baseUrl))
+
+	ui := getUI()
+	router.Get("/ui", ui)
+	router.Get("/ui/*", ui)
+
+	// the relative url is resolved against the Swagger UI page, so it works regardless of the host and port
 	router.Get("/swagger/*", httpSwagger.Handler(
-		httpSwagger.URL("http://localhost:8080/swagger/doc.json"), //The url pointing to API definition
+		httpSwagger.URL("doc.json"),
 	))
 
 	return router
 }
+
+// allowedMethods returns the methods of the routes matching the path, in the order of routeMethods. Like in the
+// routing of chi, the static segments take precedence, so only the routes with the fewest parameters are considered.
+func allowedMethods(routes chi.Routes, path string) []string {
+	matched, fewest := make(map[string]bool), -1
+	_ = chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		parameters, ok := routeMatches(route, path)
+		if !ok || (fewest != -1 && parameters > fewest) {
+			return nil
+		}
+		if parameters != fewest {
+			matched, fewest = make(map[string]bool), parameters
+		}
+		matched[method] = true
+		return nil
+	})
+
+	var allowed []string
+	for _, method := range routeMethods {
+		if matched[method] {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
+
+// routeMatches checks if the path matches the route pattern, with a parameter matching any segment and a wildcard
+// matching the rest of the path, and returns the number of parameters the route matched the path with.
+func routeMatches(route, path string) (int, bool) {
+	routeSegments := strings.Split(strings.Trim(route, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	parameters := 0
+	for i, segment := range routeSegments {
+		if segment == "*" {
+			return parameters, true
+		}
+		if i >= len(pathSegments) {
+			return 0, false
+		}
+		if strings.HasPrefix(segment, "{") {
+			parameters++
+		} else if segment != pathSegments[i] {
+			return 0, false
+		}
+	}
+	return parameters, len(routeSegments) == len(pathSegments)
+}
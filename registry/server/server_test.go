@@ -0,0 +1,378 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	"github.com/dataphos/lib-logger/logger"
+	"github.com/dataphos/lib-logger/standardlogger"
+	"github.com/dataphos/schema-registry/compatibility"
+	"github.com/dataphos/schema-registry/docs"
+	"github.com/dataphos/schema-registry/registry"
+	"github.com/dataphos/schema-registry/validity"
+)
+
+const openAPIResource = "openapi.json"
+
+// openAPIDocument is the part of the OpenAPI document the contract tests need.
+type openAPIDocument struct {
+	Servers []struct {
+		URL string `json:"url"`
+	} `json:"servers"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Responses map[string]openAPIResponse `json:"responses"`
+	} `json:"components"`
+}
+
+type openAPIOperation struct {
+	Responses map[string]openAPIResponse `json:"responses"`
+}
+
+type openAPIResponse struct {
+	Ref     string                     `json:"$ref"`
+	Content map[string]json.RawMessage `json:"content"`
+}
+
+func loadOpenAPI(t *testing.T) (openAPIDocument, []byte) {
+	encoded, err := docs.OpenAPI("/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var document openAPIDocument
+	if err = json.Unmarshal(encoded, &document); err != nil {
+		t.Fatal(err)
+	}
+	return document, encoded
+}
+
+// newTestLogger returns a logger which only logs panics, so the expected error responses don't clutter the test output.
+func newTestLogger() logger.Log {
+	return standardlogger.New(nil, standardlogger.WithLogLevel(logger.LevelPanic))
+}
+
+func newTestServer(t *testing.T, opts ...Option) *httptest.Server {
+	compChecker := compatibility.CheckerFunc(func(_ context.Context, schema string, _ []string, _ string) (bool, error) {
+		return !strings.Contains(schema, "incompatible"), nil
+	})
+	valChecker := validity.CheckerFunc(func(_ context.Context, schema, _, _ string) (bool, error) {
+		return !strings.Contains(schema, "invalid"), nil
+	})
+	service := registry.New(newMemoryRepository(), compChecker, valChecker, "BACKWARD", "none")
+
+	srv := httptest.NewServer(New(NewHandler(service, newTestLogger()), opts...))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// operationPath returns the path template of the OpenAPI document which matches the given request path.
+func operationPath(document openAPIDocument, requestPath string) (string, bool) {
+	requestSegments := strings.Split(strings.Trim(requestPath, "/"), "/")
+
+	best, bestParameters := "", len(requestSegments)+1
+	for path := range document.Paths {
+		segments := strings.Split(strings.Trim(path, "/"), "/")
+		if len(segments) != len(requestSegments) {
+			continue
+		}
+		parameters, matches := 0, true
+		for i, segment := range segments {
+			if strings.HasPrefix(segment, "{") {
+				parameters++
+			} else if segment != requestSegments[i] {
+				matches = false
+				break
+			}
+		}
+		// literal segments take precedence over path parameters, like in the router
+		if matches && parameters < bestParameters {
+			best, bestParameters = path, parameters
+		}
+	}
+	return best, best != ""
+}
+
+func TestOpenAPIDocumentsAllRoutes(t *testing.T) {
+	document, _ := loadOpenAPI(t)
+
+	router := New(NewHandler(registry.New(newMemoryRepository(), nil, nil, "", ""), newTestLogger())).(chi.Routes)
+	err := chi.Walk(router, func(method string, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		// the Swagger UI and the web UI are pages, not a part of the API
+		if strings.HasPrefix(route, "/swagger") || strings.HasPrefix(route, "/ui") {
+			return nil
+		}
+		path := strings.TrimSuffix(route, "/")
+		if path == "" {
+			path = "/"
+		}
+		operations, ok := document.Paths[path]
+		if !ok {
+			t.Errorf("route %s isn't documented", path)
+			return nil
+		}
+		if _, ok = operations[strings.ToLower(method)]; !ok {
+			t.Errorf("method %s of route %s isn't documented", method, path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenAPIServerURL(t *testing.T) {
+	srv := newTestServer(t, WithBaseURL("https://example.com/registry"))
+
+	response, err := http.Get(srv.URL + "/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	var document openAPIDocument
+	if err = json.NewDecoder(response.Body).Decode(&document); err != nil {
+		t.Fatal(err)
+	}
+	if len(document.Servers) != 1 || document.Servers[0].URL != "https://example.com/registry" {
+		t.Errorf("servers not as expected: %v", document.Servers)
+	}
+}
+
+func TestContract(t *testing.T) {
+	document, encoded := loadOpenAPI(t)
+
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft2020
+	if err := compiler.AddResource(openAPIResource, bytes.NewReader(encoded)); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := newTestServer(t)
+
+	jsonSchema := `{"type":"object","properties":{"name":{"type":"string"}}}`
+	register := func(specification string) string {
+		return fmt.Sprintf(`{"name":"person","schema_type":"json","specification":%s,"publisher_id":"publisher","compatibility_mode":"none","validity_mode":"none"}`, strconv.Quote(specification))
+	}
+
+	// the steps are executed in order against the same server, since they depend on each other's state
+	tt := []struct {
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{http.MethodGet, "/health", "", http.StatusOK},
+		{http.MethodGet, "/check/compatibility/health", "", http.StatusOK},
+		{http.MethodGet, "/check/validity/health", "", http.StatusOK},
+		{http.MethodGet, "/openapi.json", "", http.StatusOK},
+		{http.MethodGet, "/schemas", "", http.StatusOK},
+		{http.MethodGet, "/schemas/all", "", http.StatusNotFound},
+		{http.MethodPost, "/schemas", "not json", http.StatusBadRequest},
+		{http.MethodPost, "/schemas", `{"name":"person","schema_type":"yaml","specification":"{}"}`, http.StatusBadRequest},
+		{http.MethodPost, "/schemas", `{"name":"person","schema_type":"json","specification":"{}","rules":[{"name":"positive","expression":"message.amount > 0","severity":"fatal"}]}`, http.StatusBadRequest},
+		{http.MethodPost, "/schemas", register(jsonSchema), http.StatusCreated},
+		{http.MethodPost, "/schemas", register(jsonSchema), http.StatusConflict},
+		{http.MethodGet, "/schemas", "", http.StatusOK},
+		{http.MethodGet, "/schemas/all", "", http.StatusOK},
+		{http.MethodGet, "/schemas/search?name=person&orderBy=id", "", http.StatusOK},
+		{http.MethodGet, "/schemas/search?orderBy=size", "", http.StatusBadRequest},
+		{http.MethodGet, "/schemas/search?name=animal", "", http.StatusNotFound},
+		{http.MethodPost, "/schemas/lookup", fmt.Sprintf(`{"schema_type":"json","specification":%s}`, strconv.Quote(jsonSchema)), http.StatusOK},
+		{http.MethodPost, "/schemas/lookup", `{"schema_type":"json","specification":"{}"}`, http.StatusNotFound},
+		{http.MethodPost, "/schemas/lookup", `{"schema_type":"yaml","specification":"{}"}`, http.StatusBadRequest},
+		{http.MethodGet, "/fingerprints/" + fmt.Sprintf("%x", sha256.Sum256([]byte(jsonSchema))), "", http.StatusOK},
+		{http.MethodGet, "/fingerprints/" + strings.Repeat("0", 16), "", http.StatusNotFound},
+		{http.MethodGet, "/fingerprints/person", "", http.StatusBadRequest},
+		{http.MethodGet, "/schemas/1/versions", "", http.StatusOK},
+		{http.MethodGet, "/schemas/2/versions", "", http.StatusNotFound},
+		{http.MethodGet, "/schemas/1/versions/all", "", http.StatusOK},
+		{http.MethodGet, "/schemas/1/versions/latest", "", http.StatusOK},
+		{http.MethodGet, "/schemas/2/versions/latest", "", http.StatusNotFound},
+		{http.MethodGet, "/schemas/1/versions/1", "", http.StatusOK},
+		{http.MethodGet, "/schemas/1/versions/2", "", http.StatusNotFound},
+		{http.MethodGet, "/schemas/1/versions/first", "", http.StatusUnprocessableEntity},
+		{http.MethodGet, "/schemas/1/versions/1/spec", "", http.StatusOK},
+		{http.MethodGet, "/schemas/1/versions/2/spec", "", http.StatusNotFound},
+		{http.MethodGet, "/schemas/1/versions/1/spec?format=raw", "", http.StatusOK},
+		{http.MethodGet, "/schemas/1/versions/1/spec?format=raw&canonical=true", "", http.StatusOK},
+		{http.MethodGet, "/schemas/1/versions/1/spec?format=yaml", "", http.StatusBadRequest},
+		{http.MethodGet, "/schemas/1/versions/first/spec", "", http.StatusUnprocessableEntity},
+		{http.MethodGet, "/schemas/1/versions/1/examples?count=3&seed=1", "", http.StatusOK},
+		{http.MethodGet, "/schemas/1/versions/1/examples?count=0", "", http.StatusBadRequest},
+		{http.MethodGet, "/schemas/1/versions/2/examples", "", http.StatusNotFound},
+		{http.MethodPut, "/schemas/1", "not json", http.StatusBadRequest},
+		{http.MethodPut, "/schemas/1", `{"specification":"invalid"}`, http.StatusBadRequest},
+		{http.MethodPut, "/schemas/2", `{"specification":"{}"}`, http.StatusNotFound},
+		{http.MethodPut, "/schemas/1", fmt.Sprintf(`{"specification":%s}`, strconv.Quote(jsonSchema)), http.StatusConflict},
+		{http.MethodPut, "/schemas/1", `{"specification":"{}","rules":[{"name":"","expression":"true"}]}`, http.StatusBadRequest},
+		{http.MethodPut, "/schemas/1", `{"specification":"{\"type\":\"object\"}"}`, http.StatusOK},
+		{http.MethodPatch, "/schemas/1", `{"description":"a person","publisher_id":"people"}`, http.StatusOK},
+		{http.MethodPatch, "/schemas/1", "not json", http.StatusBadRequest},
+		{http.MethodPatch, "/schemas/1", `{}`, http.StatusBadRequest},
+		{http.MethodPatch, "/schemas/1", `{"name":" "}`, http.StatusBadRequest},
+		{http.MethodPatch, "/schemas/2", `{"name":"animal"}`, http.StatusNotFound},
+		{http.MethodPatch, "/schemas/first", `{"name":"animal"}`, http.StatusUnprocessableEntity},
+		{http.MethodGet, "/schemas/1/compatibility-matrix?mode=FULL_TRANSITIVE", "", http.StatusOK},
+		{http.MethodGet, "/schemas/1/compatibility-matrix?mode=sideways", "", http.StatusBadRequest},
+		{http.MethodGet, "/schemas/2/compatibility-matrix", "", http.StatusNotFound},
+		{http.MethodPut, "/schemas/1/aliases/prod", "not json", http.StatusBadRequest},
+		{http.MethodPut, "/schemas/1/aliases/Prod", `{"version":"1"}`, http.StatusBadRequest},
+		{http.MethodPut, "/schemas/1/aliases/latest", `{"version":"1"}`, http.StatusBadRequest},
+		{http.MethodPut, "/schemas/1/aliases/prod", `{"version":"3"}`, http.StatusNotFound},
+		{http.MethodPut, "/schemas/1/aliases/prod", `{"version":"1"}`, http.StatusOK},
+		{http.MethodPut, "/schemas/1/aliases/stable", `{"version":"prod"}`, http.StatusOK},
+		{http.MethodPut, "/schemas/1/aliases/prod", `{"version":"2"}`, http.StatusOK},
+		{http.MethodGet, "/schemas/1/aliases", "", http.StatusOK},
+		{http.MethodGet, "/schemas/2/aliases", "", http.StatusNotFound},
+		{http.MethodGet, "/schemas/1/versions/prod", "", http.StatusOK},
+		{http.MethodGet, "/schemas/1/versions/stable/spec", "", http.StatusOK},
+		{http.MethodGet, "/schemas/1/versions/beta", "", http.StatusUnprocessableEntity},
+		{http.MethodDelete, "/schemas/1/aliases/stable", "", http.StatusOK},
+		{http.MethodDelete, "/schemas/1/aliases/stable", "", http.StatusNotFound},
+		{http.MethodPost, "/check/compatibility", "not json", http.StatusBadRequest},
+		{http.MethodPost, "/check/compatibility", `{"schema_id":"1","new_schema":"{}"}`, http.StatusOK},
+		{http.MethodPost, "/check/compatibility", `{"schema_id":"1","new_schema":"incompatible"}`, http.StatusConflict},
+		{http.MethodPost, "/check/compatibility", `{"schema_id":"2","new_schema":"{}"}`, http.StatusNotFound},
+		{http.MethodPost, "/check/validity", "not json", http.StatusBadRequest},
+		{http.MethodPost, "/check/validity", `{"new_schema":"{}","format":"json","mode":"full"}`, http.StatusOK},
+		{http.MethodPost, "/check/validity", `{"new_schema":"invalid","format":"json","mode":"full"}`, http.StatusConflict},
+		{http.MethodPost, "/check/validity", `{"new_schema":"{\"properties\":{\"id\":{}}}","format":"json","mode":"lint"}`, http.StatusOK},
+		{http.MethodPost, "/check/validity", `{"new_schema":"{\"properties\":{\"Id\":{}}}","format":"json","mode":"lint"}`, http.StatusConflict},
+		{http.MethodPost, "/schemas/1/versions/2/usage", `{"topic":"orders","role":"relay"}`, http.StatusBadRequest},
+		{http.MethodPost, "/schemas/1/versions/3/usage", `{"topic":"orders","role":"producer"}`, http.StatusNotFound},
+		{http.MethodPost, "/schemas/1/versions/2/usage", `{"topic":"orders","role":"producer","client_id":"orders-service"}`, http.StatusOK},
+		{http.MethodPost, "/schemas/1/versions/1/usage", `{"topic":"orders","role":"consumer"}`, http.StatusOK},
+		{http.MethodPost, "/schemas/1/versions/prod/usage", `{"topic":"payments","role":"consumer"}`, http.StatusOK},
+		{http.MethodGet, "/schemas/1/usage", "", http.StatusOK},
+		{http.MethodGet, "/schemas/1/usage?version=2", "", http.StatusOK},
+		{http.MethodGet, "/schemas/first/usage", "", http.StatusBadRequest},
+		{http.MethodDelete, "/schemas/1/versions/2", "", http.StatusConflict},
+		{http.MethodDelete, "/schemas/1/versions/2?force=maybe", "", http.StatusBadRequest},
+		{http.MethodDelete, "/schemas/1/versions/2?force=true", "", http.StatusOK},
+		{http.MethodDelete, "/schemas/1/versions/2", "", http.StatusNotFound},
+		{http.MethodPost, "/schemas/1/versions/2/restore", "", http.StatusOK},
+		{http.MethodPost, "/schemas/1/versions/2/restore", "", http.StatusConflict},
+		{http.MethodPost, "/schemas/1/versions/3/restore", "", http.StatusNotFound},
+		{http.MethodPost, "/schemas/1/versions/first/restore", "", http.StatusUnprocessableEntity},
+		{http.MethodGet, "/schemas/1/versions/latest?as_of=2999-01-01T00:00:00Z", "", http.StatusOK},
+		{http.MethodGet, "/schemas/1/versions/latest?as_of=2000-01-01T00:00:00Z", "", http.StatusNotFound},
+		{http.MethodGet, "/schemas/1/versions/latest?as_of=yesterday", "", http.StatusBadRequest},
+		{http.MethodDelete, "/schemas/1", "", http.StatusConflict},
+		{http.MethodDelete, "/schemas/1?force=true", "", http.StatusOK},
+		{http.MethodDelete, "/schemas/1", "", http.StatusNotFound},
+		{http.MethodGet, "/schemas/1/history", "", http.StatusOK},
+		{http.MethodGet, "/schemas/2/history", "", http.StatusNotFound},
+		{http.MethodGet, "/schemas/first/history", "", http.StatusBadRequest},
+		{http.MethodGet, "/schemas/1/versions/latest?as_of=2999-01-01T00:00:00Z", "", http.StatusNotFound},
+		{http.MethodGet, "/schemas", "", http.StatusOK},
+		{http.MethodGet, "/audit", "", http.StatusOK},
+		{http.MethodGet, "/audit?schema_id=1&since=2024-01-01T00:00:00Z", "", http.StatusOK},
+		{http.MethodGet, "/audit?since=yesterday", "", http.StatusBadRequest},
+	}
+
+	for _, tc := range tt {
+		name := fmt.Sprintf("%s %s %d", tc.method, tc.path, tc.status)
+
+		request, err := http.NewRequest(tc.method, srv.URL+tc.path, strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(response.Body)
+		_ = response.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if response.StatusCode != tc.status {
+			t.Errorf("%s: got status %d, body %s", name, response.StatusCode, body)
+			continue
+		}
+
+		path, ok := operationPath(document, strings.SplitN(tc.path, "?", 2)[0])
+		if !ok {
+			t.Errorf("%s: path isn't documented", name)
+			continue
+		}
+		var operation openAPIOperation
+		if err = json.Unmarshal(document.Paths[path][strings.ToLower(tc.method)], &operation); err != nil {
+			t.Errorf("%s: method isn't documented", name)
+			continue
+		}
+		status := strconv.Itoa(response.StatusCode)
+		documented, ok := operation.Responses[status]
+		if !ok {
+			t.Errorf("%s: status isn't documented", name)
+			continue
+		}
+
+		schemaPointer := "#/paths/" + escapePointer(path) + "/" + strings.ToLower(tc.method) + "/responses/" + status
+		if documented.Ref != "" {
+			schemaPointer = documented.Ref
+			documented = document.Components.Responses[strings.TrimPrefix(documented.Ref, "#/components/responses/")]
+		}
+		if len(documented.Content) == 0 {
+			continue
+		}
+		contentType, _, err := mime.ParseMediaType(response.Header.Get("Content-Type"))
+		if err != nil {
+			t.Errorf("%s: got content type %s", name, response.Header.Get("Content-Type"))
+			continue
+		}
+		if _, ok = documented.Content[contentType]; !ok {
+			t.Errorf("%s: %s content isn't documented", name, contentType)
+			continue
+		}
+		// only the JSON responses are validated against their schemas, the others are specification files
+		if contentType != "application/json" {
+			continue
+		}
+
+		schema, err := compiler.Compile(openAPIResource + schemaPointer + "/content/application~1json/schema")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded interface{}
+		if err = json.Unmarshal(body, &decoded); err != nil {
+			t.Errorf("%s: body isn't valid JSON: %s", name, body)
+			continue
+		}
+		if err = schema.Validate(decoded); err != nil {
+			t.Errorf("%s: body %s doesn't match the documented schema: %v", name, body, err)
+		}
+	}
+}
+
+func escapePointer(path string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(path, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,156 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/dataphos/schema-registry/registry"
+)
+
+// specificationFile is how the specifications of a schema type are served as files.
+type specificationFile struct {
+	mediaType string
+	extension string
+}
+
+var specificationFiles = map[string]specificationFile{
+	"json":     {"application/schema+json", "json"},
+	"avro":     {"application/vnd.apache.avro+json", "avsc"},
+	"protobuf": {"text/x-protobuf", "proto"},
+	"xml":      {"application/xml", "xsd"},
+	"csv":      {"text/csv", "csv"},
+}
+
+// defaultSpecificationFile is used for the schema types without a dedicated media type.
+var defaultSpecificationFile = specificationFile{"application/octet-stream", "txt"}
+
+func specificationFileOf(schemaType string) specificationFile {
+	if file, ok := specificationFiles[schemaType]; ok {
+		return file
+	}
+	return defaultSpecificationFile
+}
+
+// specificationOptions are the query parameters of the specification endpoint.
+type specificationOptions struct {
+	// format is either "json", "raw" or empty, in which case the Accept header decides.
+	format    string
+	canonical bool
+}
+
+func readSpecificationOptions(r *http.Request) (specificationOptions, error) {
+	var options specificationOptions
+
+	query := r.URL.Query()
+	switch format := strings.ToLower(query.Get("format")); format {
+	case "", "json", "raw":
+		options.format = format
+	default:
+		return specificationOptions{}, errors.New("format must be json or raw")
+	}
+	if canonical := query.Get("canonical"); canonical != "" {
+		parsed, err := strconv.ParseBool(canonical)
+		if err != nil {
+			return specificationOptions{}, errors.New("canonical must be a boolean")
+		}
+		options.canonical = parsed
+	}
+
+	return options, nil
+}
+
+// negotiateSpecification returns the media type the specification is served as, either application/json or the media
+// type of its schema type. The format query parameter takes precedence over the Accept header, and the specification
+// is served as application/json if neither is given. ok is false if none of the acceptable media types can be served.
+func negotiateSpecification(accept, format, schemaType string) (mediaType string, ok bool) {
+	raw := specificationFileOf(schemaType).mediaType
+	switch format {
+	case "json":
+		return "application/json", true
+	case "raw":
+		return raw, true
+	}
+	if strings.TrimSpace(accept) == "" {
+		return "application/json", true
+	}
+
+	// on equal quality, application/json is preferred, since it's the original behaviour of the endpoint
+	best, bestQuality := "", 0.0
+	for _, offer := range []string{"application/json", raw} {
+		if quality := acceptQuality(accept, offer); quality > bestQuality {
+			best, bestQuality = offer, quality
+		}
+	}
+	return best, best != ""
+}
+
+// acceptQuality returns the quality the Accept header gives to the media type, taken from the most specific media
+// range which matches it. Media ranges which can't be parsed are ignored.
+func acceptQuality(accept, mediaType string) float64 {
+	mainType := strings.SplitN(mediaType, "/", 2)[0]
+
+	quality, specificity := 0.0, -1
+	for _, element := range strings.Split(accept, ",") {
+		mediaRange, params, err := mime.ParseMediaType(strings.TrimSpace(element))
+		if err != nil {
+			continue
+		}
+
+		var rangeSpecificity int
+		switch mediaRange {
+		case mediaType:
+			rangeSpecificity = 2
+		case mainType + "/*":
+			rangeSpecificity = 1
+		case "*/*":
+			rangeSpecificity = 0
+		default:
+			continue
+		}
+		if rangeSpecificity <= specificity {
+			continue
+		}
+
+		rangeQuality := 1.0
+		if q, ok := params["q"]; ok {
+			if rangeQuality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		quality, specificity = rangeQuality, rangeSpecificity
+	}
+	return quality
+}
+
+// writeSpecificationFile writes the specification as a file of the given media type.
+func writeSpecificationFile(w http.ResponseWriter, specification registry.Specification, mediaType string) {
+	name := specification.Name
+	if name == "" {
+		name = specification.SchemaID
+	}
+	filename := fmt.Sprintf("%s-%s.%s", name, specification.Version, specificationFileOf(specification.SchemaType).extension)
+
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(specification.Content)
+}
@@ -0,0 +1,114 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateSpecification(t *testing.T) {
+	tt := []struct {
+		name       string
+		accept     string
+		format     string
+		schemaType string
+		mediaType  string
+		ok         bool
+	}{
+		{"default", "", "", "avro", "application/json", true},
+		{"any", "*/*", "", "avro", "application/json", true},
+		{"json", "application/json", "", "avro", "application/json", true},
+		{"raw", "application/vnd.apache.avro+json", "", "avro", "application/vnd.apache.avro+json", true},
+		{"quality", "application/json;q=0.5, text/x-protobuf", "", "protobuf", "text/x-protobuf", true},
+		{"subtype range", "text/*", "", "protobuf", "text/x-protobuf", true},
+		{"excluded", "text/x-protobuf;q=0, */*", "", "protobuf", "application/json", true},
+		{"not acceptable", "text/html", "", "xml", "", false},
+		{"raw format", "application/json", "raw", "xml", "application/xml", true},
+		{"json format", "application/xml", "json", "xml", "application/json", true},
+		{"unknown type", "", "raw", "yaml", "application/octet-stream", true},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mediaType, ok := negotiateSpecification(tc.accept, tc.format, tc.schemaType)
+			if ok != tc.ok || mediaType != tc.mediaType {
+				t.Fatalf("expected %q %v, got %q %v", tc.mediaType, tc.ok, mediaType, ok)
+			}
+		})
+	}
+}
+
+func TestGetSpecificationAsFile(t *testing.T) {
+	srv := newTestServer(t)
+
+	register := `{"name":"person","schema_type":"json","specification":"{\"required\":[\"b\",\"a\"],\"type\":\"object\"}","compatibility_mode":"none","validity_mode":"none"}`
+	response, err := http.Post(srv.URL+"/schemas", "application/json", strings.NewReader(register))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = response.Body.Close()
+	if response.StatusCode != http.StatusCreated {
+		t.Fatalf("registration failed with status %d", response.StatusCode)
+	}
+
+	get := func(path, accept string) (*http.Response, string) {
+		request, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Header.Set("Accept", accept)
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer response.Body.Close()
+		body, err := io.ReadAll(response.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return response, string(body)
+	}
+
+	response, body := get("/schemas/1/versions/1/spec", "application/schema+json")
+	if contentType := response.Header.Get("Content-Type"); contentType != "application/schema+json" {
+		t.Fatalf("expected application/schema+json, got %s", contentType)
+	}
+	if disposition := response.Header.Get("Content-Disposition"); disposition != "attachment; filename=person-1.json" {
+		t.Fatalf("unexpected content disposition %s", disposition)
+	}
+	if body != `{"required":["b","a"],"type":"object"}` {
+		t.Fatalf("unexpected specification %s", body)
+	}
+
+	response, body = get("/schemas/1/versions/1/spec?canonical=true", "")
+	if contentType := response.Header.Get("Content-Type"); contentType != "application/json" {
+		t.Fatalf("expected application/json, got %s", contentType)
+	}
+	if response.Header.Get("Content-Disposition") != "" {
+		t.Fatal("expected no content disposition")
+	}
+	if body != `{"required":["a","b"],"type":"object"}` {
+		t.Fatalf("unexpected canonical specification %s", body)
+	}
+
+	response, _ = get("/schemas/1/versions/1/spec", "text/html")
+	if response.StatusCode != http.StatusNotAcceptable {
+		t.Fatalf("expected status %d, got %d", http.StatusNotAcceptable, response.StatusCode)
+	}
+}
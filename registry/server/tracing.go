@@ -0,0 +1,64 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	schemaIDAttribute      = attribute.Key("schema.id")
+	schemaVersionAttribute = attribute.Key("schema.version")
+)
+
+// RequestTracing starts a server span for every request, continuing the trace propagated by the caller.
+// Once the request is routed, the span is named after the route pattern and annotated with the schema id and version.
+func RequestTracing(next http.Handler) http.Handler {
+	annotate := func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+
+		route := routePattern(r)
+		span := trace.SpanFromContext(r.Context())
+		span.SetName(r.Method + " " + route)
+		span.SetAttributes(semconv.HTTPRoute(route))
+
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			if id := rctx.URLParam("id"); id != "" {
+				span.SetAttributes(schemaIDAttribute.String(id))
+			}
+			if version := rctx.URLParam("version"); version != "" {
+				span.SetAttributes(schemaVersionAttribute.String(version))
+			}
+		}
+	}
+
+	return otelhttp.NewHandler(http.HandlerFunc(annotate), "", otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+		return r.Method
+	}))
+}
+
+// routePattern returns the pattern of the route which matched the request, or unmatchedRoute if none did.
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+		return rctx.RoutePattern()
+	}
+	return unmatchedRoute
+}
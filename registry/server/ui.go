@@ -0,0 +1,47 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+//go:embed ui
+var uiFiles embed.FS
+
+// getUI returns a GET method which serves the web UI embedded in the binary. The UI only calls the REST API, relative
+// to the path it's served at, so it works behind a proxy and goes through the same middleware as any other client.
+func getUI() http.HandlerFunc {
+	files, _ := fs.Sub(uiFiles, "ui")
+	fileServer := http.StripPrefix("/ui", http.FileServer(http.FS(files)))
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// the relative links of the page only resolve under /ui/, so the bare path is redirected there, with a relative
+		// location which keeps the prefix of a proxy
+		if r.URL.Path == "/ui" {
+			w.Header().Set("Location", "ui/")
+			w.WriteHeader(http.StatusMovedPermanently)
+			return
+		}
+		if !strings.HasPrefix(r.URL.Path, "/ui/") {
+			http.NotFound(w, r)
+			return
+		}
+		fileServer.ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,312 @@
+/*
+ * Copyright 2024 Syntio Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+"use strict";
+
+// the UI is served at <registry>/ui/, so the REST API is resolved relative to its parent, which keeps it working
+// behind a proxy serving the registry under a path
+const api = new URL("../", window.location.href);
+
+// the largest number of line pairs compared by diff, larger specifications are shown without a diff
+const maxDiffCells = 4000000;
+
+const $ = (id) => document.getElementById(id);
+
+let current = null;
+
+// request calls the REST API. The credentials of the page, like basic auth or the client certificate, are sent
+// along, so the UI is subject to the same authentication as the API.
+async function request(path, options = {}) {
+    const response = await fetch(new URL(path, api), {
+        credentials: "same-origin",
+        headers: {"Accept": "application/json", "Content-Type": "application/json"},
+        ...options,
+    });
+    let body = null;
+    const text = await response.text();
+    if (text) {
+        try {
+            body = JSON.parse(text);
+        } catch (e) {
+            body = {message: text};
+        }
+    }
+    return {status: response.status, body: body};
+}
+
+// errorMessage returns the message of an error response, which is a problem details document unless the response
+// comes from a proxy in front of the registry.
+function errorMessage(response) {
+    const body = response.body || {};
+    if (body.detail || body.title) {
+        return body.detail || body.title;
+    }
+    if (response.status === 401 || response.status === 403) {
+        return "You aren't authorized to access the registry.";
+    }
+    return body.message || `Request failed with status ${response.status}.`;
+}
+
+function element(tag, text, className) {
+    const e = document.createElement(tag);
+    if (text !== undefined) {
+        e.textContent = text;
+    }
+    if (className) {
+        e.className = className;
+    }
+    return e;
+}
+
+function setStatus(id, text, className) {
+    const status = $(id);
+    status.textContent = text;
+    status.className = "status " + (className || "");
+}
+
+// decode returns the specification of a version, which the API encodes in base64.
+function decode(specification) {
+    const bytes = Uint8Array.from(atob(specification || ""), (c) => c.charCodeAt(0));
+    return new TextDecoder().decode(bytes);
+}
+
+// render pretty prints JSON specifications, the others are shown as they are.
+function render(specification) {
+    try {
+        return JSON.stringify(JSON.parse(specification), null, 2);
+    } catch (e) {
+        return specification;
+    }
+}
+
+// diff returns the lines of both texts marked as kept, added or removed, from their longest common subsequence.
+function diff(before, after) {
+    const a = before.split("\n");
+    const b = after.split("\n");
+    if (a.length * b.length > maxDiffCells) {
+        return null;
+    }
+    const lengths = Array.from({length: a.length + 1}, () => new Uint32Array(b.length + 1));
+    for (let i = a.length - 1; i >= 0; i--) {
+        for (let j = b.length - 1; j >= 0; j--) {
+            lengths[i][j] = a[i] === b[j] ? lengths[i + 1][j + 1] + 1 : Math.max(lengths[i + 1][j], lengths[i][j + 1]);
+        }
+    }
+    const lines = [];
+    let i = 0;
+    let j = 0;
+    while (i < a.length || j < b.length) {
+        if (i < a.length && j < b.length && a[i] === b[j]) {
+            lines.push({kind: "kept", text: a[i]});
+            i++;
+            j++;
+        } else if (i < a.length && (j === b.length || lengths[i + 1][j] >= lengths[i][j + 1])) {
+            lines.push({kind: "removed", text: a[i]});
+            i++;
+        } else {
+            lines.push({kind: "added", text: b[j]});
+            j++;
+        }
+    }
+    return lines;
+}
+
+function showSchemas(schemas) {
+    const list = $("schema-list");
+    list.replaceChildren();
+    for (const schema of schemas) {
+        const row = element("tr");
+        row.dataset.id = schema.schema_id;
+        for (const value of [schema.schema_id, schema.name, schema.schema_type, schema.publisher_id]) {
+            row.appendChild(element("td", value));
+        }
+        row.addEventListener("click", () => {
+            window.location.hash = schema.schema_id;
+        });
+        list.appendChild(row);
+    }
+    setStatus("schemas-status", schemas.length === 0 ? "No schemas found." : "");
+    markSelected();
+}
+
+async function loadSchemas(query) {
+    setStatus("schemas-status", "Loading...");
+    const response = await request(query ? "schemas/search?" + query : "schemas");
+    if (response.status === 404) {
+        showSchemas([]);
+        return;
+    }
+    if (response.status !== 200) {
+        showSchemas([]);
+        setStatus("schemas-status", errorMessage(response), "error");
+        return;
+    }
+    showSchemas(Array.isArray(response.body) ? response.body : []);
+}
+
+function markSelected() {
+    for (const row of $("schema-list").children) {
+        row.classList.toggle("selected", current !== null && row.dataset.id === current.schema_id);
+    }
+}
+
+function showSpecification(version, base) {
+    const spec = $("spec");
+    spec.replaceChildren();
+    const text = render(decode(version.specification));
+    if (!base) {
+        $("spec-title").textContent = `Version ${version.version}`;
+        spec.textContent = text;
+        return;
+    }
+    $("spec-title").textContent = `Version ${version.version}, compared to version ${base.version}`;
+    const lines = diff(render(decode(base.specification)), text);
+    if (lines === null) {
+        spec.textContent = "The specifications are too large to compare.";
+        return;
+    }
+    for (const line of lines) {
+        const prefix = line.kind === "added" ? "+ " : line.kind === "removed" ? "- " : "  ";
+        spec.appendChild(element("span", prefix + line.text + "\n", line.kind === "kept" ? "" : line.kind));
+    }
+}
+
+function showVersions(versions) {
+    const list = $("version-list");
+    list.replaceChildren();
+    const sorted = [...versions].sort((a, b) => Number(b.version) - Number(a.version));
+    for (const version of sorted) {
+        const row = element("tr", undefined, version.version_deactivated ? "deactivated" : "");
+        row.appendChild(element("td", version.version));
+        row.appendChild(element("td", version.semver || ""));
+        row.appendChild(element("td", new Date(version.created_at).toLocaleString()));
+        row.appendChild(element("td", version.version_deactivated ? "deactivated" : "active"));
+
+        const compare = element("select");
+        compare.appendChild(element("option", "-"));
+        for (const other of sorted) {
+            if (other.version !== version.version) {
+                const option = element("option", other.version);
+                option.value = other.version;
+                compare.appendChild(option);
+            }
+        }
+        compare.addEventListener("click", (event) => event.stopPropagation());
+        compare.addEventListener("change", () => {
+            showSpecification(version, sorted.find((other) => other.version === compare.value));
+        });
+        const cell = element("td");
+        cell.appendChild(compare);
+        row.appendChild(cell);
+
+        row.addEventListener("click", () => showSpecification(version));
+        list.appendChild(row);
+    }
+    if (sorted.length > 0) {
+        const latest = sorted.find((version) => !version.version_deactivated) || sorted[0];
+        const previous = sorted.find((version) => Number(version.version) < Number(latest.version));
+        showSpecification(latest, previous);
+    }
+}
+
+function showHistory(events) {
+    const history = $("history");
+    history.replaceChildren();
+    for (const event of events) {
+        history.appendChild(element("li", `${new Date(event.timestamp).toLocaleString()}: version ${event.version} ${event.event}`));
+    }
+}
+
+async function loadSchema(id) {
+    const response = await request(`schemas/${encodeURIComponent(id)}/versions/all`);
+    if (response.status !== 200) {
+        current = null;
+        $("schema").hidden = true;
+        markSelected();
+        setStatus("schemas-status", errorMessage(response), "error");
+        return;
+    }
+    const schema = response.body;
+    current = schema;
+    markSelected();
+
+    $("schema").hidden = false;
+    $("schema-title").textContent = `${schema.name} (${schema.schema_id})`;
+    $("schema-description").textContent = schema.description;
+    const details = $("schema-details");
+    details.replaceChildren();
+    for (const [name, value] of [
+        ["Type", schema.schema_type],
+        ["Publisher", schema.publisher_id],
+        ["Compatibility mode", schema.compatibility_mode || "global default"],
+        ["Validity mode", schema.validity_mode || "global default"],
+        ["Last created", schema.last_created],
+    ]) {
+        details.appendChild(element("dt", name));
+        details.appendChild(element("dd", value));
+    }
+    showVersions(schema.schemas || []);
+    setStatus("compatibility-result", "");
+
+    const history = await request(`schemas/${encodeURIComponent(id)}/history`);
+    showHistory(history.status === 200 && Array.isArray(history.body) ? history.body : []);
+}
+
+async function checkCompatibility(event) {
+    event.preventDefault();
+    if (current === null) {
+        return;
+    }
+    const specification = new FormData(event.target).get("specification");
+    setStatus("compatibility-result", "Checking...");
+    const response = await request("check/compatibility", {
+        method: "POST",
+        body: JSON.stringify({schema_id: current.schema_id, new_schema: specification}),
+    });
+    if (response.status === 200) {
+        setStatus("compatibility-result", `Compatible with schema ${current.schema_id} in the ${current.compatibility_mode || "global"} mode.`, "success");
+    } else if (response.status === 409) {
+        setStatus("compatibility-result", `Not compatible with schema ${current.schema_id} in the ${current.compatibility_mode || "global"} mode.`, "error");
+    } else {
+        setStatus("compatibility-result", errorMessage(response), "error");
+    }
+}
+
+function search(event) {
+    event.preventDefault();
+    const query = new URLSearchParams();
+    for (const [name, value] of new FormData(event.target)) {
+        if (value.trim() !== "") {
+            query.set(name, value.trim());
+        }
+    }
+    loadSchemas(query.toString());
+}
+
+function route() {
+    const id = decodeURIComponent(window.location.hash.slice(1));
+    if (id !== "") {
+        loadSchema(id);
+    }
+}
+
+$("search").addEventListener("submit", search);
+$("search").addEventListener("reset", () => loadSchemas(""));
+$("compatibility").addEventListener("submit", checkCompatibility);
+window.addEventListener("hashchange", route);
+
+loadSchemas("");
+route();
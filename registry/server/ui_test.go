@@ -0,0 +1,76 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestUI(t *testing.T) {
+	srv := newTestServer(t)
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	response, err := client.Get(srv.URL + "/ui")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusMovedPermanently || response.Header.Get("Location") != "ui/" {
+		t.Fatalf("expected a redirect to ui/, got %d to %q", response.StatusCode, response.Header.Get("Location"))
+	}
+
+	tt := []struct {
+		path        string
+		contentType string
+		contains    string
+	}{
+		{"/ui/", "text/html", "<title>Schema Registry</title>"},
+		{"/ui/ui.js", "javascript", "check/compatibility"},
+		{"/ui/ui.css", "text/css", "body"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.path, func(t *testing.T) {
+			response, err := client.Get(srv.URL + tc.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer response.Body.Close()
+			body, _ := io.ReadAll(response.Body)
+			if response.StatusCode != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", response.StatusCode)
+			}
+			if !strings.Contains(response.Header.Get("Content-Type"), tc.contentType) {
+				t.Errorf("expected content type %s, got %s", tc.contentType, response.Header.Get("Content-Type"))
+			}
+			if !strings.Contains(string(body), tc.contains) {
+				t.Errorf("expected %q in the response", tc.contains)
+			}
+		})
+	}
+
+	response, err = client.Get(srv.URL + "/ui/missing.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status 404 for a missing file, got %d", response.StatusCode)
+	}
+}
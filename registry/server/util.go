@@ -18,9 +18,15 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/pkg/errors"
 
 	"github.com/dataphos/schema-registry/registry"
+	"github.com/dataphos/schema-registry/registry/examples"
+	"github.com/dataphos/schema-registry/validity"
 )
 
 type responseBodyAndCode struct {
@@ -36,11 +42,6 @@ func writeResponse(w http.ResponseWriter, response responseBodyAndCode) {
 	_, _ = w.Write(response.Body)
 }
 
-func serializeErrorMessage(message string) []byte {
-	encoded, _ := json.Marshal(report{Message: message})
-	return encoded
-}
-
 func readSchemaRegisterRequest(body io.ReadCloser) (registry.SchemaRegistrationRequest, error) {
 	encoded, err := io.ReadAll(body)
 	if err != nil {
@@ -84,6 +85,38 @@ func readSchemaUpdateRequest(body io.ReadCloser) (registry.SchemaUpdateRequest,
 	return schemaUpdateRequest, nil
 }
 
+func readLookupRequest(body io.ReadCloser) (registry.LookupRequest, error) {
+	encoded, err := io.ReadAll(body)
+	if err != nil {
+		return registry.LookupRequest{}, err
+	}
+
+	var lookupRequest registry.LookupRequest
+	if err = json.Unmarshal(encoded, &lookupRequest); err != nil {
+		return registry.LookupRequest{}, err
+	}
+
+	if !containsFormat(strings.ToLower(lookupRequest.SchemaType)) {
+		return registry.LookupRequest{}, registry.ErrUnknownFormat
+	}
+
+	return lookupRequest, nil
+}
+
+func readSchemaMetadataRequest(body io.ReadCloser) (registry.SchemaMetadataRequest, error) {
+	encoded, err := io.ReadAll(body)
+	if err != nil {
+		return registry.SchemaMetadataRequest{}, err
+	}
+
+	var schemaMetadataRequest registry.SchemaMetadataRequest
+	if err = json.Unmarshal(encoded, &schemaMetadataRequest); err != nil {
+		return registry.SchemaMetadataRequest{}, err
+	}
+
+	return schemaMetadataRequest, nil
+}
+
 func readSchemaCompatibilityRequest(body io.ReadCloser) (registry.SchemaCompatibilityRequest, error) {
 	encoded, err := io.ReadAll(body)
 	if err != nil {
@@ -111,3 +144,151 @@ func readSchemaValidityRequest(body io.ReadCloser) (registry.SchemaValidityReque
 
 	return schemaValidityRequest, nil
 }
+
+func readUsageRegistrationRequest(body io.ReadCloser) (registry.UsageRegistrationRequest, error) {
+	encoded, err := io.ReadAll(body)
+	if err != nil {
+		return registry.UsageRegistrationRequest{}, err
+	}
+
+	var usageRegistrationRequest registry.UsageRegistrationRequest
+	if err = json.Unmarshal(encoded, &usageRegistrationRequest); err != nil {
+		return registry.UsageRegistrationRequest{}, err
+	}
+
+	return usageRegistrationRequest, nil
+}
+
+func readAliasRequest(body io.ReadCloser) (registry.AliasRequest, error) {
+	encoded, err := io.ReadAll(body)
+	if err != nil {
+		return registry.AliasRequest{}, err
+	}
+
+	var aliasRequest registry.AliasRequest
+	if err = json.Unmarshal(encoded, &aliasRequest); err != nil {
+		return registry.AliasRequest{}, err
+	}
+
+	return aliasRequest, nil
+}
+
+// maxExamplesCount is the maximum number of example payloads generated in a single request.
+const maxExamplesCount = 100
+
+func readExamplesOptions(r *http.Request) (examples.Options, error) {
+	options := examples.Options{
+		Count: 1,
+		Seed:  time.Now().UnixNano(),
+	}
+
+	query := r.URL.Query()
+	if count := query.Get("count"); count != "" {
+		parsed, err := strconv.Atoi(count)
+		if err != nil || parsed < 1 || parsed > maxExamplesCount {
+			return examples.Options{}, errors.Errorf("count must be an integer between 1 and %d", maxExamplesCount)
+		}
+		options.Count = parsed
+	}
+	if seed := query.Get("seed"); seed != "" {
+		parsed, err := strconv.ParseInt(seed, 10, 64)
+		if err != nil {
+			return examples.Options{}, errors.New("seed must be an integer")
+		}
+		options.Seed = parsed
+	}
+	if invalid := query.Get("invalid"); invalid != "" {
+		parsed, err := strconv.ParseBool(invalid)
+		if err != nil {
+			return examples.Options{}, errors.New("invalid must be a boolean")
+		}
+		options.Invalid = parsed
+	}
+
+	return options, nil
+}
+
+func readAuditQuery(r *http.Request) (registry.AuditQuery, error) {
+	query := registry.AuditQuery{
+		SchemaID: r.URL.Query().Get("schema_id"),
+		After:    r.URL.Query().Get("after"),
+		Limit:    registry.DefaultAuditLimit,
+	}
+	if query.After != "" {
+		if _, err := strconv.Atoi(query.After); err != nil {
+			return registry.AuditQuery{}, errors.New("after must be the id of an audit record")
+		}
+	}
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil || parsed < 1 || parsed > registry.MaxAuditLimit {
+			return registry.AuditQuery{}, errors.Errorf("limit must be an integer between 1 and %d", registry.MaxAuditLimit)
+		}
+		query.Limit = parsed
+	}
+	if since := r.URL.Query().Get("since"); since != "" {
+		parsed, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return registry.AuditQuery{}, errors.New("since must be an RFC 3339 timestamp")
+		}
+		query.Since = parsed
+	}
+	return query, nil
+}
+
+// nextAuditPage returns the URL of the page of audit records after the given one, or an empty string if the page isn't
+// full, so there are no more records.
+func nextAuditPage(r *http.Request, query registry.AuditQuery, records []registry.AuditRecord) string {
+	if len(records) < query.Limit {
+		return ""
+	}
+	values := r.URL.Query()
+	values.Set("after", records[len(records)-1].ID)
+	next := *r.URL
+	next.RawQuery = values.Encode()
+	return next.RequestURI()
+}
+
+// readAsOf returns the value of the "as_of" query parameter, an RFC 3339 timestamp, or the zero time if it isn't given.
+func readAsOf(r *http.Request) (time.Time, error) {
+	asOf := r.URL.Query().Get("as_of")
+	if asOf == "" {
+		return time.Time{}, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, asOf)
+	if err != nil {
+		return time.Time{}, errors.New("as_of must be an RFC 3339 timestamp")
+	}
+	return parsed, nil
+}
+
+// violationsOf returns the lint rule violations which made the schema invalid, if any.
+func violationsOf(err error) []validity.Violation {
+	var violationsErr *registry.ViolationsError
+	if errors.As(err, &violationsErr) {
+		return violationsErr.Violations
+	}
+	return nil
+}
+
+// readForce returns the value of the "force" query parameter of deletions, false if it isn't given.
+func readForce(r *http.Request) (bool, error) {
+	force := r.URL.Query().Get("force")
+	if force == "" {
+		return false, nil
+	}
+	parsed, err := strconv.ParseBool(force)
+	if err != nil {
+		return false, errors.New("force must be a boolean")
+	}
+	return parsed, nil
+}
+
+// usageOf returns the usage which prevented a deletion, if any.
+func usageOf(err error) []registry.Usage {
+	var inUseErr *registry.InUseError
+	if errors.As(err, &inUseErr) {
+		return inUseErr.Usage
+	}
+	return nil
+}
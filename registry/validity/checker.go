@@ -14,12 +14,14 @@
 
 package validity
 
+import "context"
+
 type Checker interface {
-	Check(schema, schemaType, mode string) (bool, error)
+	Check(ctx context.Context, schema, schemaType, mode string) (bool, error)
 }
 
-type CheckerFunc func(schema, schemaType, mode string) (bool, error)
+type CheckerFunc func(ctx context.Context, schema, schemaType, mode string) (bool, error)
 
-func (f CheckerFunc) Check(schema, schemaType, mode string) (bool, error) {
-	return f(schema, schemaType, mode)
+func (f CheckerFunc) Check(ctx context.Context, schema, schemaType, mode string) (bool, error) {
+	return f(ctx, schema, schemaType, mode)
 }
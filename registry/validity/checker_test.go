@@ -43,7 +43,7 @@ func TestValidityChecker_Check(t *testing.T) {
 	}
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	checker, err := NewExternalCheckerFromEnv(ctx)
+	checker, err := NewExternalChecker(ctx, "http://localhost:8089", DefaultTimeoutBase)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -87,7 +87,7 @@ func TestValidityChecker_Check(t *testing.T) {
 
 			newSchema := payload.Schema
 
-			valid, err := checker.Check(newSchema, tc.schemaType, tc.validity)
+			valid, err := checker.Check(context.Background(), newSchema, tc.schemaType, tc.validity)
 			if err != nil {
 				t.Errorf("validity error: %s", err)
 			}
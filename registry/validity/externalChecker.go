@@ -16,7 +16,6 @@ package validity
 
 import (
 	"context"
-	"os"
 	"strings"
 	"time"
 
@@ -27,16 +26,9 @@ import (
 	"github.com/dataphos/lib-logger/standardlogger"
 	"github.com/dataphos/lib-retry/pkg/retry"
 	"github.com/dataphos/schema-registry/internal/config"
-	"github.com/dataphos/schema-registry/internal/errtemplates"
 	"github.com/dataphos/schema-registry/validity/http"
 )
 
-const (
-	urlEnvKey          = "VALIDITY_CHECKER_URL"
-	timeoutEnvKey      = "VALIDITY_CHECKER_TIMEOUT_BASE"
-	globalValidityMode = "GLOBAL_VALIDITY_MODE"
-)
-
 const (
 	DefaultTimeoutBase        = 2 * time.Second
 	defaultGlobalValidityMode = "FULL"
@@ -48,24 +40,6 @@ type ExternalChecker struct {
 	Log         logger.Log
 }
 
-// NewExternalCheckerFromEnv loads the needed environment variables and calls NewExternalChecker.
-func NewExternalCheckerFromEnv(ctx context.Context) (*ExternalChecker, error) {
-	url := os.Getenv(urlEnvKey)
-	if url == "" {
-		return nil, errtemplates.EnvVariableNotDefined(urlEnvKey)
-	}
-	timeout := DefaultTimeoutBase
-	if timeoutStr := os.Getenv(timeoutEnvKey); timeoutStr != "" {
-		var err error
-		timeout, err = time.ParseDuration(timeoutStr)
-		if err != nil {
-			return nil, errors.Wrap(err, errtemplates.ParsingEnvVariableFailed(timeoutEnvKey))
-		}
-	}
-
-	return NewExternalChecker(ctx, url, timeout)
-}
-
 // NewExternalChecker returns a new instance of ExternalChecker.
 func NewExternalChecker(ctx context.Context, url string, timeoutBase time.Duration) (*ExternalChecker, error) {
 	if err := retry.Do(ctx, retry.WithJitter(retry.Constant(2*time.Second)), func(ctx context.Context) error {
@@ -91,14 +65,18 @@ func NewExternalChecker(ctx context.Context, url string, timeoutBase time.Durati
 	}, nil
 }
 
-func (c *ExternalChecker) Check(schema, schemaType, mode string) (bool, error) {
+func (c *ExternalChecker) Check(ctx context.Context, schema, schemaType, mode string) (bool, error) {
 	//check if validity mode is none, if it is, don't send HTTP request to java code
 	if strings.ToLower(mode) == "none" {
 		return true, nil
 	}
+	// linting happens in the registry, the external checker checks the schema like in the full mode
+	if strings.ToLower(mode) == ModeLint {
+		mode = "full"
+	}
 	if strings.ToLower(mode) == "syntax-only" || strings.ToLower(mode) == "full" {
 		size := []byte(schema + schemaType + mode)
-		ctx, cancel := context.WithTimeout(context.Background(), http.EstimateHTTPTimeout(len(size), c.TimeoutBase))
+		ctx, cancel := context.WithTimeout(ctx, http.EstimateHTTPTimeout(len(size), c.TimeoutBase))
 		defer cancel()
 
 		valid, info, err := http.CheckOverHTTP(ctx, schemaType, schema, mode, c.Url+"/")
@@ -109,19 +87,20 @@ func (c *ExternalChecker) Check(schema, schemaType, mode string) (bool, error) {
 	return false, errors.Errorf("")
 }
 
-func InitExternalValidityChecker(ctx context.Context) (*ExternalChecker, string, error) {
-	valChecker, err := NewExternalCheckerFromEnv(ctx)
-	if err != nil {
-		return nil, "", err
-	}
-	globalValMode := os.Getenv(globalValidityMode)
+// InitExternalValidityChecker connects to the validity checker at the given url and checks that the global validity
+// mode is supported, FULL if it's empty.
+func InitExternalValidityChecker(ctx context.Context, url string, timeoutBase time.Duration, globalValMode string) (*ExternalChecker, string, error) {
 	if globalValMode == "" {
 		globalValMode = defaultGlobalValidityMode
 	}
-	if globalValMode == "SYNTAX-ONLY" || globalValMode == "FULL" || globalValMode == "NONE" {
-		return valChecker, globalValMode, nil
+	if globalValMode != "SYNTAX-ONLY" && globalValMode != "FULL" && globalValMode != "LINT" && globalValMode != "NONE" {
+		return nil, "", errors.Errorf("unsupported validity mode")
+	}
+	valChecker, err := NewExternalChecker(ctx, url, timeoutBase)
+	if err != nil {
+		return nil, "", err
 	}
-	return nil, "", errors.Errorf("unsupported validity mode")
+	return valChecker, globalValMode, nil
 }
 
 func CheckIfValidMode(mode *string) bool {
@@ -129,7 +108,7 @@ func CheckIfValidMode(mode *string) bool {
 		*mode = defaultGlobalValidityMode
 	}
 	lowerMode := strings.ToLower(*mode)
-	if lowerMode != "none" && lowerMode != "syntax-only" && lowerMode != "full" {
+	if lowerMode != "none" && lowerMode != "syntax-only" && lowerMode != "full" && lowerMode != ModeLint {
 		return false
 	}
 	return true
@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 // checkRequest contains a new schema, its type and a validity mode which should be enforced. The structure represents an HTTP request body.
@@ -40,6 +41,9 @@ type checkResponse struct {
 	Info   string `json:"info"`
 }
 
+// client propagates the trace context of the request to the checker and records a span of the call.
+var client = &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}
+
 // HTTPTimeoutBytesUnit the base amount of bytes used by EstimateHTTPTimeout.
 const HTTPTimeoutBytesUnit = 1024 * 100
 
@@ -101,5 +105,5 @@ func sendCheckRequest(ctx context.Context, schemaType, schema, mode, url string)
 		return nil, err
 	}
 
-	return http.DefaultClient.Do(request)
+	return client.Do(request)
 }
@@ -0,0 +1,194 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validity
+
+import (
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// ModeLint is the validity mode which checks a schema like the full mode, then lints it with the configured rules.
+const ModeLint = "lint"
+
+const lintConfigEnvKey = "LINT_CONFIG"
+
+// Severity is the severity of a lint rule. Violations of rules with SeverityError make a schema invalid, while
+// violations of rules with SeverityWarning are only reported.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityOff     Severity = "off"
+)
+
+// Violation is a violation of a lint rule.
+type Violation struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Path     string   `json:"path"`
+	Message  string   `json:"message"`
+}
+
+// RuleParse is the rule violated by a schema the linter can't parse. It can't be configured and is always an error.
+const RuleParse = "parse"
+
+// ParseViolation returns the violation of RuleParse by a schema which the linter failed to parse with the given error.
+func ParseViolation(err error) Violation {
+	return Violation{
+		Rule:     RuleParse,
+		Severity: SeverityError,
+		Message:  err.Error(),
+	}
+}
+
+// LintTarget identifies the schema which is linted, so the rules configured for it and its group are applied.
+// The group of a schema is its publisher id. Both are empty if the schema isn't registered.
+type LintTarget struct {
+	SchemaID string
+	Group    string
+	// History holds the specifications of the previous versions of the schema.
+	History []string
+}
+
+// LintConfig holds the severities of the lint rules, for example:
+//
+//	rules:
+//	  field-description: error
+//	groups:
+//	  payments:
+//	    enum-unknown-value: error
+//	schemas:
+//	  "42":
+//	    field-name-snake-case: off
+//
+// The severities of a schema take precedence over the ones of its group, which take precedence over the rules,
+// which take precedence over the defaults of the rules.
+type LintConfig struct {
+	Rules   map[string]Severity            `yaml:"rules"`
+	Groups  map[string]map[string]Severity `yaml:"groups"`
+	Schemas map[string]map[string]Severity `yaml:"schemas"`
+}
+
+// Linter lints schemas with the configured rules.
+type Linter struct {
+	config LintConfig
+}
+
+// NewLinter returns a new instance of Linter, returning an error if the config holds unknown rules or severities.
+func NewLinter(config LintConfig) (*Linter, error) {
+	sections := []map[string]Severity{config.Rules}
+	for _, severities := range config.Groups {
+		sections = append(sections, severities)
+	}
+	for _, severities := range config.Schemas {
+		sections = append(sections, severities)
+	}
+	for _, severities := range sections {
+		for name, severity := range severities {
+			if _, ok := findRule(name); !ok {
+				return nil, errors.Errorf("unknown lint rule %q", name)
+			}
+			if severity != SeverityError && severity != SeverityWarning && severity != SeverityOff {
+				return nil, errors.Errorf("unknown severity %q of lint rule %q", severity, name)
+			}
+		}
+	}
+	return &Linter{config: config}, nil
+}
+
+// NewLinterFromEnv returns a Linter with the config of the file under the path given by the LINT_CONFIG environment
+// variable, or with the default severities if it isn't set.
+func NewLinterFromEnv() (*Linter, error) {
+	path := os.Getenv(lintConfigEnvKey)
+	if path == "" {
+		return NewLinter(LintConfig{})
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "couldn't open lint config %s", path)
+	}
+	defer file.Close()
+
+	var config LintConfig
+	if err = yaml.NewDecoder(file).Decode(&config); err != nil {
+		return nil, errors.Wrapf(err, "couldn't read lint config %s", path)
+	}
+	return NewLinter(config)
+}
+
+// Lint returns the violations of the rules enabled for the target. Schema types without lint support have no violations.
+func (l *Linter) Lint(schema, schemaType string, target LintTarget) ([]Violation, error) {
+	parsed, err := parseForLint(schema, strings.ToLower(schemaType))
+	if err != nil {
+		return nil, err
+	}
+	if parsed == nil {
+		return nil, nil
+	}
+
+	var history []*lintSchema
+	for _, previous := range target.History {
+		// previous versions were accepted under other rules, the ones which can't be parsed are skipped
+		if parsedPrevious, err := parseForLint(previous, strings.ToLower(schemaType)); err == nil && parsedPrevious != nil {
+			history = append(history, parsedPrevious)
+		}
+	}
+
+	var violations []Violation
+	for _, rule := range rules {
+		severity := l.severity(rule, target)
+		if severity == SeverityOff {
+			continue
+		}
+		for _, found := range rule.check(parsed, history) {
+			violations = append(violations, Violation{
+				Rule:     rule.name,
+				Severity: severity,
+				Path:     found.path,
+				Message:  found.message,
+			})
+		}
+	}
+	return violations, nil
+}
+
+// severity returns the severity of the rule for the target.
+func (l *Linter) severity(rule lintRule, target LintTarget) Severity {
+	if severity, ok := l.config.Schemas[target.SchemaID][rule.name]; ok && target.SchemaID != "" {
+		return severity
+	}
+	if severity, ok := l.config.Groups[target.Group][rule.name]; ok && target.Group != "" {
+		return severity
+	}
+	if severity, ok := l.config.Rules[rule.name]; ok {
+		return severity
+	}
+	return rule.severity
+}
+
+// HasErrors returns true if any of the violations has SeverityError.
+func HasErrors(violations []Violation) bool {
+	for _, violation := range violations {
+		if violation.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
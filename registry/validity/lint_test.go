@@ -0,0 +1,145 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validity
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLinter_Lint(t *testing.T) {
+	tt := []struct {
+		name       string
+		schema     string
+		schemaType string
+		history    []string
+		expected   []string
+	}{
+		{
+			name:       "clean avro",
+			schema:     `{"type":"record","name":"Order","namespace":"shop","fields":[{"name":"order_id","type":"string","doc":"id"},{"name":"state","doc":"state","type":{"type":"enum","name":"State","symbols":["STATE_UNKNOWN","OPEN"]}}]}`,
+			schemaType: "avro",
+		},
+		{
+			name:       "avro",
+			schema:     `{"type":"record","name":"Order","fields":[{"name":"orderId","type":"string"},{"name":"state","doc":"state","type":{"type":"enum","name":"State","symbols":["OPEN"]}}]}`,
+			schemaType: "avro",
+			expected: []string{
+				"field-name-snake-case Order.orderId",
+				"field-description Order.orderId",
+				"avro-record-namespace Order",
+				"enum-unknown-value State",
+			},
+		},
+		{
+			name:       "nested avro records inherit the namespace",
+			schema:     `{"type":"record","name":"shop.Order","fields":[{"name":"item","doc":"item","type":{"type":"record","name":"Item","fields":[]}}]}`,
+			schemaType: "avro",
+		},
+		{
+			name:       "json",
+			schema:     `{"type":"object","properties":{"id":{"type":"string"},"Kind":{"type":"string","description":"kind","enum":["a","b"]}}}`,
+			schemaType: "json",
+			expected: []string{
+				"field-name-snake-case $.Kind",
+				"field-description $.id",
+				"enum-unknown-value $.Kind",
+			},
+		},
+		{
+			name:       "protobuf",
+			schema:     "syntax = \"proto3\";\npackage shop;\nmessage Order {\n  // the id\n  string order_id = 1;\n  // the total\n  int64 total = 2;\n}\n",
+			schemaType: "protobuf",
+			history:    []string{"syntax = \"proto3\";\npackage shop;\nmessage Order {\n  string order_id = 1;\n  string note = 2;\n}\n"},
+			expected: []string{
+				"protobuf-field-number-reuse shop.Order.total",
+			},
+		},
+		{
+			name:       "unsupported type",
+			schema:     "<xs:schema/>",
+			schemaType: "xml",
+		},
+	}
+
+	linter, err := NewLinter(LintConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			violations, err := linter.Lint(tc.schema, tc.schemaType, LintTarget{History: tc.history})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var found []string
+			for _, violation := range violations {
+				found = append(found, violation.Rule+" "+violation.Path)
+			}
+			if !reflect.DeepEqual(found, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, found)
+			}
+		})
+	}
+}
+
+func TestLinter_Severity(t *testing.T) {
+	linter, err := NewLinter(LintConfig{
+		Rules:   map[string]Severity{"field-description": SeverityError},
+		Groups:  map[string]map[string]Severity{"payments": {"field-description": SeverityWarning}},
+		Schemas: map[string]map[string]Severity{"42": {"field-description": SeverityOff}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := `{"type":"object","properties":{"id":{"type":"string"}}}`
+
+	tt := []struct {
+		name     string
+		target   LintTarget
+		expected []Severity
+	}{
+		{"rules", LintTarget{}, []Severity{SeverityError}},
+		{"group", LintTarget{Group: "payments"}, []Severity{SeverityWarning}},
+		{"schema", LintTarget{SchemaID: "42", Group: "payments"}, nil},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			violations, err := linter.Lint(schema, "json", tc.target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var severities []Severity
+			for _, violation := range violations {
+				severities = append(severities, violation.Severity)
+			}
+			if !reflect.DeepEqual(severities, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, severities)
+			}
+			if HasErrors(violations) != (len(tc.expected) > 0 && tc.expected[0] == SeverityError) {
+				t.Errorf("unexpected HasErrors result for %v", violations)
+			}
+		})
+	}
+}
+
+func TestNewLinter(t *testing.T) {
+	if _, err := NewLinter(LintConfig{Rules: map[string]Severity{"no-such-rule": SeverityError}}); err == nil {
+		t.Error("expected an error for an unknown rule")
+	}
+	if _, err := NewLinter(LintConfig{Groups: map[string]map[string]Severity{"g": {"field-description": "fatal"}}}); err == nil {
+		t.Error("expected an error for an unknown severity")
+	}
+}
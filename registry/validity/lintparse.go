@@ -0,0 +1,260 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validity
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/pkg/errors"
+)
+
+// protoFilename is the name the protobuf specification is parsed under.
+const protoFilename = "schema.proto"
+
+// lintSchema is the structure of a schema the lint rules are checked against, regardless of its type.
+type lintSchema struct {
+	schemaType string
+	records    []lintRecord
+	enums      []lintEnum
+}
+
+// lintRecord is an Avro record, a JSON object or a protobuf message.
+type lintRecord struct {
+	path      string
+	namespace string
+	fields    []lintField
+}
+
+// lintField is a field of a lintRecord, where doc is its description, doc or comment.
+type lintField struct {
+	path   string
+	name   string
+	doc    string
+	number int32
+}
+
+// lintEnum is an enum, with the names of its values.
+type lintEnum struct {
+	path   string
+	values []string
+}
+
+// fieldName returns the name of the field with the given number, of the record under the given path.
+func (s *lintSchema) fieldName(recordPath string, number int32) (string, bool) {
+	for _, record := range s.records {
+		if record.path != recordPath {
+			continue
+		}
+		for _, field := range record.fields {
+			if field.number == number {
+				return field.name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// parseForLint parses the schema of the given type, returning nil if linting the type isn't supported.
+func parseForLint(schema, schemaType string) (*lintSchema, error) {
+	parsed := &lintSchema{schemaType: schemaType}
+	switch schemaType {
+	case "avro":
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(schema), &decoded); err != nil {
+			return nil, errors.Wrap(err, "couldn't parse avro schema")
+		}
+		parsed.walkAvro(decoded, "")
+	case "json":
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(schema), &decoded); err != nil {
+			return nil, errors.Wrap(err, "couldn't parse json schema")
+		}
+		parsed.walkJSON(decoded, "$")
+	case "protobuf":
+		parser := protoparse.Parser{
+			Accessor:              protoparse.FileContentsFromMap(map[string]string{protoFilename: schema}),
+			IncludeSourceCodeInfo: true,
+		}
+		files, err := parser.ParseFiles(protoFilename)
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't parse protobuf schema")
+		}
+		for _, message := range files[0].GetMessageTypes() {
+			parsed.walkProtobuf(message)
+		}
+		for _, enum := range files[0].GetEnumTypes() {
+			parsed.addProtobufEnum(enum)
+		}
+	default:
+		return nil, nil
+	}
+	return parsed, nil
+}
+
+// walkAvro collects the records and enums of the Avro schema, resolving their namespaces like Avro does.
+func (s *lintSchema) walkAvro(node interface{}, namespace string) {
+	switch typed := node.(type) {
+	case []interface{}:
+		for _, item := range typed {
+			s.walkAvro(item, namespace)
+		}
+	case map[string]interface{}:
+		switch typed["type"] {
+		case "record", "error":
+			fullName, namespace := avroFullName(typed, namespace)
+			record := lintRecord{path: fullName, namespace: namespace}
+			fields, _ := typed["fields"].([]interface{})
+			for _, field := range fields {
+				field, ok := field.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				name, _ := field["name"].(string)
+				doc, _ := field["doc"].(string)
+				record.fields = append(record.fields, lintField{path: fullName + "." + name, name: name, doc: doc})
+			}
+			s.records = append(s.records, record)
+			for _, field := range fields {
+				if field, ok := field.(map[string]interface{}); ok {
+					s.walkAvro(field["type"], namespace)
+				}
+			}
+		case "enum":
+			fullName, _ := avroFullName(typed, namespace)
+			enum := lintEnum{path: fullName}
+			symbols, _ := typed["symbols"].([]interface{})
+			for _, symbol := range symbols {
+				if symbol, ok := symbol.(string); ok {
+					enum.values = append(enum.values, symbol)
+				}
+			}
+			s.enums = append(s.enums, enum)
+		case "array":
+			s.walkAvro(typed["items"], namespace)
+		case "map":
+			s.walkAvro(typed["values"], namespace)
+		default:
+			s.walkAvro(typed["type"], namespace)
+		}
+	}
+}
+
+// avroFullName returns the full name and the namespace of the named Avro type, within the enclosing namespace.
+func avroFullName(named map[string]interface{}, enclosing string) (string, string) {
+	name, _ := named["name"].(string)
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name, name[:i]
+	}
+	namespace := enclosing
+	if explicit, ok := named["namespace"].(string); ok {
+		namespace = explicit
+	}
+	if namespace == "" {
+		return name, ""
+	}
+	return namespace + "." + name, namespace
+}
+
+// walkJSON collects the objects and enums of the JSON schema, with their JSON paths.
+func (s *lintSchema) walkJSON(node interface{}, path string) {
+	typed, ok := node.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	if properties, ok := typed["properties"].(map[string]interface{}); ok {
+		names := make([]string, 0, len(properties))
+		for name := range properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		record := lintRecord{path: path}
+		for _, name := range names {
+			property, _ := properties[name].(map[string]interface{})
+			doc, _ := property["description"].(string)
+			record.fields = append(record.fields, lintField{path: path + "." + name, name: name, doc: doc})
+		}
+		s.records = append(s.records, record)
+		for _, name := range names {
+			s.walkJSON(properties[name], path+"."+name)
+		}
+	}
+
+	if values, ok := typed["enum"].([]interface{}); ok {
+		enum := lintEnum{path: path}
+		for _, value := range values {
+			if value, ok := value.(string); ok {
+				enum.values = append(enum.values, value)
+			}
+		}
+		if len(enum.values) > 0 {
+			s.enums = append(s.enums, enum)
+		}
+	}
+
+	s.walkJSON(typed["items"], path+"[]")
+	for _, keyword := range []string{"allOf", "anyOf", "oneOf"} {
+		if subschemas, ok := typed[keyword].([]interface{}); ok {
+			for _, subschema := range subschemas {
+				s.walkJSON(subschema, path)
+			}
+		}
+	}
+	for _, keyword := range []string{"$defs", "definitions"} {
+		if definitions, ok := typed[keyword].(map[string]interface{}); ok {
+			for name, definition := range definitions {
+				s.walkJSON(definition, "#/"+keyword+"/"+name)
+			}
+		}
+	}
+}
+
+// walkProtobuf collects the message, together with its nested messages and enums.
+func (s *lintSchema) walkProtobuf(message *desc.MessageDescriptor) {
+	if message.IsMapEntry() {
+		return
+	}
+	record := lintRecord{path: message.GetFullyQualifiedName(), namespace: message.GetFile().GetPackage()}
+	for _, field := range message.GetFields() {
+		location := field.GetSourceInfo()
+		record.fields = append(record.fields, lintField{
+			path:   field.GetFullyQualifiedName(),
+			name:   field.GetName(),
+			doc:    location.GetLeadingComments() + location.GetTrailingComments(),
+			number: field.GetNumber(),
+		})
+	}
+	s.records = append(s.records, record)
+
+	for _, nested := range message.GetNestedMessageTypes() {
+		s.walkProtobuf(nested)
+	}
+	for _, enum := range message.GetNestedEnumTypes() {
+		s.addProtobufEnum(enum)
+	}
+}
+
+func (s *lintSchema) addProtobufEnum(enum *desc.EnumDescriptor) {
+	values := make([]string, 0, len(enum.GetValues()))
+	for _, value := range enum.GetValues() {
+		values = append(values, value.GetName())
+	}
+	s.enums = append(s.enums, lintEnum{path: enum.GetFullyQualifiedName(), values: values})
+}
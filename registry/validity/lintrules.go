@@ -0,0 +1,135 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validity
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// snakeCase matches lowercase names with words separated by single underscores.
+var snakeCase = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+
+// lintRule is a rule schemas are linted with.
+type lintRule struct {
+	name string
+	// severity is the severity of the rule if the config doesn't set one
+	severity Severity
+	check    func(schema *lintSchema, history []*lintSchema) []finding
+}
+
+// finding is a place in the schema which violates a rule.
+type finding struct {
+	path    string
+	message string
+}
+
+// rules are all the lint rules, in the order their violations are reported.
+var rules = []lintRule{
+	{name: "field-name-snake-case", severity: SeverityError, check: checkSnakeCase},
+	{name: "field-description", severity: SeverityWarning, check: checkFieldDescriptions},
+	{name: "avro-record-namespace", severity: SeverityError, check: checkAvroNamespaces},
+	{name: "protobuf-field-number-reuse", severity: SeverityError, check: checkFieldNumberReuse},
+	{name: "enum-unknown-value", severity: SeverityWarning, check: checkEnumUnknownValues},
+}
+
+// findRule returns the rule with the given name.
+func findRule(name string) (lintRule, bool) {
+	for _, rule := range rules {
+		if rule.name == name {
+			return rule, true
+		}
+	}
+	return lintRule{}, false
+}
+
+func checkSnakeCase(schema *lintSchema, _ []*lintSchema) []finding {
+	var found []finding
+	for _, record := range schema.records {
+		for _, field := range record.fields {
+			if !snakeCase.MatchString(field.name) {
+				found = append(found, finding{path: field.path, message: fmt.Sprintf("field name %q isn't snake_case", field.name)})
+			}
+		}
+	}
+	return found
+}
+
+func checkFieldDescriptions(schema *lintSchema, _ []*lintSchema) []finding {
+	var found []finding
+	for _, record := range schema.records {
+		for _, field := range record.fields {
+			if strings.TrimSpace(field.doc) == "" {
+				found = append(found, finding{path: field.path, message: fmt.Sprintf("field %q has no description", field.name)})
+			}
+		}
+	}
+	return found
+}
+
+func checkAvroNamespaces(schema *lintSchema, _ []*lintSchema) []finding {
+	if schema.schemaType != "avro" {
+		return nil
+	}
+	var found []finding
+	for _, record := range schema.records {
+		if record.namespace == "" {
+			found = append(found, finding{path: record.path, message: fmt.Sprintf("record %q has no namespace", record.path)})
+		}
+	}
+	return found
+}
+
+// checkFieldNumberReuse reports the fields of protobuf messages which have the number of a differently named field
+// of the same message in a previous version, since old payloads would be decoded into the wrong field.
+func checkFieldNumberReuse(schema *lintSchema, history []*lintSchema) []finding {
+	if schema.schemaType != "protobuf" {
+		return nil
+	}
+	var found []finding
+	for _, record := range schema.records {
+		for _, field := range record.fields {
+			for _, previous := range history {
+				if name, ok := previous.fieldName(record.path, field.number); ok && name != field.name {
+					found = append(found, finding{
+						path:    field.path,
+						message: fmt.Sprintf("field number %d was used by the field %q in a previous version", field.number, name),
+					})
+					break
+				}
+			}
+		}
+	}
+	return found
+}
+
+func checkEnumUnknownValues(schema *lintSchema, _ []*lintSchema) []finding {
+	var found []finding
+	for _, enum := range schema.enums {
+		hasUnknown := false
+		for _, value := range enum.values {
+			upper := strings.ToUpper(value)
+			if upper == "UNKNOWN" || strings.HasSuffix(upper, "_UNKNOWN") {
+				hasUnknown = true
+				break
+			}
+		}
+		if !hasUnknown {
+			found = append(found, finding{path: enum.path, message: "enum has no UNKNOWN value"})
+		}
+	}
+	return found
+}
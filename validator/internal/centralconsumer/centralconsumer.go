@@ -18,6 +18,7 @@ import (
 	"context"
 	"encoding/json"
 	"strconv"
+	"time"
 
 	"github.com/pkg/errors"
 	"go.uber.org/multierr"
@@ -25,6 +26,7 @@ import (
 	"github.com/dataphos/lib-brokers/pkg/broker"
 	"github.com/dataphos/lib-logger/logger"
 	"github.com/dataphos/schema-registry-validator/internal/config"
+	"github.com/dataphos/schema-registry-validator/internal/contract"
 	"github.com/dataphos/schema-registry-validator/internal/errcodes"
 	"github.com/dataphos/schema-registry-validator/internal/errtemplates"
 	"github.com/dataphos/schema-registry-validator/internal/janitor"
@@ -55,6 +57,8 @@ type SchemaMetadata struct {
 type Schema struct {
 	SchemaMetadata SchemaMetadata
 	Specification  []byte
+	// Rules are the data contract rules of the version the specification belongs to.
+	Rules []contract.Rule
 }
 
 type SchemaDefinition struct {
@@ -69,24 +73,28 @@ type SchemaDefinition struct {
 type VersionDetails struct {
 	Version       string `json:"version"`
 	Specification []byte `json:"specification"`
+	// Rules are collected separately with janitor.CollectRules, since not every schema registry returns them with the
+	// specification.
+	Rules []contract.Rule `json:"-"`
 }
 
 // CentralConsumer models the central consumer process.
 type CentralConsumer struct {
-	Registry            registry.SchemaRegistry
-	Validators          janitor.Validators
-	Router              janitor.Router
-	Publisher           broker.Publisher
-	topicIDs            Topics
-	topics              map[string]broker.Topic
-	registrySem         chan struct{}
-	validatorsSem       chan struct{}
-	log                 logger.Log
-	mode                Mode
-	schema              Schema
-	encryptionKey       string
-	validateHeader      bool
-	defaultHeaderSchema config.DefaultHeaderSchema
+	Registry             registry.SchemaRegistry
+	Validators           janitor.Validators
+	Router               janitor.Router
+	Publisher            broker.Publisher
+	topicIDs             Topics
+	topics               map[string]broker.Topic
+	registrySem          chan struct{}
+	validatorsSem        chan struct{}
+	log                  logger.Log
+	mode                 Mode
+	schema               Schema
+	encryptionKey        string
+	validateHeader       bool
+	defaultHeaderSchema  config.DefaultHeaderSchema
+	resolveAsOfIngestion bool
 }
 
 // Settings holds settings concerning the concurrency limits for various stages of the central consumer pipeline.
@@ -105,6 +113,10 @@ type Settings struct {
 
 	// DefaultHeaderSchemaVersion is default version of the header schema
 	DefaultHeaderSchemaVersion string
+
+	// ResolveUnpinnedAsOfIngestion defines if the messages without a schema version are validated against the version
+	// which was the latest one when they were ingested, if the schema registry keeps the version history
+	ResolveUnpinnedAsOfIngestion bool
 }
 
 // Topics defines the standard destination topics, based on validation results.
@@ -155,11 +167,16 @@ func New(registry registry.SchemaRegistry, publisher broker.Publisher, validator
 	if mode == OneCCPerTopic {
 		if schemaMetadata.ID != "" {
 			if schemaMetadata.Version != "" {
-				schemaSpecReturned, err := registry.Get(context.Background(), schemaMetadata.ID, schemaMetadata.Version)
+				// an alias is pinned to the version it points at on startup, newer versions are picked up like always
+				version, err := resolveVersion(context.Background(), registry, schemaMetadata.ID, schemaMetadata.Version)
 				if err != nil {
 					return &CentralConsumer{}, err
 				}
-				schemaVersion.Version = schemaMetadata.Version
+				schemaSpecReturned, err := registry.Get(context.Background(), schemaMetadata.ID, version)
+				if err != nil {
+					return &CentralConsumer{}, err
+				}
+				schemaVersion.Version = version
 				schemaVersion.Specification = schemaSpecReturned
 			} else {
 				schemaReturned, err = registry.GetLatest(context.Background(), schemaMetadata.ID)
@@ -170,6 +187,10 @@ func New(registry registry.SchemaRegistry, publisher broker.Publisher, validator
 					return &CentralConsumer{}, errors.Wrap(err, errtemplates.UnmarshallingJSONFailed)
 				}
 			}
+			schemaVersion.Rules, err = janitor.CollectRules(context.Background(), schemaMetadata.ID, schemaVersion.Version, registry)
+			if err != nil {
+				return &CentralConsumer{}, err
+			}
 			if schemaMetadata.Format != "" {
 				format = schemaMetadata.Format
 			} else {
@@ -198,6 +219,7 @@ func New(registry registry.SchemaRegistry, publisher broker.Publisher, validator
 				Format:  format,
 			},
 			Specification: schemaVersion.Specification,
+			Rules:         schemaVersion.Rules,
 		},
 		encryptionKey:  encryptionKey,
 		validateHeader: settings.ValidateHeader,
@@ -205,6 +227,7 @@ func New(registry registry.SchemaRegistry, publisher broker.Publisher, validator
 			DefaultHeaderSchemaId:      settings.DefaultHeaderSchemaId,
 			DefaultHeaderSchemaVersion: settings.DefaultHeaderSchemaVersion,
 		},
+		resolveAsOfIngestion: settings.ResolveUnpinnedAsOfIngestion,
 	}, nil
 }
 
@@ -317,6 +340,46 @@ func (cc *CentralConsumer) AsProcessor() *janitor.Processor {
 	return janitor.NewProcessor(cc, cc.topics, cc.topicIDs.Deadletter, cc.log)
 }
 
+// RegisterUsage registers the schema version validated in the OneCCPerTopic mode as produced to the valid topic, and
+// refreshes the registration every interval until the context is done, so the schema registry refuses to delete the
+// version while the central consumer is running.
+//
+// It returns right away in the Default mode, whose schemas aren't known up front, and if the schema registry doesn't
+// support usage registration.
+func (cc *CentralConsumer) RegisterUsage(ctx context.Context, interval time.Duration, clientID string) {
+	registrar, ok := cc.Registry.(registry.UsageRegistrar)
+	if !ok || cc.mode != OneCCPerTopic || interval <= 0 {
+		return
+	}
+
+	fields := logger.F{
+		"schema_id": cc.schema.SchemaMetadata.ID,
+		"version":   cc.schema.SchemaMetadata.Version,
+		"topic":     cc.topicIDs.Valid,
+	}
+	register := func() error {
+		err := registrar.RegisterUsage(ctx, cc.schema.SchemaMetadata.ID, cc.schema.SchemaMetadata.Version, cc.topicIDs.Valid, registry.UsageRoleProducer, clientID)
+		if err != nil && !errors.Is(err, registry.ErrUsageNotSupported) && ctx.Err() == nil {
+			cc.log.Warnw("registering usage of the schema failed: "+err.Error(), fields)
+		}
+		return err
+	}
+
+	if errors.Is(register(), registry.ErrUsageNotSupported) {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = register()
+		}
+	}
+}
+
 func (cc *CentralConsumer) Handle(ctx context.Context, message janitor.Message) (janitor.MessageTopicPair, error) {
 	var (
 		schema                []byte
@@ -380,11 +443,22 @@ func (cc *CentralConsumer) Handle(ctx context.Context, message janitor.Message)
 
 	if cc.mode == Default {
 		acquireIfSet(cc.registrySem)
-		schema, err = janitor.CollectSchema(ctx, message.SchemaID, message.Version, cc.Registry)
+		version := message.Version
+		if version == "" && cc.resolvesAsOfIngestion(message) {
+			version, err = janitor.CollectVersionAsOf(ctx, message.SchemaID, message.IngestionTime, cc.Registry)
+			if err != nil {
+				return cc.determineError(message, err, PayloadSchema)
+			}
+		}
+		schema, err = janitor.CollectSchema(ctx, message.SchemaID, version, cc.Registry)
+		if err != nil {
+			return cc.determineError(message, err, PayloadSchema)
+		}
+		rules, err := janitor.CollectRules(ctx, message.SchemaID, version, cc.Registry)
 		if err != nil {
 			return cc.determineError(message, err, PayloadSchema)
 		}
-		messageSchemaPair = janitor.MessageSchemaPair{Message: message, Schema: schema}
+		messageSchemaPair = janitor.MessageSchemaPair{Message: message, Schema: schema, Rules: rules}
 		releaseIfSet(cc.registrySem)
 
 		messageTopicPair, err = cc.getMessageTopicPair(messageSchemaPair, encryptedMessageData)
@@ -394,10 +468,21 @@ func (cc *CentralConsumer) Handle(ctx context.Context, message janitor.Message)
 		return messageTopicPair, nil
 
 	} else if cc.mode == OneCCPerTopic {
-		if message.Version == "" { // Version not set in message
+		version := message.Version
+		if version == "" && cc.resolvesAsOfIngestion(message) {
+			// an unpinned message is validated against the version which was the latest one when it was ingested
+			acquireIfSet(cc.registrySem)
+			version, err = janitor.CollectVersionAsOf(ctx, cc.schema.SchemaMetadata.ID, message.IngestionTime, cc.Registry)
+			if err != nil {
+				return cc.determineError(message, err, PayloadSchema)
+			}
+			releaseIfSet(cc.registrySem)
+		}
+		if version == "" { // Version not set in message
 			messageTopicPair, err = cc.getMessageTopicPair(janitor.MessageSchemaPair{
 				Message: message,
 				Schema:  cc.schema.Specification,
+				Rules:   cc.schema.Rules,
 			}, encryptedMessageData)
 			if err != nil {
 				return messageTopicPair, err
@@ -411,10 +496,11 @@ func (cc *CentralConsumer) Handle(ctx context.Context, message janitor.Message)
 			}
 			return messageTopicPair, nil
 		} else {
-			if message.Version == cc.schema.SchemaMetadata.Version {
+			if version == cc.schema.SchemaMetadata.Version {
 				messageTopicPair, err = cc.getMessageTopicPair(janitor.MessageSchemaPair{
 					Message: message,
 					Schema:  cc.schema.Specification,
+					Rules:   cc.schema.Rules,
 				}, encryptedMessageData)
 				if err != nil {
 					return messageTopicPair, err
@@ -422,7 +508,11 @@ func (cc *CentralConsumer) Handle(ctx context.Context, message janitor.Message)
 				return messageTopicPair, nil
 			} else {
 				acquireIfSet(cc.registrySem)
-				specificSchemaVersionSpec, err := cc.Registry.Get(ctx, cc.schema.SchemaMetadata.ID, message.Version)
+				version, err := resolveVersion(ctx, cc.Registry, cc.schema.SchemaMetadata.ID, version)
+				var specificSchemaVersionSpec []byte
+				if err == nil {
+					specificSchemaVersionSpec, err = cc.Registry.Get(ctx, cc.schema.SchemaMetadata.ID, version)
+				}
 				if err != nil {
 					if errors.Is(err, registry.ErrNotFound) {
 						setMessageRawAttributes(message, "Schema error", err)
@@ -438,11 +528,16 @@ func (cc *CentralConsumer) Handle(ctx context.Context, message janitor.Message)
 						return janitor.MessageTopicPair{Message: message, Topic: cc.Router.Route(janitor.Deadletter, message)}, err
 					}
 				}
+				rules, err := janitor.CollectRules(ctx, cc.schema.SchemaMetadata.ID, version, cc.Registry)
+				if err != nil {
+					return cc.determineError(message, err, PayloadSchema)
+				}
 				releaseIfSet(cc.registrySem)
 
 				err = cc.updateIfNewer(VersionDetails{
-					Version:       message.Version,
+					Version:       version,
 					Specification: specificSchemaVersionSpec,
+					Rules:         rules,
 				})
 				if err != nil {
 					setMessageRawAttributes(message, "Non number version", err)
@@ -452,6 +547,7 @@ func (cc *CentralConsumer) Handle(ctx context.Context, message janitor.Message)
 				messageTopicPair, err = cc.getMessageTopicPair(janitor.MessageSchemaPair{
 					Message: message,
 					Schema:  specificSchemaVersionSpec,
+					Rules:   rules,
 				}, encryptedMessageData)
 				if err != nil {
 					return messageTopicPair, err
@@ -466,6 +562,11 @@ func (cc *CentralConsumer) Handle(ctx context.Context, message janitor.Message)
 	}
 }
 
+// resolvesAsOfIngestion returns true if the version of the unpinned message is resolved by its ingestion time.
+func (cc *CentralConsumer) resolvesAsOfIngestion(message janitor.Message) bool {
+	return cc.resolveAsOfIngestion && !message.IngestionTime.IsZero()
+}
+
 func getHeaderSchemaIdAndVersion(message janitor.Message) (string, string, error) {
 	var (
 		id, version               string
@@ -518,9 +619,20 @@ func (cc *CentralConsumer) getMessageTopicPair(messageSchemaPair janitor.Message
 	return messageTopicPair, nil
 }
 
+// resolveVersion returns the version number the alias or semantic version of the schema points at, if the version is
+// one and the schema registry supports them, and the version as it is otherwise.
+func resolveVersion(ctx context.Context, schemaRegistry registry.SchemaRegistry, id, version string) (string, error) {
+	resolver, ok := schemaRegistry.(registry.VersionResolver)
+	if !ok || !registry.IsAlias(version) && !registry.IsSemver(version) {
+		return version, nil
+	}
+	return resolver.ResolveVersion(ctx, id, version)
+}
+
 func (cc *CentralConsumer) updateVersion(vd VersionDetails) {
 	cc.schema.SchemaMetadata.Version = vd.Version
 	cc.schema.Specification = vd.Specification
+	cc.schema.Rules = vd.Rules
 }
 
 // checkIfNewer checks if v2 is newer than v1
@@ -555,6 +667,10 @@ func (cc *CentralConsumer) revalidatedAgainstLatest(ctx context.Context, specifi
 		releaseIfSet(cc.registrySem)
 		return janitor.MessageTopicPair{Message: message, Topic: cc.Router.Route(janitor.Deadletter, message)}, errors.Wrap(err, errtemplates.UnmarshallingJSONFailed)
 	}
+	specificSchemaVersion.Rules, err = janitor.CollectRules(ctx, cc.schema.SchemaMetadata.ID, specificSchemaVersion.Version, cc.Registry)
+	if err != nil {
+		return cc.determineError(message, err, PayloadSchema)
+	}
 	releaseIfSet(cc.registrySem)
 
 	err = cc.updateIfNewer(specificSchemaVersion)
@@ -566,6 +682,7 @@ func (cc *CentralConsumer) revalidatedAgainstLatest(ctx context.Context, specifi
 	messageTopicPair, err = cc.getMessageTopicPair(janitor.MessageSchemaPair{
 		Message: message,
 		Schema:  cc.schema.Specification,
+		Rules:   cc.schema.Rules,
 	}, encryptedMessageData)
 	if err != nil {
 		return messageTopicPair, err
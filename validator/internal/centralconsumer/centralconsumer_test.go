@@ -15,6 +15,7 @@
 package centralconsumer
 
 import (
+	"encoding/json"
 	"golang.org/x/net/context"
 	"os"
 	"path/filepath"
@@ -22,6 +23,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/dataphos/schema-registry-validator/internal/contract"
 	"github.com/dataphos/schema-registry-validator/internal/janitor"
 	"github.com/dataphos/schema-registry-validator/internal/publisher"
 	"github.com/dataphos/schema-registry-validator/internal/registry"
@@ -197,3 +199,234 @@ func TestOneCCPerTopic(t *testing.T) {
 		})
 	}
 }
+
+func TestRegisterUsage(t *testing.T) {
+	topics := Topics{
+		Valid:      "valid",
+		Deadletter: "deadletter",
+	}
+
+	schemaRegistry := registry.NewMock()
+	schemaRegistry.SetGetResponse("1", "1", []byte(`{"type":"object"}`), nil)
+
+	validators := map[string]validator.Validator{"json": localjson.New()}
+	metadata := SchemaMetadata{ID: "1", Version: "1", Format: "json"}
+
+	cc, err := New(schemaRegistry, &publisher.MockPublisher{}, validators, topics, Settings{}, nil, RouterFlags{}, OneCCPerTopic, metadata, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the usage is registered right away and then refreshed until the context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	cc.RegisterUsage(ctx, 20*time.Millisecond, "validator-0")
+
+	if len(schemaRegistry.RegisteredUsage) < 2 {
+		t.Fatalf("expected the usage to be registered and refreshed, got %v", schemaRegistry.RegisteredUsage)
+	}
+	if expected := "1_1_valid_producer_validator-0"; schemaRegistry.RegisteredUsage[0] != expected {
+		t.Errorf("expected and actual usage not the same (%s != %s)", expected, schemaRegistry.RegisteredUsage[0])
+	}
+
+	// the schemas of the Default mode aren't known up front, so nothing is registered
+	schemaRegistry.RegisteredUsage = nil
+	cc, err = New(schemaRegistry, &publisher.MockPublisher{}, validators, topics, Settings{}, nil, RouterFlags{}, Default, SchemaMetadata{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cc.RegisterUsage(context.Background(), 20*time.Millisecond, "validator-0")
+	if len(schemaRegistry.RegisteredUsage) != 0 {
+		t.Errorf("expected no usage to be registered in the Default mode, got %v", schemaRegistry.RegisteredUsage)
+	}
+}
+
+func TestOneCCPerTopicAlias(t *testing.T) {
+	topics := Topics{
+		Valid:       "valid",
+		InvalidJSON: "deadletter",
+		Deadletter:  "deadletter",
+	}
+
+	_, b, _, _ := runtime.Caller(0)
+	testdataDir := filepath.Join(filepath.Dir(b), "testdata")
+	read := func(name string) []byte {
+		data, err := os.ReadFile(filepath.Join(testdataDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	schemaRegistry := registry.NewMock()
+	schemaRegistry.SetGetResponse("1", "1", read("schema-1.json"), nil)
+	schemaRegistry.SetGetResponse("1", "2", read("schema-2.json"), nil)
+	schemaRegistry.SetAlias("1", "prod", "1")
+	schemaRegistry.SetAlias("1", "stable", "2")
+
+	validators := map[string]validator.Validator{"json": localjson.New()}
+	metadata := SchemaMetadata{ID: "1", Version: "prod", Format: "json"}
+
+	cc, err := New(schemaRegistry, &publisher.MockPublisher{}, validators, topics, Settings{}, nil, RouterFlags{}, OneCCPerTopic, metadata, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cc.schema.SchemaMetadata.Version != "1" {
+		t.Fatalf("expected the configured alias to be resolved to version 1, got %s", cc.schema.SchemaMetadata.Version)
+	}
+
+	message := janitor.Message{
+		RawAttributes: map[string]interface{}{},
+		Payload:       read("data-2.json"),
+		SchemaID:      "1",
+		Version:       "stable",
+		Format:        "json",
+	}
+	messageTopicPair, err := cc.Handle(context.Background(), message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if messageTopicPair.Topic != "valid" {
+		t.Errorf("expected the message to be valid against the version stable points at, got %s", messageTopicPair.Topic)
+	}
+	if cc.schema.SchemaMetadata.Version != "2" {
+		t.Errorf("expected the newer version stable points at to be picked up, got %s", cc.schema.SchemaMetadata.Version)
+	}
+}
+
+func TestOneCCPerTopicAsOfIngestion(t *testing.T) {
+	topics := Topics{
+		Valid:       "valid",
+		InvalidJSON: "deadletter",
+		Deadletter:  "deadletter",
+	}
+
+	_, b, _, _ := runtime.Caller(0)
+	testdataDir := filepath.Join(filepath.Dir(b), "testdata")
+	read := func(name string) []byte {
+		data, err := os.ReadFile(filepath.Join(testdataDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	schemaRegistry := registry.NewMock()
+	schemaRegistry.SetGetResponse("1", "1", read("schema-1.json"), nil)
+	schemaRegistry.SetGetResponse("1", "3", read("schema-3.json"), nil)
+	schemaRegistry.SetHistory("1", []registry.VersionEvent{
+		{Version: "1", Event: registry.VersionActivated, SchemaHash: "a", Timestamp: start},
+		{Version: "3", Event: registry.VersionActivated, SchemaHash: "c", Timestamp: start.Add(time.Hour)},
+	})
+
+	validators := map[string]validator.Validator{"json": localjson.New()}
+	metadata := SchemaMetadata{ID: "1", Version: "3", Format: "json"}
+
+	cc, err := New(schemaRegistry, &publisher.MockPublisher{}, validators, topics, Settings{ResolveUnpinnedAsOfIngestion: true}, nil, RouterFlags{}, OneCCPerTopic, metadata, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		name          string
+		ingestionTime time.Time
+		expectedTopic string
+	}{
+		{"ingested while version 1 was the latest", start.Add(time.Minute), "valid"},
+		{"ingested while version 3 was the latest", start.Add(2 * time.Hour), "deadletter"},
+		{"ingested before the first version", start.Add(-time.Minute), "deadletter"},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			message := janitor.Message{
+				RawAttributes: map[string]interface{}{},
+				Payload:       read("data-1.json"),
+				IngestionTime: tc.ingestionTime,
+				SchemaID:      "1",
+				Format:        "json",
+			}
+			messageTopicPair, err := cc.Handle(context.Background(), message)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if messageTopicPair.Topic != tc.expectedTopic {
+				t.Errorf("expected and actual destination not the same (%s != %s)", tc.expectedTopic, messageTopicPair.Topic)
+			}
+		})
+	}
+	if cc.schema.SchemaMetadata.Version != "3" {
+		t.Errorf("expected an older version not to replace the latest one, got %s", cc.schema.SchemaMetadata.Version)
+	}
+}
+
+func TestOneCCPerTopicRules(t *testing.T) {
+	topics := Topics{
+		Valid:       "valid",
+		InvalidJSON: "invalid",
+		Deadletter:  "deadletter",
+	}
+
+	_, b, _, _ := runtime.Caller(0)
+	testdataDir := filepath.Join(filepath.Dir(b), "testdata")
+	read := func(name string) []byte {
+		data, err := os.ReadFile(filepath.Join(testdataDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	schemaRegistry := registry.NewMock()
+	schemaRegistry.SetGetResponse("1", "1", read("schema-1.json"), nil)
+	schemaRegistry.SetGetResponse("1", "2", read("schema-2.json"), nil)
+	latest, err := json.Marshal(VersionDetails{Version: "1", Specification: read("schema-1.json")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	schemaRegistry.SetGetLatestResponse("1", latest, nil)
+	schemaRegistry.SetGetRulesResponse("1", "1", []contract.Rule{{Name: "has_age", Expression: "has(message.age)", Severity: contract.SeverityError}}, nil)
+	schemaRegistry.SetGetRulesResponse("1", "2", []contract.Rule{{Name: "not_doe", Expression: "message.lastName != 'Doe'", Severity: contract.SeverityError}}, nil)
+
+	validators := map[string]validator.Validator{"json": localjson.New()}
+	metadata := SchemaMetadata{ID: "1", Version: "1", Format: "json"}
+
+	cc, err := New(schemaRegistry, &publisher.MockPublisher{}, validators, topics, Settings{}, nil, RouterFlags{}, OneCCPerTopic, metadata, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the cases run in order, the message pinned to version 2 makes it the cached version
+	tt := []struct {
+		name          string
+		data          string
+		version       string
+		expectedTopic string
+		failedRules   interface{}
+	}{
+		{"unpinned breaks the rule of the cached version", "data-1.json", "", "deadletter", "has_age"},
+		{"pinned to the cached version passes its rule", "data-3.json", "1", "valid", nil},
+		{"pinned to a newer version breaks its rule", "data-2.json", "2", "deadletter", "not_doe"},
+		{"unpinned breaks the rule of the newer cached version", "data-3.json", "", "deadletter", "not_doe"},
+	}
+	for _, tc := range tt {
+		message := janitor.Message{
+			RawAttributes: map[string]interface{}{},
+			Payload:       read(tc.data),
+			SchemaID:      "1",
+			Version:       tc.version,
+			Format:        "json",
+		}
+		messageTopicPair, err := cc.Handle(context.Background(), message)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if messageTopicPair.Topic != tc.expectedTopic {
+			t.Errorf("%s: expected and actual destination not the same (%s != %s)", tc.name, tc.expectedTopic, messageTopicPair.Topic)
+		}
+		if failed := messageTopicPair.Message.RawAttributes[janitor.AttributeFailedRules]; failed != tc.failedRules {
+			t.Errorf("%s: expected the failed rules %v, got %v", tc.name, tc.failedRules, failed)
+		}
+	}
+}
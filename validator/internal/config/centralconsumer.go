@@ -22,23 +22,25 @@ import (
 
 // CentralConsumer represents all required configuration to run an instance of central consumer.
 type CentralConsumer struct {
-	Producer               Producer                  `toml:"producer"`
-	Consumer               Consumer                  `toml:"consumer"`
-	Registry               Registry                  `toml:"registry"`
-	Topics                 CentralConsumerTopics     `toml:"topics"`
-	Validators             CentralConsumerValidators `toml:"validators"`
-	ShouldLog              CentralConsumerShouldLog  `toml:"should_log"`
-	NumSchemaCollectors    int                       `toml:"num_schema_collectors" default:"-1"`
-	NumInferrers           int                       `toml:"num_inferrers" default:"-1"`
-	ValidateHeader         bool                      `toml:"validate_header"`
-	DefaultHeaderSchema    DefaultHeaderSchema       `toml:"default_header_schema"`
-	MetricsLoggingInterval time.Duration             `toml:"metrics_logging_interval" default:"5s"`
-	RunOptions             RunOptions                `toml:"run_option"`
-	Mode                   int                       `toml:"mode"`
-	SchemaID               string                    `toml:"schema_id"`
-	SchemaVersion          string                    `toml:"schema_version"`
-	SchemaType             string                    `toml:"schema_type"`
-	Encryption             Encryption                `toml:"encryption"`
+	Producer                     Producer                  `toml:"producer"`
+	Consumer                     Consumer                  `toml:"consumer"`
+	Registry                     Registry                  `toml:"registry"`
+	Topics                       CentralConsumerTopics     `toml:"topics"`
+	Validators                   CentralConsumerValidators `toml:"validators"`
+	ShouldLog                    CentralConsumerShouldLog  `toml:"should_log"`
+	NumSchemaCollectors          int                       `toml:"num_schema_collectors" default:"-1"`
+	NumInferrers                 int                       `toml:"num_inferrers" default:"-1"`
+	ValidateHeader               bool                      `toml:"validate_header"`
+	DefaultHeaderSchema          DefaultHeaderSchema       `toml:"default_header_schema"`
+	MetricsLoggingInterval       time.Duration             `toml:"metrics_logging_interval" default:"5s"`
+	RunOptions                   RunOptions                `toml:"run_option"`
+	Mode                         int                       `toml:"mode"`
+	SchemaID                     string                    `toml:"schema_id"`
+	SchemaVersion                string                    `toml:"schema_version"`
+	SchemaType                   string                    `toml:"schema_type"`
+	Encryption                   Encryption                `toml:"encryption"`
+	UsageHeartbeatInterval       time.Duration             `toml:"usage_heartbeat_interval" default:"1m"`
+	ResolveUnpinnedAsOfIngestion bool                      `toml:"resolve_unpinned_as_of_ingestion"`
 }
 
 type Encryption struct {
@@ -0,0 +1,169 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package contract evaluates the data contract rules of schema versions against messages.
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/hamba/avro"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/pkg/errors"
+)
+
+const (
+	// SeverityError marks rules whose failures dead-letter the message.
+	SeverityError = "error"
+	// SeverityWarning marks rules whose failures are only recorded in the attributes of the message.
+	SeverityWarning = "warning"
+)
+
+// messageVariable is the name of the CEL variable the decoded message is bound to.
+const messageVariable = "message"
+
+// programCacheSize is the number of compiled expressions kept in memory.
+const programCacheSize = 1024
+
+// ErrEvaluation is returned if the rules couldn't be evaluated against a message at all.
+var ErrEvaluation = errors.New("data contract rules couldn't be evaluated")
+
+// RuleError is returned if a rule can't be evaluated against any message, because its expression doesn't compile.
+// It wraps ErrEvaluation.
+type RuleError struct {
+	Rule Rule
+	Err  error
+}
+
+func (e *RuleError) Error() string {
+	return fmt.Sprintf("%v: rule %s doesn't compile: %v", ErrEvaluation, e.Rule.Name, e.Err)
+}
+
+func (e *RuleError) Unwrap() error {
+	return ErrEvaluation
+}
+
+// Rule is a data contract rule of a schema version, a CEL expression over the message variable.
+type Rule struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+	Severity   string `json:"severity"`
+}
+
+// IsError returns true if failing the rule dead-letters the message, which is the case unless it is a warning.
+func (r Rule) IsError() bool {
+	return !strings.EqualFold(r.Severity, SeverityWarning)
+}
+
+var env *cel.Env
+
+var programs *lru.Cache
+
+func init() {
+	var err error
+	env, err = cel.NewEnv(
+		cel.Variable(messageVariable, cel.DynType),
+		cel.CrossTypeNumericComparisons(true),
+	)
+	if err != nil {
+		panic(err)
+	}
+	programs, _ = lru.New(programCacheSize)
+}
+
+// Evaluate evaluates the rules against the message of the given format, returning the rules it fails.
+//
+// Only json and avro messages are supported, avro messages are decoded with the given schema. A rule whose expression
+// can't be evaluated against the message, for example because it accesses a field the message doesn't have, fails.
+// An error wrapping ErrEvaluation is returned if the message can't be decoded, or a *RuleError naming the rule if an
+// expression doesn't compile.
+func Evaluate(rules []Rule, message, schema []byte, format string) ([]Rule, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	decoded, err := decode(message, schema, format)
+	if err != nil {
+		return nil, errors.WithMessage(ErrEvaluation, err.Error())
+	}
+	activation := map[string]interface{}{messageVariable: decoded}
+
+	var failed []Rule
+	for _, rule := range rules {
+		program, err := compile(rule.Expression)
+		if err != nil {
+			return nil, &RuleError{Rule: rule, Err: err}
+		}
+		out, _, err := program.Eval(activation)
+		if err != nil {
+			failed = append(failed, rule)
+			continue
+		}
+		if passed, ok := out.Value().(bool); !ok || !passed {
+			failed = append(failed, rule)
+		}
+	}
+	return failed, nil
+}
+
+// Names returns the comma separated names of the rules.
+func Names(rules []Rule) string {
+	names := make([]string, len(rules))
+	for i, rule := range rules {
+		names[i] = rule.Name
+	}
+	return strings.Join(names, ",")
+}
+
+// compile returns the program of the expression, compiling it only the first time it is seen.
+func compile(expression string) (cel.Program, error) {
+	if program, ok := programs.Get(expression); ok {
+		return program.(cel.Program), nil
+	}
+
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+	programs.Add(expression, program)
+	return program, nil
+}
+
+func decode(message, schema []byte, format string) (interface{}, error) {
+	var decoded interface{}
+	switch strings.ToLower(format) {
+	case "json":
+		if err := json.Unmarshal(message, &decoded); err != nil {
+			return nil, err
+		}
+	case "avro":
+		parsedSchema, err := avro.Parse(string(schema))
+		if err != nil {
+			return nil, err
+		}
+		if err = avro.Unmarshal(parsedSchema, message, &decoded); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.Errorf("rules can't be evaluated against %s messages", format)
+	}
+	return decoded, nil
+}
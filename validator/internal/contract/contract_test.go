@@ -0,0 +1,99 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package contract
+
+import (
+	"testing"
+
+	"github.com/hamba/avro"
+	"github.com/pkg/errors"
+)
+
+func TestEvaluate(t *testing.T) {
+	rules := []Rule{
+		{Name: "positive_amount", Expression: "message.status != 'PAID' || message.amount > 0", Severity: SeverityError},
+		{Name: "ordered_dates", Expression: "message.end_date >= message.start_date", Severity: SeverityWarning},
+	}
+
+	tt := []struct {
+		name     string
+		message  string
+		expected string
+	}{
+		{"passes", `{"status":"PAID","amount":10,"start_date":"2024-01-01","end_date":"2024-02-01"}`, ""},
+		{"unpaid", `{"status":"OPEN","amount":0,"start_date":"2024-01-01","end_date":"2024-02-01"}`, ""},
+		{"fails both", `{"status":"PAID","amount":0,"start_date":"2024-03-01","end_date":"2024-02-01"}`, "positive_amount,ordered_dates"},
+		{"missing field", `{"status":"PAID","amount":10}`, "ordered_dates"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			failed, err := Evaluate(rules, []byte(tc.message), nil, "json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if names := Names(failed); names != tc.expected {
+				t.Errorf("expected %q to fail, got %q", tc.expected, names)
+			}
+		})
+	}
+}
+
+func TestEvaluateAvro(t *testing.T) {
+	schema := `{"type":"record","name":"Payment","fields":[{"name":"amount","type":"long"}]}`
+	message, err := avro.Marshal(avro.MustParse(schema), map[string]interface{}{"amount": int64(-5)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failed, err := Evaluate([]Rule{{Name: "positive_amount", Expression: "message.amount > 0"}}, message, []byte(schema), "avro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 1 || !failed[0].IsError() {
+		t.Errorf("expected positive_amount to fail as an error, got %v", failed)
+	}
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	tt := []struct {
+		name    string
+		rules   []Rule
+		message string
+		format  string
+	}{
+		{"broken message", []Rule{{Name: "a", Expression: "true"}}, "{", "json"},
+		{"unsupported format", []Rule{{Name: "a", Expression: "true"}}, "a,b", "csv"},
+		{"doesn't compile", []Rule{{Name: "a", Expression: "message.amount >"}}, "{}", "json"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := Evaluate(tc.rules, []byte(tc.message), nil, tc.format); !errors.Is(err, ErrEvaluation) {
+				t.Errorf("expected ErrEvaluation, got %v", err)
+			}
+		})
+	}
+}
+
+func TestEvaluateNamesRuleWhichDoesntCompile(t *testing.T) {
+	rules := []Rule{{Name: "a", Expression: "true"}, {Name: "b", Expression: "message.amount >"}}
+	_, err := Evaluate(rules, []byte("{}"), nil, "json")
+	var ruleErr *RuleError
+	if !errors.As(err, &ruleErr) {
+		t.Fatalf("expected a RuleError, got %v", err)
+	}
+	if ruleErr.Rule.Name != "b" {
+		t.Errorf("expected rule b to be named, got %s", ruleErr.Rule.Name)
+	}
+}
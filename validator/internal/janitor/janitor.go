@@ -22,6 +22,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/dataphos/schema-registry-validator/internal/contract"
 	"github.com/dataphos/schema-registry-validator/internal/errcodes"
 	"github.com/dataphos/schema-registry-validator/internal/errtemplates"
 	"github.com/dataphos/schema-registry-validator/internal/registry"
@@ -29,6 +30,7 @@ import (
 	"github.com/dataphos/schema-registry-validator/internal/validator"
 
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/dataphos/lib-brokers/pkg/broker"
 )
@@ -85,10 +87,21 @@ const (
 	AttributeHeaderVersion = "header_version"
 )
 
-// MessageSchemaPair wraps a Message with the Schema relating to this Message.
+const (
+	// AttributeFailedRules is set on dead-lettered messages to the comma separated names of the data contract rules
+	// with the error severity they failed.
+	AttributeFailedRules = "deadLetterFailedRules"
+
+	// AttributeRuleWarnings is set on valid messages to the comma separated names of the data contract rules
+	// with the warning severity they failed.
+	AttributeRuleWarnings = "dataContractWarnings"
+)
+
+// MessageSchemaPair wraps a Message with the Schema relating to this Message, and the data contract rules of the schema.
 type MessageSchemaPair struct {
 	Message Message
 	Schema  []byte
+	Rules   []contract.Rule
 }
 
 // CollectSchema retrieves the schema with the given id and version from registry.SchemaRegistry.
@@ -98,7 +111,23 @@ type MessageSchemaPair struct {
 //
 // The returned error is an instance of OpError for improved error handling (so that the source of this error is identifiable
 // even if combined with other errors).
+//
+// The retrieval is recorded as a span, annotated with the schema id and version.
 func CollectSchema(ctx context.Context, id string, version string, schemaRegistry registry.SchemaRegistry) ([]byte, error) {
+	ctx, span := tracer.Start(ctx, "collect schema", trace.WithAttributes(
+		schemaIDAttribute.String(id),
+		schemaVersionAttribute.String(version),
+	))
+	defer span.End()
+
+	schema, err := collectSchema(ctx, id, version, schemaRegistry)
+	if err != nil {
+		recordError(span, err)
+	}
+	return schema, err
+}
+
+func collectSchema(ctx context.Context, id string, version string, schemaRegistry registry.SchemaRegistry) ([]byte, error) {
 	if id == "" {
 		return nil, intoOpErr("_", errcodes.InvalidDataInHeader, errors.New("missing schema ID"))
 	}
@@ -119,6 +148,61 @@ func CollectSchema(ctx context.Context, id string, version string, schemaRegistr
 	return schema, nil
 }
 
+// CollectRules retrieves the data contract rules of the schema with the given id and version, if the registry.SchemaRegistry
+// implements registry.RuleGetter. Otherwise, the schema has no rules.
+//
+// The returned error is an instance of OpError, like the ones returned by CollectSchema.
+func CollectRules(ctx context.Context, id string, version string, schemaRegistry registry.SchemaRegistry) ([]contract.Rule, error) {
+	getter, ok := schemaRegistry.(registry.RuleGetter)
+	if !ok {
+		return nil, nil
+	}
+
+	rules, err := getter.GetRules(ctx, id, version)
+	if err != nil {
+		if errors.Is(err, registry.ErrNotFound) {
+			return nil, intoOpErr(id, errcodes.SchemaNotRegistered, err)
+		} else if errors.Is(err, registry.InvalidHeader) {
+			return nil, intoOpErr(id, errcodes.InvalidDataInHeader, err)
+		}
+		return nil, intoOpErr(id, errcodes.RegistryUnresponsive, err)
+	}
+	return rules, nil
+}
+
+// CollectVersionAsOf returns the version of the schema with the given id which was the latest one at the given time,
+// if the registry.SchemaRegistry implements registry.HistoryGetter. Otherwise, an empty version is returned, leaving
+// the version of the message unresolved.
+//
+// The returned error is an instance of OpError, like the ones returned by CollectSchema.
+func CollectVersionAsOf(ctx context.Context, id string, asOf time.Time, schemaRegistry registry.SchemaRegistry) (string, error) {
+	getter, ok := schemaRegistry.(registry.HistoryGetter)
+	if !ok {
+		return "", nil
+	}
+	if id == "" {
+		return "", intoOpErr("_", errcodes.InvalidDataInHeader, errors.New("missing schema ID"))
+	}
+
+	events, err := getter.GetHistory(ctx, id)
+	if err != nil {
+		if errors.Is(err, registry.ErrHistoryNotSupported) {
+			return "", nil
+		} else if errors.Is(err, registry.ErrNotFound) {
+			return "", intoOpErr(id, errcodes.SchemaNotRegistered, err)
+		} else if errors.Is(err, registry.InvalidHeader) {
+			return "", intoOpErr(id, errcodes.InvalidDataInHeader, err)
+		}
+		return "", intoOpErr(id, errcodes.RegistryUnresponsive, err)
+	}
+
+	version, ok := registry.LatestVersionAt(events, asOf)
+	if !ok {
+		return "", intoOpErr(id, errcodes.SchemaNotRegistered, errors.Wrapf(registry.ErrNotFound, "schema %s had no active version at %s", id, asOf.Format(time.RFC3339)))
+	}
+	return version, nil
+}
+
 // Validators is a convenience type for a map containing validator.Validator instances for available message formats.
 type Validators map[string]validator.Validator
 
@@ -236,6 +320,10 @@ type MessageTopicPair struct {
 // If the schema exists, the message is validated against it, and the Result is passed onto the Router
 // to infer the destination topic. In case validation returns validator.ErrDeadletter, Deadletter is passed onto the Router.
 //
+// Valid messages are then checked against the data contract rules of the schema. Messages failing a rule with the error
+// severity are routed as Deadletter, with the names of the failed rules under AttributeFailedRules, while the names of
+// the failed rules with the warning severity are put under AttributeRuleWarnings.
+//
 // The returned error is an instance of OpError for improved error handling (so that the source of this error is identifiable
 // even if combined with other errors).
 func InferDestinationTopic(messageSchemaPair MessageSchemaPair, validators Validators, router Router) (MessageTopicPair, error) {
@@ -286,6 +374,36 @@ func InferDestinationTopic(messageSchemaPair MessageSchemaPair, validators Valid
 	} else {
 		result = Invalid
 	}
+
+	if isValid && len(messageSchemaPair.Rules) > 0 {
+		failed, err := contract.Evaluate(messageSchemaPair.Rules, message.Payload, schema, message.Format)
+		if err != nil {
+			setMessageRawAttributes(message, "Data contract error", err)
+			var ruleErr *contract.RuleError
+			if errors.As(err, &ruleErr) {
+				message.RawAttributes[AttributeFailedRules] = ruleErr.Rule.Name
+			}
+			return MessageTopicPair{Message: message, Topic: router.Route(Deadletter, message)}, nil
+		}
+
+		var errorRules, warningRules []contract.Rule
+		for _, rule := range failed {
+			if rule.IsError() {
+				errorRules = append(errorRules, rule)
+			} else {
+				warningRules = append(warningRules, rule)
+			}
+		}
+		if len(warningRules) > 0 {
+			message.RawAttributes[AttributeRuleWarnings] = contract.Names(warningRules)
+		}
+		if len(errorRules) > 0 {
+			setMessageRawAttributes(message, "Data contract violation", errors.Errorf("message failed the data contract rules %s", contract.Names(errorRules)))
+			message.RawAttributes[AttributeFailedRules] = contract.Names(errorRules)
+			return MessageTopicPair{Message: message, Topic: router.Route(Deadletter, message)}, nil
+		}
+	}
+
 	return MessageTopicPair{Message: message, Topic: router.Route(result, message)}, nil
 }
 
@@ -19,6 +19,7 @@ import (
 	"strconv"
 	"testing"
 
+	"github.com/dataphos/schema-registry-validator/internal/contract"
 	"github.com/dataphos/schema-registry-validator/internal/validator"
 	"github.com/dataphos/lib-streamproc/pkg/streamproc"
 
@@ -179,3 +180,72 @@ func TestValidatorsValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestInferDestinationTopicRules(t *testing.T) {
+	router := RoutingFunc(func(result Result, _ Message) string {
+		return strconv.Itoa(int(result))
+	})
+	validators := Validators(map[string]validator.Validator{
+		JSONFormat: validator.Func(func(_, _ []byte, _ string, _ string) (bool, error) {
+			return true, nil
+		}),
+	})
+	rules := []contract.Rule{
+		{Name: "positive_amount", Expression: "message.amount > 0", Severity: contract.SeverityError},
+		{Name: "has_note", Expression: "has(message.note)", Severity: contract.SeverityWarning},
+	}
+
+	tt := []struct {
+		name     string
+		payload  string
+		result   Result
+		failed   interface{}
+		warnings interface{}
+	}{
+		{"passes", `{"amount":1,"note":"a"}`, Valid, nil, nil},
+		{"warning", `{"amount":1}`, Valid, nil, "has_note"},
+		{"error", `{"amount":0,"note":"a"}`, Deadletter, "positive_amount", nil},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			message := Message{
+				ID:            "1",
+				RawAttributes: map[string]interface{}{},
+				Payload:       []byte(tc.payload),
+				Format:        JSONFormat,
+			}
+			pair, err := InferDestinationTopic(MessageSchemaPair{Message: message, Schema: []byte("{}"), Rules: rules}, validators, router)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pair.Topic != strconv.Itoa(int(tc.result)) {
+				t.Errorf("expected result %d, got %s", tc.result, pair.Topic)
+			}
+			if failed := pair.Message.RawAttributes[AttributeFailedRules]; failed != tc.failed {
+				t.Errorf("expected failed rules %v, got %v", tc.failed, failed)
+			}
+			if warnings := pair.Message.RawAttributes[AttributeRuleWarnings]; warnings != tc.warnings {
+				t.Errorf("expected rule warnings %v, got %v", tc.warnings, warnings)
+			}
+		})
+	}
+
+	// a rule which doesn't compile fails every message, so it's named like a failed rule
+	message := Message{
+		ID:            "1",
+		RawAttributes: map[string]interface{}{},
+		Payload:       []byte(`{"amount":1,"note":"a"}`),
+		Format:        JSONFormat,
+	}
+	broken := append([]contract.Rule{{Name: "broken", Expression: "message.amount >", Severity: contract.SeverityError}}, rules...)
+	pair, err := InferDestinationTopic(MessageSchemaPair{Message: message, Schema: []byte("{}"), Rules: broken}, validators, router)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pair.Topic != strconv.Itoa(int(Deadletter)) {
+		t.Errorf("expected result %d, got %s", Deadletter, pair.Topic)
+	}
+	if failed := pair.Message.RawAttributes[AttributeFailedRules]; failed != "broken" {
+		t.Errorf("expected failed rules broken, got %v", failed)
+	}
+}
@@ -60,7 +60,9 @@ func (p *Processor) HandleMessage(ctx context.Context, message streamproc.Messag
 		return err
 	}
 	if ok {
-		messageTopicPair, err := p.Handler.Handle(ctx, parsed)
+		handleCtx, span := startMessageSpan(ctx, parsed)
+		messageTopicPair, err := p.Handler.Handle(handleCtx, parsed)
+		endMessageSpan(span, messageTopicPair, err)
 		if err != nil {
 			UpdateFailureMetrics(parsed)
 			return err
@@ -120,7 +122,9 @@ func (p *Processor) HandleBatch(ctx context.Context, batch []streamproc.Message)
 				return err
 			}
 			if ok {
-				messageTopicPair, err := p.Handler.Handle(ctx, parsed)
+				handleCtx, span := startMessageSpan(ctx, parsed)
+				messageTopicPair, err := p.Handler.Handle(handleCtx, parsed)
+				endMessageSpan(span, messageTopicPair, err)
 				if err != nil {
 					UpdateFailureMetrics(parsed)
 					failed[i] = true
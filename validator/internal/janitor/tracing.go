@@ -0,0 +1,86 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package janitor
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	schemaIDAttribute      = attribute.Key("schema.id")
+	schemaVersionAttribute = attribute.Key("schema.version")
+	formatAttribute        = attribute.Key("message.format")
+	topicAttribute         = attribute.Key("message.topic")
+)
+
+var tracer = otel.Tracer("github.com/dataphos/schema-registry-validator/internal/janitor")
+
+// attributesCarrier adapts the message attributes to propagation.TextMapCarrier, so that the trace started by the
+// producer of the message is continued.
+type attributesCarrier map[string]interface{}
+
+func (c attributesCarrier) Get(key string) string {
+	value, _ := c[key].(string)
+	return value
+}
+
+func (c attributesCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+func (c attributesCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// startMessageSpan starts the span of handling the given Message.
+func startMessageSpan(ctx context.Context, message Message) (context.Context, trace.Span) {
+	if message.RawAttributes != nil {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, attributesCarrier(message.RawAttributes))
+	}
+	return tracer.Start(ctx, "handle message",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingMessageID(message.ID),
+			schemaIDAttribute.String(message.SchemaID),
+			schemaVersionAttribute.String(message.Version),
+			formatAttribute.String(message.Format),
+		),
+	)
+}
+
+// endMessageSpan ends the span of handling a Message, annotating it with the topic the Message is routed to.
+func endMessageSpan(span trace.Span, messageTopicPair MessageTopicPair, err error) {
+	if err != nil {
+		recordError(span, err)
+	} else {
+		span.SetAttributes(topicAttribute.String(messageTopicPair.Topic))
+	}
+	span.End()
+}
+
+func recordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,87 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package janitor
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/dataphos/schema-registry-validator/internal/registry"
+)
+
+func TestMessageTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	message := Message{
+		ID:       "message-1",
+		SchemaID: "1",
+		Version:  "2",
+		Format:   JSONFormat,
+		RawAttributes: map[string]interface{}{
+			"traceparent": "00-" + traceID + "-00f067aa0ba902b7-01",
+		},
+	}
+
+	schemaRegistry := registry.NewMock()
+	schemaRegistry.SetGetResponse("1", "2", []byte("{}"), nil)
+
+	ctx, span := startMessageSpan(context.Background(), message)
+	if _, err := CollectSchema(ctx, message.SchemaID, message.Version, schemaRegistry); err != nil {
+		t.Fatal(err)
+	}
+	endMessageSpan(span, MessageTopicPair{Message: message, Topic: "valid"}, nil)
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+
+	handle, ok := spans["handle message"]
+	if !ok {
+		t.Fatalf("message span not recorded, got %v", spans)
+	}
+	if handle.SpanContext().TraceID().String() != traceID {
+		t.Errorf("expected the trace %s of the producer to be continued, got %s", traceID, handle.SpanContext().TraceID())
+	}
+	attributes := map[string]string{}
+	for _, attribute := range handle.Attributes() {
+		attributes[string(attribute.Key)] = attribute.Value.Emit()
+	}
+	if attributes["messaging.message.id"] != "message-1" || attributes["schema.id"] != "1" ||
+		attributes["schema.version"] != "2" || attributes["message.topic"] != "valid" {
+		t.Errorf("unexpected message span attributes %v", attributes)
+	}
+
+	collect, ok := spans["collect schema"]
+	if !ok {
+		t.Fatalf("schema collection span not recorded, got %v", spans)
+	}
+	if collect.Parent().SpanID() != handle.SpanContext().SpanID() {
+		t.Error("schema collection span isn't a child of the message span")
+	}
+}
@@ -16,6 +16,7 @@ package janitorctl
 
 import (
 	"context"
+	"os"
 	"runtime/debug"
 
 	"github.com/dataphos/lib-brokers/pkg/broker"
@@ -67,6 +68,9 @@ func RunCentralConsumer(configFile string) {
 		log.Fatal(err.Error(), errcodes.ValidateConfigFailure)
 	}
 
+	shutdownTracing := initTracing(log, "schema-registry-central-consumer")
+	defer shutdownTracing()
+
 	initProcessor := func(ctx context.Context, registry registry.SchemaRegistry, publisher broker.Publisher) (*janitor.Processor, error) {
 		validators, err := initializeValidatorsForCentralConsumer(ctx, &cfg)
 		if err != nil {
@@ -84,11 +88,12 @@ func RunCentralConsumer(configFile string) {
 				Deadletter:  cfg.Topics.DeadLetter,
 			},
 			centralconsumer.Settings{
-				NumSchemaCollectors:        cfg.NumSchemaCollectors,
-				NumInferrers:               cfg.NumInferrers,
-				ValidateHeader:             cfg.ValidateHeader,
-				DefaultHeaderSchemaId:      cfg.DefaultHeaderSchema.DefaultHeaderSchemaId,
-				DefaultHeaderSchemaVersion: cfg.DefaultHeaderSchema.DefaultHeaderSchemaVersion,
+				NumSchemaCollectors:          cfg.NumSchemaCollectors,
+				NumInferrers:                 cfg.NumInferrers,
+				ValidateHeader:               cfg.ValidateHeader,
+				DefaultHeaderSchemaId:        cfg.DefaultHeaderSchema.DefaultHeaderSchemaId,
+				DefaultHeaderSchemaVersion:   cfg.DefaultHeaderSchema.DefaultHeaderSchemaVersion,
+				ResolveUnpinnedAsOfIngestion: cfg.ResolveUnpinnedAsOfIngestion,
 			},
 			log,
 			centralconsumer.RouterFlags{
@@ -107,6 +112,8 @@ func RunCentralConsumer(configFile string) {
 		if err != nil {
 			return nil, err
 		}
+		clientID, _ := os.Hostname()
+		go cc.RegisterUsage(ctx, cfg.UsageHeartbeatInterval, clientID)
 		return cc.AsProcessor(), nil
 	}
 
@@ -156,6 +163,9 @@ func RunPullerCleaner(configFile string) {
 		log.Fatal(err.Error(), errcodes.ValidateConfigFailure)
 	}
 
+	shutdownTracing := initTracing(log, "schema-registry-puller-cleaner")
+	defer shutdownTracing()
+
 	initProcessor := func(ctx context.Context, registry registry.SchemaRegistry, publisher broker.Publisher) (*janitor.Processor, error) {
 		validators, err := initializeValidatorsForPullerCleaner(ctx, &cfg)
 		if err != nil {
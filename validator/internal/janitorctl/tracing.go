@@ -0,0 +1,43 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package janitorctl
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/dataphos/lib-logger/logger"
+	"github.com/dataphos/schema-registry-validator/internal/errcodes"
+	"github.com/dataphos/schema-registry-validator/internal/tracing"
+)
+
+// initTracing sets up the tracer provider of the component with the given service name.
+// The returned function flushes the buffered spans and should be called on shutdown.
+func initTracing(log logger.Log, serviceName string) func() {
+	shutdown, err := tracing.Init(context.Background(), serviceName)
+	if err != nil {
+		log.Fatal(err.Error(), errcodes.Initialization)
+	}
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			log.Error(errors.Wrap(err, "flushing spans failed").Error(), errcodes.Miscellaneous)
+		}
+	}
+}
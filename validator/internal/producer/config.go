@@ -28,7 +28,7 @@ import (
 // Config represents all required configuration to run a standalone producer.
 type Config struct {
 	BaseDir          string           `toml:"base_dir" default:""`
-	FileName         string           `toml:"file_name" val:"file"`
+	FileName         string           `toml:"file_name" val:"required_unless=Generator.Enabled true,omitempty,file"`
 	NumberOfMessages int              `toml:"number_of_messages" default:"100"`
 	RateLimit        int              `toml:"rate_limit" default:"100"`
 	TopicId          string           `toml:"topic_id" val:"required"`
@@ -41,6 +41,18 @@ type Config struct {
 	Jetstream        JetstreamConfig  `toml:"jetstream"`
 	RegistryConfig   RegistryConfig   `toml:"registry_config"`
 	Mode             int              `toml:"mode"`
+	Generator        GeneratorConfig  `toml:"generator"`
+}
+
+// GeneratorConfig configures producing example payloads generated by the schema registry, instead of loading a dataset.
+type GeneratorConfig struct {
+	Enabled  bool   `toml:"enabled"`
+	SchemaID string `toml:"schema_id" val:"required_if=Enabled true"`
+	Version  string `toml:"version" val:"required_if=Enabled true"`
+	Format   string `toml:"format" val:"required_if=Enabled true,omitempty,oneof=json avro protobuf csv xml"`
+	Count    int    `toml:"count" default:"10" val:"min=1,max=100"`
+	Seed     int64  `toml:"seed"`
+	Invalid  bool   `toml:"invalid"`
 }
 
 type KafkaConfig struct {
@@ -171,6 +183,8 @@ func (cfg *Config) Validate() error {
 				errCombined = multierr.Append(errCombined, errtemplates.RequiredTagFail(fieldName))
 			case "required_if":
 				errCombined = multierr.Append(errCombined, errtemplates.RequiredTagFail(fieldName))
+			case "required_unless":
+				errCombined = multierr.Append(errCombined, errtemplates.RequiredTagFail(fieldName))
 			case "file":
 				errCombined = multierr.Append(errCombined, errtemplates.FileTagFail(fieldName, err.Value()))
 			case "url":
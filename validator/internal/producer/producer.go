@@ -95,6 +95,59 @@ func (p *Producer) LoadAndProduce(ctx context.Context, baseDir, filename string,
 	return nil
 }
 
+// GeneratorSettings defines which schema the example payloads are generated for and how.
+type GeneratorSettings struct {
+	SchemaID string
+	Version  string
+	Format   string
+	Count    int
+	Seed     int64
+	Invalid  bool
+}
+
+// GenerateAndProduce fetches example payloads of the schema given with settings from the schema registry and publishes
+// them exactly n times, in the same way as LoadAndProduce does with a loaded dataset.
+func (p *Producer) GenerateAndProduce(ctx context.Context, settings GeneratorSettings, n int) error {
+	generator, ok := p.Registry.(registry.ExampleGenerator)
+	if !ok {
+		return errors.New("schema registry doesn't support generating example payloads")
+	}
+
+	log.Printf("generating %d examples of schema %s/%s...\n", settings.Count, settings.SchemaID, settings.Version)
+	start := time.Now()
+	payloads, err := generator.GetExamples(ctx, settings.SchemaID, settings.Version, settings.Count, settings.Seed, settings.Invalid)
+	if err != nil {
+		return err
+	}
+	if len(payloads) == 0 {
+		return errors.New("schema registry returned no example payloads")
+	}
+	log.Println("examples generated in", time.Since(start))
+
+	messages := make([]broker.OutboundMessage, len(payloads))
+	for i, payload := range payloads {
+		messages[i] = broker.OutboundMessage{
+			Data: payload,
+			Attributes: map[string]interface{}{
+				janitor.AttributeFormat:        settings.Format,
+				janitor.AttributeSchemaID:      settings.SchemaID,
+				janitor.AttributeSchemaVersion: settings.Version,
+			},
+		}
+	}
+
+	log.Printf("publishing...")
+	start = time.Now()
+	if err = p.Publish(ctx, messages, n); err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return nil
+		}
+		return err
+	}
+	log.Println("published", n, "messages in", time.Since(start))
+	return nil
+}
+
 // Publish publishes the given broker.Message slice n times, in a round-robin way in case the size of the dataset is smaller
 // than n.
 func (p *Producer) Publish(ctx context.Context, messages []broker.OutboundMessage, n int) error {
@@ -81,3 +81,38 @@ func TestProducer(t *testing.T) {
 	}
 
 }
+
+func TestProducerGenerate(t *testing.T) {
+	publisher := inmem.Publisher{}
+	topic, _ := publisher.Topic("some-topic")
+
+	examples := [][]byte{[]byte(`{"id":1}`), []byte(`{"id":2}`)}
+	schemaRegistry := registry.NewMock()
+	schemaRegistry.SetGetExamplesResponse("1", "2", examples, nil)
+
+	producer := New(schemaRegistry, topic, 100, 0, "")
+
+	settings := GeneratorSettings{
+		SchemaID: "1",
+		Version:  "2",
+		Format:   "json",
+		Count:    10,
+	}
+	if err := producer.GenerateAndProduce(context.Background(), settings, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(publisher.Spawned[0].Published) != 4 {
+		t.Fatal("publish count not as expected")
+	}
+	for _, published := range publisher.Spawned[0].Published {
+		if !bytes.Equal(published.Data, examples[0]) && !bytes.Equal(published.Data, examples[1]) {
+			t.Fatal("published data not as expected")
+		}
+		if published.Attributes[janitor.AttributeFormat] != "json" ||
+			published.Attributes[janitor.AttributeSchemaID] != "1" ||
+			published.Attributes[janitor.AttributeSchemaVersion] != "2" {
+			t.Fatal("attributes not as expected")
+		}
+	}
+}
@@ -87,7 +87,20 @@ func Run(configFile string) {
 		log.Fatal(err)
 	}
 
-	if err = New(sr, topic, cfg.RateLimit, Mode(cfg.Mode), cfg.EncryptionKey).LoadAndProduce(ctx, cfg.BaseDir, cfg.FileName, cfg.NumberOfMessages); err != nil {
+	producer := New(sr, topic, cfg.RateLimit, Mode(cfg.Mode), cfg.EncryptionKey)
+	if cfg.Generator.Enabled {
+		err = producer.GenerateAndProduce(ctx, GeneratorSettings{
+			SchemaID: cfg.Generator.SchemaID,
+			Version:  cfg.Generator.Version,
+			Format:   cfg.Generator.Format,
+			Count:    cfg.Generator.Count,
+			Seed:     cfg.Generator.Seed,
+			Invalid:  cfg.Generator.Invalid,
+		}, cfg.NumberOfMessages)
+	} else {
+		err = producer.LoadAndProduce(ctx, cfg.BaseDir, cfg.FileName, cfg.NumberOfMessages)
+	}
+	if err != nil {
 		log.Fatal(err)
 	}
 	log.Println("done")
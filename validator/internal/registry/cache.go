@@ -16,6 +16,9 @@ package registry
 
 import (
 	"context"
+	"time"
+
+	"github.com/dataphos/schema-registry-validator/internal/contract"
 
 	lru "github.com/hashicorp/golang-lru"
 	"github.com/prometheus/client_golang/prometheus"
@@ -36,6 +39,18 @@ type cached struct {
 	group singleflight.Group
 }
 
+// aliasTTL is how long a resolved alias is cached, which bounds how long the validator keeps using the previous version
+// after the alias is moved. Versions themselves are immutable, so they are cached without expiry.
+const aliasTTL = 30 * time.Second
+
+// aliasKey is the cache key of an alias of a schema, distinct from the key of a version.
+type aliasKey [2]string
+
+type aliasEntry struct {
+	version string
+	expires time.Time
+}
+
 // newCache returns a new cached.
 func newCache(registry SchemaRegistry, size int) (*cached, error) {
 	cache, err := lru.New2Q(size)
@@ -53,7 +68,18 @@ func newCache(registry SchemaRegistry, size int) (*cached, error) {
 // Get overrides the SchemaRegistry.Get method, caching each call to the underlying SchemaRegistry, while also
 // making sure there's only one inflight request for the same key (if multiple goroutines request the same schema,
 // only one request is actually sent down, the rest wait for the first one to share its result).
+//
+// Aliases are resolved to versions first, so the schemas are cached by version and an alias move takes effect once the
+// resolved alias expires.
 func (c *cached) Get(ctx context.Context, id, version string) ([]byte, error) {
+	version, cacheable, err := c.resolveVersion(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+	if !cacheable {
+		return c.SchemaRegistry.Get(ctx, id, version)
+	}
+
 	// this should be faster than string concatenation
 	arrKey := [2]string{id, version}
 
@@ -81,3 +107,144 @@ func (c *cached) Get(ctx context.Context, id, version string) ([]byte, error) {
 	}
 	return v.([]byte), nil
 }
+
+// rulesKey is the cache key of the rules of a schema version, distinct from the key of its specification.
+type rulesKey [2]string
+
+// GetRules caches the data contract rules of the underlying SchemaRegistry like Get caches the schemas, returning no
+// rules if the underlying SchemaRegistry doesn't implement RuleGetter.
+func (c *cached) GetRules(ctx context.Context, id, version string) ([]contract.Rule, error) {
+	getter, ok := c.SchemaRegistry.(RuleGetter)
+	if !ok {
+		return nil, nil
+	}
+	version, cacheable, err := c.resolveVersion(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+	if !cacheable {
+		return getter.GetRules(ctx, id, version)
+	}
+
+	arrKey := rulesKey{id, version}
+	if v, ok := c.cache.Get(arrKey); ok {
+		cachedHitsCount.Inc()
+		return v.([]contract.Rule), nil
+	}
+
+	key := id + "_" + version + "_rules"
+	v, err, _ := c.group.Do(key, func() (interface{}, error) {
+		rules, err := getter.GetRules(ctx, id, version)
+		if err != nil {
+			return nil, err
+		}
+
+		c.cache.Add(arrKey, rules)
+
+		return rules, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]contract.Rule), nil
+}
+
+// ResolveVersion resolves the alias or semantic version through the underlying SchemaRegistry, caching the result for
+// aliasTTL. The alias is returned as it is if the underlying SchemaRegistry doesn't implement VersionResolver.
+func (c *cached) ResolveVersion(ctx context.Context, id, alias string) (string, error) {
+	version, _, err := c.resolveVersion(ctx, id, alias)
+	return version, err
+}
+
+// resolveVersion returns the version the given version resolves to, and whether the result of fetching it can be
+// cached, which isn't the case for aliases the underlying SchemaRegistry can't resolve, since they can move.
+func (c *cached) resolveVersion(ctx context.Context, id, version string) (string, bool, error) {
+	if !IsAlias(version) && !IsSemver(version) {
+		return version, true, nil
+	}
+	resolver, ok := c.SchemaRegistry.(VersionResolver)
+	if !ok {
+		return version, false, nil
+	}
+
+	arrKey := aliasKey{id, version}
+	if v, ok := c.cache.Get(arrKey); ok {
+		if entry := v.(aliasEntry); time.Now().Before(entry.expires) {
+			cachedHitsCount.Inc()
+			return entry.version, entry.version != version, nil
+		}
+	}
+
+	key := id + "_" + version + "_alias"
+	v, err, _ := c.group.Do(key, func() (interface{}, error) {
+		resolved, err := resolver.ResolveVersion(ctx, id, version)
+		if err != nil {
+			return nil, err
+		}
+
+		c.cache.Add(arrKey, aliasEntry{version: resolved, expires: time.Now().Add(aliasTTL)})
+
+		return resolved, nil
+	})
+	if err != nil {
+		return "", false, err
+	}
+	resolved := v.(string)
+	// an alias the registry doesn't know is passed on as it is, and must not be cached as a version
+	return resolved, resolved != version, nil
+}
+
+// RegisterUsage passes the registration through to the underlying SchemaRegistry, since it must reach the registry
+// every time to serve as a heartbeat. ErrUsageNotSupported is returned if the underlying SchemaRegistry doesn't
+// implement UsageRegistrar.
+func (c *cached) RegisterUsage(ctx context.Context, id, version, topic, role, clientID string) error {
+	registrar, ok := c.SchemaRegistry.(UsageRegistrar)
+	if !ok {
+		return ErrUsageNotSupported
+	}
+	return registrar.RegisterUsage(ctx, id, version, topic, role, clientID)
+}
+
+// historyTTL is how long the version history of a schema is cached, which bounds how long the versions activated or
+// deactivated since are missed when resolving the version of a recently ingested message.
+const historyTTL = 30 * time.Second
+
+// historyKey is the cache key of the version history of a schema.
+type historyKey string
+
+type historyEntry struct {
+	events  []VersionEvent
+	expires time.Time
+}
+
+// GetHistory caches the version history of the underlying SchemaRegistry for historyTTL. ErrHistoryNotSupported is
+// returned if the underlying SchemaRegistry doesn't implement HistoryGetter.
+func (c *cached) GetHistory(ctx context.Context, id string) ([]VersionEvent, error) {
+	getter, ok := c.SchemaRegistry.(HistoryGetter)
+	if !ok {
+		return nil, ErrHistoryNotSupported
+	}
+
+	key := historyKey(id)
+	if v, ok := c.cache.Get(key); ok {
+		if entry := v.(historyEntry); time.Now().Before(entry.expires) {
+			cachedHitsCount.Inc()
+			return entry.events, nil
+		}
+	}
+
+	v, err, _ := c.group.Do(id+"_history", func() (interface{}, error) {
+		events, err := getter.GetHistory(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		c.cache.Add(key, historyEntry{events: events, expires: time.Now().Add(historyTTL)})
+
+		return events, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]VersionEvent), nil
+}
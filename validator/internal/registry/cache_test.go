@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"context"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -69,3 +70,64 @@ func TestCacheGet(t *testing.T) {
 		}
 	})
 }
+
+func TestCacheGetAlias(t *testing.T) {
+	sr := NewMock()
+	c, err := newCache(sr, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sr.SetGetResponse("1", "1", []byte("version 1"), nil)
+	sr.SetGetResponse("1", "2", []byte("version 2"), nil)
+	sr.SetAlias("1", "prod", "1")
+
+	result, err := c.Get(context.Background(), "1", "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(result) != "version 1" {
+		t.Fatalf("expected prod to resolve to version 1, got %q", result)
+	}
+
+	// the resolved alias is cached until it expires, so moving it takes effect afterwards
+	sr.SetAlias("1", "prod", "2")
+	if result, _ = c.Get(context.Background(), "1", "prod"); string(result) != "version 1" {
+		t.Errorf("expected the resolved alias to be cached, got %q", result)
+	}
+	c.cache.Add(aliasKey{"1", "prod"}, aliasEntry{version: "1", expires: time.Now().Add(-time.Second)})
+	if result, _ = c.Get(context.Background(), "1", "prod"); string(result) != "version 2" {
+		t.Errorf("expected the moved alias to resolve to version 2 once expired, got %q", result)
+	}
+
+	// an alias the registry doesn't know isn't cached as a version
+	sr.SetGetResponse("1", "beta", nil, errors.New("oops"))
+	if _, err = c.Get(context.Background(), "1", "beta"); err == nil {
+		t.Error("expected an error")
+	}
+	if _, ok := c.cache.Get([2]string{"1", "beta"}); ok {
+		t.Error("expected the unknown alias not to be cached as a version")
+	}
+}
+
+func TestCacheGetSemver(t *testing.T) {
+	sr := NewMock()
+	c, err := newCache(sr, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sr.SetGetResponse("1", "3", []byte("version 3"), nil)
+	sr.SetAlias("1", "1.2.0", "3")
+
+	result, err := c.Get(context.Background(), "1", "1.2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(result) != "version 3" {
+		t.Fatalf("expected 1.2.0 to resolve to version 3, got %q", result)
+	}
+	if _, ok := c.cache.Get([2]string{"1", "1.2.0"}); ok {
+		t.Error("expected the semantic version not to be cached as a version")
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"strconv"
+	"time"
+)
+
+// The events in the history of a schema version.
+const (
+	VersionActivated   = "activated"
+	VersionDeactivated = "deactivated"
+)
+
+// VersionEvent records that a schema version became active or was deactivated.
+type VersionEvent struct {
+	Version    string    `json:"version"`
+	Event      string    `json:"event"`
+	SchemaHash string    `json:"schema_hash"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// LatestVersionAt replays the history of a schema up to and including asOf, returning the highest version which was
+// active at that time, and false if none was.
+//
+// A deactivated version which was registered again got a new version number, so the version is returned under the
+// number it was activated with last.
+func LatestVersionAt(events []VersionEvent, asOf time.Time) (string, bool) {
+	active := make(map[string]VersionEvent)
+	for _, event := range events {
+		if event.Timestamp.After(asOf) {
+			break
+		}
+		switch event.Event {
+		case VersionActivated:
+			active[event.Version] = event
+		case VersionDeactivated:
+			delete(active, event.Version)
+		}
+	}
+
+	var latest VersionEvent
+	found := false
+	for _, event := range active {
+		if !found || versionNumber(event.Version) > versionNumber(latest.Version) {
+			latest = event
+			found = true
+		}
+	}
+	if !found {
+		return "", false
+	}
+
+	for _, event := range events {
+		if event.Event == VersionActivated && event.SchemaHash == latest.SchemaHash {
+			latest.Version = event.Version
+		}
+	}
+	return latest.Version, true
+}
+
+// versionNumber returns the number of the version, 0 if it isn't a number.
+func versionNumber(version string) int {
+	number, _ := strconv.Atoi(version)
+	return number
+}
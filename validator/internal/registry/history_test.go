@@ -0,0 +1,56 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatestVersionAt(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time {
+		return start.Add(time.Duration(minutes) * time.Minute)
+	}
+	events := []VersionEvent{
+		{Version: "1", Event: VersionActivated, SchemaHash: "a", Timestamp: at(0)},
+		{Version: "2", Event: VersionActivated, SchemaHash: "b", Timestamp: at(10)},
+		{Version: "2", Event: VersionDeactivated, SchemaHash: "b", Timestamp: at(20)},
+		// version 2 registered again
+		{Version: "3", Event: VersionActivated, SchemaHash: "b", Timestamp: at(30)},
+	}
+
+	tt := []struct {
+		name    string
+		asOf    time.Time
+		version string
+		found   bool
+	}{
+		{"before the first version", at(-1), "", false},
+		{"first version", at(5), "1", true},
+		{"renumbered version", at(15), "3", true},
+		{"latest deactivated", at(25), "1", true},
+		{"now", at(60), "3", true},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			version, found := LatestVersionAt(events, tc.asOf)
+			if version != tc.version || found != tc.found {
+				t.Errorf("expected version %q (%t), got %q (%t)", tc.version, tc.found, version, found)
+			}
+		})
+	}
+}
@@ -14,17 +14,22 @@
 
 package janitorsr
 
-import "time"
+import (
+	"time"
+
+	"github.com/dataphos/schema-registry-validator/internal/contract"
+)
 
 type VersionDetails struct {
-	VersionID          string    `json:"version_id,omitempty"`
-	Version            string    `json:"version"`
-	SchemaID           string    `json:"schema_id"`
-	Specification      string    `json:"specification"`
-	Description        string    `json:"description"`
-	SchemaHash         string    `json:"schema_hash"`
-	CreatedAt          time.Time `json:"created_at"`
-	VersionDeactivated bool      `json:"version_deactivated"`
+	VersionID          string          `json:"version_id,omitempty"`
+	Version            string          `json:"version"`
+	SchemaID           string          `json:"schema_id"`
+	Specification      []byte          `json:"specification"`
+	Description        string          `json:"description"`
+	SchemaHash         string          `json:"schema_hash"`
+	CreatedAt          time.Time       `json:"created_at"`
+	VersionDeactivated bool            `json:"version_deactivated"`
+	Rules              []contract.Rule `json:"rules"`
 }
 
 type registrationRequest struct {
@@ -42,3 +47,24 @@ type insertInfo struct {
 	Version string `json:"version"`
 	Message string `json:"message"`
 }
+
+type usageRegistrationRequest struct {
+	Topic    string `json:"topic"`
+	Role     string `json:"role"`
+	ClientID string `json:"client_id"`
+}
+
+type examples struct {
+	SchemaID   string   `json:"schema_id"`
+	Version    string   `json:"version"`
+	SchemaType string   `json:"schema_type"`
+	Seed       int64    `json:"seed"`
+	Valid      bool     `json:"valid"`
+	Payloads   [][]byte `json:"payloads"`
+}
+
+// problem is the problem details body of an error response of the schema registry.
+type problem struct {
+	Detail string `json:"detail"`
+	Code   int    `json:"code"`
+}
@@ -17,7 +17,6 @@ package janitorsr
 import (
 	"bytes"
 	"context"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -27,10 +26,12 @@ import (
 
 	"github.com/dataphos/lib-httputil/pkg/httputil"
 	"github.com/dataphos/lib-retry/pkg/retry"
+	"github.com/dataphos/schema-registry-validator/internal/contract"
 	"github.com/dataphos/schema-registry-validator/internal/errtemplates"
 	"github.com/dataphos/schema-registry-validator/internal/registry"
 
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 // SchemaRegistry is a proxy for communicating with the janitor schema registry server.
@@ -53,6 +54,9 @@ var DefaultTimeoutSettings = TimeoutSettings{
 	UpdateTimeout:   10 * time.Second,
 }
 
+// client propagates the trace context of the caller to the schema registry and records a span of every request.
+var client = &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}
+
 // New returns an instance of SchemaRegistry.
 //
 // Performs a health check to see if the schema registry is available, retrying periodically until the context is cancelled
@@ -75,12 +79,39 @@ func New(ctx context.Context, url string, timeouts TimeoutSettings, groupID stri
 }
 
 func (sr *SchemaRegistry) Get(ctx context.Context, id, version string) ([]byte, error) {
+	schema, err := sr.getVersionDetails(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.Specification, nil
+}
+
+// GetRules returns the data contract rules of the schema version stored under the given id and version.
+func (sr *SchemaRegistry) GetRules(ctx context.Context, id, version string) ([]contract.Rule, error) {
+	schema, err := sr.getVersionDetails(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+	return schema.Rules, nil
+}
+
+// ResolveVersion returns the version number the alias of the schema stored under the given id currently points at.
+func (sr *SchemaRegistry) ResolveVersion(ctx context.Context, id, alias string) (string, error) {
+	schema, err := sr.getVersionDetails(ctx, id, alias)
+	if err != nil {
+		return "", err
+	}
+	return schema.Version, nil
+}
+
+func (sr *SchemaRegistry) getVersionDetails(ctx context.Context, id, version string) (VersionDetails, error) {
 	ctx, cancel := context.WithTimeout(ctx, sr.Timeouts.GetTimeout)
 	defer cancel()
 
 	response, err := sr.sendGetRequest(ctx, id, version)
 	if err != nil {
-		return nil, err
+		return VersionDetails{}, err
 	}
 	defer func() {
 		err := response.Body.Close()
@@ -91,29 +122,22 @@ func (sr *SchemaRegistry) Get(ctx context.Context, id, version string) ([]byte,
 
 	body, err := io.ReadAll(response.Body)
 	if err != nil {
-		return nil, errors.Wrap(err, errtemplates.ReadingResponseBodyFailed)
+		return VersionDetails{}, errors.Wrap(err, errtemplates.ReadingResponseBodyFailed)
 	}
 
 	if response.StatusCode != http.StatusOK {
-		if response.StatusCode == http.StatusNotFound {
-			return nil, errors.Wrapf(registry.ErrNotFound, "fetching schema %s/%s failed", id, version)
-		} else if response.StatusCode == http.StatusUnprocessableEntity {
-			return nil, errors.Wrapf(registry.InvalidHeader, "fetching schema %s/%s failed due to invalid type", id, version)
-		}
-		return nil, errors.Wrapf(errtemplates.BadHttpStatusCode(response.StatusCode), "fetching schema %s/%s resulted in a bad status code", id, version)
+		err = responseError(response.StatusCode, body, map[int]error{
+			http.StatusNotFound:            registry.ErrNotFound,
+			http.StatusUnprocessableEntity: registry.InvalidHeader,
+		})
+		return VersionDetails{}, errors.Wrapf(err, "fetching schema %s/%s failed", id, version)
 	}
 
 	var schema VersionDetails
 	if err = json.Unmarshal(body, &schema); err != nil {
-		return nil, errors.Wrap(err, errtemplates.UnmarshallingJSONFailed)
+		return VersionDetails{}, errors.Wrap(err, errtemplates.UnmarshallingJSONFailed)
 	}
-
-	specification, err := base64.StdEncoding.DecodeString(schema.Specification)
-	if err != nil {
-		return nil, errors.Wrap(err, "decoding schema failed")
-	}
-
-	return specification, nil
+	return schema, nil
 }
 
 func (sr *SchemaRegistry) sendGetRequest(ctx context.Context, id, version string) (*http.Response, error) {
@@ -124,7 +148,7 @@ func (sr *SchemaRegistry) sendGetRequest(ctx context.Context, id, version string
 		return nil, err
 	}
 
-	response, err := http.DefaultClient.Do(request)
+	response, err := client.Do(request)
 	if err != nil {
 		return nil, errors.Wrap(err, errtemplates.HttpRequestToUrlFailed(http.MethodGet, url))
 	}
@@ -157,10 +181,8 @@ func (sr *SchemaRegistry) GetLatest(ctx context.Context, id string) ([]byte, err
 	}
 
 	if response.StatusCode != http.StatusOK {
-		if response.StatusCode == http.StatusNotFound {
-			return nil, errors.Wrapf(registry.ErrNotFound, "fetching schema %s/latest failed", id)
-		}
-		return nil, errors.Wrapf(errtemplates.BadHttpStatusCode(response.StatusCode), "fetching schema %s/latest resulted in a bad status code", id)
+		err = responseError(response.StatusCode, body, map[int]error{http.StatusNotFound: registry.ErrNotFound})
+		return nil, errors.Wrapf(err, "fetching schema %s/latest failed", id)
 	}
 
 	return body, nil
@@ -174,7 +196,110 @@ func (sr *SchemaRegistry) sendGetLatestRequest(ctx context.Context, id string) (
 		return nil, err
 	}
 
-	response, err := http.DefaultClient.Do(request)
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, errors.Wrap(err, errtemplates.HttpRequestToUrlFailed(http.MethodGet, url))
+	}
+
+	return response, nil
+}
+
+// GetHistory returns the activations and deactivations of the versions of the schema stored under the given id,
+// oldest first.
+func (sr *SchemaRegistry) GetHistory(ctx context.Context, id string) ([]registry.VersionEvent, error) {
+	ctx, cancel := context.WithTimeout(ctx, sr.Timeouts.GetTimeout)
+	defer cancel()
+
+	response, err := sr.sendGetHistoryRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err := response.Body.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, errtemplates.ReadingResponseBodyFailed)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		err = responseError(response.StatusCode, body, map[int]error{
+			http.StatusNotFound:   registry.ErrNotFound,
+			http.StatusBadRequest: registry.InvalidHeader,
+		})
+		return nil, errors.Wrapf(err, "fetching history of schema %s failed", id)
+	}
+
+	var events []registry.VersionEvent
+	if err = json.Unmarshal(body, &events); err != nil {
+		return nil, errors.Wrap(err, errtemplates.UnmarshallingJSONFailed)
+	}
+	return events, nil
+}
+
+func (sr *SchemaRegistry) sendGetHistoryRequest(ctx context.Context, id string) (*http.Response, error) {
+	url := fmt.Sprintf("%s/schemas/%s/history", sr.Url, id)
+
+	request, err := httputil.Get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, errors.Wrap(err, errtemplates.HttpRequestToUrlFailed(http.MethodGet, url))
+	}
+
+	return response, nil
+}
+
+// GetExamples returns example payloads of the schema stored under the given id and version, generated by the schema registry.
+func (sr *SchemaRegistry) GetExamples(ctx context.Context, id, version string, count int, seed int64, invalid bool) ([][]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, sr.Timeouts.GetTimeout)
+	defer cancel()
+
+	response, err := sr.sendGetExamplesRequest(ctx, id, version, count, seed, invalid)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err := response.Body.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, errtemplates.ReadingResponseBodyFailed)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		err = responseError(response.StatusCode, body, map[int]error{http.StatusNotFound: registry.ErrNotFound})
+		return nil, errors.Wrapf(err, "fetching examples of schema %s/%s failed", id, version)
+	}
+
+	var generated examples
+	if err = json.Unmarshal(body, &generated); err != nil {
+		return nil, errors.Wrap(err, errtemplates.UnmarshallingJSONFailed)
+	}
+
+	return generated.Payloads, nil
+}
+
+func (sr *SchemaRegistry) sendGetExamplesRequest(ctx context.Context, id, version string, count int, seed int64, invalid bool) (*http.Response, error) {
+	url := fmt.Sprintf("%s/schemas/%s/versions/%s/examples?count=%d&seed=%d&invalid=%t", sr.Url, id, version, count, seed, invalid)
+
+	request, err := httputil.Get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := client.Do(request)
 	if err != nil {
 		return nil, errors.Wrap(err, errtemplates.HttpRequestToUrlFailed(http.MethodGet, url))
 	}
@@ -182,6 +307,60 @@ func (sr *SchemaRegistry) sendGetLatestRequest(ctx context.Context, id string) (
 	return response, nil
 }
 
+// RegisterUsage registers, or refreshes, the usage of the schema stored under the given id and version by a producer
+// or consumer of the topic.
+func (sr *SchemaRegistry) RegisterUsage(ctx context.Context, id, version, topic, role, clientID string) error {
+	ctx, cancel := context.WithTimeout(ctx, sr.Timeouts.RegisterTimeout)
+	defer cancel()
+
+	response, err := sr.sendRegisterUsageRequest(ctx, id, version, topic, role, clientID)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := response.Body.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	// the response body always needs to be read, so the connection can be reused
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return errors.Wrap(err, errtemplates.ReadingResponseBodyFailed)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		err = responseError(response.StatusCode, body, map[int]error{http.StatusNotFound: registry.ErrNotFound})
+		return errors.Wrapf(err, "registering usage of schema %s/%s failed", id, version)
+	}
+
+	return nil
+}
+
+func (sr *SchemaRegistry) sendRegisterUsageRequest(ctx context.Context, id, version, topic, role, clientID string) (*http.Response, error) {
+	// this can't generate an error, so it's safe to ignore
+	data, _ := json.Marshal(usageRegistrationRequest{
+		Topic:    topic,
+		Role:     role,
+		ClientID: clientID,
+	})
+
+	url := fmt.Sprintf("%s/schemas/%s/versions/%s/usage", sr.Url, id, version)
+
+	request, err := httputil.Post(ctx, url, "application/json", bytes.NewBuffer(data))
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, errors.Wrap(err, errtemplates.HttpRequestToUrlFailed(http.MethodPost, url))
+	}
+
+	return response, nil
+}
+
 func (sr *SchemaRegistry) Register(ctx context.Context, schema []byte, schemaType, compMode, valMode string) (string, string, error) {
 	ctx, cancel := context.WithTimeout(ctx, sr.Timeouts.RegisterTimeout)
 	defer cancel()
@@ -206,7 +385,7 @@ func (sr *SchemaRegistry) Register(ctx context.Context, schema []byte, schemaTyp
 	// the schema registry returns either 201, if the new schema version is successfully inserted, or 409 if
 	// the given schema already exists
 	if response.StatusCode != http.StatusCreated && response.StatusCode != http.StatusConflict {
-		return "", "", errtemplates.BadHttpStatusCode(response.StatusCode)
+		return "", "", errors.Wrap(responseError(response.StatusCode, body, nil), "registering schema failed")
 	}
 
 	var info insertInfo
@@ -234,7 +413,7 @@ func (sr *SchemaRegistry) sendRegisterRequest(ctx context.Context, schema []byte
 		return nil, err
 	}
 
-	response, err := http.DefaultClient.Do(request)
+	response, err := client.Do(request)
 	if err != nil {
 		return nil, errors.Wrap(err, errtemplates.HttpRequestToUrlFailed(http.MethodPost, url))
 	}
@@ -271,7 +450,7 @@ func (sr *SchemaRegistry) Update(ctx context.Context, id string, schema []byte)
 	// the schema registry returns either 200, if the new schema version is successfully inserted, or 409 if
 	// the given schema already exists
 	if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusConflict {
-		return "", errtemplates.BadHttpStatusCode(response.StatusCode)
+		return "", errors.Wrapf(responseError(response.StatusCode, body, map[int]error{http.StatusNotFound: registry.ErrNotFound}), "updating schema %s failed", id)
 	}
 
 	var info insertInfo
@@ -293,7 +472,7 @@ func (sr *SchemaRegistry) sendUpdateRequest(ctx context.Context, id string, sche
 		return nil, err
 	}
 
-	response, err := http.DefaultClient.Do(request)
+	response, err := client.Do(request)
 	if err != nil {
 		return nil, errors.Wrap(err, errtemplates.HttpRequestToUrlFailed(http.MethodPut, url))
 	}
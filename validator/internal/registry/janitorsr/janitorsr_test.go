@@ -16,7 +16,6 @@ package janitorsr
 
 import (
 	"context"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -27,6 +26,9 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+
+	"github.com/dataphos/schema-registry-validator/internal/contract"
+	srregistry "github.com/dataphos/schema-registry-validator/internal/registry"
 )
 
 func TestNew(t *testing.T) {
@@ -76,7 +78,7 @@ func TestGet(t *testing.T) {
 		VersionID:          "1",
 		Version:            "1",
 		SchemaID:           "1",
-		Specification:      base64.StdEncoding.EncodeToString(schema),
+		Specification:      schema,
 		Description:        "some description",
 		SchemaHash:         "some schema hash",
 		CreatedAt:          time.Now(),
@@ -107,6 +109,255 @@ func TestGet(t *testing.T) {
 	}
 }
 
+func TestGetRules(t *testing.T) {
+	details := VersionDetails{
+		Version:  "1",
+		SchemaID: "1",
+		Rules: []contract.Rule{
+			{Name: "positive_amount", Expression: "message.amount > 0", Severity: contract.SeverityError},
+		},
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method == http.MethodGet && request.URL.Path == fmt.Sprintf("/schemas/%s/versions/%s", details.SchemaID, details.Version) {
+			_ = json.NewEncoder(writer).Encode(details)
+		} else {
+			writer.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	registry := SchemaRegistry{
+		Url:      srv.URL,
+		Timeouts: DefaultTimeoutSettings,
+	}
+
+	rules, err := registry.GetRules(context.Background(), details.SchemaID, details.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(details.Rules, rules) {
+		t.Fatalf("expected and actual rules not the same (%v != %v)", details.Rules, rules)
+	}
+
+	if _, err = registry.GetRules(context.Background(), details.SchemaID, "2"); !errors.Is(err, srregistry.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestResolveVersion(t *testing.T) {
+	details := VersionDetails{
+		Version:  "3",
+		SchemaID: "1",
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method == http.MethodGet && request.URL.Path == "/schemas/1/versions/prod" {
+			_ = json.NewEncoder(writer).Encode(details)
+		} else {
+			writer.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	registry := SchemaRegistry{
+		Url:      srv.URL,
+		Timeouts: DefaultTimeoutSettings,
+	}
+
+	version, err := registry.ResolveVersion(context.Background(), "1", "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != details.Version {
+		t.Fatalf("expected prod to resolve to version %s, got %s", details.Version, version)
+	}
+
+	if _, err = registry.ResolveVersion(context.Background(), "1", "stable"); !errors.Is(err, srregistry.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestGetHistory(t *testing.T) {
+	activated := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	events := []srregistry.VersionEvent{
+		{Version: "1", Event: srregistry.VersionActivated, SchemaHash: "a", Timestamp: activated},
+		{Version: "1", Event: srregistry.VersionDeactivated, SchemaHash: "a", Timestamp: activated.Add(time.Hour)},
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method == http.MethodGet && request.URL.Path == "/schemas/1/history" {
+			_ = json.NewEncoder(writer).Encode(events)
+		} else {
+			writer.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	registry := SchemaRegistry{
+		Url:      srv.URL,
+		Timeouts: DefaultTimeoutSettings,
+	}
+
+	history, err := registry.GetHistory(context.Background(), "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != len(events) || history[1] != events[1] {
+		t.Fatalf("expected and actual history not the same (%v != %v)", events, history)
+	}
+
+	if _, err = registry.GetHistory(context.Background(), "2"); !errors.Is(err, srregistry.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestProblemCodes(t *testing.T) {
+	tt := []struct {
+		name     string
+		status   int
+		body     string
+		expected error
+	}{
+		{"not found", http.StatusNotFound, `{"title":"Not found","status":404,"code":404}`, srregistry.ErrNotFound},
+		{"invalid value", http.StatusUnprocessableEntity, `{"title":"Invalid value","status":422,"code":40001}`, srregistry.InvalidHeader},
+		{"invalid value with another status", http.StatusBadRequest, `{"title":"Invalid value","status":400,"code":40001}`, srregistry.InvalidHeader},
+		{"code over status", http.StatusNotFound, `{"title":"Method not allowed","status":404,"code":405}`, nil},
+		{"status without code", http.StatusUnprocessableEntity, `{"message":"Id=1 and/or version=first are not of supported data types"}`, srregistry.InvalidHeader},
+		{"unknown", http.StatusInternalServerError, `{"title":"Internal server error","status":500,"code":500}`, nil},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				writer.Header().Set("Content-Type", "application/problem+json")
+				writer.WriteHeader(tc.status)
+				_, _ = writer.Write([]byte(tc.body))
+			}))
+			defer srv.Close()
+
+			registry := SchemaRegistry{
+				Url:      srv.URL,
+				Timeouts: DefaultTimeoutSettings,
+			}
+
+			_, err := registry.Get(context.Background(), "1", "first")
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if tc.expected != nil && !errors.Is(err, tc.expected) {
+				t.Fatalf("expected %v, got %v", tc.expected, err)
+			}
+			if tc.expected == nil && (errors.Is(err, srregistry.ErrNotFound) || errors.Is(err, srregistry.InvalidHeader)) {
+				t.Fatalf("expected an unexpected status error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestProblemCodesWithoutRegistryErrors(t *testing.T) {
+	tt := []struct {
+		code        int
+		status      int
+		problemType string
+	}{
+		{CodeBadRequest, http.StatusBadRequest, "bad-request"},
+		{CodeMethodNotAllowed, http.StatusMethodNotAllowed, "method-not-allowed"},
+		{CodeNotAcceptable, http.StatusNotAcceptable, "not-acceptable"},
+		{CodePayloadTooLarge, http.StatusRequestEntityTooLarge, "payload-too-large"},
+		{CodeTooManyRequests, http.StatusTooManyRequests, "too-many-requests"},
+		{CodeInternalServerError, http.StatusInternalServerError, "internal-server-error"},
+		{CodeUnknownCompatibilityMode, http.StatusBadRequest, "unknown-compatibility-mode"},
+		{CodeUnknownValidityMode, http.StatusBadRequest, "unknown-validity-mode"},
+		{CodeUnknownSchemaFormat, http.StatusBadRequest, "unknown-schema-format"},
+		{CodeSchemaNotValid, http.StatusBadRequest, "schema-not-valid"},
+		{CodeSchemaNotCompatible, http.StatusBadRequest, "schema-not-compatible"},
+		{CodeBreakingChange, http.StatusBadRequest, "breaking-change"},
+		{CodeInvalidRules, http.StatusBadRequest, "invalid-rules"},
+		{CodeInvalidUsage, http.StatusBadRequest, "invalid-usage"},
+		{CodeInvalidAlias, http.StatusBadRequest, "invalid-alias"},
+		{CodeInvalidFingerprint, http.StatusBadRequest, "invalid-fingerprint"},
+		{CodeInvalidMetadata, http.StatusBadRequest, "invalid-metadata"},
+		{CodeAdmissionDenied, http.StatusForbidden, "admission-denied"},
+		{CodeSchemaInUse, http.StatusConflict, "schema-in-use"},
+		{CodeNotCanonicalizable, http.StatusUnprocessableEntity, "not-canonicalizable"},
+		{CodeExamplesUnsupported, http.StatusUnprocessableEntity, "examples-unsupported"},
+		{CodeAdmissionUnavailable, http.StatusServiceUnavailable, "admission-unavailable"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.problemType, func(t *testing.T) {
+			body := fmt.Sprintf(`{"title":"Problem","status":%d,"detail":"details","code":%d}`, tc.status, tc.code)
+			err := responseError(tc.status, []byte(body), map[int]error{tc.status: srregistry.ErrNotFound})
+
+			var problemErr *ProblemError
+			if !errors.As(err, &problemErr) {
+				t.Fatalf("expected a ProblemError, got %v", err)
+			}
+			if problemErr.Code != tc.code || problemErr.Type != tc.problemType || problemErr.Status != tc.status || problemErr.Detail != "details" {
+				t.Errorf("unexpected problem %+v", problemErr)
+			}
+			if errors.Is(err, srregistry.ErrNotFound) || errors.Is(err, srregistry.InvalidHeader) {
+				t.Errorf("expected the code to take precedence over the status, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRegisterProblem(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/problem+json")
+		writer.WriteHeader(http.StatusBadRequest)
+		_, _ = writer.Write([]byte(`{"title":"Schema not valid","status":400,"detail":"Schema is not valid","code":40005}`))
+	}))
+	defer srv.Close()
+
+	registry := SchemaRegistry{
+		Url:      srv.URL,
+		Timeouts: DefaultTimeoutSettings,
+	}
+
+	_, _, err := registry.Register(context.Background(), []byte("{}"), "json", "none", "full")
+	var problemErr *ProblemError
+	if !errors.As(err, &problemErr) || problemErr.Code != CodeSchemaNotValid {
+		t.Fatalf("expected a schema-not-valid problem, got %v", err)
+	}
+	if _, err = registry.Update(context.Background(), "1", []byte("{}")); !errors.As(err, &problemErr) || problemErr.Code != CodeSchemaNotValid {
+		t.Fatalf("expected a schema-not-valid problem, got %v", err)
+	}
+}
+
+func TestRegisterUsage(t *testing.T) {
+	var registered usageRegistrationRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method == http.MethodPost && request.URL.Path == "/schemas/1/versions/1/usage" {
+			if err := json.NewDecoder(request.Body).Decode(&registered); err != nil {
+				writer.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = writer.Write([]byte("{}"))
+		} else {
+			writer.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	registry := SchemaRegistry{
+		Url:      srv.URL,
+		Timeouts: DefaultTimeoutSettings,
+	}
+
+	if err := registry.RegisterUsage(context.Background(), "1", "1", "orders", srregistry.UsageRoleProducer, "validator-0"); err != nil {
+		t.Fatal(err)
+	}
+	expected := usageRegistrationRequest{Topic: "orders", Role: srregistry.UsageRoleProducer, ClientID: "validator-0"}
+	if registered != expected {
+		t.Fatalf("expected and actual registration not the same (%v != %v)", expected, registered)
+	}
+
+	if err := registry.RegisterUsage(context.Background(), "1", "2", "orders", srregistry.UsageRoleProducer, "validator-0"); !errors.Is(err, srregistry.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
 func TestRegister(t *testing.T) {
 	schema := []byte("some specification")
 	schemaType := "json"
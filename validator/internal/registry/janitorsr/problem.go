@@ -0,0 +1,136 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package janitorsr
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/dataphos/schema-registry-validator/internal/errtemplates"
+	"github.com/dataphos/schema-registry-validator/internal/registry"
+
+	"github.com/pkg/errors"
+)
+
+// The stable codes of the problem details the schema registry responds with. They mirror the problem codes of the
+// internal/errcodes package of the schema registry, which this module can't import, and only ever grow.
+const (
+	CodeBadRequest          = 400
+	CodeNotFound            = 404
+	CodeMethodNotAllowed    = 405
+	CodeNotAcceptable       = 406
+	CodePayloadTooLarge     = 413
+	CodeTooManyRequests     = 429
+	CodeInternalServerError = 500
+
+	CodeInvalidValue             = 40001
+	CodeUnknownCompatibilityMode = 40002
+	CodeUnknownValidityMode      = 40003
+	CodeUnknownSchemaFormat      = 40004
+	CodeSchemaNotValid           = 40005
+	CodeSchemaNotCompatible      = 40006
+	CodeBreakingChange           = 40007
+	CodeInvalidRules             = 40008
+	CodeInvalidUsage             = 40009
+	CodeInvalidAlias             = 40010
+	CodeInvalidFingerprint       = 40011
+	CodeInvalidMetadata          = 40012
+	CodeAdmissionDenied          = 40301
+	CodeSchemaInUse              = 40901
+	CodeNotCanonicalizable       = 42201
+	CodeExamplesUnsupported      = 42202
+	CodeAdmissionUnavailable     = 50301
+)
+
+// problemTypes are the problem types of the known codes, the last segment of their type URI.
+var problemTypes = map[int]string{
+	CodeBadRequest:               "bad-request",
+	CodeNotFound:                 "not-found",
+	CodeMethodNotAllowed:         "method-not-allowed",
+	CodeNotAcceptable:            "not-acceptable",
+	CodePayloadTooLarge:          "payload-too-large",
+	CodeTooManyRequests:          "too-many-requests",
+	CodeInternalServerError:      "internal-server-error",
+	CodeInvalidValue:             "invalid-value",
+	CodeUnknownCompatibilityMode: "unknown-compatibility-mode",
+	CodeUnknownValidityMode:      "unknown-validity-mode",
+	CodeUnknownSchemaFormat:      "unknown-schema-format",
+	CodeSchemaNotValid:           "schema-not-valid",
+	CodeSchemaNotCompatible:      "schema-not-compatible",
+	CodeBreakingChange:           "breaking-change",
+	CodeInvalidRules:             "invalid-rules",
+	CodeInvalidUsage:             "invalid-usage",
+	CodeInvalidAlias:             "invalid-alias",
+	CodeInvalidFingerprint:       "invalid-fingerprint",
+	CodeInvalidMetadata:          "invalid-metadata",
+	CodeAdmissionDenied:          "admission-denied",
+	CodeSchemaInUse:              "schema-in-use",
+	CodeNotCanonicalizable:       "not-canonicalizable",
+	CodeExamplesUnsupported:      "examples-unsupported",
+	CodeAdmissionUnavailable:     "admission-unavailable",
+}
+
+// problemErrors are the errors of the registry package the codes which have one unwrap to.
+var problemErrors = map[int]error{
+	CodeNotFound:     registry.ErrNotFound,
+	CodeInvalidValue: registry.InvalidHeader,
+}
+
+// ProblemError is an error response of the schema registry carrying one of the known problem codes.
+type ProblemError struct {
+	Status int
+	Code   int
+	Type   string
+	Detail string
+}
+
+func (e *ProblemError) Error() string {
+	message := fmt.Sprintf("schema registry responded with %s (code %d, status %d)", e.Type, e.Code, e.Status)
+	if e.Detail != "" {
+		message += ": " + e.Detail
+	}
+	return message
+}
+
+// Unwrap returns the error of the registry package the code maps to, if there's one.
+func (e *ProblemError) Unwrap() error {
+	return problemErrors[e.Code]
+}
+
+// responseError maps an error response of the schema registry to an error. A known problem code of the problem details
+// body takes precedence and results in a ProblemError, while the responses of older schema registries, which don't
+// carry one, are mapped by their status using statuses.
+func responseError(status int, body []byte, statuses map[int]error) error {
+	var details problem
+	_ = json.Unmarshal(body, &details)
+
+	if problemType, ok := problemTypes[details.Code]; ok {
+		return &ProblemError{
+			Status: status,
+			Code:   details.Code,
+			Type:   problemType,
+			Detail: details.Detail,
+		}
+	}
+	if details.Code == 0 {
+		if err, ok := statuses[status]; ok {
+			return err
+		}
+	}
+	if details.Detail != "" {
+		return errors.Wrap(errtemplates.BadHttpStatusCode(status), details.Detail)
+	}
+	return errtemplates.BadHttpStatusCode(status)
+}
@@ -14,13 +14,23 @@
 
 package registry
 
-import "context"
+import (
+	"context"
+
+	"github.com/dataphos/schema-registry-validator/internal/contract"
+)
 
 type Mock struct {
 	getSchemaResponse       map[string]mockGetSchemaResponse
 	getLatestSchemaResponse map[string]mockGetLatestSchemaResponse
 	registrationResponse    map[string]mockRegisterResponse
 	updateResponse          map[string]mockUpdateResponse
+	getExamplesResponse     map[string]mockGetExamplesResponse
+	getRulesResponse        map[string]mockGetRulesResponse
+	aliases                 map[string]string
+	history                 map[string][]VersionEvent
+	// RegisteredUsage holds the usage registered through RegisterUsage, as id_version_topic_role_clientID keys.
+	RegisteredUsage []string
 }
 
 type mockGetSchemaResponse struct {
@@ -44,12 +54,26 @@ type mockUpdateResponse struct {
 	err     error
 }
 
+type mockGetRulesResponse struct {
+	rules []contract.Rule
+	err   error
+}
+
+type mockGetExamplesResponse struct {
+	examples [][]byte
+	err      error
+}
+
 func NewMock() *Mock {
 	return &Mock{
 		getSchemaResponse:       map[string]mockGetSchemaResponse{},
 		getLatestSchemaResponse: map[string]mockGetLatestSchemaResponse{},
 		registrationResponse:    map[string]mockRegisterResponse{},
 		updateResponse:          map[string]mockUpdateResponse{},
+		getExamplesResponse:     map[string]mockGetExamplesResponse{},
+		getRulesResponse:        map[string]mockGetRulesResponse{},
+		aliases:                 map[string]string{},
+		history:                 map[string][]VersionEvent{},
 	}
 }
 
@@ -105,3 +129,66 @@ func (m *Mock) Update(_ context.Context, id string, _ []byte) (string, error) {
 	response := m.updateResponse[id]
 	return response.version, response.err
 }
+
+func (m *Mock) SetGetExamplesResponse(id, version string, examples [][]byte, err error) {
+	key := id + "_" + version
+	m.getExamplesResponse[key] = mockGetExamplesResponse{
+		examples: examples,
+		err:      err,
+	}
+}
+
+func (m *Mock) GetExamples(_ context.Context, id, version string, count int, _ int64, _ bool) ([][]byte, error) {
+	key := id + "_" + version
+	response := m.getExamplesResponse[key]
+	if len(response.examples) > count {
+		return response.examples[:count], response.err
+	}
+	return response.examples, response.err
+}
+
+func (m *Mock) SetGetRulesResponse(id, version string, rules []contract.Rule, err error) {
+	key := id + "_" + version
+	m.getRulesResponse[key] = mockGetRulesResponse{
+		rules: rules,
+		err:   err,
+	}
+}
+
+func (m *Mock) GetRules(_ context.Context, id, version string) ([]contract.Rule, error) {
+	key := id + "_" + version
+	response := m.getRulesResponse[key]
+	return response.rules, response.err
+}
+
+func (m *Mock) RegisterUsage(_ context.Context, id, version, topic, role, clientID string) error {
+	m.RegisteredUsage = append(m.RegisteredUsage, id+"_"+version+"_"+topic+"_"+role+"_"+clientID)
+	return nil
+}
+
+func (m *Mock) SetAlias(id, alias, version string) {
+	m.aliases[id+"_"+alias] = version
+}
+
+// ResolveVersion returns the version the alias was set to, or the alias itself if it wasn't set, like the registry
+// leaves unknown aliases to be rejected as versions.
+func (m *Mock) ResolveVersion(_ context.Context, id, alias string) (string, error) {
+	if version, ok := m.aliases[id+"_"+alias]; ok {
+		return version, nil
+	}
+	return alias, nil
+}
+
+func (m *Mock) SetHistory(id string, events []VersionEvent) {
+	m.history[id] = events
+}
+
+// GetHistory returns the history set for the schema, or ErrHistoryNotSupported if none was, so the mock behaves like a
+// registry without the version history unless told otherwise.
+func (m *Mock) GetHistory(_ context.Context, id string) ([]VersionEvent, error) {
+	events, ok := m.history[id]
+	if !ok {
+		return nil, ErrHistoryNotSupported
+	}
+	return events, nil
+}
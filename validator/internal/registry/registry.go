@@ -17,12 +17,24 @@ package registry
 
 import (
 	"context"
+	"regexp"
+	"strconv"
 
 	"github.com/pkg/errors"
+
+	"github.com/dataphos/schema-registry-validator/internal/contract"
 )
 
 var ErrNotFound = errors.New("no schema registered under given id and version")
 var InvalidHeader = errors.New("id and/or version are not in supported format")
+var ErrUsageNotSupported = errors.New("schema registry doesn't support usage registration")
+var ErrHistoryNotSupported = errors.New("schema registry doesn't keep the version history")
+
+// The roles a service can use a schema version in.
+const (
+	UsageRoleProducer = "producer"
+	UsageRoleConsumer = "consumer"
+)
 
 // SchemaRegistry models schema registries.
 type SchemaRegistry interface {
@@ -44,6 +56,66 @@ type SchemaRegistry interface {
 	// Update(ctx context.Context, id string, schema []byte) (string, error)
 }
 
+// ExampleGenerator models schema registries which can generate example payloads of registered schemas.
+type ExampleGenerator interface {
+	// GetExamples returns count randomly generated payloads of the schema stored under the given id and version.
+	// The same seed always results in the same payloads. If invalid is set, the payloads violate the schema.
+	// If no schema exists, ErrNotFound must be returned.
+	GetExamples(ctx context.Context, id, version string, count int, seed int64, invalid bool) ([][]byte, error)
+}
+
+// RuleGetter models schema registries which store data contract rules with schema versions.
+type RuleGetter interface {
+	// GetRules returns the data contract rules of the schema stored under the given id and version.
+	// If no schema exists, ErrNotFound must be returned.
+	GetRules(ctx context.Context, id, version string) ([]contract.Rule, error)
+}
+
+// UsageRegistrar models schema registries which track which services use the registered schema versions.
+type UsageRegistrar interface {
+	// RegisterUsage registers that the client produces or consumes the schema stored under the given id and version
+	// on the given topic, or refreshes the registration, which expires unless it's refreshed periodically.
+	// If no schema exists, ErrNotFound must be returned.
+	RegisterUsage(ctx context.Context, id, version, topic, role, clientID string) error
+}
+
+// VersionResolver models schema registries which support version aliases, named and movable pointers to versions,
+// or semantic versions.
+type VersionResolver interface {
+	// ResolveVersion returns the version number the alias or semantic version of the schema stored under the given id
+	// currently points at.
+	// If no schema exists, ErrNotFound must be returned.
+	ResolveVersion(ctx context.Context, id, alias string) (string, error)
+}
+
+// HistoryGetter models schema registries which keep the history of the activations and deactivations of schema
+// versions, from which the version which was the latest one at any point in time can be told.
+type HistoryGetter interface {
+	// GetHistory returns the activations and deactivations of the versions of the schema stored under the given id,
+	// oldest first.
+	// If no schema exists, ErrNotFound must be returned.
+	GetHistory(ctx context.Context, id string) ([]VersionEvent, error)
+}
+
+// aliasPattern matches the names the registry accepts as version aliases.
+var aliasPattern = regexp.MustCompile(`^[a-z][a-z0-9_.-]{0,63}$`)
+
+// IsAlias returns true if the version is an alias name rather than a version number.
+func IsAlias(version string) bool {
+	if _, err := strconv.Atoi(version); err == nil {
+		return false
+	}
+	return aliasPattern.MatchString(version)
+}
+
+// semverPattern matches the semantic versions the registry assigns to schema versions.
+var semverPattern = regexp.MustCompile(`^(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)$`)
+
+// IsSemver returns true if the version is a semantic version, like 1.4.2, rather than a version number.
+func IsSemver(version string) bool {
+	return semverPattern.MatchString(version)
+}
+
 // WithCache decorates the given SchemaRegistry with an in-memory cache of the given size.
 func WithCache(registry SchemaRegistry, size int) (SchemaRegistry, error) {
 	return newCache(registry, size)
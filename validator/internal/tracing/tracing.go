@@ -0,0 +1,127 @@
+// Copyright 2024 Syntio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tracing configures the OpenTelemetry tracer provider of the validator components.
+package tracing
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+
+	"github.com/dataphos/schema-registry-validator/internal/errtemplates"
+)
+
+const (
+	ExporterEnvKey = "OTEL_TRACES_EXPORTER"
+	FileEnvKey     = "OTEL_TRACES_FILE"
+)
+
+const (
+	OTLPExporter    = "otlp"
+	ConsoleExporter = "console"
+	FileExporter    = "file"
+	NoneExporter    = "none"
+)
+
+// ShutdownFunc flushes the buffered spans and releases the resources of the exporter.
+type ShutdownFunc func(context.Context) error
+
+// Init sets up the global tracer provider and propagator, based on environment variables.
+//
+// The exporter is chosen with OTEL_TRACES_EXPORTER: otlp exports the spans over OTLP/HTTP and is configured by the
+// standard OTEL_EXPORTER_OTLP_* variables, console writes them to the standard output and file appends them to the file
+// given by OTEL_TRACES_FILE. Tracing is disabled if the variable isn't set or is set to none, but the trace context of
+// incoming requests is still propagated.
+func Init(ctx context.Context, serviceName string) (ShutdownFunc, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	exporterName := os.Getenv(ExporterEnvKey)
+	if exporterName == "" || exporterName == NoneExporter {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, closer, err := newExporter(ctx, exporterName)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating tracing resource failed")
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			if closeErr := closer.Close(); closeErr != nil && err == nil {
+				err = closeErr
+			}
+		}
+		return err
+	}, nil
+}
+
+func newExporter(ctx context.Context, name string) (sdktrace.SpanExporter, io.Closer, error) {
+	switch name {
+	case OTLPExporter:
+		exporter, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "creating OTLP exporter failed")
+		}
+		return exporter, nil, nil
+	case ConsoleExporter:
+		exporter, err := stdouttrace.New()
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "creating console exporter failed")
+		}
+		return exporter, nil, nil
+	case FileExporter:
+		path := os.Getenv(FileEnvKey)
+		if path == "" {
+			return nil, nil, errtemplates.EnvVariableNotDefined(FileEnvKey)
+		}
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "opening %s failed", path)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			_ = file.Close()
+			return nil, nil, errors.Wrap(err, "creating file exporter failed")
+		}
+		return exporter, file, nil
+	default:
+		return nil, nil, errors.Errorf("unsupported value %s for %s", name, ExporterEnvKey)
+	}
+}